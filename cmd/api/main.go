@@ -24,16 +24,19 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/Maro1O9/goauth/internal/config"
 	"github.com/Maro1O9/goauth/internal/server"
 )
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
+func gracefulShutdown(apiServer *http.Server, timeout time.Duration, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -43,9 +46,9 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 
 	log.Println("shutting down gracefully, press Ctrl+C again to force")
 
-	// The context is used to inform the server it has 5 seconds to finish
+	// The context is used to inform the server how long it has to finish
 	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	if err := apiServer.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown with error: %v", err)
@@ -57,17 +60,44 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 	done <- true
 }
 
+// commands are the subcommands of the binary, run as main <command> [args].
+// Without a command the binary serves the API.
+var commands = map[string]func(cfg *config.Config, args []string, in io.Reader, out io.Writer) error{
+	"migrate":         runMigrate,
+	"createsuperuser": runCreateSuperuser,
+	"user":            runUser,
+	"set-password":    runSetPassword,
+	"token":           runToken,
+}
+
 func main() {
+	// The configuration is read from the config file named by CONFIG_FILE,
+	// the .env file and the environment
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	if len(os.Args) > 1 {
+		command, ok := commands[os.Args[1]]
+		if !ok {
+			log.Fatalf("unknown command %q", os.Args[1])
+		}
+		if err := command(cfg, os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			log.Fatalln(err.Error())
+		}
+		return
+	}
 
-	server := server.NewServer()
+	server := server.NewServer(cfg)
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server, cfg.HTTP.ShutdownTimeout, done)
 
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
 		panic(fmt.Sprintf("http server error: %s", err))
 	}
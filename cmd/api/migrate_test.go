@@ -0,0 +1,55 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMigrate(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.URL = filepath.Join(t.TempDir(), "test.db")
+
+	run := func(args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+		require.NoError(t, runMigrate(cfg, args, nil, &out))
+		return out.String()
+	}
+
+	require.Contains(t, run("status"), "1        initial_schema    pending")
+	require.Equal(t, "applied 1_initial_schema\napplied 2_password_history\napplied 3_token_version\n", run("up"))
+	require.Equal(t, "no pending migrations\n", run("up"))
+	require.NotContains(t, run("status"), "pending")
+	require.Equal(t, "reverted 3_token_version\nreverted 2_password_history\nreverted 1_initial_schema\n", run("down", "-steps", "5"))
+	require.Equal(t, "no applied migrations\n", run("down"))
+
+	for _, args := range [][]string{nil, {"sideways"}, {"up", "extra"}, {"down", "-steps", "many"}} {
+		require.Error(t, runMigrate(cfg, args, nil, &bytes.Buffer{}), args)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+// token.go implements the token command, which mints access tokens for
+// users and inspects tokens signed by the server.
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+)
+
+const tokenUsage = `usage: main token <command>
+
+commands:
+  mint [-ttl duration] <user>   print an access token of a user, lasting for the
+                                access token lifetime by default
+  inspect <token>               verify a token and show its claims
+
+<user> is an ID, a username or an email.`
+
+// runToken runs the token command with its arguments.
+func runToken(cfg *config.Config, args []string, _ io.Reader, out io.Writer) error {
+	if len(args) == 0 || (args[0] != "mint" && args[0] != "inspect") {
+		return errors.New(tokenUsage)
+	}
+
+	flags := flag.NewFlagSet("token "+args[0], flag.ContinueOnError)
+	flags.SetOutput(out)
+	var ttl *time.Duration
+	if args[0] == "mint" {
+		ttl = flags.Duration("ttl", 0, "lifetime of the token")
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(tokenUsage)
+	}
+
+	operator, err := openOperator(cfg)
+	if err != nil {
+		return err
+	}
+	defer operator.Close()
+
+	if args[0] == "mint" {
+		if *ttl < 0 {
+			return errors.New("the lifetime of the token must be positive")
+		}
+		user, err := operator.FindUser(flags.Arg(0), false)
+		if err != nil {
+			return err
+		}
+		token, err := operator.MintToken(user, *ttl)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, token)
+		return nil
+	}
+
+	info, err := operator.InspectToken(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	claims := info.Claims
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\t%s\n", claims.ID)
+	fmt.Fprintf(w, "Type\t%s\n", claims.Type)
+	fmt.Fprintf(w, "Subject\t%s\n", claims.Subject)
+	if info.User != nil {
+		fmt.Fprintf(w, "User\t%s (%d)\n", info.User.Username, info.User.ID)
+	}
+	if claims.ClientID != "" {
+		fmt.Fprintf(w, "Client\t%s\n", claims.ClientID)
+	}
+	if claims.Scope != "" {
+		fmt.Fprintf(w, "Scope\t%s\n", claims.Scope)
+	}
+	fmt.Fprintf(w, "Roles\t%s\n", strings.Join(claims.Roles, ", "))
+	fmt.Fprintf(w, "Permissions\t%s\n", strings.Join(claims.Permissions, ", "))
+	if claims.SessionID != "" {
+		fmt.Fprintf(w, "Session\t%s\n", claims.SessionID)
+	}
+	if claims.IssuedAt != nil {
+		fmt.Fprintf(w, "Issued\t%s\n", claims.IssuedAt.Local().Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Expires\t%s\n", claims.ExpiresAt.Local().Format(time.RFC3339))
+	fmt.Fprintf(w, "Revoked\t%s\n", yesNo(info.Revoked))
+	return w.Flush()
+}
@@ -0,0 +1,292 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+// users.go implements the createsuperuser, user and set-password commands,
+// which manage the users of the configured database like the admin routes.
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	osuser "os/user"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/server"
+	"golang.org/x/term"
+)
+
+const createSuperuserUsage = `usage: main createsuperuser -username name -email address [-name name]
+
+The password is prompted for, or read from the first two lines of the
+standard input if it is not a terminal.`
+
+const userUsage = `usage: main user <command>
+
+commands:
+  list [-q search] [-deleted]   list the users, with the soft deleted ones if -deleted
+  show <user>                   show the details of a user
+  activate <user>               let a user log in again
+  deactivate <user>             stop a user from logging in and sign it out
+  delete <user>                 soft delete a user and sign it out
+
+<user> is an ID, a username or an email.`
+
+const setPasswordUsage = `usage: main set-password <user>
+
+<user> is an ID, a username or an email. The password is prompted for, or
+read from the first two lines of the standard input if it is not a terminal.`
+
+// openOperator creates the operator running the command, named after the
+// system user.
+func openOperator(cfg *config.Config) (*server.Operator, error) {
+	name := "unknown"
+	if current, err := osuser.Current(); err == nil {
+		name = current.Username
+	}
+	return server.NewOperator(cfg, name)
+}
+
+// passwordReader reads passwords from the standard input.
+type passwordReader struct {
+	in    io.Reader
+	lines *bufio.Reader
+	out   io.Writer
+}
+
+func newPasswordReader(in io.Reader, out io.Writer) *passwordReader {
+	return &passwordReader{in: in, lines: bufio.NewReader(in), out: out}
+}
+
+// read prints the prompt and reads a password, without echoing it if the
+// input is a terminal.
+func (r *passwordReader) read(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	if file, ok := r.in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		password, err := term.ReadPassword(int(file.Fd()))
+		fmt.Fprintln(r.out)
+		return string(password), err
+	}
+
+	line, err := r.lines.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", errors.New("could not read the password")
+	}
+	fmt.Fprintln(r.out)
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readNew reads a new password and its confirmation.
+func (r *passwordReader) readNew() (password, confirmation string, err error) {
+	if password, err = r.read("Password: "); err != nil {
+		return "", "", err
+	}
+	if confirmation, err = r.read("Password (again): "); err != nil {
+		return "", "", err
+	}
+	return password, confirmation, nil
+}
+
+// runCreateSuperuser runs the createsuperuser command with its arguments.
+func runCreateSuperuser(cfg *config.Config, args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("createsuperuser", flag.ContinueOnError)
+	flags.SetOutput(out)
+	username := flags.String("username", "", "username of the superuser")
+	email := flags.String("email", "", "email of the superuser")
+	name := flags.String("name", "", "name of the superuser, the username by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 || *username == "" || *email == "" {
+		return errors.New(createSuperuserUsage)
+	}
+	if *name == "" {
+		*name = *username
+	}
+
+	password, confirmation, err := newPasswordReader(in, out).readNew()
+	if err != nil {
+		return err
+	}
+
+	operator, err := openOperator(cfg)
+	if err != nil {
+		return err
+	}
+	defer operator.Close()
+
+	user, err := operator.CreateSuperuser(inputs.InputUser{
+		Username:        *username,
+		Name:            *name,
+		Email:           *email,
+		Password:        password,
+		ConfirmPassword: confirmation,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "created superuser %s (%d)\n", user.Username, user.ID)
+	return nil
+}
+
+// runUser runs the user command with its arguments.
+func runUser(cfg *config.Config, args []string, _ io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+
+	flags := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	flags.SetOutput(out)
+	var search *string
+	var deleted *bool
+	switch args[0] {
+	case "list":
+		search = flags.String("q", "", "part of the username, email or name")
+		deleted = flags.Bool("deleted", false, "list the soft deleted users too")
+	case "show", "activate", "deactivate", "delete":
+	default:
+		return errors.New(userUsage)
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if (args[0] == "list") != (flags.NArg() == 0) || flags.NArg() > 1 {
+		return errors.New(userUsage)
+	}
+
+	operator, err := openOperator(cfg)
+	if err != nil {
+		return err
+	}
+	defer operator.Close()
+
+	if args[0] == "list" {
+		filter := repository.UserFilter{Search: *search}
+		if *deleted {
+			filter.Deleted = repository.IncludeDeleted
+		}
+		users, _, err := operator.ListUsers(filter)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tACTIVE\tSTAFF\tSUPERUSER\tDELETED")
+		for _, user := range users {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", user.ID, user.Username, user.Email,
+				yesNo(user.IsActive), yesNo(user.IsStaff), yesNo(user.IsSuperuser), yesNo(user.DeletedAt.Valid))
+		}
+		return w.Flush()
+	}
+
+	user, err := operator.FindUser(flags.Arg(0), args[0] == "show")
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "show":
+		return showUser(out, user)
+	case "activate", "deactivate":
+		if err := operator.SetActive(user, args[0] == "activate"); err != nil {
+			return err
+		}
+		if args[0] == "deactivate" {
+			fmt.Fprintf(out, "deactivated user %s, the user was signed out everywhere\n", user.Username)
+		} else {
+			fmt.Fprintf(out, "activated user %s\n", user.Username)
+		}
+	case "delete":
+		if err := operator.DeleteUser(user); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "deleted user %s, the user was signed out everywhere\n", user.Username)
+	}
+	return nil
+}
+
+// showUser prints the details of the user.
+func showUser(out io.Writer, user *models.User) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\t%d\n", user.ID)
+	fmt.Fprintf(w, "Username\t%s\n", user.Username)
+	fmt.Fprintf(w, "Name\t%s\n", user.Name)
+	fmt.Fprintf(w, "Email\t%s\n", user.Email)
+	fmt.Fprintf(w, "Email verified\t%s\n", yesNo(user.EmailVerified))
+	fmt.Fprintf(w, "Active\t%s\n", yesNo(user.IsActive))
+	fmt.Fprintf(w, "Staff\t%s\n", yesNo(user.IsStaff))
+	fmt.Fprintf(w, "Superuser\t%s\n", yesNo(user.IsSuperuser))
+	fmt.Fprintf(w, "Password reset required\t%s\n", yesNo(user.PasswordResetRequired))
+	fmt.Fprintf(w, "Created\t%s\n", user.CreatedAt.Local().Format(time.RFC3339))
+	if user.DeletedAt.Valid {
+		fmt.Fprintf(w, "Deleted\t%s\n", user.DeletedAt.Time.Local().Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// runSetPassword runs the set-password command with its arguments.
+func runSetPassword(cfg *config.Config, args []string, in io.Reader, out io.Writer) error {
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		return errors.New(setPasswordUsage)
+	}
+
+	operator, err := openOperator(cfg)
+	if err != nil {
+		return err
+	}
+	defer operator.Close()
+
+	user, err := operator.FindUser(args[0], false)
+	if err != nil {
+		return err
+	}
+
+	password, confirmation, err := newPasswordReader(in, out).readNew()
+	if err != nil {
+		return err
+	}
+	if password != confirmation {
+		return errors.New("passwords do not match")
+	}
+
+	if err := operator.SetPassword(user, password); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "changed the password of %s, the user was signed out everywhere\n", user.Username)
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCommands(t *testing.T) {
+	cfg := config.Default()
+	cfg.SecretKey = "a test secret key of at least 32 characters"
+	cfg.Database.URL = filepath.Join(t.TempDir(), "test.db")
+	cfg.Database.MigrateOnStart = true
+	cfg.PasswordPolicy.MinStrength = 0
+
+	type command func(cfg *config.Config, args []string, in io.Reader, out io.Writer) error
+	run := func(command command, input string, args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+		require.NoError(t, command(cfg, args, strings.NewReader(input), &out))
+		return out.String()
+	}
+
+	// Passwords are read from the input when it is not a terminal
+	require.Contains(t, run(runCreateSuperuser, "Password123\nPassword123\n", "-username", "root", "-email", "root@example.com"),
+		"created superuser root (1)")
+	require.Error(t, runCreateSuperuser(cfg, []string{"-username", "root", "-email", "root@example.com"},
+		strings.NewReader("Password123\nPassword123\n"), &bytes.Buffer{}), "taken")
+	require.Error(t, runCreateSuperuser(cfg, []string{"-username", "other", "-email", "other@example.com"},
+		strings.NewReader("Password123\n"), &bytes.Buffer{}), "no confirmation")
+
+	require.Regexp(t, `1\s+root\s+root@example.com\s+yes\s+yes\s+yes\s+no`, run(runUser, "", "list"))
+	require.Regexp(t, `Superuser\s+yes`, run(runUser, "", "show", "root@example.com"))
+
+	token := strings.TrimSpace(run(runToken, "", "mint", "-ttl", "5m", "root"))
+	inspected := run(runToken, "", "inspect", token)
+	require.Regexp(t, `User\s+root \(1\)`, inspected)
+	require.Regexp(t, `Roles\s+.*superuser`, inspected)
+	require.Regexp(t, `Revoked\s+no`, inspected)
+
+	require.Contains(t, run(runSetPassword, "Password456\nPassword456\n", "root"), "changed the password of root")
+	require.Regexp(t, `Revoked\s+yes`, run(runToken, "", "inspect", token))
+	require.Error(t, runSetPassword(cfg, []string{"root"}, strings.NewReader("Password456\nPassword789\n"), &bytes.Buffer{}))
+
+	require.Equal(t, "deactivated user root, the user was signed out everywhere\n", run(runUser, "", "deactivate", "1"))
+	require.Regexp(t, `Active\s+no`, run(runUser, "", "show", "root"))
+	require.Error(t, runToken(cfg, []string{"mint", "root"}, nil, &bytes.Buffer{}))
+	require.Equal(t, "activated user root\n", run(runUser, "", "activate", "root"))
+	require.Equal(t, "deleted user root, the user was signed out everywhere\n", run(runUser, "", "delete", "root"))
+	require.NotContains(t, run(runUser, "", "list"), "root")
+	require.Regexp(t, `Deleted\s+`, run(runUser, "", "show", "1"))
+
+	usage := []struct {
+		command command
+		args    []string
+	}{
+		{runCreateSuperuser, []string{"-username", "root"}},
+		{runUser, nil},
+		{runUser, []string{"ban", "root"}},
+		{runUser, []string{"show"}},
+		{runUser, []string{"list", "extra"}},
+		{runSetPassword, nil},
+		{runToken, []string{"mint"}},
+		{runToken, []string{"decode", token}},
+	}
+	for _, test := range usage {
+		require.Contains(t, test.command(cfg, test.args, nil, &bytes.Buffer{}).Error(), "usage:", test.args)
+	}
+}
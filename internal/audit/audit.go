@@ -0,0 +1,185 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package audit
+
+// audit.go records security relevant events without blocking the requests
+// that cause them.
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionSignup             = "user.signup"
+	ActionUserCreate         = "user.create"
+	ActionLogin              = "user.login"
+	ActionUserUpdate         = "user.update"
+	ActionUserDelete         = "user.delete"
+	ActionUserRestore        = "user.restore"
+	ActionUserPasswordReset  = "user.password_reset"
+	ActionUserSetPassword    = "user.set_password"
+	ActionUserUnlock         = "user.unlock"
+	ActionSessionRevoke      = "session.revoke"
+	ActionTokenMint          = "token.mint"
+	ActionRoleCreate         = "role.create"
+	ActionRoleUpdate         = "role.update"
+	ActionRoleDelete         = "role.delete"
+	ActionRoleAssign         = "role.assign"
+	ActionRoleUnassign       = "role.unassign"
+	ActionClientCreate       = "client.create"
+	ActionClientRotateSecret = "client.rotate_secret"
+	ActionClientDisable      = "client.disable"
+	ActionClientEnable       = "client.enable"
+	ActionAccessDenied       = "access.denied"
+)
+
+// Outcomes of an action.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Types of actors and targets. Operators are the people running the admin
+// commands, identified by their system user name.
+const (
+	TypeUser     = "user"
+	TypeClient   = "client"
+	TypeRole     = "role"
+	TypeOperator = "operator"
+)
+
+// Metadata holds the details of an event.
+type Metadata map[string]interface{}
+
+// Defaults of a Writer.
+const (
+	DefaultBufferSize    = 1024
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = time.Second
+)
+
+// Writer writes events to a Store in the background. Events are queued by
+// Write and inserted in batches by Run.
+type Writer struct {
+	store         Store
+	events        chan *models.AuditEvent
+	flushes       chan chan struct{}
+	batchSize     int
+	flushInterval time.Duration
+}
+
+// NewWriter creates a writer to store queuing up to bufferSize events, or
+// DefaultBufferSize if it is not positive.
+func NewWriter(store Store, bufferSize int) *Writer {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Writer{
+		store:         store,
+		events:        make(chan *models.AuditEvent, bufferSize),
+		flushes:       make(chan chan struct{}),
+		batchSize:     DefaultBatchSize,
+		flushInterval: DefaultFlushInterval,
+	}
+}
+
+// Write queues the event with the given metadata. It never blocks: if the
+// queue is full, the event is logged and dropped.
+func (w *Writer) Write(event *models.AuditEvent, metadata Metadata) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if len(metadata) > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			log.Printf("could not encode audit metadata of %s: %v", event.Action, err)
+		}
+		event.Metadata = string(encoded)
+	}
+
+	select {
+	case w.events <- event:
+	default:
+		log.Printf("audit queue full, dropped %s event of %s %s", event.Action, event.ActorType, event.ActorID)
+	}
+}
+
+// Flush waits until the queued events are written. Run must be running.
+func (w *Writer) Flush() {
+	done := make(chan struct{})
+	w.flushes <- done
+	<-done
+}
+
+// Run writes the queued events until ctx is canceled, then writes the
+// events still queued and returns.
+func (w *Writer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	var batch []*models.AuditEvent
+	for {
+		select {
+		case event := <-w.events:
+			if batch = append(batch, event); len(batch) >= w.batchSize {
+				batch = w.write(batch)
+			}
+		case <-ticker.C:
+			batch = w.write(batch)
+		case done := <-w.flushes:
+			batch = w.write(w.drain(batch))
+			close(done)
+		case <-ctx.Done():
+			w.write(w.drain(batch))
+			return
+		}
+	}
+}
+
+// drain appends the queued events to batch.
+func (w *Writer) drain(batch []*models.AuditEvent) []*models.AuditEvent {
+	for {
+		select {
+		case event := <-w.events:
+			batch = append(batch, event)
+		default:
+			return batch
+		}
+	}
+}
+
+// write inserts the batch and returns it emptied for reuse.
+func (w *Writer) write(batch []*models.AuditEvent) []*models.AuditEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := w.store.Insert(batch); err != nil {
+		log.Printf("could not write %d audit events: %v", len(batch), err)
+	}
+	return batch[:0]
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditEvent{}))
+	return db
+}
+
+func TestWriter(t *testing.T) {
+	db := newDB(t)
+	writer := audit.NewWriter(audit.NewDBStore(db), 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		writer.Run(ctx)
+		close(done)
+	}()
+
+	writer.Write(&models.AuditEvent{Action: audit.ActionSignup, Outcome: audit.OutcomeSuccess}, audit.Metadata{"email": "a@example.com"})
+	writer.Flush()
+
+	var events []models.AuditEvent
+	require.NoError(t, db.Find(&events).Error)
+	require.Len(t, events, 1)
+	require.False(t, events[0].CreatedAt.IsZero())
+	require.JSONEq(t, `{"email":"a@example.com"}`, events[0].Metadata)
+
+	// Events still queued are written on shutdown
+	writer.Write(&models.AuditEvent{Action: audit.ActionLogin, Outcome: audit.OutcomeFailure}, nil)
+	cancel()
+	<-done
+
+	var count int64
+	require.NoError(t, db.Model(&models.AuditEvent{}).Count(&count).Error)
+	require.Equal(t, int64(2), count)
+
+	// Events cannot be changed
+	require.ErrorIs(t, db.Model(&events[0]).Update("outcome", audit.OutcomeFailure).Error, models.ErrAuditEventImmutable)
+	require.ErrorIs(t, db.Delete(&events[0]).Error, models.ErrAuditEventImmutable)
+}
+
+func TestWriterDropsWhenFull(t *testing.T) {
+	db := newDB(t)
+	writer := audit.NewWriter(audit.NewDBStore(db), 2)
+
+	// Nothing consumes the queue, yet writing never blocks
+	for i := 0; i < 5; i++ {
+		writer.Write(&models.AuditEvent{Action: audit.ActionLogin, Outcome: audit.OutcomeSuccess}, nil)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	writer.Run(ctx)
+
+	var count int64
+	require.NoError(t, db.Model(&models.AuditEvent{}).Count(&count).Error)
+	require.Equal(t, int64(2), count)
+}
+
+func TestFind(t *testing.T) {
+	stores := map[string]func(t *testing.T) audit.Store{
+		"memory": func(t *testing.T) audit.Store { return audit.NewMemoryStore() },
+		"db":     func(t *testing.T) audit.Store { return audit.NewDBStore(newDB(t)) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			testFind(t, newStore(t))
+		})
+	}
+}
+
+func testFind(t *testing.T, store audit.Store) {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	for i := 0; i < 7; i++ {
+		event := models.AuditEvent{
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+			Action:    audit.ActionLogin,
+			Outcome:   audit.OutcomeSuccess,
+			ActorType: audit.TypeUser,
+			ActorID:   fmt.Sprint(i % 2),
+		}
+		if i == 6 {
+			event.Action = audit.ActionUserDelete
+			event.ActorID = "9"
+			event.TargetType, event.TargetID = audit.TypeUser, "1"
+		}
+		require.NoError(t, store.Insert([]*models.AuditEvent{&event}))
+	}
+
+	ids := func(events []models.AuditEvent) []uint {
+		result := []uint{}
+		for _, event := range events {
+			result = append(result, event.ID)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		query audit.Query
+		want  []uint
+	}{
+		{"all", audit.Query{}, []uint{7, 6, 5, 4, 3, 2, 1}},
+		{"actor or target", audit.Query{UserID: "1"}, []uint{7, 6, 4, 2}},
+		{"action", audit.Query{Action: audit.ActionUserDelete}, []uint{7}},
+		{"time range", audit.Query{Since: start.Add(2 * time.Minute), Until: start.Add(4 * time.Minute)}, []uint{4, 3}},
+		{"combined", audit.Query{UserID: "0", Action: audit.ActionLogin, Since: start.Add(time.Minute)}, []uint{5, 3}},
+		{"no match", audit.Query{UserID: "42"}, []uint{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, next, err := audit.Find(store, test.query)
+			require.NoError(t, err)
+			require.Equal(t, test.want, ids(events))
+			require.Empty(t, next)
+		})
+	}
+
+	// Pages follow each other without gaps or overlaps
+	var pages [][]uint
+	query := audit.Query{Limit: 3}
+	for {
+		events, next, err := audit.Find(store, query)
+		require.NoError(t, err)
+		pages = append(pages, ids(events))
+		if next == "" {
+			break
+		}
+		query.Cursor = next
+	}
+	require.Equal(t, [][]uint{{7, 6, 5}, {4, 3, 2}, {1}}, pages)
+
+	for _, cursor := range []string{"!", "YWJj", "MA"} {
+		_, _, err := audit.Find(store, audit.Query{Cursor: cursor})
+		require.ErrorIs(t, err, audit.ErrInvalidCursor, cursor)
+	}
+}
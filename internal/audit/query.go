@@ -0,0 +1,116 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package audit
+
+// query.go finds recorded events.
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+)
+
+// Limits of a query page.
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// ErrInvalidCursor is returned for a cursor that was not returned by Find.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Query selects events. Zero fields do not filter.
+type Query struct {
+	// UserID matches the events the user performed or was the target of.
+	UserID string
+	Action string
+	// Since and Until bound the time of the events, Until excluded.
+	Since time.Time
+	Until time.Time
+	// Cursor continues after the last event of a previous page.
+	Cursor string
+	// Limit is the size of the page.
+	Limit int
+}
+
+// Find returns a page of events matching the query, newest first, and the
+// cursor of the next page. The cursor is empty on the last page.
+func Find(store Store, q Query) ([]models.AuditEvent, string, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	var beforeID uint
+	if q.Cursor != "" {
+		id, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		beforeID = id
+	}
+
+	// One more event than asked tells whether there is a next page
+	events, err := store.Search(q, beforeID, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(events) <= limit {
+		return events, "", nil
+	}
+	events = events[:limit]
+	return events, encodeCursor(events[limit-1].ID), nil
+}
+
+// matches reports whether the event matches the filters of the query.
+func (q Query) matches(event *models.AuditEvent) bool {
+	if q.UserID != "" &&
+		!(event.ActorType == TypeUser && event.ActorID == q.UserID) &&
+		!(event.TargetType == TypeUser && event.TargetID == q.UserID) {
+		return false
+	}
+	return (q.Action == "" || event.Action == q.Action) &&
+		(q.Since.IsZero() || !event.CreatedAt.Before(q.Since)) &&
+		(q.Until.IsZero() || event.CreatedAt.Before(q.Until))
+}
+
+func encodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+func decodeCursor(cursor string) (uint, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(string(decoded), 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidCursor
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package audit
+
+// store.go persists the recorded events.
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// Store persists the events of a Writer and looks them up for Find.
+type Store interface {
+	// Insert stores the events and sets their IDs.
+	Insert(events []*models.AuditEvent) error
+	// Search returns up to limit events matching the filters of the query,
+	// newest first. Unless beforeID is 0, only the events with a lower ID
+	// are returned. The cursor and limit of the query are ignored.
+	Search(q Query, beforeID uint, limit int) ([]models.AuditEvent, error)
+}
+
+// MemoryStore keeps the events in memory. They are lost when the process
+// exits, so it is meant for tests and setups without a database.
+type MemoryStore struct {
+	mu     sync.Mutex
+	events []models.AuditEvent
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Insert stores copies of the events.
+func (s *MemoryStore) Insert(events []*models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		event.ID = uint(len(s.events) + 1)
+		s.events = append(s.events, *event)
+	}
+	return nil
+}
+
+// Search returns the matching events.
+func (s *MemoryStore) Search(q Query, beforeID uint, limit int) ([]models.AuditEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := []models.AuditEvent{}
+	for _, event := range s.events {
+		if (beforeID == 0 || event.ID < beforeID) && q.matches(&event) {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID > events[j].ID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// DBStore keeps the events in the audit_events table.
+type DBStore struct {
+	db *gorm.DB
+}
+
+// NewDBStore creates a store that persists the events to db.
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// Insert inserts the events in batches of DefaultBatchSize.
+func (s *DBStore) Insert(events []*models.AuditEvent) error {
+	return s.db.CreateInBatches(events, DefaultBatchSize).Error
+}
+
+// Search queries the matching events.
+func (s *DBStore) Search(q Query, beforeID uint, limit int) ([]models.AuditEvent, error) {
+	query := s.db.Model(&models.AuditEvent{})
+	if q.UserID != "" {
+		query = query.Where("(actor_type = ? AND actor_id = ?) OR (target_type = ? AND target_id = ?)",
+			TypeUser, q.UserID, TypeUser, q.UserID)
+	}
+	if q.Action != "" {
+		query = query.Where("action = ?", q.Action)
+	}
+	if !q.Since.IsZero() {
+		query = query.Where("created_at >= ?", q.Since)
+	}
+	if !q.Until.IsZero() {
+		query = query.Where("created_at < ?", q.Until)
+	}
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	var events []models.AuditEvent
+	if err := query.Order("id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
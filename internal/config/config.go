@@ -0,0 +1,565 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package config
+
+// config.go defines the settings of the server, their defaults and how they
+// are validated. Every setting can be given in the config file under its
+// key and in the environment under its env name.
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database"
+	"github.com/Maro1O9/goauth/internal/keys"
+	"github.com/Maro1O9/goauth/internal/lockout"
+	"github.com/Maro1O9/goauth/internal/password"
+	"github.com/Maro1O9/goauth/internal/ratelimit"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minSecretKeyLength is the minimum length of SECRET_KEY.
+const minSecretKeyLength = 32
+
+// Bounds of the password hashing parameters. scrypt uses 1 KiB times
+// 2^SCRYPT_COST of memory.
+const (
+	maxArgon2Memory = 4 * 1024 * 1024
+	minScryptCost   = 10
+	maxScryptCost   = 20
+)
+
+// maxPasswordLength bounds PASSWORD_MAX_LENGTH, as hashing and estimating
+// the strength of long passwords is costly.
+const maxPasswordLength = 1024
+
+// Config is the configuration of the server.
+type Config struct {
+	// Port is the port the HTTP server listens on.
+	Port int `key:"port" env:"PORT"`
+	// AppURL is the public URL of the application, used in the links sent
+	// by email.
+	AppURL string `key:"app_url" env:"APP_URL"`
+	// SecretKey encrypts secrets at rest unless EncryptionKey is set.
+	SecretKey string `key:"secret_key" env:"SECRET_KEY"`
+	// EncryptionKey encrypts secrets at rest, such as TOTP secrets and
+	// signing keys.
+	EncryptionKey string `key:"encryption_key" env:"ENCRYPTION_KEY"`
+
+	HTTP           HTTP           `key:"http"`
+	Cookies        Cookies        `key:"cookies"`
+	CORS           CORS           `key:"cors"`
+	Database       Database       `key:"database"`
+	Tokens         Tokens         `key:"tokens"`
+	JWT            JWT            `key:"jwt"`
+	Password       Password       `key:"password"`
+	PasswordPolicy PasswordPolicy `key:"password_policy"`
+	Mail           Mail           `key:"mail"`
+	OIDC           OIDC           `key:"oidc"`
+	TOTP           TOTP           `key:"totp"`
+	WebAuthn       WebAuthn       `key:"webauthn"`
+	Verification   Verification   `key:"verification"`
+	Lockout        Lockout        `key:"lockout"`
+	RateLimit      RateLimit      `key:"rate_limit"`
+}
+
+// HTTP configures the HTTP server.
+type HTTP struct {
+	ReadTimeout  time.Duration `key:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout time.Duration `key:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `key:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	// ShutdownTimeout is how long the requests in flight may take to finish
+	// when the server shuts down.
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+	// TrustedProxies are the proxies whose forwarding headers are trusted.
+	TrustedProxies []string `key:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+// Cookies configures the token cookies.
+type Cookies struct {
+	// Domain is the domain of the cookies. If empty they are only sent to
+	// the host that set them.
+	Domain string `key:"domain" env:"COOKIE_DOMAIN"`
+	// Secure restricts the cookies to HTTPS.
+	Secure bool `key:"secure" env:"COOKIE_SECURE"`
+	// SameSite is lax, strict, none or empty to leave the attribute out.
+	SameSite string `key:"same_site" env:"COOKIE_SAME_SITE"`
+}
+
+// CORS configures the cross-origin requests browsers may make.
+type CORS struct {
+	AllowOrigins     []string      `key:"allow_origins" env:"CORS_ALLOW_ORIGINS"`
+	AllowCredentials bool          `key:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `key:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// Database configures the database connection, see database.Config.
+type Database struct {
+	URL             string        `key:"url" env:"DATABASE_URL"`
+	MaxOpenConns    int           `key:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `key:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `key:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `key:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	ConnectTimeout  time.Duration `key:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	// MigrateOnStart applies the pending migrations when the server starts.
+	// Otherwise the server refuses to start while migrations are pending.
+	MigrateOnStart bool `key:"migrate_on_start" env:"DB_MIGRATE_ON_START"`
+}
+
+// Config returns the settings to open the database with.
+func (d Database) Config() database.Config {
+	return database.Config{
+		URL:             d.URL,
+		MaxOpenConns:    d.MaxOpenConns,
+		MaxIdleConns:    d.MaxIdleConns,
+		ConnMaxLifetime: d.ConnMaxLifetime,
+		ConnMaxIdleTime: d.ConnMaxIdleTime,
+		ConnectTimeout:  d.ConnectTimeout,
+	}
+}
+
+// Tokens configures the lifetime of the issued tokens.
+type Tokens struct {
+	AccessTTL            time.Duration `key:"access_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTTL           time.Duration `key:"refresh_ttl" env:"REFRESH_TOKEN_TTL"`
+	MFATTL               time.Duration `key:"mfa_ttl" env:"MFA_TOKEN_TTL"`
+	SessionTTL           time.Duration `key:"session_ttl" env:"SESSION_TOKEN_TTL"`
+	PasswordResetTTL     time.Duration `key:"password_reset_ttl" env:"PASSWORD_RESET_TOKEN_TTL"`
+	EmailVerificationTTL time.Duration `key:"email_verification_ttl" env:"EMAIL_VERIFICATION_TOKEN_TTL"`
+	AuthorizationCodeTTL time.Duration `key:"authorization_code_ttl" env:"AUTHORIZATION_CODE_TTL"`
+	IDTokenTTL           time.Duration `key:"id_token_ttl" env:"ID_TOKEN_TTL"`
+}
+
+// JWT configures the signing keys.
+type JWT struct {
+	// Algorithm is RS256, ES256 or EdDSA.
+	Algorithm           string        `key:"algorithm" env:"JWT_ALGORITHM"`
+	KeyRotationInterval time.Duration `key:"key_rotation_interval" env:"JWT_KEY_ROTATION_INTERVAL"`
+	// KeyOverlap is how long a rotated key keeps verifying tokens. It must
+	// be at least the lifetime of the access tokens.
+	KeyOverlap time.Duration `key:"key_overlap" env:"JWT_KEY_OVERLAP"`
+}
+
+// Password configures how passwords are hashed. New passwords are hashed
+// with Algorithm, and the stored hashes made with another algorithm or
+// other parameters are upgraded when their user logs in.
+type Password struct {
+	// Algorithm is argon2id, bcrypt or scrypt.
+	Algorithm string `key:"algorithm" env:"PASSWORD_HASH_ALGORITHM"`
+	// Argon2Memory is the memory used by argon2id in KiB.
+	Argon2Memory      int `key:"argon2_memory" env:"ARGON2_MEMORY"`
+	Argon2Time        int `key:"argon2_time" env:"ARGON2_TIME"`
+	Argon2Parallelism int `key:"argon2_parallelism" env:"ARGON2_PARALLELISM"`
+	BcryptCost        int `key:"bcrypt_cost" env:"BCRYPT_COST"`
+	// ScryptCost is the binary logarithm of the cost parameter N of scrypt.
+	ScryptCost int `key:"scrypt_cost" env:"SCRYPT_COST"`
+}
+
+// Manager returns the manager hashing the passwords with the algorithm
+// and verifying the hashes of every algorithm.
+func (p Password) Manager() *password.Manager {
+	hashers := []password.Hasher{
+		password.Argon2id{Memory: uint32(p.Argon2Memory), Time: uint32(p.Argon2Time), Parallelism: uint8(p.Argon2Parallelism)},
+		password.Bcrypt{Cost: p.BcryptCost},
+		password.Scrypt{LogN: uint8(p.ScryptCost), BlockSize: 8, Parallelism: 1},
+	}
+	current := hashers[0]
+	for _, hasher := range hashers {
+		if hasher.Algorithm() == p.Algorithm {
+			current = hasher
+		}
+	}
+	return password.NewManager(current, hashers...)
+}
+
+// PasswordPolicy configures the rules new passwords follow, see
+// password.Policy.
+type PasswordPolicy struct {
+	MinLength int `key:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	// MaxLength is at most 72 with bcrypt, which refuses longer passwords.
+	MaxLength        int  `key:"max_length" env:"PASSWORD_MAX_LENGTH"`
+	RequireUppercase bool `key:"require_uppercase" env:"PASSWORD_REQUIRE_UPPERCASE"`
+	RequireLowercase bool `key:"require_lowercase" env:"PASSWORD_REQUIRE_LOWERCASE"`
+	RequireDigit     bool `key:"require_digit" env:"PASSWORD_REQUIRE_DIGIT"`
+	RequireSymbol    bool `key:"require_symbol" env:"PASSWORD_REQUIRE_SYMBOL"`
+	// MinStrength is the minimal zxcvbn score from 0 to 4, 0 disabling the
+	// strength estimation.
+	MinStrength    int  `key:"min_strength" env:"PASSWORD_MIN_STRENGTH"`
+	RejectPersonal bool `key:"reject_personal" env:"PASSWORD_REJECT_PERSONAL"`
+	// History is the number of the last passwords of a user, the current
+	// one included, a new password must differ from. 0 allows reusing any.
+	History int `key:"history" env:"PASSWORD_HISTORY"`
+	// BreachedFile is a file of SHA-1 hashes of breached passwords, or a
+	// directory of Pwned Passwords range files. If empty, passwords are not
+	// checked against breaches.
+	BreachedFile string `key:"breached_file" env:"PASSWORD_BREACHED_FILE"`
+}
+
+// Policy returns the password policy, loading the breached passwords.
+func (p PasswordPolicy) Policy() (*password.Policy, error) {
+	policy := &password.Policy{
+		MinLength:        p.MinLength,
+		MaxLength:        p.MaxLength,
+		RequireUppercase: p.RequireUppercase,
+		RequireLowercase: p.RequireLowercase,
+		RequireDigit:     p.RequireDigit,
+		RequireSymbol:    p.RequireSymbol,
+		MinStrength:      p.MinStrength,
+		RejectPersonal:   p.RejectPersonal,
+	}
+	if p.BreachedFile != "" {
+		breached, err := password.OpenBreachedList(p.BreachedFile)
+		if err != nil {
+			return nil, fmt.Errorf("PASSWORD_BREACHED_FILE: %w", err)
+		}
+		policy.Breached = breached
+	}
+	return policy, nil
+}
+
+// Mail configures the outbound mail.
+type Mail struct {
+	// Dir is the directory messages are written to as files. If empty they
+	// are logged.
+	Dir string `key:"dir" env:"MAIL_DIR"`
+}
+
+// OIDC configures the OpenID Connect provider.
+type OIDC struct {
+	// Issuer defaults to AppURL, or http://localhost if that is not set.
+	Issuer string `key:"issuer" env:"OIDC_ISSUER"`
+}
+
+// TOTP configures the authenticator apps.
+type TOTP struct {
+	// Issuer is the name authenticator apps show for the accounts.
+	Issuer string `key:"issuer" env:"TOTP_ISSUER"`
+}
+
+// WebAuthn configures the WebAuthn relying party.
+type WebAuthn struct {
+	RPID string `key:"rp_id" env:"WEBAUTHN_RP_ID"`
+	// RPName defaults to the TOTP issuer.
+	RPName string `key:"rp_name" env:"WEBAUTHN_RP_NAME"`
+	// Origins default to AppURL, or http://localhost if that is not set.
+	Origins []string `key:"origins" env:"WEBAUTHN_ORIGINS"`
+}
+
+// Verification configures when users have to verify their email.
+type Verification struct {
+	// Policy is allow, deny or grace.
+	Policy string `key:"policy" env:"EMAIL_VERIFICATION_POLICY"`
+	// Grace is how long unverified users may log in with the grace policy.
+	Grace time.Duration `key:"grace" env:"EMAIL_VERIFICATION_GRACE"`
+	// ResendInterval is how long a user waits before another verification
+	// email is sent.
+	ResendInterval time.Duration `key:"resend_interval" env:"EMAIL_VERIFICATION_RESEND_INTERVAL"`
+}
+
+// Lockout configures how failed logins lock accounts and IPs, see
+// lockout.Config.
+type Lockout struct {
+	// Store is database or memory.
+	Store              string        `key:"store" env:"LOGIN_ATTEMPT_STORE"`
+	MaxAccountFailures int           `key:"max_account_failures" env:"LOGIN_MAX_ACCOUNT_FAILURES"`
+	MaxIPFailures      int           `key:"max_ip_failures" env:"LOGIN_MAX_IP_FAILURES"`
+	FreeFailures       int           `key:"free_failures" env:"LOGIN_FREE_FAILURES"`
+	FailureWindow      time.Duration `key:"failure_window" env:"LOGIN_FAILURE_WINDOW"`
+	LockoutDuration    time.Duration `key:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION"`
+}
+
+// RateLimit configures the rate limits of the route groups, written as
+// requests/period like 60/1m, or off.
+type RateLimit struct {
+	// Store is memory or redis.
+	Store    string `key:"store" env:"RATE_LIMIT_STORE"`
+	RedisURL string `key:"redis_url" env:"REDIS_URL"`
+	Auth     string `key:"auth" env:"RATE_LIMIT_AUTH"`
+	OAuth    string `key:"oauth" env:"RATE_LIMIT_OAUTH"`
+	Token    string `key:"token" env:"RATE_LIMIT_TOKEN"`
+	API      string `key:"api" env:"RATE_LIMIT_API"`
+}
+
+// Default returns the default configuration. It lacks the required
+// DATABASE_URL and SECRET_KEY.
+func Default() *Config {
+	return &Config{
+		Port: 8080,
+		HTTP: HTTP{
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     time.Minute,
+			ShutdownTimeout: 5 * time.Second,
+		},
+		Cookies: Cookies{Secure: true},
+		CORS: CORS{
+			AllowOrigins:     []string{"*"},
+			AllowCredentials: true,
+			MaxAge:           12 * time.Hour,
+		},
+		Database: Database{
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  10 * time.Second,
+		},
+		Tokens: Tokens{
+			AccessTTL:            15 * time.Minute,
+			RefreshTTL:           30 * 24 * time.Hour,
+			MFATTL:               5 * time.Minute,
+			SessionTTL:           12 * time.Hour,
+			PasswordResetTTL:     time.Hour,
+			EmailVerificationTTL: 24 * time.Hour,
+			AuthorizationCodeTTL: time.Minute,
+			IDTokenTTL:           time.Hour,
+		},
+		JWT: JWT{
+			Algorithm:           string(keys.ES256),
+			KeyRotationInterval: keys.DefaultRotationInterval,
+			KeyOverlap:          keys.DefaultOverlap,
+		},
+		// The argon2id and scrypt parameters recommended by OWASP
+		Password: Password{
+			Algorithm:         password.AlgorithmArgon2id,
+			Argon2Memory:      19 * 1024,
+			Argon2Time:        2,
+			Argon2Parallelism: 1,
+			BcryptCost:        bcrypt.DefaultCost,
+			ScryptCost:        15,
+		},
+		PasswordPolicy: PasswordPolicy{
+			MinLength:      8,
+			MaxLength:      128,
+			MinStrength:    3,
+			RejectPersonal: true,
+			History:        5,
+		},
+		TOTP:     TOTP{Issuer: "goAuth"},
+		WebAuthn: WebAuthn{RPID: "localhost"},
+		Verification: Verification{
+			Policy:         "allow",
+			Grace:          72 * time.Hour,
+			ResendInterval: 2 * time.Minute,
+		},
+		Lockout: Lockout{
+			Store:              "database",
+			MaxAccountFailures: lockout.DefaultMaxAccountFailures,
+			MaxIPFailures:      lockout.DefaultMaxIPFailures,
+			FreeFailures:       lockout.DefaultFreeFailures,
+			FailureWindow:      lockout.DefaultWindow,
+			LockoutDuration:    lockout.DefaultLockoutDuration,
+		},
+		RateLimit: RateLimit{
+			Store: "memory",
+			Auth:  "60/1m",
+			OAuth: "120/1m",
+			Token: "60/1m",
+			API:   "600/1m",
+		},
+	}
+}
+
+// Error lists every problem found in a configuration.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// problems collects the problems of a configuration.
+type problems []string
+
+func (p *problems) addf(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+// err returns an *Error listing the problems, or nil if there are none.
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return &Error{Problems: p}
+}
+
+// Validate checks the configuration and returns an *Error listing every
+// problem found.
+func (c *Config) Validate() error {
+	var p problems
+
+	if c.Port < 1 || c.Port > 65535 {
+		p.addf("PORT must be between 1 and 65535, got %d", c.Port)
+	}
+	if c.AppURL != "" {
+		if u, err := url.Parse(c.AppURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			p.addf("APP_URL must be an absolute http or https URL, got %q", c.AppURL)
+		}
+	}
+	switch {
+	case c.SecretKey == "":
+		p.addf("SECRET_KEY is required")
+	case len(c.SecretKey) < minSecretKeyLength:
+		p.addf("SECRET_KEY must be at least %d characters long", minSecretKeyLength)
+	}
+	if c.Database.URL == "" {
+		p.addf("DATABASE_URL is required")
+	} else if _, err := c.Database.Config().Dialector(); err != nil {
+		p.addf("DATABASE_URL: %v", err)
+	}
+
+	positive := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", c.HTTP.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.HTTP.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.HTTP.IdleTimeout},
+		{"HTTP_SHUTDOWN_TIMEOUT", c.HTTP.ShutdownTimeout},
+		{"ACCESS_TOKEN_TTL", c.Tokens.AccessTTL},
+		{"REFRESH_TOKEN_TTL", c.Tokens.RefreshTTL},
+		{"MFA_TOKEN_TTL", c.Tokens.MFATTL},
+		{"SESSION_TOKEN_TTL", c.Tokens.SessionTTL},
+		{"PASSWORD_RESET_TOKEN_TTL", c.Tokens.PasswordResetTTL},
+		{"EMAIL_VERIFICATION_TOKEN_TTL", c.Tokens.EmailVerificationTTL},
+		{"AUTHORIZATION_CODE_TTL", c.Tokens.AuthorizationCodeTTL},
+		{"ID_TOKEN_TTL", c.Tokens.IDTokenTTL},
+		{"JWT_KEY_ROTATION_INTERVAL", c.JWT.KeyRotationInterval},
+		{"LOGIN_FAILURE_WINDOW", c.Lockout.FailureWindow},
+		{"LOGIN_LOCKOUT_DURATION", c.Lockout.LockoutDuration},
+	}
+	for _, setting := range positive {
+		if setting.value <= 0 {
+			p.addf("%s must be positive", setting.name)
+		}
+	}
+
+	switch strings.ToLower(c.Cookies.SameSite) {
+	case "", "lax", "strict":
+	case "none":
+		if !c.Cookies.Secure {
+			p.addf("COOKIE_SAME_SITE none requires COOKIE_SECURE")
+		}
+	default:
+		p.addf("COOKIE_SAME_SITE must be lax, strict or none, got %q", c.Cookies.SameSite)
+	}
+	if len(c.CORS.AllowOrigins) == 0 {
+		p.addf("CORS_ALLOW_ORIGINS must list at least one origin")
+	}
+
+	if _, err := keys.ParseAlgorithm(c.JWT.Algorithm); err != nil {
+		p.addf("JWT_ALGORITHM: %v", err)
+	}
+	if c.JWT.KeyOverlap < c.Tokens.AccessTTL {
+		p.addf("JWT_KEY_OVERLAP must be at least the access token lifetime (%s)", c.Tokens.AccessTTL)
+	}
+	switch c.Password.Algorithm {
+	case password.AlgorithmArgon2id, password.AlgorithmBcrypt, password.AlgorithmScrypt:
+	default:
+		p.addf("PASSWORD_HASH_ALGORITHM must be argon2id, bcrypt or scrypt, got %q", c.Password.Algorithm)
+	}
+	if c.Password.Argon2Parallelism < 1 || c.Password.Argon2Parallelism > 255 {
+		p.addf("ARGON2_PARALLELISM must be between 1 and 255, got %d", c.Password.Argon2Parallelism)
+	} else if minMemory := 8 * c.Password.Argon2Parallelism; c.Password.Argon2Memory < minMemory || c.Password.Argon2Memory > maxArgon2Memory {
+		p.addf("ARGON2_MEMORY must be between %d and %d KiB, got %d", minMemory, maxArgon2Memory, c.Password.Argon2Memory)
+	}
+	if c.Password.Argon2Time < 1 {
+		p.addf("ARGON2_TIME must be a positive number")
+	}
+	if c.Password.BcryptCost < bcrypt.MinCost || c.Password.BcryptCost > bcrypt.MaxCost {
+		p.addf("BCRYPT_COST must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, c.Password.BcryptCost)
+	}
+	if c.Password.ScryptCost < minScryptCost || c.Password.ScryptCost > maxScryptCost {
+		p.addf("SCRYPT_COST must be between %d and %d, got %d", minScryptCost, maxScryptCost, c.Password.ScryptCost)
+	}
+	if c.PasswordPolicy.MinLength < 1 {
+		p.addf("PASSWORD_MIN_LENGTH must be a positive number")
+	}
+	if c.PasswordPolicy.MaxLength < c.PasswordPolicy.MinLength || c.PasswordPolicy.MaxLength > maxPasswordLength {
+		p.addf("PASSWORD_MAX_LENGTH must be between PASSWORD_MIN_LENGTH and %d, got %d", maxPasswordLength, c.PasswordPolicy.MaxLength)
+	} else if c.Password.Algorithm == password.AlgorithmBcrypt && c.PasswordPolicy.MaxLength > password.BcryptMaxLength {
+		p.addf("PASSWORD_MAX_LENGTH must be at most %d with bcrypt, got %d", password.BcryptMaxLength, c.PasswordPolicy.MaxLength)
+	}
+	if c.PasswordPolicy.MinStrength < 0 || c.PasswordPolicy.MinStrength > 4 {
+		p.addf("PASSWORD_MIN_STRENGTH must be between 0 and 4, got %d", c.PasswordPolicy.MinStrength)
+	}
+	if c.PasswordPolicy.History < 0 {
+		p.addf("PASSWORD_HISTORY must not be negative, got %d", c.PasswordPolicy.History)
+	}
+	if c.WebAuthn.RPID == "" {
+		p.addf("WEBAUTHN_RP_ID is required")
+	}
+
+	switch c.Verification.Policy {
+	case "allow", "deny", "grace":
+	default:
+		p.addf("EMAIL_VERIFICATION_POLICY must be allow, deny or grace, got %q", c.Verification.Policy)
+	}
+
+	switch c.Lockout.Store {
+	case "database", "memory":
+	default:
+		p.addf("LOGIN_ATTEMPT_STORE must be database or memory, got %q", c.Lockout.Store)
+	}
+	counts := []struct {
+		name  string
+		value int
+	}{
+		{"LOGIN_MAX_ACCOUNT_FAILURES", c.Lockout.MaxAccountFailures},
+		{"LOGIN_MAX_IP_FAILURES", c.Lockout.MaxIPFailures},
+		{"LOGIN_FREE_FAILURES", c.Lockout.FreeFailures},
+	}
+	for _, setting := range counts {
+		if setting.value < 1 {
+			p.addf("%s must be a positive number", setting.name)
+		}
+	}
+
+	switch c.RateLimit.Store {
+	case "memory":
+	case "redis":
+		if c.RateLimit.RedisURL == "" {
+			p.addf("REDIS_URL is required by RATE_LIMIT_STORE redis")
+		}
+	default:
+		p.addf("RATE_LIMIT_STORE must be memory or redis, got %q", c.RateLimit.Store)
+	}
+	limits := []struct {
+		name  string
+		value string
+	}{
+		{"RATE_LIMIT_AUTH", c.RateLimit.Auth},
+		{"RATE_LIMIT_OAUTH", c.RateLimit.OAuth},
+		{"RATE_LIMIT_TOKEN", c.RateLimit.Token},
+		{"RATE_LIMIT_API", c.RateLimit.API},
+	}
+	for _, setting := range limits {
+		if _, err := ratelimit.ParseLimit(setting.value); err != nil {
+			p.addf("%s: %v", setting.name, err)
+		}
+	}
+
+	return p.err()
+}
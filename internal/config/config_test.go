@@ -0,0 +1,244 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+const secretKey = "a test secret key of at least 32 characters"
+
+// writeFile writes a file to a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// env returns a LookupEnv reading from values.
+func env(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+// problems returns the problems listed by a *config.Error.
+func problems(t *testing.T, err error) []string {
+	t.Helper()
+
+	var cfgErr *config.Error
+	require.True(t, errors.As(err, &cfgErr), "%v", err)
+	return cfgErr.Problems
+}
+
+func TestLoad(t *testing.T) {
+	file := writeFile(t, "goauth.yaml", `
+port: 9000
+cookies:
+  domain: auth.example.com
+  same_site: strict
+cors:
+  allow_origins: [https://app.example.com, https://admin.example.com]
+http:
+  read_timeout: 20s
+tokens:
+  access_ttl: 10m
+`)
+	dotEnv := writeFile(t, ".env", `
+PORT=9100
+SECRET_KEY=`+secretKey+`
+DATABASE_URL=postgres://db/goauth
+DB_MAX_OPEN_CONNS=50
+`)
+
+	cfg, err := config.Sources{
+		File:      file,
+		DotEnv:    dotEnv,
+		LookupEnv: env(map[string]string{"PORT": "9200", "ACCESS_TOKEN_TTL": "5m"}),
+	}.Load()
+	require.NoError(t, err)
+
+	// The environment wins over the .env file, which wins over the file
+	require.Equal(t, 9200, cfg.Port)
+	require.Equal(t, 5*time.Minute, cfg.Tokens.AccessTTL)
+	require.Equal(t, secretKey, cfg.SecretKey)
+	require.Equal(t, config.Cookies{Domain: "auth.example.com", Secure: true, SameSite: "strict"}, cfg.Cookies)
+	require.Equal(t, []string{"https://app.example.com", "https://admin.example.com"}, cfg.CORS.AllowOrigins)
+	require.Equal(t, 20*time.Second, cfg.HTTP.ReadTimeout)
+	require.Equal(t, 30*time.Second, cfg.HTTP.WriteTimeout, "default")
+	require.Equal(t, database.Config{
+		URL:             "postgres://db/goauth",
+		MaxOpenConns:    50,
+		MaxIdleConns:    25,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+		ConnectTimeout:  10 * time.Second,
+	}, cfg.Database.Config())
+}
+
+func TestLoadTOML(t *testing.T) {
+	breached := writeFile(t, "breached.txt", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n")
+	file := writeFile(t, "goauth.toml", `
+secret_key = "`+secretKey+`"
+
+[database]
+url = "goauth.db"
+migrate_on_start = true
+
+[password]
+algorithm = "scrypt"
+bcrypt_cost = 12
+
+[password_policy]
+require_symbol = true
+breached_file = "`+breached+`"
+
+[rate_limit]
+api = "off"
+`)
+
+	// CONFIG_FILE names the file unless it is given
+	cfg, err := config.Sources{LookupEnv: env(map[string]string{"CONFIG_FILE": file})}.Load()
+	require.NoError(t, err)
+	require.Equal(t, "goauth.db", cfg.Database.URL)
+	require.True(t, cfg.Database.MigrateOnStart)
+	require.Equal(t, 12, cfg.Password.BcryptCost)
+	hash, err := cfg.Password.Manager().Hash("Password123")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(hash), "$scrypt$ln=15,r=8,p=1$"), string(hash))
+	policy, err := cfg.PasswordPolicy.Policy()
+	require.NoError(t, err)
+	require.True(t, policy.RequireSymbol)
+	require.Equal(t, 8, policy.MinLength, "default")
+	require.True(t, policy.Breached.Contains("password"))
+	require.Equal(t, "off", cfg.RateLimit.API)
+	require.Equal(t, 8080, cfg.Port, "default")
+
+	cfg.PasswordPolicy.BreachedFile += ".missing"
+	_, err = cfg.PasswordPolicy.Policy()
+	require.ErrorContains(t, err, "PASSWORD_BREACHED_FILE")
+}
+
+func TestLoadErrors(t *testing.T) {
+	// Every problem is reported at once
+	file := writeFile(t, "goauth.yaml", `
+http:
+  read_timout: 20s
+tokens:
+  refresh_ttl: 30
+`)
+	_, err := config.Sources{
+		File:      file,
+		LookupEnv: env(map[string]string{"PORT": "http", "COOKIE_SECURE": "sometimes"}),
+	}.Load()
+	require.ElementsMatch(t, []string{
+		file + `: tokens.refresh_ttl: invalid duration "30"`,
+		file + ": unknown setting http.read_timout",
+		`PORT: invalid number "http"`,
+		`COOKIE_SECURE: invalid boolean "sometimes"`,
+	}, problems(t, err))
+
+	_, err = config.Sources{LookupEnv: env(map[string]string{"SECRET_KEY": "short"})}.Load()
+	require.ElementsMatch(t, []string{
+		"SECRET_KEY must be at least 32 characters long",
+		"DATABASE_URL is required",
+	}, problems(t, err))
+
+	// Empty numbers keep their default
+	cfg, err := config.Sources{LookupEnv: env(map[string]string{
+		"SECRET_KEY":   secretKey,
+		"DATABASE_URL": "goauth.db",
+		"PORT":         "",
+	})}.Load()
+	require.NoError(t, err)
+	require.Equal(t, 8080, cfg.Port)
+
+	for _, name := range []string{"goauth.json", "missing.yaml"} {
+		_, err := config.Sources{File: filepath.Join(t.TempDir(), name)}.Load()
+		require.Error(t, err, name)
+	}
+	_, err = config.Sources{File: writeFile(t, "broken.yaml", "port: [")}.Load()
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *config.Config {
+		cfg := config.Default()
+		cfg.SecretKey = secretKey
+		cfg.Database.URL = "goauth.db"
+		return cfg
+	}
+	require.NoError(t, valid().Validate())
+
+	tests := []struct {
+		name    string
+		change  func(cfg *config.Config)
+		problem string
+	}{
+		{"port", func(cfg *config.Config) { cfg.Port = 70000 }, "PORT must be between 1 and 65535, got 70000"},
+		{"app URL", func(cfg *config.Config) { cfg.AppURL = "example.com" }, `APP_URL must be an absolute http or https URL, got "example.com"`},
+		{"secret key", func(cfg *config.Config) { cfg.SecretKey = "" }, "SECRET_KEY is required"},
+		{"database URL", func(cfg *config.Config) { cfg.Database.URL = "redis://cache" }, `DATABASE_URL: unsupported database URL scheme "redis"`},
+		{"timeout", func(cfg *config.Config) { cfg.HTTP.WriteTimeout = 0 }, "HTTP_WRITE_TIMEOUT must be positive"},
+		{"token lifetime", func(cfg *config.Config) { cfg.Tokens.IDTokenTTL = 0 }, "ID_TOKEN_TTL must be positive"},
+		{"same site", func(cfg *config.Config) { cfg.Cookies.SameSite = "sometimes" }, `COOKIE_SAME_SITE must be lax, strict or none, got "sometimes"`},
+		{"insecure same site none", func(cfg *config.Config) { cfg.Cookies.SameSite, cfg.Cookies.Secure = "none", false }, "COOKIE_SAME_SITE none requires COOKIE_SECURE"},
+		{"origins", func(cfg *config.Config) { cfg.CORS.AllowOrigins = nil }, "CORS_ALLOW_ORIGINS must list at least one origin"},
+		{"algorithm", func(cfg *config.Config) { cfg.JWT.Algorithm = "HS256" }, `JWT_ALGORITHM: unsupported signing algorithm "HS256"`},
+		{"key overlap", func(cfg *config.Config) { cfg.JWT.KeyOverlap = time.Minute }, "JWT_KEY_OVERLAP must be at least the access token lifetime (15m0s)"},
+		{"hash algorithm", func(cfg *config.Config) { cfg.Password.Algorithm = "md5" }, `PASSWORD_HASH_ALGORITHM must be argon2id, bcrypt or scrypt, got "md5"`},
+		{"argon2 memory", func(cfg *config.Config) { cfg.Password.Argon2Memory, cfg.Password.Argon2Parallelism = 16, 4 }, "ARGON2_MEMORY must be between 32 and 4194304 KiB, got 16"},
+		{"argon2 time", func(cfg *config.Config) { cfg.Password.Argon2Time = 0 }, "ARGON2_TIME must be a positive number"},
+		{"argon2 parallelism", func(cfg *config.Config) { cfg.Password.Argon2Parallelism = 256 }, "ARGON2_PARALLELISM must be between 1 and 255, got 256"},
+		{"bcrypt cost", func(cfg *config.Config) { cfg.Password.BcryptCost = 40 }, "BCRYPT_COST must be between 4 and 31, got 40"},
+		{"scrypt cost", func(cfg *config.Config) { cfg.Password.ScryptCost = 8 }, "SCRYPT_COST must be between 10 and 20, got 8"},
+		{"password min length", func(cfg *config.Config) { cfg.PasswordPolicy.MinLength = 0 }, "PASSWORD_MIN_LENGTH must be a positive number"},
+		{"password max length", func(cfg *config.Config) { cfg.PasswordPolicy.MaxLength = 6 }, "PASSWORD_MAX_LENGTH must be between PASSWORD_MIN_LENGTH and 1024, got 6"},
+		{"password bcrypt length", func(cfg *config.Config) { cfg.Password.Algorithm = "bcrypt"; cfg.PasswordPolicy.MaxLength = 100 }, "PASSWORD_MAX_LENGTH must be at most 72 with bcrypt, got 100"},
+		{"password strength", func(cfg *config.Config) { cfg.PasswordPolicy.MinStrength = 5 }, "PASSWORD_MIN_STRENGTH must be between 0 and 4, got 5"},
+		{"password history", func(cfg *config.Config) { cfg.PasswordPolicy.History = -1 }, "PASSWORD_HISTORY must not be negative, got -1"},
+		{"verification policy", func(cfg *config.Config) { cfg.Verification.Policy = "maybe" }, `EMAIL_VERIFICATION_POLICY must be allow, deny or grace, got "maybe"`},
+		{"lockout store", func(cfg *config.Config) { cfg.Lockout.Store = "disk" }, `LOGIN_ATTEMPT_STORE must be database or memory, got "disk"`},
+		{"lockout failures", func(cfg *config.Config) { cfg.Lockout.FreeFailures = 0 }, "LOGIN_FREE_FAILURES must be a positive number"},
+		{"redis URL", func(cfg *config.Config) { cfg.RateLimit.Store = "redis" }, "REDIS_URL is required by RATE_LIMIT_STORE redis"},
+		{"rate limit", func(cfg *config.Config) { cfg.RateLimit.Token = "lots" }, `RATE_LIMIT_TOKEN: invalid rate limit "lots": must be requests/period`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := valid()
+			test.change(cfg)
+			require.Equal(t, []string{test.problem}, problems(t, cfg.Validate()))
+		})
+	}
+}
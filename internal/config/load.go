@@ -0,0 +1,265 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package config
+
+// load.go reads the configuration from its sources. From the lowest to the
+// highest precedence these are the defaults, the config file, the .env file
+// and the environment.
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources are where Load reads the configuration from.
+type Sources struct {
+	// File is the config file, YAML or TOML by its extension. If empty,
+	// CONFIG_FILE names it, if set.
+	File string
+	// DotEnv is the .env file. It is skipped if it does not exist.
+	DotEnv string
+	// LookupEnv looks up environment variables. If nil the environment is
+	// not read.
+	LookupEnv func(name string) (string, bool)
+}
+
+// DefaultSources reads the file named by CONFIG_FILE, the .env file of
+// the working directory and the environment of the process.
+var DefaultSources = Sources{DotEnv: ".env", LookupEnv: os.LookupEnv}
+
+// Load reads the configuration from the default sources and validates it.
+func Load() (*Config, error) {
+	return DefaultSources.Load()
+}
+
+// Load reads the configuration from the sources and validates it. The
+// returned error lists every problem found.
+func (s Sources) Load() (*Config, error) {
+	dotEnv := map[string]string{}
+	if s.DotEnv != "" {
+		values, err := godotenv.Read(s.DotEnv)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading %s: %w", s.DotEnv, err)
+		}
+		if values != nil {
+			dotEnv = values
+		}
+	}
+	lookup := func(name string) (string, bool) {
+		if s.LookupEnv != nil {
+			if value, ok := s.LookupEnv(name); ok {
+				return value, true
+			}
+		}
+		value, ok := dotEnv[name]
+		return value, ok
+	}
+
+	cfg := Default()
+	var p problems
+
+	file := s.File
+	if file == "" {
+		file, _ = lookup("CONFIG_FILE")
+	}
+	if file != "" {
+		values, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, setting := range settings(cfg) {
+			value, ok := values[setting.key]
+			if !ok {
+				continue
+			}
+			delete(values, setting.key)
+			if err := setting.set(value); err != nil {
+				p.addf("%s: %s: %v", file, setting.key, err)
+			}
+		}
+		for _, key := range sortedKeys(values) {
+			p.addf("%s: unknown setting %s", file, key)
+		}
+	}
+
+	for _, setting := range settings(cfg) {
+		if value, ok := lookup(setting.env); ok {
+			if err := setting.set(value); err != nil {
+				p.addf("%s: %v", setting.env, err)
+			}
+		}
+	}
+
+	// Values that cannot be parsed would be reported again, with their
+	// defaults, by the validation
+	if err := p.err(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readFile reads a YAML or TOML config file into its values by dotted key,
+// such as http.read_timeout, written as they would be in the environment.
+func readFile(name string) (map[string]string, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var tree map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q: must be .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+
+	values := map[string]string{}
+	flatten("", tree, values)
+	return values, nil
+}
+
+// flatten adds the values of tree to values by dotted key. Lists become
+// comma separated values.
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			flatten(key, value, values)
+		case []interface{}:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setting is a field of a Config.
+type setting struct {
+	key   string
+	env   string
+	field reflect.Value
+}
+
+// settings returns every setting of cfg, walking the sections.
+func settings(cfg *Config) []setting {
+	var result []setting
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			key := prefix + field.Tag.Get("key")
+			if env, ok := field.Tag.Lookup("env"); ok {
+				result = append(result, setting{key: key, env: env, field: v.Field(i)})
+			} else if field.Type.Kind() == reflect.Struct {
+				walk(key+".", v.Field(i))
+			}
+		}
+	}
+	walk("", reflect.ValueOf(cfg).Elem())
+	return result
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses value into the field of the setting.
+func (s setting) set(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" && s.field.Kind() != reflect.String && s.field.Kind() != reflect.Slice {
+		// Numbers, booleans and durations left empty keep their value
+		return nil
+	}
+	switch {
+	case s.field.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		if d < 0 {
+			return fmt.Errorf("must not be negative, got %s", value)
+		}
+		s.field.SetInt(int64(d))
+	case s.field.Kind() == reflect.String:
+		s.field.SetString(value)
+	case s.field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		s.field.SetInt(int64(n))
+	case s.field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		s.field.SetBool(b)
+	case s.field.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		s.field.Set(reflect.ValueOf(items))
+	default:
+		panic("config: unsupported setting type " + s.field.Type().String())
+	}
+	return nil
+}
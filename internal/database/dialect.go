@@ -0,0 +1,164 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package database
+
+// dialect.go selects the database driver from the scheme of the database
+// URL and configures the connection pool.
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Names of the supported dialects, as returned by gorm.Dialector.Name.
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+)
+
+// Config describes how to connect to the database. Zero pool settings keep
+// the defaults of database/sql.
+type Config struct {
+	// URL selects the driver by its scheme: postgres:// or postgresql://
+	// for PostgreSQL, mysql:// for MySQL and file:, sqlite:// or a plain
+	// path for SQLite. An empty URL opens a temporary SQLite database.
+	URL string
+	// MaxOpenConns limits the open connections.
+	MaxOpenConns int
+	// MaxIdleConns limits the idle connections kept in the pool.
+	MaxIdleConns int
+	// ConnMaxLifetime is how long a connection is reused.
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime is how long a connection stays idle before it is
+	// closed.
+	ConnMaxIdleTime time.Duration
+	// ConnectTimeout limits how long connecting to a PostgreSQL or MySQL
+	// server takes.
+	ConnectTimeout time.Duration
+}
+
+// Dialector returns the gorm dialector for the URL of the config.
+func (c Config) Dialector() (gorm.Dialector, error) {
+	scheme, _, found := strings.Cut(c.URL, "://")
+	if !found || strings.HasPrefix(c.URL, "file:") {
+		return sqlite.Open(c.URL), nil
+	}
+	switch strings.ToLower(scheme) {
+	case "sqlite", "sqlite3":
+		return sqlite.Open(c.URL[len(scheme)+len("://"):]), nil
+	case "postgres", "postgresql":
+		dsn, err := c.postgresDSN()
+		if err != nil {
+			return nil, err
+		}
+		return postgres.Open(dsn), nil
+	case "mysql":
+		dsn, err := c.mysqlDSN()
+		if err != nil {
+			return nil, err
+		}
+		return mysql.Open(dsn), nil
+	}
+	return nil, fmt.Errorf("unsupported database URL scheme %q", scheme)
+}
+
+// postgresDSN returns the URL with the connect timeout, which pgx accepts
+// as is.
+func (c Config) postgresDSN() (string, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid database URL: %w", err)
+	}
+
+	query := u.Query()
+	if c.ConnectTimeout > 0 && query.Get("connect_timeout") == "" {
+		// The timeout is given in whole seconds, rounded up
+		query.Set("connect_timeout", strconv.Itoa(int((c.ConnectTimeout+time.Second-1)/time.Second)))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// mysqlDSN converts the URL to the DSN format of the MySQL driver. Times
+// are always parsed, as the models use time.Time columns.
+func (c Config) mysqlDSN() (string, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid database URL: %w", err)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "3306")
+	}
+	dsn := fmt.Sprintf("tcp(%s)/%s", addr, strings.TrimPrefix(u.Path, "/"))
+	if u.RawQuery != "" {
+		dsn += "?" + u.RawQuery
+	}
+
+	// The credentials are set after parsing, as they may contain any
+	// character
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid database URL: %w", err)
+	}
+	cfg.User = u.User.Username()
+	cfg.Passwd, _ = u.User.Password()
+	cfg.ParseTime = true
+	if c.ConnectTimeout > 0 && cfg.Timeout == 0 {
+		cfg.Timeout = c.ConnectTimeout
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// configurePool applies the pool settings of the config to db.
+func (c Config) configurePool(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	if c.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+	return nil
+}
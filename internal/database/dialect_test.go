@@ -0,0 +1,115 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// dsnOf returns the connection string a dialector was opened with.
+func dsnOf(t *testing.T, dialector gorm.Dialector) string {
+	t.Helper()
+
+	switch d := dialector.(type) {
+	case *sqlite.Dialector:
+		return d.DSN
+	case *postgres.Dialector:
+		return d.Config.DSN
+	case *mysql.Dialector:
+		return d.Config.DSN
+	}
+	t.Fatalf("unexpected dialector %T", dialector)
+	return ""
+}
+
+func TestDialector(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  database.Config
+		dialect string
+		dsn     string
+	}{
+		{"plain path", database.Config{URL: "goauth.db"}, database.DialectSQLite, "goauth.db"},
+		{"empty", database.Config{}, database.DialectSQLite, ""},
+		{"file URI", database.Config{URL: "file:goauth.db?cache=shared"}, database.DialectSQLite, "file:goauth.db?cache=shared"},
+		{"sqlite scheme", database.Config{URL: "sqlite:///var/lib/goauth.db"}, database.DialectSQLite, "/var/lib/goauth.db"},
+		{
+			"postgres",
+			database.Config{URL: "postgres://goauth:secret@db:5432/goauth?sslmode=disable", ConnectTimeout: 1500 * time.Millisecond},
+			database.DialectPostgres,
+			"postgres://goauth:secret@db:5432/goauth?connect_timeout=2&sslmode=disable",
+		},
+		{
+			"postgresql keeps its timeout",
+			database.Config{URL: "postgresql://db/goauth?connect_timeout=3", ConnectTimeout: 10 * time.Second},
+			database.DialectPostgres,
+			"postgresql://db/goauth?connect_timeout=3",
+		},
+		{
+			"mysql",
+			database.Config{URL: "mysql://goauth:p%40ss%2Fword@db/goauth?charset=utf8mb4", ConnectTimeout: 5 * time.Second},
+			database.DialectMySQL,
+			"goauth:p@ss/word@tcp(db:3306)/goauth?parseTime=true&timeout=5s&charset=utf8mb4",
+		},
+		{
+			"mysql keeps its timeout",
+			database.Config{URL: "mysql://goauth@db:3307/goauth?timeout=1s", ConnectTimeout: 5 * time.Second},
+			database.DialectMySQL,
+			"goauth@tcp(db:3307)/goauth?parseTime=true&timeout=1s",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dialector, err := test.config.Dialector()
+			require.NoError(t, err)
+			require.Equal(t, test.dialect, dialector.Name())
+			require.Equal(t, test.dsn, dsnOf(t, dialector))
+		})
+	}
+
+	for _, url := range []string{"redis://localhost:6379", "mysql://db/goauth?timeout=soon", "postgres://db:port/goauth"} {
+		_, err := database.Config{URL: url}.Dialector()
+		require.Error(t, err, url)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	db, err := database.Open(database.Config{
+		URL:          filepath.Join(t.TempDir(), "test.db"),
+		MaxOpenConns: 3,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("SELECT 1").Error)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.Equal(t, 3, sqlDB.Stats().MaxOpenConnections)
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditEventImmutable is returned when updating or deleting an audit
+// event.
+var ErrAuditEventImmutable = errors.New("audit events cannot be changed")
+
+// AuditEvent records a security relevant action. Events are only ever
+// appended.
+//
+// The actor is who performed the action and the target what it was
+// performed on, each identified by a type (user, client or role) and an ID.
+// Actions of unauthenticated requests have no actor. Outcome is success or
+// failure, and Metadata is a JSON object with details about the action.
+type AuditEvent struct {
+	ID         uint      `gorm:"primaryKey"`
+	CreatedAt  time.Time `gorm:"index;not null"`
+	ActorType  string    `gorm:"size:16;index:idx_audit_events_actor"`
+	ActorID    string    `gorm:"size:64;index:idx_audit_events_actor"`
+	TargetType string    `gorm:"size:16;index:idx_audit_events_target"`
+	TargetID   string    `gorm:"size:64;index:idx_audit_events_target"`
+	Action     string    `gorm:"size:64;index;not null"`
+	Outcome    string    `gorm:"size:16;not null"`
+	IP         string    `gorm:"size:64"`
+	UserAgent  string    `gorm:"size:512"`
+	Metadata   string    `gorm:"type:text"`
+}
+
+// BeforeUpdate keeps events from being changed.
+func (*AuditEvent) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditEventImmutable
+}
+
+// BeforeDelete keeps events from being deleted.
+func (*AuditEvent) BeforeDelete(*gorm.DB) error {
+	return ErrAuditEventImmutable
+}
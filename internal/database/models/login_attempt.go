@@ -0,0 +1,40 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"time"
+)
+
+// LoginAttempt counts the failed logins of an account or a client IP.
+//
+// Key identifies what is counted, for example an email address or an IP.
+// Failures is reset once LastFailureAt is older than the counting window.
+// A key cannot be used to log in before LockedUntil.
+type LoginAttempt struct {
+	ID            uint       `gorm:"primaryKey"`
+	Key           string     `gorm:"column:attempt_key;uniqueIndex;size:320;not null"`
+	Failures      int        `gorm:"not null"`
+	LastFailureAt time.Time  `gorm:"index;not null"`
+	LockedUntil   *time.Time `gorm:"index"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime"`
+}
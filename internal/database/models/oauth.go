@@ -0,0 +1,89 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"time"
+)
+
+// OAuthClient is an application registered to obtain tokens from goAuth.
+//
+// ClientSecretHash is the bcrypt hash of the client secret and is empty for
+// public clients, which cannot keep a secret and rely on PKCE alone.
+// RedirectURIs, Scopes and GrantTypes are space separated lists of the
+// redirect URIs, scopes and grant types the client may use. Clients without
+// grant types predate them and use the authorization code grant. Service
+// accounts are confidential clients with the client_credentials grant that
+// act on their own behalf. SkipConsent is set for first party clients that
+// do not need the user's consent. Disabled clients cannot obtain tokens and
+// the tokens they hold are rejected.
+type OAuthClient struct {
+	ID               uint   `gorm:"primaryKey"`
+	ClientID         string `gorm:"uniqueIndex;size:64;not null"`
+	ClientSecretHash []byte
+	Name             string `gorm:"size:100;not null"`
+	RedirectURIs     string `gorm:"type:text"`
+	Scopes           string `gorm:"size:1000"`
+	GrantTypes       string `gorm:"size:255"`
+	Public           bool   `gorm:"default:false"`
+	SkipConsent      bool   `gorm:"default:false"`
+	SecretRotatedAt  *time.Time
+	DisabledAt       *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// AuthorizationCode is an OAuth authorization code waiting to be exchanged
+// for tokens. Only the SHA-256 hash of the code is stored. AccessTokenID is
+// the jti of the access token issued for the code, so it can be revoked if
+// the code is used twice.
+type AuthorizationCode struct {
+	ID                  uint        `gorm:"primaryKey"`
+	CodeHash            string      `gorm:"uniqueIndex;size:64;not null"`
+	ClientID            uint        `gorm:"index;not null"`
+	Client              OAuthClient `gorm:"constraint:OnDelete:CASCADE;"`
+	UserID              uint        `gorm:"index;not null"`
+	User                User        `gorm:"constraint:OnDelete:CASCADE;"`
+	RedirectURI         string      `gorm:"type:text;not null"`
+	Scope               string      `gorm:"size:1000"`
+	Nonce               string      `gorm:"size:255"`
+	CodeChallenge       string      `gorm:"size:128;not null"`
+	CodeChallengeMethod string      `gorm:"size:8;not null"`
+	AuthTime            time.Time
+	AccessTokenID       string    `gorm:"size:64"`
+	ExpiresAt           time.Time `gorm:"index;not null"`
+	UsedAt              *time.Time
+	CreatedAt           time.Time
+}
+
+// OAuthConsent records the scopes a user granted to a client, so the
+// consent page is not shown again for them.
+type OAuthConsent struct {
+	ID        uint        `gorm:"primaryKey"`
+	UserID    uint        `gorm:"uniqueIndex:idx_oauth_consent;not null"`
+	User      User        `gorm:"constraint:OnDelete:CASCADE;"`
+	ClientID  uint        `gorm:"uniqueIndex:idx_oauth_consent;not null"`
+	Client    OAuthClient `gorm:"constraint:OnDelete:CASCADE;"`
+	Scope     string      `gorm:"size:1000"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -30,15 +30,26 @@ import (
 
 // System User model
 type User struct {
-	ID           uint           `gorm:"primaryKey"`
-	Name         string         `gorm:"size:250;"`
-	Username     string         `gorm:"uniqueIndex;size:100;not null"`
-	Email        string         `gorm:"uniqueIndex;size:255;not null"`
-	PasswordHash []byte         `gorm:"not null"`
-	IsStaff      bool           `gorm:"default:false"` // For staff members
-	IsSuperuser  bool           `gorm:"default:false"` // For admins
-	IsActive     bool           `gorm:"default:true"`  // Can be banned or active
-	CreatedAt    time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt    time.Time      `gorm:"autoCreateTime"`
-	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	ID            uint           `gorm:"primaryKey"`
+	Name          string         `gorm:"size:250;"`
+	Username      string         `gorm:"uniqueIndex;size:100;not null"`
+	Email         string         `gorm:"uniqueIndex;size:255;not null"`
+	PasswordHash  []byte         `gorm:"not null"`
+	IsStaff       bool           `gorm:"default:false"` // For staff members
+	IsSuperuser   bool           `gorm:"default:false"` // For admins
+	IsActive      bool           `gorm:"default:true"`  // Can be banned or active
+	EmailVerified bool           `gorm:"default:false"`
+	VerifiedAt    *time.Time     `gorm:"default:null"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `gorm:"autoCreateTime"`
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	Roles         []Role         `gorm:"many2many:user_roles;constraint:OnDelete:CASCADE;"`
+
+	// PasswordResetRequired is set by an admin to make the user choose a
+	// new password before logging in again.
+	PasswordResetRequired bool `gorm:"default:false"`
+
+	// TokenVersion is carried by the tokens issued to the user. Raising it
+	// revokes all of them at once.
+	TokenVersion uint `gorm:"not null;default:0"`
 }
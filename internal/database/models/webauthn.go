@@ -0,0 +1,55 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"time"
+)
+
+// WebAuthnCredential is a passkey or security key registered by a user.
+// CredentialID is the base64url encoded credential ID and PublicKey the
+// CBOR encoded COSE key of the credential.
+type WebAuthnCredential struct {
+	ID              uint   `gorm:"primaryKey"`
+	UserID          uint   `gorm:"index;not null"`
+	User            User   `gorm:"constraint:OnDelete:CASCADE;"`
+	CredentialID    string `gorm:"uniqueIndex;size:255;not null"`
+	PublicKey       []byte `gorm:"not null"`
+	SignCount       uint32
+	AAGUID          []byte
+	AttestationType string `gorm:"size:16"`
+	Name            string `gorm:"size:100"`
+	LastUsedAt      *time.Time
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+}
+
+// WebAuthnChallenge is a pending WebAuthn ceremony. Registration challenges
+// belong to the user registering a credential, login challenges have no user.
+// Only the SHA-256 hash of the challenge is stored.
+type WebAuthnChallenge struct {
+	ID            uint      `gorm:"primaryKey"`
+	UserID        *uint     `gorm:"index"`
+	Ceremony      string    `gorm:"size:16;not null"`
+	ChallengeHash string    `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt     time.Time `gorm:"index;not null"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
@@ -22,6 +22,8 @@
 
 package inputs
 
+import "github.com/Maro1O9/goauth/internal/webauthn"
+
 type InputUser struct {
 	Username        string `json:"username"`
 	Name            string `json:"name"`
@@ -33,3 +35,127 @@ type LoginUser struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+type ForgotPasswordInput struct {
+	Email string `json:"email"`
+}
+type ResetPasswordInput struct {
+	Token           string `json:"token"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+type VerifyEmailInput struct {
+	Token string `json:"token" form:"token"`
+}
+type ResendVerificationInput struct {
+	Email string `json:"email"`
+}
+type TOTPCodeInput struct {
+	Code string `json:"code"`
+}
+type DisableTOTPInput struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+type MFAChallengeInput struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+type WebAuthnRegistrationBeginInput struct {
+	Password string `json:"password"`
+}
+type WebAuthnRegistrationInput struct {
+	Name       string                       `json:"name"`
+	Credential webauthn.AttestationResponse `json:"credential"`
+}
+type WebAuthnLoginBeginInput struct {
+	Email string `json:"email"`
+}
+type AuthorizeInput struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	Prompt              string `form:"prompt"`
+}
+type AuthorizeLoginInput struct {
+	Email    string `form:"email"`
+	Password string `form:"password"`
+	Code     string `form:"code"`
+}
+type AuthorizeConsentInput struct {
+	ConsentToken string `form:"consent_token"`
+	Decision     string `form:"decision"`
+}
+type TokenInput struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Scope        string `form:"scope"`
+}
+type CreateClientInput struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	GrantTypes   []string `json:"grant_types"`
+	Public       bool     `json:"public"`
+	SkipConsent  bool     `json:"skip_consent"`
+}
+type CreateRoleInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+type UpdateRoleInput struct {
+	Description *string   `json:"description"`
+	Permissions *[]string `json:"permissions"`
+}
+type AssignRoleInput struct {
+	Role string `json:"role"`
+}
+type ListUsersInput struct {
+	Page          int    `form:"page"`
+	PerPage       int    `form:"per_page"`
+	Search        string `form:"q"`
+	IsActive      *bool  `form:"is_active"`
+	IsStaff       *bool  `form:"is_staff"`
+	IsSuperuser   *bool  `form:"is_superuser"`
+	EmailVerified *bool  `form:"email_verified"`
+	Deleted       string `form:"deleted"`
+}
+type UpdateUserInput struct {
+	IsActive    *bool `json:"is_active"`
+	IsStaff     *bool `json:"is_staff"`
+	IsSuperuser *bool `json:"is_superuser"`
+}
+type UpdateProfileInput struct {
+	Username *string `json:"username"`
+	Name     *string `json:"name"`
+}
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+type ChangeEmailInput struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+type ListAuditEventsInput struct {
+	UserID string `form:"user_id"`
+	Action string `form:"action"`
+	Since  string `form:"since"`
+	Until  string `form:"until"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
@@ -0,0 +1,284 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package keys
+
+// keys.go defines the asymmetric keys used to sign JWTs and their JSON Web
+// Key (RFC 7517) representation.
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithm is a JWS algorithm supported by the key manager.
+type Algorithm string
+
+const (
+	// RS256 is RSASSA-PKCS1-v1_5 with SHA-256 and a 2048 bit key.
+	RS256 Algorithm = "RS256"
+	// ES256 is ECDSA with P-256 and SHA-256.
+	ES256 Algorithm = "ES256"
+	// EdDSA is Ed25519.
+	EdDSA Algorithm = "EdDSA"
+)
+
+// rsaKeySize is the size of the generated RSA keys in bits.
+const rsaKeySize = 2048
+
+// ParseAlgorithm parses the name of an algorithm. An empty value defaults to
+// ES256.
+func ParseAlgorithm(value string) (Algorithm, error) {
+	switch alg := Algorithm(value); alg {
+	case "":
+		return ES256, nil
+	case RS256, ES256, EdDSA:
+		return alg, nil
+	default:
+		return "", fmt.Errorf("unsupported signing algorithm %q", value)
+	}
+}
+
+// SigningMethod returns the JWT signing method of the algorithm.
+func (a Algorithm) SigningMethod() jwt.SigningMethod {
+	switch a {
+	case RS256:
+		return jwt.SigningMethodRS256
+	case ES256:
+		return jwt.SigningMethodES256
+	case EdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return nil
+	}
+}
+
+// Key is a signing key. A key signs new tokens until RotatesAt and verifies
+// tokens until ExpiresAt.
+type Key struct {
+	ID        string
+	Algorithm Algorithm
+	Signer    crypto.Signer
+	CreatedAt time.Time
+	RotatesAt time.Time
+	ExpiresAt time.Time
+}
+
+// Generate creates a new key for the algorithm. The key ID is the RFC 7638
+// thumbprint of its public key.
+func Generate(alg Algorithm, now time.Time, rotation, overlap time.Duration) (*Key, error) {
+	var signer crypto.Signer
+	var err error
+
+	switch alg {
+	case RS256:
+		signer, err = rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case ES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{
+		Algorithm: alg,
+		Signer:    signer,
+		CreatedAt: now,
+		RotatesAt: now.Add(rotation),
+		ExpiresAt: now.Add(rotation + overlap),
+	}
+	if key.ID, err = Thumbprint(signer.Public()); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// canSign reports whether the key signs new tokens at now.
+func (k *Key) canSign(now time.Time) bool {
+	return now.Before(k.RotatesAt)
+}
+
+// canVerify reports whether the key verifies tokens at now.
+func (k *Key) canVerify(now time.Time) bool {
+	return now.Before(k.ExpiresAt)
+}
+
+// JWK returns the public JSON Web Key of the key.
+func (k *Key) JWK() (JWK, error) {
+	jwk, err := NewJWK(k.Signer.Public())
+	if err != nil {
+		return JWK{}, err
+	}
+	jwk.Use = "sig"
+	jwk.Algorithm = string(k.Algorithm)
+	jwk.KeyID = k.ID
+	return jwk, nil
+}
+
+// JWK is a public JSON Web Key.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set, as served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key returns the key with the given ID, or nil.
+func (s *JWKSet) Key(kid string) *JWK {
+	for i := range s.Keys {
+		if s.Keys[i].KeyID == kid {
+			return &s.Keys[i]
+		}
+	}
+	return nil
+}
+
+var encoding = base64.RawURLEncoding
+
+// NewJWK returns the JSON Web Key of an RSA, P-256 or Ed25519 public key.
+func NewJWK(pub crypto.PublicKey) (JWK, error) {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA",
+			N:       encoding.EncodeToString(pub.N.Bytes()),
+			E:       encoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return JWK{}, errors.New("unsupported elliptic curve")
+		}
+		return JWK{
+			KeyType: "EC",
+			Curve:   "P-256",
+			X:       encoding.EncodeToString(pub.X.FillBytes(make([]byte, 32))),
+			Y:       encoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32))),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType: "OKP",
+			Curve:   "Ed25519",
+			X:       encoding.EncodeToString(pub),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// PublicKey returns the public key described by the JSON Web Key.
+func (k *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := encoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := encoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if k.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported elliptic curve %q", k.Curve)
+		}
+		x, err := encoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := encoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("invalid elliptic curve point")
+		}
+		return pub, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := encoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+// Thumbprint returns the base64url encoded RFC 7638 SHA-256 thumbprint of
+// a public key.
+func Thumbprint(pub crypto.PublicKey) (string, error) {
+	jwk, err := NewJWK(pub)
+	if err != nil {
+		return "", err
+	}
+
+	// The required members in lexicographic order, without whitespace
+	var canonical string
+	switch jwk.KeyType {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, jwk.Curve, jwk.X, jwk.Y)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Curve, jwk.X)
+	}
+
+	sum := sha256.Sum256([]byte(canonical))
+	return encoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package keys_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/keys"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func sign(t *testing.T, manager *keys.Manager, subject string) string {
+	t.Helper()
+
+	token, err := manager.Sign(jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	require.NoError(t, err)
+	return token
+}
+
+func verify(manager *keys.Manager, token string) error {
+	_, err := jwt.Parse(token, manager.Keyfunc, jwt.WithValidMethods(manager.Algorithms()))
+	return err
+}
+
+func TestManagerAlgorithms(t *testing.T) {
+	for _, alg := range []keys.Algorithm{keys.RS256, keys.ES256, keys.EdDSA} {
+		t.Run(string(alg), func(t *testing.T) {
+			manager, err := keys.NewManager(keys.NewMemoryStore(), keys.Config{Algorithm: alg})
+			require.NoError(t, err)
+
+			token := sign(t, manager, "user@example.com")
+			require.NoError(t, verify(manager, token))
+
+			// The published key verifies the token on its own
+			set, err := manager.JWKS()
+			require.NoError(t, err)
+			require.Len(t, set.Keys, 1)
+			require.Equal(t, string(alg), set.Keys[0].Algorithm)
+
+			parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+				return set.Key(token.Header["kid"].(string)).PublicKey()
+			}, jwt.WithValidMethods([]string{string(alg)}))
+			require.NoError(t, err)
+			subject, err := parsed.Claims.GetSubject()
+			require.NoError(t, err)
+			require.Equal(t, "user@example.com", subject)
+		})
+	}
+}
+
+func TestManagerSignWithType(t *testing.T) {
+	manager, err := keys.NewManager(keys.NewMemoryStore(), keys.Config{})
+	require.NoError(t, err)
+
+	token, err := manager.SignWithType(jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}, "at+jwt")
+	require.NoError(t, err)
+	parsed, err := jwt.Parse(token, manager.Keyfunc, jwt.WithValidMethods(manager.Algorithms()))
+	require.NoError(t, err)
+	require.Equal(t, "at+jwt", parsed.Header["typ"])
+
+	parsed, err = jwt.Parse(sign(t, manager, "user@example.com"), manager.Keyfunc, jwt.WithValidMethods(manager.Algorithms()))
+	require.NoError(t, err)
+	require.Equal(t, "JWT", parsed.Header["typ"])
+}
+
+func TestManagerRejects(t *testing.T) {
+	manager, err := keys.NewManager(keys.NewMemoryStore(), keys.Config{})
+	require.NoError(t, err)
+	key, err := manager.SigningKey()
+	require.NoError(t, err)
+
+	// A token of another manager has an unknown kid
+	other, err := keys.NewManager(keys.NewMemoryStore(), keys.Config{})
+	require.NoError(t, err)
+	require.Error(t, verify(manager, sign(t, other, "user@example.com")))
+
+	// The alg header must match the key
+	hs256 := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user@example.com"})
+	hs256.Header["kid"] = key.ID
+	token, err := hs256.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	require.Error(t, verify(manager, token))
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": "user@example.com"})
+	unsigned.Header["kid"] = key.ID
+	token, err = unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	require.Error(t, verify(manager, token))
+}
+
+func TestManagerRotation(t *testing.T) {
+	cfg := keys.Config{Algorithm: keys.ES256, RotationInterval: time.Hour, Overlap: 30 * time.Minute}
+	manager, err := keys.NewManager(keys.NewMemoryStore(), cfg)
+	require.NoError(t, err)
+
+	first, err := manager.SigningKey()
+	require.NoError(t, err)
+	token := sign(t, manager, "user@example.com")
+
+	// Nothing is due yet
+	require.NoError(t, manager.Maintain(time.Now()))
+	key, err := manager.SigningKey()
+	require.NoError(t, err)
+	require.Equal(t, first.ID, key.ID)
+
+	now := time.Now()
+	second, err := manager.Rotate(now)
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, second.ID)
+
+	// The previous key keeps verifying during the overlap
+	key, err = manager.SigningKey()
+	require.NoError(t, err)
+	require.Equal(t, second.ID, key.ID)
+	require.NoError(t, verify(manager, token))
+	set, err := manager.JWKS()
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 2)
+	require.Equal(t, second.ID, set.Keys[0].KeyID)
+
+	// and is removed once the overlap is over
+	require.NoError(t, manager.Maintain(now.Add(cfg.Overlap+time.Second)))
+	set, err = manager.JWKS()
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 1)
+	require.Nil(t, set.Key(first.ID))
+	require.Error(t, verify(manager, token))
+
+	// A key that reached the end of its rotation interval is replaced
+	require.NoError(t, manager.Maintain(now.Add(cfg.RotationInterval+time.Second)))
+	set, err = manager.JWKS()
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 2)
+	require.NotEqual(t, second.ID, set.Keys[0].KeyID)
+}
+
+func TestDBStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.SigningKey{}))
+	store := keys.NewDBStore(db, []byte("a test secret"))
+
+	manager, err := keys.NewManager(store, keys.Config{Algorithm: keys.EdDSA})
+	require.NoError(t, err)
+	token := sign(t, manager, "user@example.com")
+
+	// The private key is not stored in clear
+	key, err := manager.SigningKey()
+	require.NoError(t, err)
+	var record models.SigningKey
+	require.NoError(t, db.Where("kid = ?", key.ID).First(&record).Error)
+	require.NotContains(t, string(record.PrivateKey), string(key.Signer.(ed25519.PrivateKey).Seed()))
+
+	// Another instance sharing the store reuses the key
+	restarted, err := keys.NewManager(store, keys.Config{Algorithm: keys.EdDSA})
+	require.NoError(t, err)
+	require.NoError(t, verify(restarted, token))
+	other, err := restarted.SigningKey()
+	require.NoError(t, err)
+	require.Equal(t, key.ID, other.ID)
+
+	// Changing the algorithm rotates the key, old tokens stay valid
+	switched, err := keys.NewManager(store, keys.Config{Algorithm: keys.RS256})
+	require.NoError(t, err)
+	require.NoError(t, verify(switched, token))
+	key, err = switched.SigningKey()
+	require.NoError(t, err)
+	require.Equal(t, keys.RS256, key.Algorithm)
+
+	// Tokens signed by a key rotated elsewhere are verified after a reload
+	token = sign(t, switched, "user@example.com")
+	require.Error(t, verify(manager, token))
+	require.NoError(t, manager.Load())
+	require.NoError(t, verify(manager, token))
+}
+
+func TestThumbprint(t *testing.T) {
+	// Example from RFC 7638, section 3.1
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	require.NoError(t, err)
+
+	thumbprint, err := keys.Thumbprint(&rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537})
+	require.NoError(t, err)
+	require.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    keys.Algorithm
+		wantErr bool
+	}{
+		{"", keys.ES256, false},
+		{"RS256", keys.RS256, false},
+		{"EdDSA", keys.EdDSA, false},
+		{"HS256", "", true},
+		{"none", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			alg, err := keys.ParseAlgorithm(test.value)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, alg)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package keys
+
+// manager.go signs and verifies JWTs with a rotating set of keys.
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Defaults used for the zero values of Config.
+const (
+	DefaultRotationInterval = 30 * 24 * time.Hour
+	DefaultOverlap          = 24 * time.Hour
+)
+
+// reloadInterval limits how often a token with an unknown kid makes the
+// manager reload the keys from the store.
+const reloadInterval = time.Minute
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// Config configures a Manager.
+type Config struct {
+	// Algorithm is the algorithm of the generated keys.
+	Algorithm Algorithm
+	// RotationInterval is how long a key signs new tokens before it is
+	// replaced by a new one.
+	RotationInterval time.Duration
+	// Overlap is how long a key keeps verifying tokens after it was
+	// rotated. It must be longer than the lifetime of the tokens it signs.
+	Overlap time.Duration
+}
+
+// Manager signs tokens with the newest key and verifies them with any key
+// that did not expire. Keys are rotated on schedule and shared through the
+// store, so every instance using the same store issues tokens the others
+// can verify.
+type Manager struct {
+	store  Store
+	config Config
+
+	mu       sync.RWMutex
+	keys     []*Key // newest first
+	loadedAt time.Time
+}
+
+// NewManager creates a manager backed by store. It loads the stored keys
+// and generates a first key if none can sign.
+func NewManager(store Store, cfg Config) (*Manager, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = ES256
+	}
+	if cfg.Algorithm.SigningMethod() == nil {
+		return nil, errors.New("unsupported signing algorithm " + string(cfg.Algorithm))
+	}
+	if cfg.RotationInterval <= 0 {
+		cfg.RotationInterval = DefaultRotationInterval
+	}
+	if cfg.Overlap <= 0 {
+		cfg.Overlap = DefaultOverlap
+	}
+
+	m := &Manager{store: store, config: cfg}
+	if err := m.Load(); err != nil {
+		return nil, err
+	}
+	if err := m.Maintain(time.Now()); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Load replaces the keys of the manager with the ones from the store.
+func (m *Manager) Load() error {
+	keys, err := m.store.Keys()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+
+	m.mu.Lock()
+	m.keys, m.loadedAt = keys, time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// Maintain deletes the expired keys and rotates the signing key if it is
+// due.
+func (m *Manager) Maintain(now time.Time) error {
+	if err := m.store.DeleteExpired(now); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := m.keys[:0]
+	for _, key := range m.keys {
+		if key.canVerify(now) {
+			keys = append(keys, key)
+		}
+	}
+	m.keys = keys
+
+	if m.signingKey(now) == nil {
+		_, err := m.rotate(now)
+		return err
+	}
+	return nil
+}
+
+// Rotate generates a new signing key right away. The previous keys stop
+// signing and keep verifying tokens for the configured overlap.
+func (m *Manager) Rotate(now time.Time) (*Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rotate(now)
+}
+
+func (m *Manager) rotate(now time.Time) (*Key, error) {
+	key, err := Generate(m.config.Algorithm, now, m.config.RotationInterval, m.config.Overlap)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Save(key); err != nil {
+		return nil, err
+	}
+
+	for _, old := range m.keys {
+		if !old.canSign(now) {
+			continue
+		}
+		retired := *old
+		retired.RotatesAt = now
+		if expiresAt := now.Add(m.config.Overlap); expiresAt.Before(retired.ExpiresAt) {
+			retired.ExpiresAt = expiresAt
+		}
+		if err := m.store.Save(&retired); err != nil {
+			return nil, err
+		}
+		*old = retired
+	}
+
+	m.keys = append([]*Key{key}, m.keys...)
+	return key, nil
+}
+
+// signingKey returns the newest key that signs tokens at now with the
+// configured algorithm, or nil. The caller must hold m.mu.
+func (m *Manager) signingKey(now time.Time) *Key {
+	for _, key := range m.keys {
+		if key.Algorithm == m.config.Algorithm && key.canSign(now) {
+			return key
+		}
+	}
+	return nil
+}
+
+// SigningKey returns the key new tokens are signed with, rotating it if it
+// is due.
+func (m *Manager) SigningKey() (*Key, error) {
+	now := time.Now()
+
+	m.mu.RLock()
+	key := m.signingKey(now)
+	m.mu.RUnlock()
+	if key != nil {
+		return key, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if key := m.signingKey(now); key != nil {
+		return key, nil
+	}
+	return m.rotate(now)
+}
+
+// Sign signs the claims with the current signing key. The kid header
+// identifies the key.
+func (m *Manager) Sign(claims jwt.Claims) (string, error) {
+	return m.SignWithType(claims, "")
+}
+
+// SignWithType is like Sign but sets the typ header to typ, so verifiers
+// can tell the kinds of tokens apart. An empty typ keeps the default JWT.
+func (m *Manager) SignWithType(claims jwt.Claims, typ string) (string, error) {
+	key, err := m.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Algorithm.SigningMethod(), claims)
+	token.Header["kid"] = key.ID
+	if typ != "" {
+		token.Header["typ"] = typ
+	}
+	return token.SignedString(key.Signer)
+}
+
+// Keyfunc returns the public key a token must be verified with, based on
+// its kid header. It can be passed to jwt.Parse. Tokens signed with an
+// expired key or with another algorithm than the key's are rejected.
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errUnknownKey
+	}
+
+	key := m.verificationKey(kid)
+	if key == nil && m.reloadDue() {
+		// Another instance may have rotated the key
+		if err := m.Load(); err != nil {
+			return nil, err
+		}
+		key = m.verificationKey(kid)
+	}
+	if key == nil {
+		return nil, errUnknownKey
+	}
+
+	if token.Method.Alg() != string(key.Algorithm) {
+		return nil, errors.New("signing method does not match the key")
+	}
+	return key.Signer.Public(), nil
+}
+
+func (m *Manager) verificationKey(kid string) *Key {
+	now := time.Now()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, key := range m.keys {
+		if key.ID == kid && key.canVerify(now) {
+			return key
+		}
+	}
+	return nil
+}
+
+func (m *Manager) reloadDue() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return time.Since(m.loadedAt) >= reloadInterval
+}
+
+// Algorithm returns the algorithm new tokens are signed with.
+func (m *Manager) Algorithm() Algorithm {
+	return m.config.Algorithm
+}
+
+// Algorithms returns the algorithms tokens can be signed with.
+func (m *Manager) Algorithms() []string {
+	return []string{string(RS256), string(ES256), string(EdDSA)}
+}
+
+// JWKS returns the public keys that verify tokens, newest first.
+func (m *Manager) JWKS() (*JWKSet, error) {
+	now := time.Now()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := &JWKSet{Keys: []JWK{}}
+	for _, key := range m.keys {
+		if !key.canVerify(now) {
+			continue
+		}
+		jwk, err := key.JWK()
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// Run reloads the keys from the store and rotates them when due, every
+// interval until ctx is canceled.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := m.Load(); err != nil {
+				log.Printf("could not reload signing keys: %v", err)
+				continue
+			}
+			if err := m.Maintain(now); err != nil {
+				log.Printf("could not rotate signing keys: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package keys
+
+// store.go persists signing keys.
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store persists the signing keys of a Manager.
+type Store interface {
+	// Keys returns every stored key.
+	Keys() ([]*Key, error)
+	// Save creates the key, or updates it if a key with the same ID exists.
+	Save(key *Key) error
+	// DeleteExpired deletes the keys that expired before now.
+	DeleteExpired(now time.Time) error
+}
+
+// MemoryStore keeps keys in memory. Keys are lost when the process exits,
+// so it is meant for tests and single instance setups that can afford to
+// invalidate every token on restart.
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]Key
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: map[string]Key{}}
+}
+
+// Keys returns copies of the stored keys, oldest first.
+func (s *MemoryStore) Keys() ([]*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		key := key
+		keys = append(keys, &key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// Save stores a copy of the key.
+func (s *MemoryStore) Save(key *Key) error {
+	s.mu.Lock()
+	s.keys[key.ID] = *key
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteExpired deletes the keys that expired before now.
+func (s *MemoryStore) DeleteExpired(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, key := range s.keys {
+		if !key.canVerify(now) {
+			delete(s.keys, id)
+		}
+	}
+	return nil
+}
+
+// DBStore keeps keys in the signing_keys table. Private keys are encrypted
+// with utils.EncryptWith before they are written.
+type DBStore struct {
+	db     *gorm.DB
+	secret []byte
+}
+
+// NewDBStore creates a store that persists keys to db, encrypting them with
+// the key derived from secret.
+func NewDBStore(db *gorm.DB, secret []byte) *DBStore {
+	return &DBStore{db: db, secret: secret}
+}
+
+// Keys returns the stored keys, oldest first.
+func (s *DBStore) Keys() ([]*Key, error) {
+	var records []models.SigningKey
+	if err := s.db.Order("created_at, id").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(records))
+	for _, record := range records {
+		der, err := utils.DecryptWith(s.secret, record.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("stored signing key is not a signer")
+		}
+
+		keys = append(keys, &Key{
+			ID:        record.KID,
+			Algorithm: Algorithm(record.Algorithm),
+			Signer:    signer,
+			CreatedAt: record.CreatedAt,
+			RotatesAt: record.RotatesAt,
+			ExpiresAt: record.ExpiresAt,
+		})
+	}
+	return keys, nil
+}
+
+// Save encrypts and stores the key. Only the rotation and expiry times of
+// an existing key are updated.
+func (s *DBStore) Save(key *Key) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Signer)
+	if err != nil {
+		return err
+	}
+	encrypted, err := utils.EncryptWith(s.secret, der)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rotates_at", "expires_at"}),
+	}).Create(&models.SigningKey{
+		KID:        key.ID,
+		Algorithm:  string(key.Algorithm),
+		PrivateKey: encrypted,
+		RotatesAt:  key.RotatesAt,
+		ExpiresAt:  key.ExpiresAt,
+		CreatedAt:  key.CreatedAt,
+	}).Error
+}
+
+// DeleteExpired deletes the keys that expired before now.
+func (s *DBStore) DeleteExpired(now time.Time) error {
+	return s.db.Where("expires_at <= ?", now).Delete(&models.SigningKey{}).Error
+}
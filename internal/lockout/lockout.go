@@ -0,0 +1,225 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package lockout
+
+// lockout.go protects the login against password guessing by counting the
+// failed logins of every account and client IP.
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// Defaults used for the zero values of Config.
+const (
+	DefaultMaxAccountFailures = 10
+	DefaultMaxIPFailures      = 100
+	DefaultWindow             = 15 * time.Minute
+	DefaultLockoutDuration    = 15 * time.Minute
+	DefaultFreeFailures       = 3
+	DefaultBaseDelay          = time.Second
+	DefaultMaxDelay           = 30 * time.Second
+)
+
+// Config configures a Guard.
+type Config struct {
+	// MaxAccountFailures is how many failed logins lock an account.
+	MaxAccountFailures int
+	// MaxIPFailures is how many failed logins lock a client IP, whatever
+	// the accounts tried.
+	MaxIPFailures int
+	// Window is how long a failure is counted. The count starts over once
+	// the last failure is older than that.
+	Window time.Duration
+	// LockoutDuration is how long an account or IP stays locked.
+	LockoutDuration time.Duration
+	// FreeFailures is how many failed logins of an account are allowed
+	// before further attempts are delayed.
+	FreeFailures int
+	// BaseDelay is the delay after the first failure past FreeFailures. It
+	// doubles with every further failure, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Guard decides whether a login attempt is allowed. Accounts are delayed
+// progressively and then locked, client IPs are locked. Unknown accounts
+// are counted like existing ones, so the responses do not reveal which
+// accounts exist.
+type Guard struct {
+	store  Store
+	config Config
+}
+
+// NewGuard creates a guard keeping its counters in store.
+func NewGuard(store Store, cfg Config) *Guard {
+	if cfg.MaxAccountFailures <= 0 {
+		cfg.MaxAccountFailures = DefaultMaxAccountFailures
+	}
+	if cfg.MaxIPFailures <= 0 {
+		cfg.MaxIPFailures = DefaultMaxIPFailures
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = DefaultLockoutDuration
+	}
+	if cfg.FreeFailures <= 0 {
+		cfg.FreeFailures = DefaultFreeFailures
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = DefaultBaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = DefaultMaxDelay
+	}
+	return &Guard{store: store, config: cfg}
+}
+
+func accountKey(account string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns how long the client has to wait before it may try to log in
+// to the account from ip. It returns 0 if the attempt is allowed now.
+func (g *Guard) Check(account, ip string, now time.Time) (time.Duration, error) {
+	entry, err := g.store.Get(ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+	wait := g.wait(entry, now, false)
+
+	if entry, err = g.store.Get(accountKey(account)); err != nil {
+		return 0, err
+	}
+	if accountWait := g.wait(entry, now, true); accountWait > wait {
+		wait = accountWait
+	}
+	return wait, nil
+}
+
+// wait returns how long the entry blocks logins at now. Only accounts are
+// delayed before they are locked.
+func (g *Guard) wait(entry Entry, now time.Time, delayed bool) time.Duration {
+	if entry.LockedUntil.After(now) {
+		return entry.LockedUntil.Sub(now)
+	}
+	if !delayed || now.Sub(entry.LastFailure) > g.config.Window {
+		return 0
+	}
+	if wait := entry.LastFailure.Add(g.Delay(entry.Failures)).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Delay returns how long an account has to wait after its nth failure.
+func (g *Guard) Delay(failures int) time.Duration {
+	extra := failures - g.config.FreeFailures
+	if extra <= 0 {
+		return 0
+	}
+
+	delay := g.config.BaseDelay
+	for i := 1; i < extra && delay < g.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.config.MaxDelay {
+		delay = g.config.MaxDelay
+	}
+	return delay
+}
+
+// Fail records a failed login to the account from ip, locking either when
+// it reaches its limit.
+func (g *Guard) Fail(account, ip string, now time.Time) error {
+	if _, err := g.fail(ipKey(ip), g.config.MaxIPFailures, now); err != nil {
+		return err
+	}
+	_, err := g.fail(accountKey(account), g.config.MaxAccountFailures, now)
+	return err
+}
+
+// FailAccount records a failure of the account alone, for attempts whose IP
+// was already counted under another account, and returns how many failures
+// of the account are counted. It locks the account when it reaches its
+// limit.
+func (g *Guard) FailAccount(account string, now time.Time) (int, error) {
+	return g.fail(accountKey(account), g.config.MaxAccountFailures, now)
+}
+
+func (g *Guard) fail(key string, max int, now time.Time) (int, error) {
+	entry, err := g.store.Fail(key, now, g.config.Window)
+	if err != nil {
+		return 0, err
+	}
+	if entry.Failures >= max {
+		return entry.Failures, g.store.Lock(key, now.Add(g.config.LockoutDuration))
+	}
+	return entry.Failures, nil
+}
+
+// Succeed forgets the failures of the account after a successful login.
+// The failures of the IP are kept, or logging in to an account of their
+// own would let attackers reset them.
+func (g *Guard) Succeed(account string) error {
+	return g.store.Reset(accountKey(account))
+}
+
+// Unlock forgets the failures of the account and lifts its lockout.
+func (g *Guard) Unlock(account string) error {
+	return g.store.Reset(accountKey(account))
+}
+
+// LockedUntil returns when the lockout of the account ends. It returns the
+// zero time if the account is not locked at now.
+func (g *Guard) LockedUntil(account string, now time.Time) (time.Time, error) {
+	entry, err := g.store.Get(accountKey(account))
+	if err != nil || !entry.LockedUntil.After(now) {
+		return time.Time{}, err
+	}
+	return entry.LockedUntil, nil
+}
+
+// Run prunes the counters that ended every interval until ctx is canceled.
+func (g *Guard) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := g.store.Prune(now.Add(-g.config.Window)); err != nil {
+				log.Printf("could not prune login attempts: %v", err)
+			}
+		}
+	}
+}
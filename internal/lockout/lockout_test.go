@@ -0,0 +1,216 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package lockout_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/lockout"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// stores returns a constructor for every Store implementation.
+func stores() map[string]func(t *testing.T) lockout.Store {
+	return map[string]func(t *testing.T) lockout.Store{
+		"memory": func(t *testing.T) lockout.Store {
+			return lockout.NewMemoryStore()
+		},
+		"database": func(t *testing.T) lockout.Store {
+			db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+			require.NoError(t, err)
+			require.NoError(t, db.AutoMigrate(&models.LoginAttempt{}))
+			return lockout.NewDBStore(db)
+		},
+	}
+}
+
+var config = lockout.Config{
+	MaxAccountFailures: 5,
+	MaxIPFailures:      8,
+	Window:             time.Hour,
+	LockoutDuration:    30 * time.Minute,
+	FreeFailures:       2,
+	BaseDelay:          time.Second,
+	MaxDelay:           4 * time.Second,
+}
+
+func TestDelay(t *testing.T) {
+	guard := lockout.NewGuard(lockout.NewMemoryStore(), config)
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{20, 4 * time.Second},
+		{1000, 4 * time.Second},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.want, guard.Delay(test.failures), "%d failures", test.failures)
+	}
+}
+
+func TestStore(t *testing.T) {
+	for name, newStore := range stores() {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			now := time.Now().Truncate(time.Second)
+
+			entry, err := store.Get("a")
+			require.NoError(t, err)
+			require.Zero(t, entry)
+
+			for i := 1; i <= 3; i++ {
+				entry, err = store.Fail("a", now, time.Minute)
+				require.NoError(t, err)
+				require.Equal(t, i, entry.Failures)
+			}
+			require.True(t, entry.LastFailure.Equal(now))
+
+			// The count starts over after the window
+			later := now.Add(2 * time.Minute)
+			entry, err = store.Fail("a", later, time.Minute)
+			require.NoError(t, err)
+			require.Equal(t, 1, entry.Failures)
+
+			require.NoError(t, store.Lock("a", later.Add(time.Hour)))
+			entry, err = store.Get("a")
+			require.NoError(t, err)
+			require.True(t, entry.LockedUntil.Equal(later.Add(time.Hour)))
+
+			_, err = store.Fail("b", now, time.Minute)
+			require.NoError(t, err)
+
+			// Locked entries are kept until the lock ended
+			require.NoError(t, store.Prune(later.Add(time.Minute)))
+			entry, err = store.Get("a")
+			require.NoError(t, err)
+			require.Equal(t, 1, entry.Failures)
+			entry, err = store.Get("b")
+			require.NoError(t, err)
+			require.Zero(t, entry.Failures)
+
+			require.NoError(t, store.Reset("a"))
+			entry, err = store.Get("a")
+			require.NoError(t, err)
+			require.Zero(t, entry)
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	for name, newStore := range stores() {
+		t.Run(name, func(t *testing.T) {
+			guard := lockout.NewGuard(newStore(t), config)
+			now := time.Now()
+
+			// The first failures are not delayed
+			for i := 0; i < 2; i++ {
+				require.NoError(t, guard.Fail("user@example.com", "192.0.2.1", now))
+				wait, err := guard.Check("user@example.com", "192.0.2.1", now)
+				require.NoError(t, err)
+				require.Zero(t, wait)
+			}
+
+			// Then every failure doubles the delay
+			require.NoError(t, guard.Fail("user@example.com", "192.0.2.1", now))
+			wait, err := guard.Check("USER@example.com ", "192.0.2.2", now)
+			require.NoError(t, err)
+			require.Equal(t, time.Second, wait)
+
+			now = now.Add(time.Second)
+			require.NoError(t, guard.Fail("user@example.com", "192.0.2.1", now))
+			wait, err = guard.Check("user@example.com", "192.0.2.2", now)
+			require.NoError(t, err)
+			require.Equal(t, 2*time.Second, wait)
+
+			// Other accounts are not affected
+			wait, err = guard.Check("other@example.com", "192.0.2.2", now)
+			require.NoError(t, err)
+			require.Zero(t, wait)
+
+			// Reaching the limit locks the account
+			now = now.Add(2 * time.Second)
+			require.NoError(t, guard.Fail("user@example.com", "192.0.2.1", now))
+			wait, err = guard.Check("user@example.com", "192.0.2.2", now)
+			require.NoError(t, err)
+			require.Equal(t, config.LockoutDuration, wait)
+
+			lockedUntil, err := guard.LockedUntil("user@example.com", now)
+			require.NoError(t, err)
+			require.Equal(t, now.Add(config.LockoutDuration).Unix(), lockedUntil.Unix())
+
+			// The lockout ends on its own or when unlocked
+			wait, err = guard.Check("user@example.com", "192.0.2.2", now.Add(config.LockoutDuration))
+			require.NoError(t, err)
+			require.Zero(t, wait)
+
+			require.NoError(t, guard.Unlock("user@example.com"))
+			wait, err = guard.Check("user@example.com", "192.0.2.2", now)
+			require.NoError(t, err)
+			require.Zero(t, wait)
+			lockedUntil, err = guard.LockedUntil("user@example.com", now)
+			require.NoError(t, err)
+			require.True(t, lockedUntil.IsZero())
+
+			// Failures from one IP on many accounts lock the IP, but not
+			// the accounts from elsewhere
+			for i := 0; i < 3; i++ {
+				require.NoError(t, guard.Fail("spray@example.com", "192.0.2.1", now))
+			}
+			wait, err = guard.Check("fresh@example.com", "192.0.2.1", now)
+			require.NoError(t, err)
+			require.Equal(t, config.LockoutDuration, wait)
+			wait, err = guard.Check("fresh@example.com", "192.0.2.2", now)
+			require.NoError(t, err)
+			require.Zero(t, wait)
+
+			// A successful login resets the account but not the IP
+			require.NoError(t, guard.Succeed("spray@example.com"))
+			wait, err = guard.Check("spray@example.com", "192.0.2.2", now)
+			require.NoError(t, err)
+			require.Zero(t, wait)
+			wait, err = guard.Check("spray@example.com", "192.0.2.1", now)
+			require.NoError(t, err)
+			require.NotZero(t, wait)
+
+			// Failures of an account alone leave the IP alone
+			for i := 1; i <= 3; i++ {
+				failures, err := guard.FailAccount("challenge", now)
+				require.NoError(t, err)
+				require.Equal(t, i, failures)
+			}
+			wait, err = guard.Check("fresh@example.com", "192.0.2.3", now)
+			require.NoError(t, err)
+			require.Zero(t, wait)
+		})
+	}
+}
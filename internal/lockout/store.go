@@ -0,0 +1,188 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package lockout
+
+// store.go persists the failed login counters.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Entry is the failed login counter of a key.
+type Entry struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Store persists the failed login counters of a Guard.
+type Store interface {
+	// Get returns the entry of key, or a zero entry if there is none.
+	Get(key string) (Entry, error)
+	// Fail records a failed login at now and returns the updated entry. The
+	// count starts over if the previous failure is older than window.
+	Fail(key string, now time.Time, window time.Duration) (Entry, error)
+	// Lock prevents logins with key until the given time.
+	Lock(key string, until time.Time) error
+	// Reset forgets the failures of key and unlocks it.
+	Reset(key string) error
+	// Prune deletes the entries whose last failure and lock both ended
+	// before the given time.
+	Prune(before time.Time) error
+}
+
+// MemoryStore keeps the counters in memory. They are lost when the process
+// exits and are not shared between instances.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]Entry{}}
+}
+
+// Get returns the entry of key.
+func (s *MemoryStore) Get(key string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries[key], nil
+}
+
+// Fail records a failed login at now.
+func (s *MemoryStore) Fail(key string, now time.Time, window time.Duration) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entries[key]
+	if now.Sub(entry.LastFailure) > window {
+		entry.Failures = 0
+	}
+	entry.Failures++
+	entry.LastFailure = now
+	s.entries[key] = entry
+	return entry, nil
+}
+
+// Lock prevents logins with key until the given time.
+func (s *MemoryStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entries[key]
+	entry.LockedUntil = until
+	s.entries[key] = entry
+	return nil
+}
+
+// Reset forgets the failures of key.
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// Prune deletes the entries that ended before the given time.
+func (s *MemoryStore) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.entries {
+		if entry.LastFailure.Before(before) && entry.LockedUntil.Before(before) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
+
+// DBStore keeps the counters in the login_attempts table, so they are
+// shared by every instance using the same database.
+type DBStore struct {
+	db *gorm.DB
+}
+
+// NewDBStore creates a store that persists the counters to db.
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// Get returns the entry of key.
+func (s *DBStore) Get(key string) (Entry, error) {
+	// Most keys have no failures, so a missing row is not worth logging
+	var attempt models.LoginAttempt
+	result := s.db.Where("attempt_key = ?", key).Limit(1).Find(&attempt)
+	if result.Error != nil {
+		return Entry{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return Entry{}, nil
+	}
+
+	entry := Entry{Failures: attempt.Failures, LastFailure: attempt.LastFailureAt}
+	if attempt.LockedUntil != nil {
+		entry.LockedUntil = *attempt.LockedUntil
+	}
+	return entry, nil
+}
+
+// Fail records a failed login at now. The counter is updated with a single
+// upsert, so concurrent failures are all counted.
+func (s *DBStore) Fail(key string, now time.Time, window time.Duration) (Entry, error) {
+	err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "attempt_key"}},
+		// Assignments are sorted by column, so failures is computed from
+		// the previous last_failure_at
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END", now.Add(-window)),
+			"last_failure_at": now,
+			"updated_at":      now,
+		}),
+	}).Create(&models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now}).Error
+	if err != nil {
+		return Entry{}, err
+	}
+	return s.Get(key)
+}
+
+// Lock prevents logins with key until the given time.
+func (s *DBStore) Lock(key string, until time.Time) error {
+	return s.db.Model(&models.LoginAttempt{}).Where("attempt_key = ?", key).Update("locked_until", until).Error
+}
+
+// Reset forgets the failures of key.
+func (s *DBStore) Reset(key string) error {
+	return s.db.Where("attempt_key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+// Prune deletes the entries that ended before the given time.
+func (s *DBStore) Prune(before time.Time) error {
+	return s.db.
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, before).
+		Delete(&models.LoginAttempt{}).Error
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mailer
+
+// mailer.go defines how goAuth sends outbound messages such as password
+// reset links. Implementations are pluggable, the ones in this package work
+// without an SMTP server.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+	"time"
+)
+
+// Message is an outbound plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages to users.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns a FileMailer writing to dir if it is set, otherwise a
+// LogMailer writing to the standard logger.
+func New(dir string) Mailer {
+	if dir != "" {
+		return &FileMailer{Dir: dir}
+	}
+	return &LogMailer{}
+}
+
+// LogMailer writes messages to a logger instead of delivering them.
+// It is meant for development.
+type LogMailer struct {
+	// Logger is the logger messages are written to. If nil the standard
+	// logger is used.
+	Logger *log.Logger
+}
+
+// Send writes the message to the logger.
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	logf := log.Printf
+	if m.Logger != nil {
+		logf = m.Logger.Printf
+	}
+	logf("mail to=%q subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer writes every message as a separate .eml file to Dir.
+type FileMailer struct {
+	Dir string
+
+	seq atomic.Uint64
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// Send writes the message to a new file in Dir, creating Dir if needed.
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o700); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%d-%s.eml", time.Now().UnixNano(), m.seq.Add(1), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		msg.To, msg.Subject, time.Now().Format(time.RFC1123Z), msg.Body)
+
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(content), 0o600)
+}
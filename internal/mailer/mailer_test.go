@@ -0,0 +1,71 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mailer_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/mailer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := &mailer.FileMailer{Dir: dir}
+
+	msg := mailer.Message{To: "test@example.com", Subject: "Hello", Body: "Hello there"}
+	require.NoError(t, m.Send(context.Background(), msg))
+	require.NoError(t, m.Send(context.Background(), msg))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	content, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(content), "To: test@example.com")
+	require.Contains(t, string(content), "Subject: Hello")
+	require.Contains(t, string(content), "Hello there")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, m.Send(ctx, msg))
+}
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	m := &mailer.LogMailer{Logger: log.New(&buf, "", 0)}
+
+	require.NoError(t, m.Send(context.Background(), mailer.Message{To: "test@example.com", Subject: "Hello", Body: "Hello there"}))
+	require.Contains(t, buf.String(), "test@example.com")
+	require.Contains(t, buf.String(), "Hello there")
+}
+
+func TestNew(t *testing.T) {
+	require.IsType(t, &mailer.LogMailer{}, mailer.New(""))
+	require.IsType(t, &mailer.FileMailer{}, mailer.New(t.TempDir()))
+}
@@ -0,0 +1,324 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package migrate_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/migrate"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	return db
+}
+
+// exec returns a migration step that executes a statement.
+func exec(statement string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Exec(statement).Error
+	}
+}
+
+func versions(migrations []migrate.Migration) []int {
+	var result []int
+	for _, migration := range migrations {
+		result = append(result, migration.Version)
+	}
+	return result
+}
+
+var testMigrations = []migrate.Migration{
+	{Version: 2, Name: "add_notes_body", Up: exec("ALTER TABLE notes ADD COLUMN body TEXT"), Down: exec("ALTER TABLE notes DROP COLUMN body")},
+	{Version: 1, Name: "create_notes", Up: exec("CREATE TABLE notes (id INTEGER PRIMARY KEY)"), Down: exec("DROP TABLE notes")},
+}
+
+func TestMigrator(t *testing.T) {
+	db := openDB(t)
+	migrator, err := migrate.New(db, testMigrations)
+	require.NoError(t, err)
+
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, versions(pending))
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, versions(applied), "applied in order")
+	require.True(t, db.Migrator().HasColumn("notes", "body"))
+
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	require.Empty(t, applied)
+
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.Equal(t, "create_notes", statuses[0].Name)
+	require.NotNil(t, statuses[0].AppliedAt)
+	require.NotNil(t, statuses[1].AppliedAt)
+
+	reverted, err := migrator.Down(1)
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, versions(reverted))
+	require.False(t, db.Migrator().HasColumn("notes", "body"))
+	statuses, err = migrator.Status()
+	require.NoError(t, err)
+	require.Nil(t, statuses[1].AppliedAt)
+
+	reverted, err = migrator.Down(5)
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, versions(reverted))
+	require.False(t, db.Migrator().HasTable("notes"))
+
+	_, err = migrator.Down(0)
+	require.Error(t, err)
+}
+
+func TestMigratorFailure(t *testing.T) {
+	db := openDB(t)
+	fail := errors.New("backfill failed")
+	migrations := append([]migrate.Migration{{
+		Version: 3,
+		Name:    "backfill",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("INSERT INTO notes (body) VALUES ('half done')").Error; err != nil {
+				return err
+			}
+			return fail
+		},
+		Down: exec("DELETE FROM notes"),
+	}}, testMigrations...)
+	migrator, err := migrate.New(db, migrations)
+	require.NoError(t, err)
+
+	// The migrations before the failing one stay applied, the failing one
+	// is rolled back
+	applied, err := migrator.Up()
+	require.ErrorIs(t, err, fail)
+	require.Equal(t, []int{1, 2}, versions(applied))
+	var count int64
+	require.NoError(t, db.Table("notes").Count(&count).Error)
+	require.Zero(t, count)
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	require.Equal(t, []int{3}, versions(pending))
+}
+
+func TestMigratorUnknownMigration(t *testing.T) {
+	db := openDB(t)
+	newer, err := migrate.New(db, append(testMigrations, migrate.Migration{
+		Version: 3, Name: "from_the_future", Up: exec("SELECT 1"), Down: exec("SELECT 1"),
+	}))
+	require.NoError(t, err)
+	_, err = newer.Up()
+	require.NoError(t, err)
+
+	older, err := migrate.New(db, testMigrations)
+	require.NoError(t, err)
+	statuses, err := older.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	require.True(t, statuses[2].Unknown)
+	require.Equal(t, "from_the_future", statuses[2].Name)
+
+	_, err = older.Down(1)
+	require.Error(t, err)
+}
+
+func TestNewValidates(t *testing.T) {
+	tests := []struct {
+		name      string
+		migration migrate.Migration
+	}{
+		{"duplicate version", migrate.Migration{Version: 1, Name: "again", Up: exec("SELECT 1"), Down: exec("SELECT 1")}},
+		{"invalid version", migrate.Migration{Version: 0, Name: "zero", Up: exec("SELECT 1"), Down: exec("SELECT 1")}},
+		{"missing down", migrate.Migration{Version: 3, Name: "oneway", Up: exec("SELECT 1")}},
+	}
+	for _, test := range tests {
+		_, err := migrate.New(openDB(t), append(testMigrations, test.migration))
+		require.Error(t, err, test.name)
+	}
+}
+
+func TestLoadSQL(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sqlite/0001_create_notes.up.sql": {Data: []byte(`-- Notes
+CREATE TABLE notes (
+	id INTEGER PRIMARY KEY,
+	body TEXT
+);
+INSERT INTO notes (body) VALUES ('first; not the end');
+INSERT INTO notes (body) VALUES ('second')`)},
+		"sqlite/0001_create_notes.down.sql": {Data: []byte("DROP TABLE notes;\n")},
+		"broken/0001_create_notes.up.sql":   {Data: []byte("SELECT 1;\n")},
+		"misnamed/create_notes.sql":         {Data: []byte("SELECT 1;\n")},
+	}
+
+	migrations, err := migrate.LoadSQL(fsys, "sqlite")
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+	require.Equal(t, 1, migrations[0].Version)
+	require.Equal(t, "create_notes", migrations[0].Name)
+
+	db := openDB(t)
+	require.NoError(t, migrations[0].Up(db))
+	var bodies []string
+	require.NoError(t, db.Table("notes").Order("id").Pluck("body", &bodies).Error)
+	require.Equal(t, []string{"first; not the end", "second"}, bodies)
+	require.NoError(t, migrations[0].Down(db))
+	require.False(t, db.Migrator().HasTable("notes"))
+
+	for _, dir := range []string{"broken", "misnamed", "missing"} {
+		_, err := migrate.LoadSQL(fsys, dir)
+		require.Error(t, err, dir)
+	}
+}
+
+// schema lists the models the embedded migrations have to create.
+var schema = []interface{}{
+	&models.User{},
+	&models.Session{},
+	&models.RefreshToken{},
+	&models.RevokedToken{},
+	&models.PasswordResetToken{},
+	&models.EmailVerificationToken{},
+	&models.TOTPCredential{},
+	&models.RecoveryCode{},
+	&models.WebAuthnCredential{},
+	&models.WebAuthnChallenge{},
+	&models.SigningKey{},
+	&models.OAuthClient{},
+	&models.AuthorizationCode{},
+	&models.OAuthConsent{},
+	&models.Role{},
+	&models.Permission{},
+	&models.LoginAttempt{},
+	&models.AuditEvent{},
+	&models.PasswordHistory{},
+}
+
+// requireSchema fails unless every column and index of the models exists.
+func requireSchema(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	for _, model := range schema {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		require.True(t, db.Migrator().HasTable(model), stmt.Schema.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" {
+				require.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+			}
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			require.True(t, db.Migrator().HasIndex(model, index.Name), "%s %s", stmt.Schema.Table, index.Name)
+		}
+		for _, relationship := range stmt.Schema.Relationships.Relations {
+			if relationship.JoinTable != nil {
+				require.True(t, db.Migrator().HasTable(relationship.JoinTable.Table), relationship.JoinTable.Table)
+			}
+		}
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	for _, dialect := range []string{"sqlite", "postgres", "mysql"} {
+		migrations, err := migrate.Embedded(dialect)
+		require.NoError(t, err, dialect)
+		require.NotEmpty(t, migrations, dialect)
+	}
+	_, err := migrate.Embedded("oracle")
+	require.Error(t, err)
+
+	// The migrations create the schema of the models and revert cleanly
+	db := openDB(t)
+	migrator, err := migrate.ForDatabase(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	requireSchema(t, db)
+
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	_, err = migrator.Down(len(statuses))
+	require.NoError(t, err)
+	for _, model := range schema {
+		require.False(t, db.Migrator().HasTable(model))
+	}
+	require.False(t, db.Migrator().HasTable("user_roles"))
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	requireSchema(t, db)
+}
+
+// originalUser is the user model the server created the users table from
+// on startup before migrations were versioned.
+type originalUser struct {
+	ID           uint           `gorm:"primaryKey"`
+	Name         string         `gorm:"size:250;"`
+	Username     string         `gorm:"uniqueIndex;size:100;not null"`
+	Email        string         `gorm:"uniqueIndex;size:255;not null"`
+	PasswordHash []byte         `gorm:"not null"`
+	IsStaff      bool           `gorm:"default:false"`
+	IsSuperuser  bool           `gorm:"default:false"`
+	IsActive     bool           `gorm:"default:true"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time      `gorm:"autoCreateTime"`
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+func (originalUser) TableName() string {
+	return "users"
+}
+
+func TestEmbeddedAdoptsExistingDatabase(t *testing.T) {
+	db := openDB(t)
+	require.NoError(t, db.AutoMigrate(&originalUser{}))
+	require.NoError(t, db.Create(&originalUser{Username: "existing", Email: "existing@example.com", PasswordHash: []byte("x")}).Error)
+
+	migrator, err := migrate.ForDatabase(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	requireSchema(t, db)
+
+	// The existing users get the defaults of the new columns
+	var user models.User
+	require.NoError(t, db.Where("username = ?", "existing").First(&user).Error)
+	require.True(t, user.IsActive)
+	require.False(t, user.EmailVerified)
+	require.Nil(t, user.VerifiedAt)
+	require.False(t, user.PasswordResetRequired)
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package migrate
+
+// sql.go loads migrations written in SQL. Every database dialect has its
+// own directory of files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, such as 0002_add_user_locale.up.sql.
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var embedded embed.FS
+
+// sqlFileRe matches the name of a migration file.
+var sqlFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Embedded returns the migrations of the server for the dialect, as named
+// by gorm.Dialector.Name.
+func Embedded(dialect string) ([]Migration, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return LoadSQL(sub, dialect)
+}
+
+// ForDatabase returns a Migrator that applies the migrations of the server
+// to db.
+func ForDatabase(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Embedded(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return New(db, migrations)
+}
+
+// LoadSQL loads the migrations in the dir directory of fsys. Every
+// migration needs both an up and a down file.
+func LoadSQL(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: no migrations for %q: %w", dir, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	var versions []int
+	for _, entry := range entries {
+		match := sqlFileRe.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("migrate: unexpected file %s", path.Join(dir, entry.Name()))
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		step := execSQL(string(content))
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+			versions = append(versions, version)
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrate: migration %d is named both %q and %q", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = step
+		} else {
+			migration.Down = step
+		}
+	}
+
+	migrations := make([]Migration, 0, len(versions))
+	for _, version := range versions {
+		migration := byVersion[version]
+		if migration.Up == nil || migration.Down == nil {
+			return nil, fmt.Errorf("migrate: migration %d_%s needs both an up and a down file", version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	return migrations, nil
+}
+
+// execSQL returns a step that executes the statements of a SQL file one by
+// one, as not every driver accepts several statements at once. Statements
+// end with a semicolon at the end of a line, lines starting with -- are
+// comments.
+func execSQL(content string) func(tx *gorm.DB) error {
+	var statements []string
+	var statement strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(statement.String()))
+			statement.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(statement.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS `audit_events`;
+DROP TABLE IF EXISTS `login_attempts`;
+DROP TABLE IF EXISTS `role_permissions`;
+DROP TABLE IF EXISTS `permissions`;
+DROP TABLE IF EXISTS `o_auth_consents`;
+DROP TABLE IF EXISTS `authorization_codes`;
+DROP TABLE IF EXISTS `o_auth_clients`;
+DROP TABLE IF EXISTS `signing_keys`;
+DROP TABLE IF EXISTS `web_authn_challenges`;
+DROP TABLE IF EXISTS `web_authn_credentials`;
+DROP TABLE IF EXISTS `recovery_codes`;
+DROP TABLE IF EXISTS `totp_credentials`;
+DROP TABLE IF EXISTS `email_verification_tokens`;
+DROP TABLE IF EXISTS `password_reset_tokens`;
+DROP TABLE IF EXISTS `revoked_tokens`;
+DROP TABLE IF EXISTS `refresh_tokens`;
+DROP TABLE IF EXISTS `sessions`;
+DROP TABLE IF EXISTS `user_roles`;
+DROP TABLE IF EXISTS `roles`;
+DROP TABLE IF EXISTS `users`;
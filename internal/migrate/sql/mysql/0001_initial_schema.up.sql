@@ -0,0 +1,40 @@
+-- The schema the server created on startup before migrations were
+-- versioned. Existing tables are kept, so databases created back then
+-- adopt the migrations. The users table is created with the columns it had
+-- back then and the columns added since are added to it if missing. MySQL
+-- has no ADD COLUMN IF NOT EXISTS and commits every statement, so the
+-- columns are looked up first and the migration can be run again if it
+-- fails halfway.
+
+CREATE TABLE IF NOT EXISTS `users` (`id` bigint unsigned AUTO_INCREMENT,`name` varchar(250),`username` varchar(100) NOT NULL,`email` varchar(255) NOT NULL,`password_hash` longblob NOT NULL,`is_staff` boolean DEFAULT false,`is_superuser` boolean DEFAULT false,`is_active` boolean DEFAULT true,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_users_username` (`username`),UNIQUE INDEX `idx_users_email` (`email`),INDEX `idx_users_deleted_at` (`deleted_at`));
+SET @statement = IF((SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'email_verified') = 0, 'ALTER TABLE `users` ADD COLUMN `email_verified` boolean DEFAULT false', 'SELECT 1');
+PREPARE add_column FROM @statement;
+EXECUTE add_column;
+DEALLOCATE PREPARE add_column;
+SET @statement = IF((SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'verified_at') = 0, 'ALTER TABLE `users` ADD COLUMN `verified_at` datetime(3) NULL DEFAULT null', 'SELECT 1');
+PREPARE add_column FROM @statement;
+EXECUTE add_column;
+DEALLOCATE PREPARE add_column;
+SET @statement = IF((SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'password_reset_required') = 0, 'ALTER TABLE `users` ADD COLUMN `password_reset_required` boolean DEFAULT false', 'SELECT 1');
+PREPARE add_column FROM @statement;
+EXECUTE add_column;
+DEALLOCATE PREPARE add_column;
+CREATE TABLE IF NOT EXISTS `roles` (`id` bigint unsigned AUTO_INCREMENT,`name` varchar(64) NOT NULL,`description` varchar(255),`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_roles_name` (`name`));
+CREATE TABLE IF NOT EXISTS `user_roles` (`user_id` bigint unsigned,`role_id` bigint unsigned,PRIMARY KEY (`user_id`,`role_id`),CONSTRAINT `fk_user_roles_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_user_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `sessions` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`device` varchar(100),`user_agent` varchar(512),`ip` varchar(45),`last_seen_at` datetime(3) NULL,`expires_at` datetime(3) NOT NULL,`revoked_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_sessions_expires_at` (`expires_at`),INDEX `idx_sessions_user_id` (`user_id`),CONSTRAINT `fk_sessions_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`family_id` varchar(64) NOT NULL,`session_id` bigint unsigned,`token_hash` varchar(64) NOT NULL,`expires_at` datetime(3) NOT NULL,`used_at` datetime(3) NULL,`revoked_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_refresh_tokens_user_id` (`user_id`),INDEX `idx_refresh_tokens_family_id` (`family_id`),INDEX `idx_refresh_tokens_session_id` (`session_id`),UNIQUE INDEX `idx_refresh_tokens_token_hash` (`token_hash`),CONSTRAINT `fk_refresh_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `revoked_tokens` (`id` bigint unsigned AUTO_INCREMENT,`jti` varchar(64),`user_id` bigint unsigned NOT NULL,`expires_at` datetime(3) NOT NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_revoked_tokens_jti` (`jti`),INDEX `idx_revoked_tokens_user_id` (`user_id`),INDEX `idx_revoked_tokens_expires_at` (`expires_at`));
+CREATE TABLE IF NOT EXISTS `password_reset_tokens` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`token_hash` varchar(64) NOT NULL,`expires_at` datetime(3) NOT NULL,`used_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_password_reset_tokens_user_id` (`user_id`),UNIQUE INDEX `idx_password_reset_tokens_token_hash` (`token_hash`),CONSTRAINT `fk_password_reset_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `email_verification_tokens` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`email` varchar(255) NOT NULL,`token_hash` varchar(64) NOT NULL,`expires_at` datetime(3) NOT NULL,`used_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_email_verification_tokens_user_id` (`user_id`),UNIQUE INDEX `idx_email_verification_tokens_token_hash` (`token_hash`),CONSTRAINT `fk_email_verification_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `totp_credentials` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`secret` longblob NOT NULL,`confirmed_at` datetime(3) NULL,`last_used_step` bigint,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_totp_credentials_user_id` (`user_id`),CONSTRAINT `fk_totp_credentials_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `recovery_codes` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`code_hash` varchar(64) NOT NULL,`used_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_recovery_codes_user_id` (`user_id`),CONSTRAINT `fk_recovery_codes_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `web_authn_credentials` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`credential_id` varchar(255) NOT NULL,`public_key` longblob NOT NULL,`sign_count` int unsigned,`aa_guid` longblob,`attestation_type` varchar(16),`name` varchar(100),`last_used_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_web_authn_credentials_user_id` (`user_id`),UNIQUE INDEX `idx_web_authn_credentials_credential_id` (`credential_id`),CONSTRAINT `fk_web_authn_credentials_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `web_authn_challenges` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned,`ceremony` varchar(16) NOT NULL,`challenge_hash` varchar(64) NOT NULL,`expires_at` datetime(3) NOT NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_web_authn_challenges_user_id` (`user_id`),UNIQUE INDEX `idx_web_authn_challenges_challenge_hash` (`challenge_hash`),INDEX `idx_web_authn_challenges_expires_at` (`expires_at`));
+CREATE TABLE IF NOT EXISTS `signing_keys` (`id` bigint unsigned AUTO_INCREMENT,`kid` varchar(64) NOT NULL,`algorithm` varchar(16) NOT NULL,`private_key` longblob NOT NULL,`rotates_at` datetime(3) NOT NULL,`expires_at` datetime(3) NOT NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_signing_keys_expires_at` (`expires_at`),UNIQUE INDEX `idx_signing_keys_k_id` (`kid`));
+CREATE TABLE IF NOT EXISTS `o_auth_clients` (`id` bigint unsigned AUTO_INCREMENT,`client_id` varchar(64) NOT NULL,`client_secret_hash` longblob,`name` varchar(100) NOT NULL,`redirect_uris` text,`scopes` varchar(1000),`grant_types` varchar(255),`public` boolean DEFAULT false,`skip_consent` boolean DEFAULT false,`secret_rotated_at` datetime(3) NULL,`disabled_at` datetime(3) NULL,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_o_auth_clients_client_id` (`client_id`));
+CREATE TABLE IF NOT EXISTS `authorization_codes` (`id` bigint unsigned AUTO_INCREMENT,`code_hash` varchar(64) NOT NULL,`client_id` bigint unsigned NOT NULL,`user_id` bigint unsigned NOT NULL,`redirect_uri` text NOT NULL,`scope` varchar(1000),`nonce` varchar(255),`code_challenge` varchar(128) NOT NULL,`code_challenge_method` varchar(8) NOT NULL,`auth_time` datetime(3) NULL,`access_token_id` varchar(64),`expires_at` datetime(3) NOT NULL,`used_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_authorization_codes_code_hash` (`code_hash`),INDEX `idx_authorization_codes_client_id` (`client_id`),INDEX `idx_authorization_codes_user_id` (`user_id`),INDEX `idx_authorization_codes_expires_at` (`expires_at`),CONSTRAINT `fk_authorization_codes_client` FOREIGN KEY (`client_id`) REFERENCES `o_auth_clients`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_authorization_codes_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `o_auth_consents` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`client_id` bigint unsigned NOT NULL,`scope` varchar(1000),`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_oauth_consent` (`user_id`,`client_id`),CONSTRAINT `fk_o_auth_consents_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_o_auth_consents_client` FOREIGN KEY (`client_id`) REFERENCES `o_auth_clients`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `permissions` (`id` bigint unsigned AUTO_INCREMENT,`name` varchar(100) NOT NULL,`description` varchar(255),`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_permissions_name` (`name`));
+CREATE TABLE IF NOT EXISTS `role_permissions` (`role_id` bigint unsigned,`permission_id` bigint unsigned,PRIMARY KEY (`role_id`,`permission_id`),CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `login_attempts` (`id` bigint unsigned AUTO_INCREMENT,`attempt_key` varchar(320) NOT NULL,`failures` bigint NOT NULL,`last_failure_at` datetime(3) NOT NULL,`locked_until` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_login_attempts_key` (`attempt_key`),INDEX `idx_login_attempts_last_failure_at` (`last_failure_at`),INDEX `idx_login_attempts_locked_until` (`locked_until`));
+CREATE TABLE IF NOT EXISTS `audit_events` (`id` bigint unsigned AUTO_INCREMENT,`created_at` datetime(3) NOT NULL,`actor_type` varchar(16),`actor_id` varchar(64),`target_type` varchar(16),`target_id` varchar(64),`action` varchar(64) NOT NULL,`outcome` varchar(16) NOT NULL,`ip` varchar(64),`user_agent` varchar(512),`metadata` text,PRIMARY KEY (`id`),INDEX `idx_audit_events_target` (`target_type`,`target_id`),INDEX `idx_audit_events_action` (`action`),INDEX `idx_audit_events_created_at` (`created_at`),INDEX `idx_audit_events_actor` (`actor_type`,`actor_id`));
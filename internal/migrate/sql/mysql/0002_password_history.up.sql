@@ -0,0 +1,3 @@
+-- The former password hashes of the users, checked by PASSWORD_HISTORY.
+
+CREATE TABLE IF NOT EXISTS `password_histories` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`password_hash` longblob NOT NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_password_histories_user_id` (`user_id`),CONSTRAINT `fk_password_histories_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
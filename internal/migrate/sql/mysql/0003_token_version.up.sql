@@ -0,0 +1,4 @@
+-- The version of the tokens of the users, raised to revoke all of them at
+-- once.
+
+ALTER TABLE `users` ADD COLUMN `token_version` bigint unsigned NOT NULL DEFAULT 0;
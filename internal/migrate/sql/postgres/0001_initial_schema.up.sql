@@ -0,0 +1,68 @@
+-- The schema the server created on startup before migrations were
+-- versioned. Existing tables are kept, so databases created back then
+-- adopt the migrations. The users table is created with the columns it had
+-- back then and the columns added since are added to it if missing.
+
+CREATE TABLE IF NOT EXISTS "users" ("id" bigserial,"name" varchar(250),"username" varchar(100) NOT NULL,"email" varchar(255) NOT NULL,"password_hash" bytea NOT NULL,"is_staff" boolean DEFAULT false,"is_superuser" boolean DEFAULT false,"is_active" boolean DEFAULT true,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username");
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email_verified" boolean DEFAULT false;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "verified_at" timestamptz DEFAULT null;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "password_reset_required" boolean DEFAULT false;
+CREATE TABLE IF NOT EXISTS "roles" ("id" bigserial,"name" varchar(64) NOT NULL,"description" varchar(255),"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_roles_name" ON "roles" ("name");
+CREATE TABLE IF NOT EXISTS "user_roles" ("user_id" bigint,"role_id" bigint,PRIMARY KEY ("user_id","role_id"),CONSTRAINT "fk_user_roles_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE,CONSTRAINT "fk_user_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id") ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS "sessions" ("id" bigserial,"user_id" bigint NOT NULL,"device" varchar(100),"user_agent" varchar(512),"ip" varchar(45),"last_seen_at" timestamptz,"expires_at" timestamptz NOT NULL,"revoked_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_sessions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_sessions_expires_at" ON "sessions" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_sessions_user_id" ON "sessions" ("user_id");
+CREATE TABLE IF NOT EXISTS "refresh_tokens" ("id" bigserial,"user_id" bigint NOT NULL,"family_id" varchar(64) NOT NULL,"session_id" bigint,"token_hash" varchar(64) NOT NULL,"expires_at" timestamptz NOT NULL,"used_at" timestamptz,"revoked_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_refresh_tokens_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_session_id" ON "refresh_tokens" ("session_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE TABLE IF NOT EXISTS "revoked_tokens" ("id" bigserial,"jti" varchar(64),"user_id" bigint NOT NULL,"expires_at" timestamptz NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_revoked_tokens_expires_at" ON "revoked_tokens" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_revoked_tokens_jti" ON "revoked_tokens" ("jti");
+CREATE INDEX IF NOT EXISTS "idx_revoked_tokens_user_id" ON "revoked_tokens" ("user_id");
+CREATE TABLE IF NOT EXISTS "password_reset_tokens" ("id" bigserial,"user_id" bigint NOT NULL,"token_hash" varchar(64) NOT NULL,"expires_at" timestamptz NOT NULL,"used_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_password_reset_tokens_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_password_reset_tokens_user_id" ON "password_reset_tokens" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_password_reset_tokens_token_hash" ON "password_reset_tokens" ("token_hash");
+CREATE TABLE IF NOT EXISTS "email_verification_tokens" ("id" bigserial,"user_id" bigint NOT NULL,"email" varchar(255) NOT NULL,"token_hash" varchar(64) NOT NULL,"expires_at" timestamptz NOT NULL,"used_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_email_verification_tokens_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_email_verification_tokens_user_id" ON "email_verification_tokens" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_email_verification_tokens_token_hash" ON "email_verification_tokens" ("token_hash");
+CREATE TABLE IF NOT EXISTS "totp_credentials" ("id" bigserial,"user_id" bigint NOT NULL,"secret" bytea NOT NULL,"confirmed_at" timestamptz,"last_used_step" bigint,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_totp_credentials_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_totp_credentials_user_id" ON "totp_credentials" ("user_id");
+CREATE TABLE IF NOT EXISTS "recovery_codes" ("id" bigserial,"user_id" bigint NOT NULL,"code_hash" varchar(64) NOT NULL,"used_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_recovery_codes_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+CREATE TABLE IF NOT EXISTS "web_authn_credentials" ("id" bigserial,"user_id" bigint NOT NULL,"credential_id" varchar(255) NOT NULL,"public_key" bytea NOT NULL,"sign_count" bigint,"aa_guid" bytea,"attestation_type" varchar(16),"name" varchar(100),"last_used_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_web_authn_credentials_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_web_authn_credentials_user_id" ON "web_authn_credentials" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_web_authn_credentials_credential_id" ON "web_authn_credentials" ("credential_id");
+CREATE TABLE IF NOT EXISTS "web_authn_challenges" ("id" bigserial,"user_id" bigint,"ceremony" varchar(16) NOT NULL,"challenge_hash" varchar(64) NOT NULL,"expires_at" timestamptz NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_web_authn_challenges_expires_at" ON "web_authn_challenges" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_web_authn_challenges_user_id" ON "web_authn_challenges" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_web_authn_challenges_challenge_hash" ON "web_authn_challenges" ("challenge_hash");
+CREATE TABLE IF NOT EXISTS "signing_keys" ("id" bigserial,"kid" varchar(64) NOT NULL,"algorithm" varchar(16) NOT NULL,"private_key" bytea NOT NULL,"rotates_at" timestamptz NOT NULL,"expires_at" timestamptz NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_signing_keys_expires_at" ON "signing_keys" ("expires_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_signing_keys_k_id" ON "signing_keys" ("kid");
+CREATE TABLE IF NOT EXISTS "o_auth_clients" ("id" bigserial,"client_id" varchar(64) NOT NULL,"client_secret_hash" bytea,"name" varchar(100) NOT NULL,"redirect_uris" text,"scopes" varchar(1000),"grant_types" varchar(255),"public" boolean DEFAULT false,"skip_consent" boolean DEFAULT false,"secret_rotated_at" timestamptz,"disabled_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_o_auth_clients_client_id" ON "o_auth_clients" ("client_id");
+CREATE TABLE IF NOT EXISTS "authorization_codes" ("id" bigserial,"code_hash" varchar(64) NOT NULL,"client_id" bigint NOT NULL,"user_id" bigint NOT NULL,"redirect_uri" text NOT NULL,"scope" varchar(1000),"nonce" varchar(255),"code_challenge" varchar(128) NOT NULL,"code_challenge_method" varchar(8) NOT NULL,"auth_time" timestamptz,"access_token_id" varchar(64),"expires_at" timestamptz NOT NULL,"used_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_authorization_codes_client" FOREIGN KEY ("client_id") REFERENCES "o_auth_clients"("id") ON DELETE CASCADE,CONSTRAINT "fk_authorization_codes_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_authorization_codes_client_id" ON "authorization_codes" ("client_id");
+CREATE INDEX IF NOT EXISTS "idx_authorization_codes_expires_at" ON "authorization_codes" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_authorization_codes_user_id" ON "authorization_codes" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_authorization_codes_code_hash" ON "authorization_codes" ("code_hash");
+CREATE TABLE IF NOT EXISTS "o_auth_consents" ("id" bigserial,"user_id" bigint NOT NULL,"client_id" bigint NOT NULL,"scope" varchar(1000),"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_o_auth_consents_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE,CONSTRAINT "fk_o_auth_consents_client" FOREIGN KEY ("client_id") REFERENCES "o_auth_clients"("id") ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_oauth_consent" ON "o_auth_consents" ("user_id","client_id");
+CREATE TABLE IF NOT EXISTS "permissions" ("id" bigserial,"name" varchar(100) NOT NULL,"description" varchar(255),"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_permissions_name" ON "permissions" ("name");
+CREATE TABLE IF NOT EXISTS "role_permissions" ("role_id" bigint,"permission_id" bigint,PRIMARY KEY ("role_id","permission_id"),CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id") ON DELETE CASCADE,CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions"("id") ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS "login_attempts" ("id" bigserial,"attempt_key" varchar(320) NOT NULL,"failures" bigint NOT NULL,"last_failure_at" timestamptz NOT NULL,"locked_until" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_login_attempts_last_failure_at" ON "login_attempts" ("last_failure_at");
+CREATE INDEX IF NOT EXISTS "idx_login_attempts_locked_until" ON "login_attempts" ("locked_until");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_login_attempts_key" ON "login_attempts" ("attempt_key");
+CREATE TABLE IF NOT EXISTS "audit_events" ("id" bigserial,"created_at" timestamptz NOT NULL,"actor_type" varchar(16),"actor_id" varchar(64),"target_type" varchar(16),"target_id" varchar(64),"action" varchar(64) NOT NULL,"outcome" varchar(16) NOT NULL,"ip" varchar(64),"user_agent" varchar(512),"metadata" text,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_audit_events_action" ON "audit_events" ("action");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_actor" ON "audit_events" ("actor_type","actor_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_created_at" ON "audit_events" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_target" ON "audit_events" ("target_type","target_id");
@@ -0,0 +1,70 @@
+-- The schema the server created on startup before migrations were
+-- versioned. Existing tables are kept, so databases created back then
+-- adopt the migrations. The users table is created with the columns it had
+-- back then and the columns added since are added to it, existing or not.
+-- SQLite cannot add a column only if it is missing, but the whole migration
+-- runs in a transaction, so it can be run again if it fails.
+
+CREATE TABLE IF NOT EXISTS `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text,`username` text NOT NULL,`email` text NOT NULL,`password_hash` blob NOT NULL,`is_staff` numeric DEFAULT false,`is_superuser` numeric DEFAULT false,`is_active` numeric DEFAULT true,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_email` ON `users`(`email`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_username` ON `users`(`username`);
+ALTER TABLE `users` ADD COLUMN `email_verified` numeric DEFAULT false;
+ALTER TABLE `users` ADD COLUMN `verified_at` datetime DEFAULT null;
+ALTER TABLE `users` ADD COLUMN `password_reset_required` numeric DEFAULT false;
+CREATE TABLE IF NOT EXISTS `roles` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text NOT NULL,`description` text,`created_at` datetime,`updated_at` datetime);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_roles_name` ON `roles`(`name`);
+CREATE TABLE IF NOT EXISTS `user_roles` (`user_id` integer,`role_id` integer,PRIMARY KEY (`user_id`,`role_id`),CONSTRAINT `fk_user_roles_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_user_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `sessions` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`device` text,`user_agent` text,`ip` text,`last_seen_at` datetime,`expires_at` datetime NOT NULL,`revoked_at` datetime,`created_at` datetime,CONSTRAINT `fk_sessions_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_sessions_expires_at` ON `sessions`(`expires_at`);
+CREATE INDEX IF NOT EXISTS `idx_sessions_user_id` ON `sessions`(`user_id`);
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`family_id` text NOT NULL,`session_id` integer,`token_hash` text NOT NULL,`expires_at` datetime NOT NULL,`used_at` datetime,`revoked_at` datetime,`created_at` datetime,CONSTRAINT `fk_refresh_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_family_id` ON `refresh_tokens`(`family_id`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_session_id` ON `refresh_tokens`(`session_id`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_user_id` ON `refresh_tokens`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_refresh_tokens_token_hash` ON `refresh_tokens`(`token_hash`);
+CREATE TABLE IF NOT EXISTS `revoked_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`jti` text,`user_id` integer NOT NULL,`expires_at` datetime NOT NULL,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_revoked_tokens_expires_at` ON `revoked_tokens`(`expires_at`);
+CREATE INDEX IF NOT EXISTS `idx_revoked_tokens_jti` ON `revoked_tokens`(`jti`);
+CREATE INDEX IF NOT EXISTS `idx_revoked_tokens_user_id` ON `revoked_tokens`(`user_id`);
+CREATE TABLE IF NOT EXISTS `password_reset_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`token_hash` text NOT NULL,`expires_at` datetime NOT NULL,`used_at` datetime,`created_at` datetime,CONSTRAINT `fk_password_reset_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_password_reset_tokens_user_id` ON `password_reset_tokens`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_password_reset_tokens_token_hash` ON `password_reset_tokens`(`token_hash`);
+CREATE TABLE IF NOT EXISTS `email_verification_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`email` text NOT NULL,`token_hash` text NOT NULL,`expires_at` datetime NOT NULL,`used_at` datetime,`created_at` datetime,CONSTRAINT `fk_email_verification_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_email_verification_tokens_user_id` ON `email_verification_tokens`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_email_verification_tokens_token_hash` ON `email_verification_tokens`(`token_hash`);
+CREATE TABLE IF NOT EXISTS `totp_credentials` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`secret` blob NOT NULL,`confirmed_at` datetime,`last_used_step` integer,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_totp_credentials_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_totp_credentials_user_id` ON `totp_credentials`(`user_id`);
+CREATE TABLE IF NOT EXISTS `recovery_codes` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`code_hash` text NOT NULL,`used_at` datetime,`created_at` datetime,CONSTRAINT `fk_recovery_codes_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_recovery_codes_user_id` ON `recovery_codes`(`user_id`);
+CREATE TABLE IF NOT EXISTS `web_authn_credentials` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`credential_id` text NOT NULL,`public_key` blob NOT NULL,`sign_count` integer,`aa_guid` blob,`attestation_type` text,`name` text,`last_used_at` datetime,`created_at` datetime,CONSTRAINT `fk_web_authn_credentials_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_web_authn_credentials_user_id` ON `web_authn_credentials`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_web_authn_credentials_credential_id` ON `web_authn_credentials`(`credential_id`);
+CREATE TABLE IF NOT EXISTS `web_authn_challenges` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer,`ceremony` text NOT NULL,`challenge_hash` text NOT NULL,`expires_at` datetime NOT NULL,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_web_authn_challenges_expires_at` ON `web_authn_challenges`(`expires_at`);
+CREATE INDEX IF NOT EXISTS `idx_web_authn_challenges_user_id` ON `web_authn_challenges`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_web_authn_challenges_challenge_hash` ON `web_authn_challenges`(`challenge_hash`);
+CREATE TABLE IF NOT EXISTS `signing_keys` (`id` integer PRIMARY KEY AUTOINCREMENT,`kid` text NOT NULL,`algorithm` text NOT NULL,`private_key` blob NOT NULL,`rotates_at` datetime NOT NULL,`expires_at` datetime NOT NULL,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_signing_keys_expires_at` ON `signing_keys`(`expires_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_signing_keys_k_id` ON `signing_keys`(`kid`);
+CREATE TABLE IF NOT EXISTS `o_auth_clients` (`id` integer PRIMARY KEY AUTOINCREMENT,`client_id` text NOT NULL,`client_secret_hash` blob,`name` text NOT NULL,`redirect_uris` text,`scopes` text,`grant_types` text,`public` numeric DEFAULT false,`skip_consent` numeric DEFAULT false,`secret_rotated_at` datetime,`disabled_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_o_auth_clients_client_id` ON `o_auth_clients`(`client_id`);
+CREATE TABLE IF NOT EXISTS `authorization_codes` (`id` integer PRIMARY KEY AUTOINCREMENT,`code_hash` text NOT NULL,`client_id` integer NOT NULL,`user_id` integer NOT NULL,`redirect_uri` text NOT NULL,`scope` text,`nonce` text,`code_challenge` text NOT NULL,`code_challenge_method` text NOT NULL,`auth_time` datetime,`access_token_id` text,`expires_at` datetime NOT NULL,`used_at` datetime,`created_at` datetime,CONSTRAINT `fk_authorization_codes_client` FOREIGN KEY (`client_id`) REFERENCES `o_auth_clients`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_authorization_codes_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_authorization_codes_client_id` ON `authorization_codes`(`client_id`);
+CREATE INDEX IF NOT EXISTS `idx_authorization_codes_expires_at` ON `authorization_codes`(`expires_at`);
+CREATE INDEX IF NOT EXISTS `idx_authorization_codes_user_id` ON `authorization_codes`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_authorization_codes_code_hash` ON `authorization_codes`(`code_hash`);
+CREATE TABLE IF NOT EXISTS `o_auth_consents` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`client_id` integer NOT NULL,`scope` text,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_o_auth_consents_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_o_auth_consents_client` FOREIGN KEY (`client_id`) REFERENCES `o_auth_clients`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_oauth_consent` ON `o_auth_consents`(`user_id`,`client_id`);
+CREATE TABLE IF NOT EXISTS `permissions` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text NOT NULL,`description` text,`created_at` datetime);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_permissions_name` ON `permissions`(`name`);
+CREATE TABLE IF NOT EXISTS `role_permissions` (`role_id` integer,`permission_id` integer,PRIMARY KEY (`role_id`,`permission_id`),CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`) ON DELETE CASCADE);
+CREATE TABLE IF NOT EXISTS `login_attempts` (`id` integer PRIMARY KEY AUTOINCREMENT,`attempt_key` text NOT NULL,`failures` integer NOT NULL,`last_failure_at` datetime NOT NULL,`locked_until` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_login_attempts_last_failure_at` ON `login_attempts`(`last_failure_at`);
+CREATE INDEX IF NOT EXISTS `idx_login_attempts_locked_until` ON `login_attempts`(`locked_until`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_login_attempts_key` ON `login_attempts`(`attempt_key`);
+CREATE TABLE IF NOT EXISTS `audit_events` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime NOT NULL,`actor_type` text,`actor_id` text,`target_type` text,`target_id` text,`action` text NOT NULL,`outcome` text NOT NULL,`ip` text,`user_agent` text,`metadata` text);
+CREATE INDEX IF NOT EXISTS `idx_audit_events_action` ON `audit_events`(`action`);
+CREATE INDEX IF NOT EXISTS `idx_audit_events_actor` ON `audit_events`(`actor_type`,`actor_id`);
+CREATE INDEX IF NOT EXISTS `idx_audit_events_created_at` ON `audit_events`(`created_at`);
+CREATE INDEX IF NOT EXISTS `idx_audit_events_target` ON `audit_events`(`target_type`,`target_id`);
@@ -0,0 +1,4 @@
+-- The former password hashes of the users, checked by PASSWORD_HISTORY.
+
+CREATE TABLE IF NOT EXISTS `password_histories` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`password_hash` blob NOT NULL,`created_at` datetime,CONSTRAINT `fk_password_histories_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_password_histories_user_id` ON `password_histories`(`user_id`);
@@ -0,0 +1,270 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package oidc
+
+// client.go registers and authenticates OAuth clients.
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidClient is returned when a client is unknown or its
+	// credentials are wrong.
+	ErrInvalidClient = errors.New("invalid client credentials")
+	// ErrInvalidRegistration wraps the errors of an invalid client
+	// registration.
+	ErrInvalidRegistration = errors.New("invalid client registration")
+)
+
+// Grant types supported by the token endpoint.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// ClientRegistration describes a client to register.
+type ClientRegistration struct {
+	Name         string
+	RedirectURIs []string
+	// Scopes the client may request. DefaultScopes are used if it is empty,
+	// unless the client only uses the client_credentials grant.
+	Scopes []string
+	// GrantTypes the client may use. The authorization code grant is used
+	// if it is empty.
+	GrantTypes []string
+	// Public clients get no secret and must use PKCE.
+	Public bool
+	// SkipConsent is meant for first party clients.
+	SkipConsent bool
+}
+
+// RegisterClient creates a client and returns it along with its secret.
+// The secret is only stored hashed, so it cannot be retrieved later. Public
+// clients get an empty secret.
+func RegisterClient(clients repository.ClientRepository, registration ClientRegistration) (*models.OAuthClient, string, error) {
+	if registration.Name == "" {
+		return nil, "", fmt.Errorf("%w: client name cannot be empty", ErrInvalidRegistration)
+	}
+
+	grantTypes := registration.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = []string{GrantTypeAuthorizationCode}
+	}
+	for _, grantType := range grantTypes {
+		switch grantType {
+		case GrantTypeAuthorizationCode:
+			if len(registration.RedirectURIs) == 0 {
+				return nil, "", fmt.Errorf("%w: the authorization_code grant requires a redirect URI", ErrInvalidRegistration)
+			}
+		case GrantTypeClientCredentials:
+			if registration.Public {
+				return nil, "", fmt.Errorf("%w: public clients cannot use the client_credentials grant", ErrInvalidRegistration)
+			}
+		default:
+			return nil, "", fmt.Errorf("%w: unsupported grant type %q", ErrInvalidRegistration, grantType)
+		}
+	}
+
+	for _, uri := range registration.RedirectURIs {
+		if err := ValidateRedirectURI(uri); err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidRegistration, err)
+		}
+	}
+
+	scopes := registration.Scopes
+	if len(scopes) == 0 && contains(grantTypes, GrantTypeAuthorizationCode) {
+		scopes = DefaultScopes
+	}
+	for _, scope := range scopes {
+		if err := ValidateScope(scope); err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidRegistration, err)
+		}
+	}
+
+	clientID, err := utils.GenerateRandomToken(18)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := &models.OAuthClient{
+		ClientID:     clientID,
+		Name:         registration.Name,
+		RedirectURIs: strings.Join(registration.RedirectURIs, " "),
+		Scopes:       FormatScope(scopes),
+		GrantTypes:   strings.Join(grantTypes, " "),
+		Public:       registration.Public,
+		SkipConsent:  registration.SkipConsent,
+	}
+
+	var secret string
+	if !registration.Public {
+		if secret, client.ClientSecretHash, err = generateClientSecret(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := clients.Create(client); err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+// RotateClientSecret replaces the secret of a confidential client and
+// returns the new one. The old secret stops working immediately, tokens
+// already issued stay valid until they expire.
+func RotateClientSecret(clients repository.ClientRepository, client *models.OAuthClient) (string, error) {
+	if client.Public {
+		return "", errors.New("public clients have no secret")
+	}
+
+	secret, hash, err := generateClientSecret()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = clients.Update(client.ID, map[string]interface{}{"client_secret_hash": hash, "secret_rotated_at": now})
+	if err != nil {
+		return "", err
+	}
+	client.ClientSecretHash, client.SecretRotatedAt = hash, &now
+	return secret, nil
+}
+
+// generateClientSecret returns a random client secret and its bcrypt hash.
+func generateClientSecret() (string, []byte, error) {
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, err
+	}
+	return secret, hash, nil
+}
+
+// ValidateScope checks that scope is a valid scope token as defined in
+// RFC 6749, section 3.3.
+func ValidateScope(scope string) error {
+	if scope == "" {
+		return errors.New("scope cannot be empty")
+	}
+	for _, r := range scope {
+		if r < 0x21 || r == 0x22 || r == 0x5c || r > 0x7e {
+			return fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+	return nil
+}
+
+// ValidateRedirectURI checks that uri is an absolute URI without fragment,
+// as required for redirect URIs.
+func ValidateRedirectURI(uri string) error {
+	parsed, err := url.Parse(uri)
+	if err != nil || !parsed.IsAbs() || parsed.Host == "" || strings.ContainsAny(uri, " \t\r\n") {
+		return errors.New("redirect URI must be an absolute URI")
+	}
+	if parsed.Fragment != "" {
+		return errors.New("redirect URI cannot contain a fragment")
+	}
+	return nil
+}
+
+// FindClient returns the client with the given client ID. Disabled clients
+// are treated as unknown.
+func FindClient(clients repository.ClientRepository, clientID string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, ErrInvalidClient
+	}
+
+	client, err := clients.FindByClientID(clientID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if client.DisabledAt != nil {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// AuthenticateClient returns the client with the given credentials. Public
+// clients must not send a secret, confidential clients must send theirs.
+func AuthenticateClient(clients repository.ClientRepository, clientID, secret string) (*models.OAuthClient, error) {
+	client, err := FindClient(clients, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Public {
+		if secret != "" {
+			return nil, ErrInvalidClient
+		}
+		return client, nil
+	}
+	if secret == "" || bcrypt.CompareHashAndPassword(client.ClientSecretHash, []byte(secret)) != nil {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// AllowsRedirectURI reports whether uri is one of the redirect URIs of the
+// client. URIs are compared as strings, as required by OAuth 2.1.
+func AllowsRedirectURI(client *models.OAuthClient, uri string) bool {
+	for _, allowed := range strings.Fields(client.RedirectURIs) {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientScopes returns the scopes the client may request.
+func ClientScopes(client *models.OAuthClient) []string {
+	return ParseScope(client.Scopes)
+}
+
+// ClientGrantTypes returns the grant types the client may use.
+func ClientGrantTypes(client *models.OAuthClient) []string {
+	if client.GrantTypes == "" {
+		return []string{GrantTypeAuthorizationCode}
+	}
+	return strings.Fields(client.GrantTypes)
+}
+
+// AllowsGrantType reports whether the client may use the grant type.
+func AllowsGrantType(client *models.OAuthClient, grantType string) bool {
+	return contains(ClientGrantTypes(client), grantType)
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package oidc
+
+// oidc.go contains the protocol pieces of the OpenID Connect provider:
+// scopes, PKCE, errors, discovery and the claims derived from a user.
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+)
+
+// Scopes understood by the provider.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// DefaultScopes are the scopes a client may request if its registration
+// does not list any.
+var DefaultScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+// ParseScope splits a space separated scope parameter into its scopes.
+// Duplicates are removed and the order is kept.
+func ParseScope(scope string) []string {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !HasScope(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// FormatScope joins scopes into a scope parameter.
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// HasScope reports whether scope is one of scopes.
+func HasScope(scopes []string, scope string) bool {
+	return contains(scopes, scope)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsAll reports whether every scope of requested is in granted.
+func ContainsAll(granted, requested []string) bool {
+	for _, scope := range requested {
+		if !HasScope(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// CodeChallengeMethodS256 is the only PKCE method accepted. The plain
+// method would offer no protection if the authorization request leaks.
+const CodeChallengeMethodS256 = "S256"
+
+// codeVerifierPattern is the syntax of a code verifier from RFC 7636.
+var codeVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+
+// S256Challenge returns the S256 code challenge of a code verifier.
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyCodeChallenge reports whether verifier matches the code challenge
+// sent in the authorization request.
+func VerifyCodeChallenge(challenge, method, verifier string) bool {
+	if method != CodeChallengeMethodS256 || !codeVerifierPattern.MatchString(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(S256Challenge(verifier)), []byte(challenge)) == 1
+}
+
+// Error codes from RFC 6749 and OpenID Connect Core.
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorUnauthorizedClient      = "unauthorized_client"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorAccessDenied            = "access_denied"
+	ErrorServerError             = "server_error"
+	ErrorLoginRequired           = "login_required"
+	ErrorConsentRequired         = "consent_required"
+	ErrorInsufficientScope       = "insufficient_scope"
+)
+
+// Error is an OAuth error returned to a client.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// Errorf returns an OAuth error with a formatted description.
+func Errorf(code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Description: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// Configuration is the OpenID Provider Metadata served at
+// /.well-known/openid-configuration.
+type Configuration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	ResponseModesSupported            []string `json:"response_modes_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	PromptValuesSupported             []string `json:"prompt_values_supported"`
+	AuthorizationResponseIssParameter bool     `json:"authorization_response_iss_parameter_supported"`
+}
+
+// NewConfiguration returns the metadata of a provider with the given issuer
+// URL that signs ID tokens with alg.
+func NewConfiguration(issuer, alg string) *Configuration {
+	issuer = strings.TrimSuffix(issuer, "/")
+	return &Configuration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserInfoEndpoint:                  issuer + "/oauth/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   DefaultScopes,
+		ResponseTypesSupported:            []string{"code"},
+		ResponseModesSupported:            []string{"query"},
+		GrantTypesSupported:               []string{GrantTypeAuthorizationCode, GrantTypeClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{alg},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce",
+			"name", "preferred_username", "updated_at", "email", "email_verified",
+		},
+		CodeChallengeMethodsSupported:     []string{CodeChallengeMethodS256},
+		PromptValuesSupported:             []string{"none", "login", "consent"},
+		AuthorizationResponseIssParameter: true,
+	}
+}
+
+// Subject returns the subject identifier of a user. It is the user ID,
+// which unlike the email never changes.
+func Subject(user *models.User) string {
+	return strconv.FormatUint(uint64(user.ID), 10)
+}
+
+// UserClaims returns the standard claims about the user that are released
+// for the granted scopes.
+func UserClaims(user *models.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{"sub": Subject(user)}
+
+	if HasScope(scopes, ScopeProfile) {
+		claims["name"] = user.Name
+		claims["preferred_username"] = user.Username
+		claims["updated_at"] = user.UpdatedAt.Unix()
+	}
+	if HasScope(scopes, ScopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.EmailVerified
+	}
+	return claims
+}
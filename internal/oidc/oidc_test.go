@@ -0,0 +1,111 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package oidc_test
+
+import (
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/oidc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// Example from RFC 7636, appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	require.Equal(t, challenge, oidc.S256Challenge(verifier))
+
+	tests := []struct {
+		name     string
+		method   string
+		verifier string
+		want     bool
+	}{
+		{"valid verifier", "S256", verifier, true},
+		{"plain method", "plain", verifier, false},
+		{"wrong verifier", "S256", verifier[1:] + "a", false},
+		{"short verifier", "S256", "abc", false},
+		{"invalid characters", "S256", verifier[1:] + "!", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, oidc.VerifyCodeChallenge(challenge, test.method, test.verifier))
+		})
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	scopes := oidc.ParseScope("  openid email openid  profile ")
+	require.Equal(t, []string{"openid", "email", "profile"}, scopes)
+	require.Equal(t, "openid email profile", oidc.FormatScope(scopes))
+	require.True(t, oidc.ContainsAll(scopes, []string{"email", "openid"}))
+	require.False(t, oidc.ContainsAll(scopes, []string{"email", "admin"}))
+	require.Empty(t, oidc.ParseScope(""))
+}
+
+func TestUserClaims(t *testing.T) {
+	user := &models.User{ID: 42, Name: "Jane Doe", Username: "jane", Email: "jane@example.com", EmailVerified: true}
+
+	claims := oidc.UserClaims(user, []string{oidc.ScopeOpenID})
+	require.Equal(t, map[string]interface{}{"sub": "42"}, claims)
+
+	claims = oidc.UserClaims(user, []string{oidc.ScopeOpenID, oidc.ScopeEmail})
+	require.Equal(t, "jane@example.com", claims["email"])
+	require.Equal(t, true, claims["email_verified"])
+	require.Nil(t, claims["name"])
+
+	claims = oidc.UserClaims(user, oidc.DefaultScopes)
+	require.Equal(t, "jane", claims["preferred_username"])
+}
+
+func TestValidateRedirectURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		wantErr bool
+	}{
+		{"https://app.example.com/callback", false},
+		{"http://localhost:8080/cb?x=1", false},
+		{"/callback", true},
+		{"https://app.example.com/callback#frag", true},
+		{"https://app.example.com/call back", true},
+	}
+	for _, test := range tests {
+		t.Run(test.uri, func(t *testing.T) {
+			err := oidc.ValidateRedirectURI(test.uri)
+			if test.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateScope(t *testing.T) {
+	for _, scope := range []string{"openid", "orders:read", "https://api.example.com/write"} {
+		require.NoError(t, oidc.ValidateScope(scope), scope)
+	}
+	for _, scope := range []string{"", "a b", `a"b`, `a\b`, "café"} {
+		require.Error(t, oidc.ValidateScope(scope), scope)
+	}
+}
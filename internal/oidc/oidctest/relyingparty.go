@@ -0,0 +1,205 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package oidctest
+
+// relyingparty.go provides a minimal OpenID Connect relying party, so the
+// provider can be tested end to end the way a client application uses it.
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Maro1O9/goauth/internal/keys"
+	"github.com/Maro1O9/goauth/internal/oidc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RelyingParty is an OpenID Connect client using the authorization code
+// flow with PKCE.
+type RelyingParty struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	// Client sends the requests to the provider.
+	Client *http.Client
+
+	config *oidc.Configuration
+	jwks   *keys.JWKSet
+}
+
+// TokenResponse is the successful response of the token endpoint.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+	IDToken     string `json:"id_token"`
+}
+
+// NewCodeVerifier returns a random PKCE code verifier.
+func NewCodeVerifier() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Discover fetches the provider metadata and its key set.
+func (rp *RelyingParty) Discover() error {
+	var config oidc.Configuration
+	if err := rp.getJSON(rp.Issuer+"/.well-known/openid-configuration", "", &config); err != nil {
+		return err
+	}
+	if config.Issuer != rp.Issuer {
+		return fmt.Errorf("oidctest: issuer mismatch %q", config.Issuer)
+	}
+
+	var jwks keys.JWKSet
+	if err := rp.getJSON(config.JWKSURI, "", &jwks); err != nil {
+		return err
+	}
+
+	rp.config, rp.jwks = &config, &jwks
+	return nil
+}
+
+// AuthCodeURL returns the URL of the authorization request.
+func (rp *RelyingParty) AuthCodeURL(state, nonce, verifier string, scopes ...string) string {
+	values := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {rp.ClientID},
+		"redirect_uri":          {rp.RedirectURI},
+		"scope":                 {oidc.FormatScope(scopes)},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {oidc.S256Challenge(verifier)},
+		"code_challenge_method": {oidc.CodeChallengeMethodS256},
+	}
+	return rp.config.AuthorizationEndpoint + "?" + values.Encode()
+}
+
+// Exchange exchanges an authorization code for tokens, authenticating with
+// HTTP Basic authentication if the client has a secret.
+func (rp *RelyingParty) Exchange(code, verifier string) (*TokenResponse, error) {
+	values := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {rp.RedirectURI},
+		"code_verifier": {verifier},
+	}
+	if rp.ClientSecret == "" {
+		values.Set("client_id", rp.ClientID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, rp.config.TokenEndpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if rp.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(rp.ClientID), url.QueryEscape(rp.ClientSecret))
+	}
+
+	resp, err := rp.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr oidc.Error
+		_ = json.NewDecoder(resp.Body).Decode(&oauthErr)
+		return nil, &oauthErr
+	}
+
+	var tokens TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+// VerifyIDToken verifies the signature of an ID token with the key set of
+// the provider and checks its issuer, audience, expiry and nonce.
+func (rp *RelyingParty) VerifyIDToken(raw, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		jwk := rp.jwks.Key(kid)
+		if jwk == nil {
+			return nil, errors.New("oidctest: unknown kid")
+		}
+		return jwk.PublicKey()
+	},
+		jwt.WithValidMethods(rp.config.IDTokenSigningAlgValuesSupported),
+		jwt.WithIssuer(rp.Issuer),
+		jwt.WithAudience(rp.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims["nonce"] != nonce {
+		return nil, errors.New("oidctest: nonce mismatch")
+	}
+	return claims, nil
+}
+
+// UserInfo fetches the claims about the user from the userinfo endpoint.
+func (rp *RelyingParty) UserInfo(accessToken string) (map[string]interface{}, error) {
+	var claims map[string]interface{}
+	if err := rp.getJSON(rp.config.UserInfoEndpoint, accessToken, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (rp *RelyingParty) getJSON(url, accessToken string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := rp.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidctest: GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package password
+
+// argon2.go implements the argon2id hasher (RFC 9106).
+
+import (
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id hashes passwords with argon2id. Its hashes look like
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+type Argon2id struct {
+	// Memory is the memory used in KiB.
+	Memory uint32
+	// Time is the number of passes over the memory.
+	Time uint32
+	// Parallelism is the number of lanes.
+	Parallelism uint8
+}
+
+// Algorithm implements Hasher.
+func (h Argon2id) Algorithm() string {
+	return AlgorithmArgon2id
+}
+
+// Hash implements Hasher.
+func (h Argon2id) Hash(password string) (string, error) {
+	salt, err := randomSalt()
+	if err != nil {
+		return "", err
+	}
+
+	p := &phc{
+		id:      AlgorithmArgon2id,
+		version: strconv.Itoa(argon2.Version),
+		params:  h.params(),
+		salt:    salt,
+		hash:    argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Parallelism, keyLength),
+	}
+	return p.encode("m", "t", "p"), nil
+}
+
+func (h Argon2id) params() map[string]string {
+	return map[string]string{
+		"m": strconv.FormatUint(uint64(h.Memory), 10),
+		"t": strconv.FormatUint(uint64(h.Time), 10),
+		"p": strconv.FormatUint(uint64(h.Parallelism), 10),
+	}
+}
+
+// decode decodes an argon2id hash into its parameters.
+func (h Argon2id) decode(hash string) (*phc, Argon2id, error) {
+	p, err := decodePHC(AlgorithmArgon2id, hash)
+	if err != nil {
+		return nil, Argon2id{}, err
+	}
+	if p.version != strconv.Itoa(argon2.Version) {
+		return nil, Argon2id{}, ErrMalformedHash
+	}
+
+	memory, err := p.uintParam("m", 32)
+	if err != nil {
+		return nil, Argon2id{}, err
+	}
+	time, err := p.uintParam("t", 32)
+	if err != nil {
+		return nil, Argon2id{}, err
+	}
+	parallelism, err := p.uintParam("p", 8)
+	if err != nil {
+		return nil, Argon2id{}, err
+	}
+	if time == 0 || parallelism == 0 {
+		return nil, Argon2id{}, ErrMalformedHash
+	}
+	return p, Argon2id{Memory: uint32(memory), Time: uint32(time), Parallelism: uint8(parallelism)}, nil
+}
+
+// Verify implements Hasher.
+func (h Argon2id) Verify(password, hash string) (bool, error) {
+	p, params, err := h.decode(hash)
+	if err != nil {
+		return false, err
+	}
+	key := argon2.IDKey([]byte(password), p.salt, params.Time, params.Memory, params.Parallelism, uint32(len(p.hash)))
+	return equal(key, p.hash), nil
+}
+
+// Outdated implements Hasher.
+func (h Argon2id) Outdated(hash string) bool {
+	p, params, err := h.decode(hash)
+	return err != nil || params != h || len(p.hash) != keyLength
+}
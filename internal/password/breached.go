@@ -0,0 +1,176 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package password
+
+// breached.go implements the lists of breached passwords, in the formats of
+// the Pwned Passwords downloads.
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// prefixLength is the number of hex digits of the SHA-1 hash naming the
+// range of a hash, as in the k-anonymity API of Pwned Passwords.
+const prefixLength = 5
+
+// BreachedList tells whether passwords appeared in a data breach.
+type BreachedList interface {
+	// Contains reports whether the password is in the list.
+	Contains(password string) bool
+}
+
+// OpenBreachedList opens a list of breached passwords, identified by the
+// uppercase hex SHA-1 hash of the password, each optionally followed by a
+// colon and the number of times it was seen. path is either
+//
+//   - a file with one hash per line, loaded in memory, or
+//   - a directory of range files as written by the Pwned Passwords
+//     downloader, named after the first 5 hex digits of the hashes (with
+//     an optional .txt extension) and listing the remaining 35 digits, one
+//     per line. Only the range of a password is read when it is checked,
+//     so the directory can hold the whole Pwned Passwords list.
+func OpenBreachedList(path string) (BreachedList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return rangeDir(path), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBreachedList(f)
+}
+
+// ReadBreachedList reads a list of breached passwords with one hash per
+// line into memory. Empty lines and lines starting with # are skipped.
+func ReadBreachedList(r io.Reader) (BreachedList, error) {
+	var list hashList
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, err := parseHash(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		var sum [sha1.Size]byte
+		copy(sum[:], hash)
+		list = append(list, sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool { return bytes.Compare(list[i][:], list[j][:]) < 0 })
+	return list, nil
+}
+
+// parseHash decodes the hex hash at the start of a line of a list, before
+// the optional count.
+func parseHash(line string) ([]byte, error) {
+	hash, _, _ := strings.Cut(line, ":")
+	if len(hash) != sha1.Size*2 {
+		return nil, fmt.Errorf("invalid hash %q", hash)
+	}
+	decoded, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash %q", hash)
+	}
+	return decoded, nil
+}
+
+// hashList is a list of breached password hashes loaded in memory, sorted
+// for lookups.
+type hashList [][sha1.Size]byte
+
+// Contains implements BreachedList.
+func (l hashList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	i := sort.Search(len(l), func(i int) bool { return bytes.Compare(l[i][:], sum[:]) >= 0 })
+	return i < len(l) && l[i] == sum
+}
+
+// rangeDir is a directory of range files.
+type rangeDir string
+
+// Contains implements BreachedList. If the range file cannot be read, the
+// error is logged and the password is considered not breached, so a broken
+// list does not prevent users from changing their password.
+func (d rangeDir) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	digits := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digits[:prefixLength], digits[prefixLength:]
+
+	found, err := d.rangeContains(prefix, suffix)
+	if err != nil {
+		log.Printf("breached passwords: range %s: %v", prefix, err)
+	}
+	return found
+}
+
+// rangeContains looks for the hash suffix in the file of the range prefix.
+// A missing file is an empty range.
+func (d rangeDir) rangeContains(prefix, suffix string) (bool, error) {
+	var f *os.File
+	var err error
+	for _, name := range []string{prefix + ".txt", prefix} {
+		f, err = os.Open(filepath.Join(string(d), name))
+		if !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(hash, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
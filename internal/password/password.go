@@ -0,0 +1,217 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package password
+
+// password.go defines the password hashers and the manager picking the
+// hasher of a stored hash. Hashes are encoded in the PHC string format:
+//
+//	$<id>[$v=<version>]$<param>=<value>(,<param>=<value>)*$<salt>$<hash>
+//
+// with the salt and hash in unpadded standard base64. bcrypt hashes keep
+// their own $2a$ format, which is what every existing bcrypt hash uses.
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Identifiers of the supported algorithms.
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmScrypt   = "scrypt"
+)
+
+// Lengths of the generated salts and derived keys in bytes.
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+// ErrMalformedHash is returned for hashes that cannot be decoded.
+var ErrMalformedHash = errors.New("malformed password hash")
+
+// Hasher hashes passwords with an algorithm and its parameters.
+type Hasher interface {
+	// Algorithm returns the identifier of the algorithm.
+	Algorithm() string
+	// Hash returns the encoded hash of the password with a random salt.
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches an encoded hash of the
+	// algorithm, whatever its parameters.
+	Verify(password, hash string) (bool, error)
+	// Outdated reports whether an encoded hash of the algorithm was made
+	// with other parameters than the ones of the hasher.
+	Outdated(hash string) bool
+}
+
+// Manager hashes new passwords with its current hasher and verifies the
+// hashes of every hasher it knows, so the algorithm or its parameters can
+// change while the stored hashes keep working.
+type Manager struct {
+	current Hasher
+	hashers map[string]Hasher
+}
+
+// NewManager creates a manager hashing with current and verifying the
+// hashes of current and others.
+func NewManager(current Hasher, others ...Hasher) *Manager {
+	m := &Manager{current: current, hashers: map[string]Hasher{}}
+	for _, hasher := range others {
+		m.hashers[hasher.Algorithm()] = hasher
+	}
+	m.hashers[current.Algorithm()] = current
+	return m
+}
+
+// Hash returns the encoded hash of the password made by the current hasher.
+func (m *Manager) Hash(password string) ([]byte, error) {
+	hash, err := m.current.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(hash), nil
+}
+
+// Verify reports whether the password matches the encoded hash. If it
+// does, rehash reports whether the hash was made with another algorithm or
+// other parameters than the current ones, and should be replaced with a new
+// hash of the password. Hashes of unknown algorithms never match.
+func (m *Manager) Verify(password string, hash []byte) (ok, rehash bool) {
+	encoded := string(hash)
+	hasher, found := m.hashers[algorithm(encoded)]
+	if !found {
+		return false, false
+	}
+
+	if ok, err := hasher.Verify(password, encoded); err != nil || !ok {
+		return false, false
+	}
+	return true, hasher != m.current || hasher.Outdated(encoded)
+}
+
+// algorithm returns the identifier of the algorithm of an encoded hash.
+func algorithm(hash string) string {
+	if !strings.HasPrefix(hash, "$") {
+		return ""
+	}
+	id, _, _ := strings.Cut(hash[1:], "$")
+	switch id {
+	case "2a", "2b", "2y":
+		return AlgorithmBcrypt
+	}
+	return id
+}
+
+// randomSalt returns a new random salt.
+func randomSalt() ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// phc is a decoded PHC string.
+type phc struct {
+	id      string
+	version string
+	params  map[string]string
+	salt    []byte
+	hash    []byte
+}
+
+// encode returns the PHC string. The parameters are written in the given
+// order.
+func (p *phc) encode(order ...string) string {
+	var b strings.Builder
+	b.WriteString("$" + p.id)
+	if p.version != "" {
+		b.WriteString("$v=" + p.version)
+	}
+	for i, name := range order {
+		if i == 0 {
+			b.WriteString("$")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(name + "=" + p.params[name])
+	}
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(p.salt))
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(p.hash))
+	return b.String()
+}
+
+// decodePHC decodes a PHC string of the algorithm id.
+func decodePHC(id, encoded string) (*phc, error) {
+	fields := strings.Split(encoded, "$")
+	// The string starts with a $, so the first field is empty
+	if len(fields) < 5 || fields[0] != "" || fields[1] != id {
+		return nil, ErrMalformedHash
+	}
+
+	p := &phc{id: id, params: map[string]string{}}
+	fields = fields[2:]
+	if strings.HasPrefix(fields[0], "v=") {
+		p.version = strings.TrimPrefix(fields[0], "v=")
+		fields = fields[1:]
+	}
+	if len(fields) != 3 {
+		return nil, ErrMalformedHash
+	}
+
+	for _, param := range strings.Split(fields[0], ",") {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, ErrMalformedHash
+		}
+		p.params[name] = value
+	}
+
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(fields[1]); err != nil {
+		return nil, ErrMalformedHash
+	}
+	if p.hash, err = base64.RawStdEncoding.DecodeString(fields[2]); err != nil || len(p.hash) == 0 {
+		return nil, ErrMalformedHash
+	}
+	return p, nil
+}
+
+// uintParam returns the parameter as a number of at most bits bits.
+func (p *phc) uintParam(name string, bits int) (uint64, error) {
+	value, err := strconv.ParseUint(p.params[name], 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid %s", ErrMalformedHash, name)
+	}
+	return value, nil
+}
+
+// equal compares two derived keys in constant time.
+func equal(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/password"
+	"github.com/stretchr/testify/require"
+)
+
+// Cheap parameters keep the tests fast.
+var (
+	argon2id = password.Argon2id{Memory: 1024, Time: 1, Parallelism: 1}
+	bcrypt   = password.Bcrypt{Cost: 4}
+	scrypt   = password.Scrypt{LogN: 10, BlockSize: 8, Parallelism: 1}
+)
+
+func TestHashers(t *testing.T) {
+	tests := []struct {
+		hasher password.Hasher
+		prefix string
+		other  password.Hasher
+	}{
+		{argon2id, "$argon2id$v=19$m=1024,t=1,p=1$", password.Argon2id{Memory: 2048, Time: 1, Parallelism: 1}},
+		{bcrypt, "$2a$04$", password.Bcrypt{Cost: 5}},
+		{scrypt, "$scrypt$ln=10,r=8,p=1$", password.Scrypt{LogN: 11, BlockSize: 8, Parallelism: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.hasher.Algorithm(), func(t *testing.T) {
+			hash, err := test.hasher.Hash("Password123")
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(hash, test.prefix), hash)
+
+			again, err := test.hasher.Hash("Password123")
+			require.NoError(t, err)
+			require.NotEqual(t, hash, again, "salted")
+
+			ok, err := test.hasher.Verify("Password123", hash)
+			require.NoError(t, err)
+			require.True(t, ok)
+			ok, err = test.hasher.Verify("Password124", hash)
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			// Hashes verify whatever the parameters of the hasher
+			ok, err = test.other.Verify("Password123", hash)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.False(t, test.hasher.Outdated(hash))
+			require.True(t, test.other.Outdated(hash))
+
+			_, err = test.hasher.Verify("Password123", hash[:len(hash)/2]+"$")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLongPasswords(t *testing.T) {
+	long := strings.Repeat("a", 100)
+
+	// Unlike bcrypt, argon2id and scrypt use the whole password
+	for _, hasher := range []password.Hasher{argon2id, scrypt} {
+		hash, err := hasher.Hash(long)
+		require.NoError(t, err)
+		ok, err := hasher.Verify(long[:72], hash)
+		require.NoError(t, err)
+		require.False(t, ok, hasher.Algorithm())
+	}
+
+	_, err := bcrypt.Hash(long)
+	require.Error(t, err)
+}
+
+func TestManager(t *testing.T) {
+	manager := password.NewManager(argon2id, bcrypt, scrypt)
+
+	hash, err := manager.Hash("Password123")
+	require.NoError(t, err)
+	ok, rehash := manager.Verify("Password123", hash)
+	require.True(t, ok)
+	require.False(t, rehash)
+	ok, _ = manager.Verify("Password124", hash)
+	require.False(t, ok)
+
+	// Hashes of the other algorithms are verified and should be upgraded
+	for _, hasher := range []password.Hasher{bcrypt, scrypt, password.Argon2id{Memory: 2048, Time: 1, Parallelism: 1}} {
+		old, err := hasher.Hash("Password123")
+		require.NoError(t, err)
+		ok, rehash := manager.Verify("Password123", []byte(old))
+		require.True(t, ok, old)
+		require.True(t, rehash, old)
+		ok, rehash = manager.Verify("Password124", []byte(old))
+		require.False(t, ok, old)
+		require.False(t, rehash, old)
+	}
+
+	// Unknown and malformed hashes never match
+	for _, hash := range []string{"", "Password123", "$md5$abc", "$argon2id$v=19$m=1024$c2FsdA$aGFzaA", "$scrypt$ln=x,r=8,p=1$c2FsdA$aGFzaA"} {
+		ok, _ := manager.Verify("Password123", []byte(hash))
+		require.False(t, ok, hash)
+	}
+
+	// The hashes of an algorithm the manager does not know are rejected
+	ok, _ = password.NewManager(argon2id).Verify("Password123", []byte(mustHash(t, bcrypt, "Password123")))
+	require.False(t, ok)
+}
+
+func mustHash(t *testing.T, hasher password.Hasher, value string) string {
+	t.Helper()
+
+	hash, err := hasher.Hash(value)
+	require.NoError(t, err)
+	return hash
+}
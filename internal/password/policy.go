@@ -0,0 +1,197 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package password
+
+// policy.go implements the policy new passwords are checked against.
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// Identifiers of the rules of a policy, reported by the violations.
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleMaxBytes  = "max_bytes"
+	RuleControl   = "control_characters"
+	RuleUppercase = "uppercase"
+	RuleLowercase = "lowercase"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleStrength  = "strength"
+	RulePersonal  = "personal_information"
+	RuleBreached  = "breached"
+	RuleReused    = "reused"
+)
+
+// minPersonalLength is the length personal information needs for a password
+// containing it to be rejected, so short names do not forbid common words.
+const minPersonalLength = 3
+
+// Violation is a rule of the policy a password breaks. The messages of the
+// rules are in the catalogs of the validation package.
+type Violation struct {
+	// Rule is the identifier of the rule.
+	Rule string
+	// Params are the settings of the rule the message mentions, such as
+	// the min length.
+	Params map[string]interface{}
+}
+
+// Policy is the set of rules new passwords follow. Lengths are counted in
+// characters rather than bytes, and any character is allowed except control
+// characters, so passphrases with spaces and non ASCII letters are fine.
+type Policy struct {
+	// MinLength is the minimal length of a password.
+	MinLength int
+	// MaxLength is the maximal length of a password, 0 for no limit.
+	MaxLength int
+	// MaxBytes is the maximal length of a password in bytes, 0 for no
+	// limit. It is set for the hashers that refuse longer passwords.
+	MaxBytes int
+	// RequireUppercase, RequireLowercase, RequireDigit and RequireSymbol
+	// require a character of the class. Symbols are the characters that
+	// are neither letters nor digits, spaces included.
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// MinStrength is the minimal strength of a password, estimated by
+	// zxcvbn from 0 (too guessable) to 4 (very unguessable). 0 disables
+	// the estimation.
+	MinStrength int
+	// RejectPersonal rejects the passwords containing the personal
+	// information of the user, such as the username or the email address.
+	RejectPersonal bool
+	// Breached rejects the passwords it contains if it is not nil.
+	Breached BreachedList
+}
+
+// Check returns the rules of the policy the password breaks, nil if it
+// follows the policy. personal is the personal information of the user,
+// such as the username, name and email address.
+func (p *Policy) Check(password string, personal ...string) []Violation {
+	var violations []Violation
+	add := func(rule string) {
+		violations = append(violations, Violation{Rule: rule})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{Rule: RuleMinLength, Params: map[string]interface{}{"min": p.MinLength}})
+	}
+	tooLong := p.MaxLength > 0 && length > p.MaxLength
+	if tooLong {
+		violations = append(violations, Violation{Rule: RuleMaxLength, Params: map[string]interface{}{"max": p.MaxLength}})
+	} else if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		// Only passwords with multibyte characters get there
+		tooLong = true
+		violations = append(violations, Violation{Rule: RuleMaxBytes, Params: map[string]interface{}{"max": p.MaxBytes}})
+	}
+
+	var control, upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsControl(r) || r == utf8.RuneError:
+			control = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsLetter(r):
+			// Letters without case, such as the ones of most Asian
+			// scripts, count for neither class
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	if control {
+		add(RuleControl)
+	}
+	if p.RequireUppercase && !upper {
+		add(RuleUppercase)
+	}
+	if p.RequireLowercase && !lower {
+		add(RuleLowercase)
+	}
+	if p.RequireDigit && !digit {
+		add(RuleDigit)
+	}
+	if p.RequireSymbol && !symbol {
+		add(RuleSymbol)
+	}
+
+	inputs := personalInputs(personal)
+	if p.RejectPersonal && containsAny(password, inputs) {
+		add(RulePersonal)
+	}
+
+	// The estimation takes time growing with the length, so passwords
+	// already too long are not estimated
+	if p.MinStrength > 0 && !tooLong && password != "" {
+		if score := zxcvbn.PasswordStrength(password, inputs).Score; score < p.MinStrength {
+			add(RuleStrength)
+		}
+	}
+
+	if p.Breached != nil && password != "" && p.Breached.Contains(password) {
+		add(RuleBreached)
+	}
+	return violations
+}
+
+// personalInputs returns the lowercased personal information long enough
+// to be looked for in passwords. Email addresses also give their local part.
+func personalInputs(personal []string) []string {
+	var inputs []string
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		values := []string{value}
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			values = append(values, local)
+		}
+		for _, value := range values {
+			if utf8.RuneCountInString(value) >= minPersonalLength {
+				inputs = append(inputs, value)
+			}
+		}
+	}
+	return inputs
+}
+
+// containsAny reports whether the password contains one of the lowercased
+// inputs, ignoring case.
+func containsAny(password string, inputs []string) bool {
+	password = strings.ToLower(password)
+	for _, input := range inputs {
+		if strings.Contains(password, input) {
+			return true
+		}
+	}
+	return false
+}
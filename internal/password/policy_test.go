@@ -0,0 +1,123 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package password_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/password"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	breached, err := password.ReadBreachedList(strings.NewReader(sha1Hex("Tr0ub4dor&3") + ":42\n"))
+	require.NoError(t, err)
+
+	strict := &password.Policy{
+		MinLength:        8,
+		MaxLength:        64,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		MinStrength:      3,
+		RejectPersonal:   true,
+		Breached:         breached,
+	}
+	lenient := &password.Policy{MinLength: 8, MaxLength: 64, MinStrength: 3, RejectPersonal: true}
+
+	tests := []struct {
+		name     string
+		policy   *password.Policy
+		password string
+		want     []string
+	}{
+		{"strong", strict, "Xk9#mQ2vLp", nil},
+		{"passphrase", lenient, "tulip ocean marble 9", nil},
+		{"unicode passphrase", lenient, "żółta łódź pływa po jeziorze", nil},
+		{"common", lenient, "Password1", []string{password.RuleStrength}},
+		{"every class missing", strict, "tulip ocean marble", []string{password.RuleUppercase, password.RuleDigit}},
+		{"short", strict, "Ab1!", []string{password.RuleMinLength, password.RuleStrength}},
+		{"long", lenient, strings.Repeat("ab", 33), []string{password.RuleMaxLength}},
+		{"characters not bytes", &password.Policy{MaxLength: 28}, "żółta łódź pływa po jeziorze", nil},
+		{"bytes", &password.Policy{MaxLength: 28, MaxBytes: 28}, "żółta łódź pływa po jeziorze", []string{password.RuleMaxBytes}},
+		{"control characters", lenient, "tulip ocean\x00marble 9", []string{password.RuleControl}},
+		{"username", lenient, "tulip ALICE marble 9", []string{password.RulePersonal}},
+		{"email local part", lenient, "tulip ocean asmith 9", []string{password.RulePersonal}},
+		{"breached", strict, "Tr0ub4dor&3", []string{password.RuleBreached}},
+		{"empty", lenient, "", []string{password.RuleMinLength}},
+		{"zero policy", &password.Policy{}, "a", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var rules []string
+			for _, violation := range test.policy.Check(test.password, "alice", "Alice Smith", "asmith@example.com") {
+				rules = append(rules, violation.Rule)
+			}
+			require.Equal(t, test.want, rules)
+		})
+	}
+}
+
+func TestBreachedList(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "breached.txt")
+	content := "# most common passwords\n" + sha1Hex("password") + ":9545824\n\n" + sha1Hex("123456") + "\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	// A directory of range files, with and without extension
+	ranges := filepath.Join(dir, "ranges")
+	require.NoError(t, os.Mkdir(ranges, 0o700))
+	for i, value := range []string{"password", "123456"} {
+		hash := sha1Hex(value)
+		name := hash[:5]
+		if i == 0 {
+			name += ".txt"
+		}
+		content := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + hash[5:] + ":3\r\n"
+		require.NoError(t, os.WriteFile(filepath.Join(ranges, name), []byte(content), 0o600))
+	}
+
+	for _, path := range []string{file, ranges} {
+		list, err := password.OpenBreachedList(path)
+		require.NoError(t, err)
+		require.True(t, list.Contains("password"), path)
+		require.True(t, list.Contains("123456"), path)
+		require.False(t, list.Contains("Password"), path)
+		require.False(t, list.Contains("tulip ocean marble 9"), path)
+	}
+
+	_, err := password.OpenBreachedList(filepath.Join(dir, "missing.txt"))
+	require.Error(t, err)
+	_, err = password.ReadBreachedList(strings.NewReader("not a hash\n"))
+	require.ErrorContains(t, err, "line 1")
+}
+
+func sha1Hex(value string) string {
+	sum := sha1.Sum([]byte(value))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package password
+
+// scrypt.go implements the scrypt hasher (RFC 7914).
+
+import (
+	"strconv"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Scrypt hashes passwords with scrypt. Its hashes look like
+//
+//	$scrypt$ln=15,r=8,p=1$<salt>$<hash>
+type Scrypt struct {
+	// LogN is the binary logarithm of the CPU and memory cost N.
+	LogN uint8
+	// BlockSize is the block size r.
+	BlockSize int
+	// Parallelism is the parallelization p.
+	Parallelism int
+}
+
+// Algorithm implements Hasher.
+func (h Scrypt) Algorithm() string {
+	return AlgorithmScrypt
+}
+
+// Hash implements Hasher.
+func (h Scrypt) Hash(password string) (string, error) {
+	salt, err := randomSalt()
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(password), salt, 1<<h.LogN, h.BlockSize, h.Parallelism, keyLength)
+	if err != nil {
+		return "", err
+	}
+
+	p := &phc{
+		id: AlgorithmScrypt,
+		params: map[string]string{
+			"ln": strconv.Itoa(int(h.LogN)),
+			"r":  strconv.Itoa(h.BlockSize),
+			"p":  strconv.Itoa(h.Parallelism),
+		},
+		salt: salt,
+		hash: key,
+	}
+	return p.encode("ln", "r", "p"), nil
+}
+
+// decode decodes a scrypt hash into its parameters.
+func (h Scrypt) decode(hash string) (*phc, Scrypt, error) {
+	p, err := decodePHC(AlgorithmScrypt, hash)
+	if err != nil {
+		return nil, Scrypt{}, err
+	}
+
+	logN, err := p.uintParam("ln", 8)
+	if err != nil {
+		return nil, Scrypt{}, err
+	}
+	blockSize, err := p.uintParam("r", 30)
+	if err != nil {
+		return nil, Scrypt{}, err
+	}
+	parallelism, err := p.uintParam("p", 30)
+	if err != nil {
+		return nil, Scrypt{}, err
+	}
+	if logN == 0 || logN > 62 {
+		return nil, Scrypt{}, ErrMalformedHash
+	}
+	return p, Scrypt{LogN: uint8(logN), BlockSize: int(blockSize), Parallelism: int(parallelism)}, nil
+}
+
+// Verify implements Hasher.
+func (h Scrypt) Verify(password, hash string) (bool, error) {
+	p, params, err := h.decode(hash)
+	if err != nil {
+		return false, err
+	}
+	key, err := scrypt.Key([]byte(password), p.salt, 1<<params.LogN, params.BlockSize, params.Parallelism, len(p.hash))
+	if err != nil {
+		return false, err
+	}
+	return equal(key, p.hash), nil
+}
+
+// Outdated implements Hasher.
+func (h Scrypt) Outdated(hash string) bool {
+	p, params, err := h.decode(hash)
+	return err != nil || params != h || len(p.hash) != keyLength
+}
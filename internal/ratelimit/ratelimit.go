@@ -0,0 +1,169 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package ratelimit
+
+// ratelimit.go limits how many requests a key may make per period with a
+// sliding window.
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests requests per Period. The zero Limit is no limit.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses a limit written as requests/period, for example 60/1m.
+// An empty value or off is no limit.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "off" {
+		return Limit{}, nil
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: must be requests/period", value)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive number", value)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d < time.Second {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a duration of at least 1s", value)
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// Result is the outcome of a request.
+type Result struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+	// Limit is the number of requests allowed per period.
+	Limit int
+	// Remaining is how many more requests are allowed right now.
+	Remaining int
+	// Reset is how long until the current window ends, or until the request
+	// can be retried if it was rejected.
+	Reset time.Duration
+	// RetryAfter is how long to wait before retrying a rejected request.
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests in a Store.
+//
+// Each key has a counter per fixed window of the limit period. The number of
+// requests in the sliding window ending now is estimated by weighting the
+// previous window's counter by how much of it the sliding window still
+// covers. Rejected requests are not counted.
+type Limiter struct {
+	store Store
+}
+
+// NewLimiter creates a limiter keeping its counters in store.
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Allow counts a request for the key at now and reports whether it is
+// within the limit. Requests are always allowed if the limit is not
+// enabled.
+func (l *Limiter) Allow(key string, limit Limit, now time.Time) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	window := now.UnixNano() / int64(limit.Period)
+	elapsed := time.Duration(now.UnixNano() - window*int64(limit.Period))
+	currentKey := fmt.Sprintf("%s:%d", key, window)
+
+	// Counters are kept for the next window, which still looks at them
+	current, err := l.store.Increment(currentKey, 1, 2*limit.Period)
+	if err != nil {
+		return Result{}, err
+	}
+	previous, err := l.store.Get(fmt.Sprintf("%s:%d", key, window-1))
+	if err != nil {
+		return Result{}, err
+	}
+
+	weight := 1 - float64(elapsed)/float64(limit.Period)
+	count := float64(previous)*weight + float64(current)
+
+	result := Result{
+		Allowed:   count <= float64(limit.Requests),
+		Limit:     limit.Requests,
+		Remaining: int(math.Max(0, math.Floor(float64(limit.Requests)-count))),
+		Reset:     limit.Period - elapsed,
+	}
+	if result.Allowed {
+		return result, nil
+	}
+
+	if _, err := l.store.Increment(currentKey, -1, 2*limit.Period); err != nil {
+		return Result{}, err
+	}
+	result.RetryAfter = retryAfter(limit, elapsed, previous, current-1)
+	result.Reset = result.RetryAfter
+	return result, nil
+}
+
+// retryAfter returns how long until one more request fits in the limit,
+// given the counters of the previous and current window.
+func retryAfter(limit Limit, elapsed time.Duration, previous, current int64) time.Duration {
+	period := float64(limit.Period)
+	allowed := float64(limit.Requests) - 1
+
+	// Within the current window, the weight of the previous window has to
+	// drop enough
+	if float64(current) <= allowed && previous > 0 {
+		at := period * (1 - (allowed-float64(current))/float64(previous))
+		if wait := time.Duration(at) - elapsed; wait > 0 {
+			return wait
+		}
+		return time.Second
+	}
+
+	// Otherwise the current window has to become the previous one and lose
+	// enough weight
+	at := period * (1 - allowed/float64(current))
+	return limit.Period - elapsed + time.Duration(math.Max(0, at))
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/ratelimit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ratelimit.Limit
+		wantErr bool
+	}{
+		{"", ratelimit.Limit{}, false},
+		{"off", ratelimit.Limit{}, false},
+		{"60/1m", ratelimit.Limit{Requests: 60, Period: time.Minute}, false},
+		{" 5/10s ", ratelimit.Limit{Requests: 5, Period: 10 * time.Second}, false},
+		{"60", ratelimit.Limit{}, true},
+		{"0/1m", ratelimit.Limit{}, true},
+		{"x/1m", ratelimit.Limit{}, true},
+		{"60/minute", ratelimit.Limit{}, true},
+		{"60/10ms", ratelimit.Limit{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			limit, err := ratelimit.ParseLimit(test.value)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, limit)
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	stores := map[string]func(t *testing.T) ratelimit.Store{
+		"memory": func(t *testing.T) ratelimit.Store {
+			return ratelimit.NewMemoryStore()
+		},
+		"redis": func(t *testing.T) ratelimit.Store {
+			store, err := ratelimit.NewRedisStore(newFakeRedis(t, "").url(""))
+			require.NoError(t, err)
+			t.Cleanup(func() { store.Close() })
+			return store
+		},
+	}
+
+	limit := ratelimit.Limit{Requests: 4, Period: time.Minute}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			limiter := ratelimit.NewLimiter(newStore(t))
+			start := time.Now().Truncate(time.Minute)
+
+			// Without a limit everything is allowed
+			result, err := limiter.Allow("off", ratelimit.Limit{}, start)
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+
+			for i := 0; i < 4; i++ {
+				result, err = limiter.Allow("a", limit, start.Add(30*time.Second))
+				require.NoError(t, err)
+				require.True(t, result.Allowed)
+				require.Equal(t, 4, result.Limit)
+				require.Equal(t, 3-i, result.Remaining)
+				require.Equal(t, 30*time.Second, result.Reset)
+			}
+
+			// Rejected requests are not counted
+			for i := 0; i < 3; i++ {
+				result, err = limiter.Allow("a", limit, start.Add(30*time.Second))
+				require.NoError(t, err)
+				require.False(t, result.Allowed)
+				require.Zero(t, result.Remaining)
+				require.Equal(t, 45*time.Second, result.RetryAfter)
+			}
+
+			// Other keys have their own quota
+			result, err = limiter.Allow("b", limit, start.Add(30*time.Second))
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+
+			// Halfway through the next window, half of the previous one
+			// still counts
+			result, err = limiter.Allow("a", limit, start.Add(90*time.Second))
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+			require.Equal(t, 1, result.Remaining)
+			result, err = limiter.Allow("a", limit, start.Add(90*time.Second))
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+			result, err = limiter.Allow("a", limit, start.Add(90*time.Second))
+			require.NoError(t, err)
+			require.False(t, result.Allowed)
+			require.Equal(t, 15*time.Second, result.RetryAfter)
+
+			result, err = limiter.Allow("a", limit, start.Add(105*time.Second))
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+		})
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package ratelimit
+
+// redis.go keeps the request counters in Redis, or any server speaking the
+// Redis protocol, so they are shared by every instance.
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of a RedisStore.
+const (
+	redisDefaultPort  = "6379"
+	redisPoolSize     = 16
+	redisDialTimeout  = 5 * time.Second
+	redisReplyTimeout = 2 * time.Second
+)
+
+// RedisError is an error reply of the server.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// RedisStore keeps the counters in a Redis server. It speaks the protocol
+// itself and keeps a small pool of connections.
+type RedisStore struct {
+	addr     string
+	host     string
+	tls      bool
+	username string
+	password string
+	db       int
+
+	pool chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// NewRedisStore creates a store for the server at rawURL, written as
+// redis://[[username]:password@]host[:port][/db]. The rediss scheme connects
+// with TLS. No connection is made until the store is used.
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid redis URL: unsupported scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("invalid redis URL: missing host")
+	}
+
+	s := &RedisStore{
+		addr: u.Host,
+		host: u.Hostname(),
+		tls:  u.Scheme == "rediss",
+		pool: make(chan *redisConn, redisPoolSize),
+	}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), redisDefaultPort)
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if s.db, err = strconv.Atoi(db); err != nil || s.db < 0 {
+			return nil, fmt.Errorf("invalid redis URL: invalid database %q", db)
+		}
+	}
+	return s, nil
+}
+
+// Increment adds delta to the counter of key. The increment and the expiry
+// are sent together, so it takes a single round trip.
+func (s *RedisStore) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	replies, err := s.do(
+		[]string{"INCRBY", key, strconv.FormatInt(delta, 10)},
+		[]string{"PEXPIRE", key, strconv.FormatInt(ttl.Milliseconds(), 10)},
+	)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := replies[0].(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply %v to INCRBY", replies[0])
+	}
+	return value, nil
+}
+
+// Get returns the counter of key.
+func (s *RedisStore) Get(key string) (int64, error) {
+	replies, err := s.do([]string{"GET", key})
+	if err != nil {
+		return 0, err
+	}
+	switch reply := replies[0].(type) {
+	case nil:
+		return 0, nil
+	case string:
+		return strconv.ParseInt(reply, 10, 64)
+	default:
+		return 0, fmt.Errorf("redis: unexpected reply %v to GET", reply)
+	}
+}
+
+// Close closes the pooled connections.
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-s.pool:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends the commands in a pipeline and returns their replies. An error
+// reply to any of them is returned as a RedisError.
+func (s *RedisStore) do(commands ...[]string) ([]interface{}, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := conn.pipeline(commands...)
+	var redisErr RedisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// The connection may be out of sync with the server
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case s.pool <- conn:
+	default:
+		conn.Close()
+	}
+	return replies, err
+}
+
+// conn returns a pooled connection or dials a new one.
+func (s *RedisStore) conn() (*redisConn, error) {
+	select {
+	case conn := <-s.pool:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: redisDialTimeout}
+	var nc net.Conn
+	var err error
+	if s.tls {
+		nc, err = tls.DialWithDialer(dialer, "tcp", s.addr, &tls.Config{ServerName: s.host})
+	} else {
+		nc, err = dialer.Dial("tcp", s.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+
+	var setup [][]string
+	if s.password != "" {
+		if s.username != "" {
+			setup = append(setup, []string{"AUTH", s.username, s.password})
+		} else {
+			setup = append(setup, []string{"AUTH", s.password})
+		}
+	}
+	if s.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	if len(setup) > 0 {
+		if _, err := conn.pipeline(setup...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// pipeline writes the commands and reads one reply for each. Every reply is
+// read even if one is an error, so the connection stays usable.
+func (c *redisConn) pipeline(commands ...[]string) ([]interface{}, error) {
+	if err := c.SetDeadline(time.Now().Add(redisReplyTimeout)); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	for _, args := range commands {
+		fmt.Fprintf(&b, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if _, err := io.WriteString(c, b.String()); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(commands))
+	var firstErr error
+	for i := range commands {
+		reply, err := readReply(c.r)
+		var redisErr RedisError
+		if err != nil && !errors.As(err, &redisErr) {
+			return nil, err
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		replies[i] = reply
+	}
+	return replies, firstErr
+}
+
+// readReply reads a reply: a string for simple and bulk strings, an int64
+// for integers, a []interface{} for arrays and nil for null replies. Error
+// replies are returned as a RedisError, or kept as one inside arrays.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, RedisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", payload)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: malformed array length %q", payload)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		array := make([]interface{}, n)
+		for i := range array {
+			var redisErr RedisError
+			if array[i], err = readReply(r); errors.As(err, &redisErr) {
+				array[i] = redisErr
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
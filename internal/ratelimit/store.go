@@ -0,0 +1,97 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package ratelimit
+
+// store.go keeps the request counters in memory.
+
+import (
+	"sync"
+	"time"
+)
+
+// Store keeps the request counters of a Limiter.
+type Store interface {
+	// Increment adds delta to the counter of key and returns the new value.
+	// The counter expires ttl after it was last incremented.
+	Increment(key string, delta int64, ttl time.Duration) (int64, error)
+	// Get returns the counter of key, or 0 if there is none.
+	Get(key string) (int64, error)
+}
+
+// sweepInterval is how often the memory store deletes expired counters.
+const sweepInterval = time.Minute
+
+type counter struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the counters in memory. They are not shared between
+// instances, so every instance enforces the limits on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]counter
+	nextSweep time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]counter{}}
+}
+
+// Increment adds delta to the counter of key.
+func (s *MemoryStore) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.nextSweep) {
+		for key, c := range s.counters {
+			if !c.expiresAt.After(now) {
+				delete(s.counters, key)
+			}
+		}
+		s.nextSweep = now.Add(sweepInterval)
+	}
+
+	c := s.counters[key]
+	if !c.expiresAt.After(now) {
+		c.value = 0
+	}
+	c.value += delta
+	c.expiresAt = now.Add(ttl)
+	s.counters[key] = c
+	return c.value, nil
+}
+
+// Get returns the counter of key.
+func (s *MemoryStore) Get(key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || !c.expiresAt.After(time.Now()) {
+		return 0, nil
+	}
+	return c.value, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+// rbac.go resolves the roles and permissions of users and checks them.
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/repository"
+)
+
+// Permissions checked by the admin routes.
+const (
+	UsersRead    = "users:read"
+	UsersWrite   = "users:write"
+	ClientsRead  = "clients:read"
+	ClientsWrite = "clients:write"
+	RolesRead    = "roles:read"
+	RolesWrite   = "roles:write"
+	AuditRead    = "audit:read"
+)
+
+// Wildcard grants every permission. A permission ending in :* grants every
+// action on its resource, like users:*.
+const Wildcard = "*"
+
+// permissionPattern is the syntax of a permission name.
+var permissionPattern = regexp.MustCompile(`^(\*|[a-z0-9_.-]+:(\*|[a-z0-9_.-]+))$`)
+
+// rolePattern is the syntax of a role name.
+var rolePattern = regexp.MustCompile(`^[a-z0-9_.-]{1,64}$`)
+
+// ValidatePermission checks that name is a valid permission name.
+func ValidatePermission(name string) error {
+	if len(name) > 100 || !permissionPattern.MatchString(name) {
+		return fmt.Errorf("invalid permission %q, expected resource:action", name)
+	}
+	return nil
+}
+
+// ValidateRole checks that name is a valid role name.
+func ValidateRole(name string) error {
+	if !rolePattern.MatchString(name) {
+		return fmt.Errorf("invalid role name %q", name)
+	}
+	return nil
+}
+
+// Allows reports whether the granted permissions include permission,
+// directly or through a wildcard.
+func Allows(granted []string, permission string) bool {
+	resource, _, _ := strings.Cut(permission, ":")
+	for _, g := range granted {
+		if g == permission || g == Wildcard || g == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Covered returns the names of the existing permissions that are granted by
+// holding the given ones, which may include wildcards.
+func Covered(roles repository.RoleRepository, granted []string) ([]string, error) {
+	permissions, err := roles.ListPermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	covered := []string{}
+	for _, permission := range permissions {
+		if Allows(granted, permission.Name) {
+			covered = append(covered, permission.Name)
+		}
+	}
+	return covered, nil
+}
+
+// AllowsAll reports whether the granted permissions include every one of
+// permissions.
+func AllowsAll(granted, permissions []string) bool {
+	for _, permission := range permissions {
+		if !Allows(granted, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// Grants are the roles of a user and the permissions they add up to.
+type Grants struct {
+	Roles       []string
+	Permissions []string
+}
+
+// Allows reports whether the grants include permission.
+func (g *Grants) Allows(permission string) bool {
+	return Allows(g.Permissions, permission)
+}
+
+// FlagRoles returns the names of the roles granted by the IsStaff and
+// IsSuperuser flags of the user.
+func FlagRoles(user *models.User) []string {
+	var roles []string
+	if user.IsSuperuser {
+		roles = append(roles, RoleSuperuser)
+	}
+	if user.IsStaff {
+		roles = append(roles, RoleStaff)
+	}
+	return roles
+}
+
+// Resolve returns the grants of the user: the roles assigned to it, the
+// roles granted by its flags, and their permissions. Names are sorted.
+func Resolve(roles repository.RoleRepository, user *models.User) (*Grants, error) {
+	if user.ID == 0 {
+		return nil, errors.New("user must be saved")
+	}
+
+	found, err := roles.ForUser(user.ID, FlagRoles(user))
+	if err != nil {
+		return nil, err
+	}
+
+	grants := &Grants{Roles: []string{}, Permissions: []string{}}
+	seen := map[string]bool{}
+	for _, role := range found {
+		grants.Roles = append(grants.Roles, role.Name)
+		for _, permission := range role.Permissions {
+			if !seen[permission.Name] {
+				seen[permission.Name] = true
+				grants.Permissions = append(grants.Permissions, permission.Name)
+			}
+		}
+	}
+	sort.Strings(grants.Roles)
+	sort.Strings(grants.Permissions)
+	return grants, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/rbac"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name       string
+		granted    []string
+		permission string
+		want       bool
+	}{
+		{"exact", []string{"users:read"}, "users:read", true},
+		{"other action", []string{"users:read"}, "users:write", false},
+		{"resource wildcard", []string{"users:*"}, "users:write", true},
+		{"other resource", []string{"users:*"}, "roles:read", false},
+		{"wildcard", []string{"*"}, "roles:write", true},
+		{"nothing", nil, "users:read", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, rbac.Allows(test.granted, test.permission))
+		})
+	}
+}
+
+func TestValidatePermission(t *testing.T) {
+	for _, name := range []string{"users:read", "users:*", "*", "billing.invoices:export"} {
+		require.NoError(t, rbac.ValidatePermission(name), name)
+	}
+	for _, name := range []string{"", "users", "Users:read", "users:read:all", "*:read", "users: read"} {
+		require.Error(t, rbac.ValidatePermission(name), name)
+	}
+}
+
+func TestSeedAndResolve(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}))
+
+	for name, repos := range map[string]repository.Repositories{
+		"memory": repository.NewMemoryRepositories(),
+		"gorm":   repository.NewGormRepositories(db),
+	} {
+		t.Run(name, func(t *testing.T) {
+			testSeedAndResolve(t, repos.Users, repos.Roles)
+		})
+	}
+}
+
+func testSeedAndResolve(t *testing.T, users repository.UserRepository, roles repository.RoleRepository) {
+	// Seeding twice changes nothing
+	require.NoError(t, rbac.Seed(roles))
+	require.NoError(t, rbac.Seed(roles))
+	list, err := roles.List()
+	require.NoError(t, err)
+	require.Len(t, list, len(rbac.DefaultRoles))
+	permissions, err := roles.ListPermissions()
+	require.NoError(t, err)
+	require.Len(t, permissions, len(rbac.DefaultPermissions))
+	staff, err := roles.FindByName(rbac.RoleStaff)
+	require.NoError(t, err)
+	require.Len(t, staff.Permissions, 4)
+
+	// Seeding gives a default role back its default permissions
+	require.NoError(t, roles.Update(staff, nil, &[]string{rbac.UsersRead, "billing:read"}))
+	require.NoError(t, rbac.Seed(roles))
+	staff, err = roles.FindByName(rbac.RoleStaff)
+	require.NoError(t, err)
+	require.Len(t, staff.Permissions, 5)
+
+	user := &models.User{Username: "rbac", Email: "rbac@example.com", PasswordHash: []byte("x")}
+	require.NoError(t, users.Create(user))
+	grants, err := rbac.Resolve(roles, user)
+	require.NoError(t, err)
+	require.Empty(t, grants.Roles)
+	require.False(t, grants.Allows(rbac.UsersRead))
+
+	// The staff flag grants the staff role
+	user.IsStaff = true
+	grants, err = rbac.Resolve(roles, user)
+	require.NoError(t, err)
+	require.Equal(t, []string{rbac.RoleStaff}, grants.Roles)
+	require.True(t, grants.Allows(rbac.UsersRead))
+	require.False(t, grants.Allows(rbac.UsersWrite))
+
+	// Assigned roles add up with the flag roles
+	role := &models.Role{Name: "support"}
+	require.NoError(t, roles.Create(role, []string{"users:write", "billing:read"}))
+	require.NoError(t, roles.Assign(user.ID, role))
+
+	grants, err = rbac.Resolve(roles, user)
+	require.NoError(t, err)
+	require.Equal(t, []string{rbac.RoleStaff, "support"}, grants.Roles)
+	require.Equal(t, []string{"audit:read", "billing:read", "clients:read", "roles:read", "users:read", "users:write"}, grants.Permissions)
+
+	user.IsSuperuser = true
+	grants, err = rbac.Resolve(roles, user)
+	require.NoError(t, err)
+	require.True(t, grants.Allows("anything:at-all"))
+
+	covered, err := rbac.Covered(roles, []string{"users:*"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"users:read", "users:write"}, covered)
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+// seed.go creates the default roles and permissions.
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/repository"
+)
+
+// Default roles, granted by the flags of a user.
+const (
+	RoleSuperuser = "superuser"
+	RoleStaff     = "staff"
+)
+
+// DefaultPermissions are the permissions known to goAuth, by name.
+var DefaultPermissions = map[string]string{
+	Wildcard:     "Every permission",
+	UsersRead:    "List and view users",
+	UsersWrite:   "Manage users",
+	ClientsRead:  "List and view OAuth clients",
+	ClientsWrite: "Manage OAuth clients and service accounts",
+	RolesRead:    "List roles and their assignments",
+	RolesWrite:   "Manage roles and assign them to users",
+	AuditRead:    "Query and export the audit log",
+}
+
+// DefaultRole describes a role created by Seed.
+type DefaultRole struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// DefaultRoles are the roles created by Seed. Superusers may do anything,
+// staff members may look around.
+var DefaultRoles = []DefaultRole{
+	{RoleSuperuser, "Granted to superusers", []string{Wildcard}},
+	{RoleStaff, "Granted to staff members", []string{UsersRead, ClientsRead, RolesRead, AuditRead}},
+}
+
+// Seed creates the default permissions and roles that do not exist yet and
+// gives the default roles their default permissions. It is safe to call on
+// every start.
+func Seed(roles repository.RoleRepository) error {
+	names := make([]string, 0, len(DefaultPermissions))
+	for name := range DefaultPermissions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	permissions := make([]models.Permission, len(names))
+	for i, name := range names {
+		permissions[i] = models.Permission{Name: name, Description: DefaultPermissions[name]}
+	}
+	if err := roles.EnsurePermissions(permissions); err != nil {
+		return err
+	}
+
+	for _, defaultRole := range DefaultRoles {
+		role, err := roles.FindByName(defaultRole.Name)
+		if errors.Is(err, repository.ErrNotFound) {
+			role := &models.Role{Name: defaultRole.Name, Description: defaultRole.Description}
+			if err := roles.Create(role, defaultRole.Permissions); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// Permissions given to a default role are kept
+		granted := make([]string, 0, len(role.Permissions)+len(defaultRole.Permissions))
+		has := map[string]bool{}
+		for _, permission := range role.Permissions {
+			granted = append(granted, permission.Name)
+			has[permission.Name] = true
+		}
+		for _, name := range defaultRole.Permissions {
+			if !has[name] {
+				granted = append(granted, name)
+			}
+		}
+		if len(granted) > len(role.Permissions) {
+			if err := roles.Update(role, nil, &granted); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IsDefaultRole reports whether name is one of DefaultRoles.
+func IsDefaultRole(name string) bool {
+	for _, role := range DefaultRoles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepositories(t)
+			user := newUser("alice")
+			require.NoError(t, repos.Users.Create(user))
+			totp := repos.TOTP
+
+			_, err := totp.Find(user.ID, false)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+
+			// Enrolling again replaces the pending credential
+			require.NoError(t, totp.Enroll(user.ID, []byte("first")))
+			require.NoError(t, totp.Enroll(user.ID, []byte("second")))
+			pending, err := totp.Find(user.ID, false)
+			require.NoError(t, err)
+			require.Equal(t, []byte("second"), pending.Secret)
+			_, err = totp.Find(user.ID, true)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+
+			// A step is only accepted once, and never an older one
+			require.NoError(t, totp.UseStep(pending.ID, 10))
+			require.ErrorIs(t, totp.UseStep(pending.ID, 10), repository.ErrNotFound)
+			require.ErrorIs(t, totp.UseStep(pending.ID, 9), repository.ErrNotFound)
+			require.NoError(t, totp.UseStep(pending.ID, 11))
+
+			// Confirming hands out the recovery codes
+			now := time.Now()
+			require.NoError(t, totp.Confirm(pending.ID, now, []string{"a", "b"}))
+			require.ErrorIs(t, totp.Confirm(pending.ID, now, []string{"c"}), repository.ErrNotFound)
+			confirmed, err := totp.Find(user.ID, true)
+			require.NoError(t, err)
+			require.NotNil(t, confirmed.ConfirmedAt)
+			require.EqualValues(t, 11, confirmed.LastUsedStep)
+
+			require.NoError(t, totp.UseRecoveryCode(user.ID, "a", now))
+			require.ErrorIs(t, totp.UseRecoveryCode(user.ID, "a", now), repository.ErrNotFound)
+			require.ErrorIs(t, totp.UseRecoveryCode(user.ID, "c", now), repository.ErrNotFound)
+
+			require.NoError(t, totp.ReplaceRecoveryCodes(user.ID, []string{"c"}))
+			require.ErrorIs(t, totp.UseRecoveryCode(user.ID, "b", now), repository.ErrNotFound)
+			require.NoError(t, totp.UseRecoveryCode(user.ID, "c", now))
+
+			// Deleting takes the codes along
+			require.NoError(t, totp.ReplaceRecoveryCodes(user.ID, []string{"d"}))
+			require.NoError(t, totp.Delete(user.ID))
+			_, err = totp.Find(user.ID, true)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+			require.ErrorIs(t, totp.UseRecoveryCode(user.ID, "d", now), repository.ErrNotFound)
+		})
+	}
+}
+
+func TestWebAuthnRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepositories(t)
+			alice, bob := newUser("alice"), newUser("bob")
+			require.NoError(t, repos.Users.Create(alice))
+			require.NoError(t, repos.Users.Create(bob))
+			webauthn := repos.WebAuthn
+
+			// Challenges are consumed once, by their ceremony and user
+			now := time.Now()
+			require.NoError(t, webauthn.CreateChallenge(&models.WebAuthnChallenge{UserID: &alice.ID, Ceremony: "registration", ChallengeHash: "registration", ExpiresAt: now.Add(time.Minute)}))
+			require.NoError(t, webauthn.CreateChallenge(&models.WebAuthnChallenge{Ceremony: "login", ChallengeHash: "login", ExpiresAt: now.Add(time.Minute)}))
+			require.NoError(t, webauthn.CreateChallenge(&models.WebAuthnChallenge{Ceremony: "login", ChallengeHash: "expired", ExpiresAt: now.Add(-time.Minute)}))
+			require.ErrorIs(t, webauthn.ConsumeChallenge("registration", "login", nil, now), repository.ErrNotFound)
+			require.ErrorIs(t, webauthn.ConsumeChallenge("registration", "registration", &bob.ID, now), repository.ErrNotFound)
+			require.NoError(t, webauthn.ConsumeChallenge("registration", "registration", &alice.ID, now))
+			require.ErrorIs(t, webauthn.ConsumeChallenge("registration", "registration", &alice.ID, now), repository.ErrNotFound)
+			require.ErrorIs(t, webauthn.ConsumeChallenge("login", "login", nil, now.Add(2*time.Minute)), repository.ErrNotFound)
+			require.NoError(t, webauthn.ConsumeChallenge("login", "login", nil, now))
+
+			require.NoError(t, webauthn.DeleteExpiredChallenges(now))
+			require.NoError(t, webauthn.CreateChallenge(&models.WebAuthnChallenge{Ceremony: "login", ChallengeHash: "expired", ExpiresAt: now.Add(time.Minute)}))
+
+			// Credential IDs are unique
+			first := &models.WebAuthnCredential{UserID: alice.ID, CredentialID: "first", PublicKey: []byte("key")}
+			second := &models.WebAuthnCredential{UserID: alice.ID, CredentialID: "second", PublicKey: []byte("key")}
+			require.NoError(t, webauthn.CreateCredential(first))
+			require.NoError(t, webauthn.CreateCredential(second))
+			require.ErrorIs(t, webauthn.CreateCredential(&models.WebAuthnCredential{UserID: bob.ID, CredentialID: "first", PublicKey: []byte("key")}), repository.ErrDuplicate)
+
+			require.NoError(t, webauthn.UseCredential(first.ID, 7, now))
+			found, err := webauthn.FindCredential("first")
+			require.NoError(t, err)
+			require.EqualValues(t, 7, found.SignCount)
+			require.NotNil(t, found.LastUsedAt)
+			_, err = webauthn.FindCredential("nothing")
+			require.ErrorIs(t, err, repository.ErrNotFound)
+
+			list, err := webauthn.ListCredentials(alice.ID)
+			require.NoError(t, err)
+			require.Len(t, list, 2)
+			require.Equal(t, first.ID, list[0].ID)
+
+			// Credentials are only deleted by their user
+			require.ErrorIs(t, webauthn.DeleteCredential(first.ID, bob.ID), repository.ErrNotFound)
+			require.NoError(t, webauthn.DeleteCredential(first.ID, alice.ID))
+			require.ErrorIs(t, webauthn.DeleteCredential(first.ID, alice.ID), repository.ErrNotFound)
+			list, err = webauthn.ListCredentials(alice.ID)
+			require.NoError(t, err)
+			require.Len(t, list, 1)
+		})
+	}
+}
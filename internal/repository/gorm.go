@@ -0,0 +1,559 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern with '!', which unlike
+// a backslash needs no escaping in the string literals of any dialect.
+var likeEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+
+// NewGormRepositories returns the repositories keeping their records in
+// the tables of db.
+func NewGormRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:              NewGormUserRepository(db),
+		Sessions:           NewGormSessionRepository(db),
+		RefreshTokens:      NewGormRefreshTokenRepository(db),
+		Roles:              NewGormRoleRepository(db),
+		Clients:            NewGormClientRepository(db),
+		TOTP:               NewGormTOTPRepository(db),
+		WebAuthn:           NewGormWebAuthnRepository(db),
+		Consents:           NewGormConsentRepository(db),
+		AuthorizationCodes: NewGormAuthorizationCodeRepository(db),
+		PasswordResets:     NewGormPasswordResetRepository(db),
+		EmailVerifications: NewGormEmailVerificationRepository(db),
+		PasswordHistory:    NewGormPasswordHistoryRepository(db),
+	}
+}
+
+// GormUserRepository is a UserRepository backed by the users table.
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository returns a UserRepository that keeps the users in db.
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+// translate maps the errors of the database to the errors of the package.
+func translate(db *gorm.DB, err error) error {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok && err != nil {
+		err = translator.Translate(err)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *GormUserRepository) first(db *gorm.DB, query string, args ...interface{}) (*models.User, error) {
+	var user models.User
+	if err := db.Where(query, args...).First(&user).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &user, nil
+}
+
+// FindByID implements UserRepository.
+func (r *GormUserRepository) FindByID(id uint, withDeleted bool) (*models.User, error) {
+	db := r.db
+	if withDeleted {
+		db = db.Unscoped()
+	}
+	return r.first(db, "id = ?", id)
+}
+
+// FindByEmail implements UserRepository.
+func (r *GormUserRepository) FindByEmail(email string) (*models.User, error) {
+	return r.first(r.db, "email = ?", email)
+}
+
+// FindByUsername implements UserRepository.
+func (r *GormUserRepository) FindByUsername(username string) (*models.User, error) {
+	return r.first(r.db, "username = ?", username)
+}
+
+func (r *GormUserRepository) taken(column, value string, exceptID uint) (bool, error) {
+	_, err := r.first(r.db.Unscoped(), column+" = ? AND id <> ?", value, exceptID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// EmailTaken implements UserRepository.
+func (r *GormUserRepository) EmailTaken(email string, exceptID uint) (bool, error) {
+	return r.taken("email", email, exceptID)
+}
+
+// UsernameTaken implements UserRepository.
+func (r *GormUserRepository) UsernameTaken(username string, exceptID uint) (bool, error) {
+	return r.taken("username", username, exceptID)
+}
+
+// List implements UserRepository.
+func (r *GormUserRepository) List(filter UserFilter) ([]models.User, int64, error) {
+	query := r.db.Model(&models.User{})
+	switch filter.Deleted {
+	case IncludeDeleted:
+		query = query.Unscoped()
+	case OnlyDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(search)) + "%"
+		query = query.Where(`(LOWER(username) LIKE ? ESCAPE '!' OR LOWER(email) LIKE ? ESCAPE '!' OR LOWER(name) LIKE ? ESCAPE '!')`, pattern, pattern, pattern)
+	}
+	flags := []struct {
+		column string
+		value  *bool
+	}{
+		{"is_active", filter.IsActive},
+		{"is_staff", filter.IsStaff},
+		{"is_superuser", filter.IsSuperuser},
+		{"email_verified", filter.EmailVerified},
+	}
+	for _, flag := range flags {
+		if flag.value != nil {
+			query = query.Where(flag.column+" = ?", *flag.value)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Order("id").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Create implements UserRepository.
+func (r *GormUserRepository) Create(user *models.User) error {
+	return translate(r.db, r.db.Create(user).Error)
+}
+
+// Update implements UserRepository.
+func (r *GormUserRepository) Update(id uint, fields map[string]interface{}) error {
+	result := r.db.Model(&models.User{}).Where("id = ?", id).Updates(fields)
+	if result.Error != nil {
+		return translate(r.db, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ReplacePasswordHash implements UserRepository.
+func (r *GormUserRepository) ReplacePasswordHash(id uint, current, hash []byte) error {
+	result := r.db.Model(&models.User{}).Where("id = ? AND password_hash = ?", id, current).Update("password_hash", hash)
+	if result.Error != nil {
+		return translate(r.db, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// IncrementTokenVersion implements UserRepository. The version is raised
+// in the database, so concurrent increments all count.
+func (r *GormUserRepository) IncrementTokenVersion(id uint) error {
+	result := r.db.Unscoped().Model(&models.User{}).Where("id = ?", id).Update("token_version", gorm.Expr("token_version + 1"))
+	if result.Error != nil {
+		return translate(r.db, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete implements UserRepository.
+func (r *GormUserRepository) Delete(id uint) error {
+	result := r.db.Where("id = ?", id).Delete(&models.User{})
+	if result.Error != nil {
+		return translate(r.db, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Restore implements UserRepository.
+func (r *GormUserRepository) Restore(id uint) error {
+	result := r.db.Unscoped().Model(&models.User{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return translate(r.db, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// rowAffected returns ErrNotFound if the result changed no row.
+func rowAffected(db *gorm.DB, result *gorm.DB) error {
+	if result.Error != nil {
+		return translate(db, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GormSessionRepository is a SessionRepository backed by the sessions
+// table.
+type GormSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewGormSessionRepository returns a SessionRepository that keeps the
+// sessions in db.
+func NewGormSessionRepository(db *gorm.DB) *GormSessionRepository {
+	return &GormSessionRepository{db: db}
+}
+
+// Create implements SessionRepository.
+func (r *GormSessionRepository) Create(session *models.Session) error {
+	return translate(r.db, r.db.Create(session).Error)
+}
+
+// active returns the query of the sessions of the user active at now.
+func (r *GormSessionRepository) active(db *gorm.DB, userID uint, now time.Time) *gorm.DB {
+	return db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now)
+}
+
+// FindActive implements SessionRepository.
+func (r *GormSessionRepository) FindActive(id, userID uint, now time.Time) (*models.Session, error) {
+	var session models.Session
+	if err := r.active(r.db, userID, now).Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &session, nil
+}
+
+// ListActive implements SessionRepository.
+func (r *GormSessionRepository) ListActive(userID uint, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := r.active(r.db, userID, now).Order("last_seen_at DESC, id DESC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Touch implements SessionRepository.
+func (r *GormSessionRepository) Touch(id uint, ip string, now, expiresAt time.Time) error {
+	fields := map[string]interface{}{"last_seen_at": now, "ip": ip}
+	if !expiresAt.IsZero() {
+		fields["expires_at"] = expiresAt
+	}
+	return rowAffected(r.db, r.db.Model(&models.Session{}).Where("id = ?", id).Updates(fields))
+}
+
+// Revoke implements SessionRepository. The session and its refresh tokens
+// are revoked in one transaction.
+func (r *GormSessionRepository) Revoke(id, userID uint, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RefreshToken{}).
+			Where("session_id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return rowAffected(tx, tx.Model(&models.Session{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+			Update("revoked_at", now))
+	})
+}
+
+// RevokeAll implements SessionRepository. The sessions and refresh tokens
+// are revoked in one transaction.
+func (r *GormSessionRepository) RevokeAll(userID uint, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// GormRefreshTokenRepository is a RefreshTokenRepository backed by the
+// refresh_tokens table.
+type GormRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRefreshTokenRepository returns a RefreshTokenRepository that keeps
+// the refresh tokens in db.
+func NewGormRefreshTokenRepository(db *gorm.DB) *GormRefreshTokenRepository {
+	return &GormRefreshTokenRepository{db: db}
+}
+
+// Create implements RefreshTokenRepository.
+func (r *GormRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	return translate(r.db, r.db.Create(token).Error)
+}
+
+// FindByHash implements RefreshTokenRepository.
+func (r *GormRefreshTokenRepository) FindByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &token, nil
+}
+
+// Rotate implements RefreshTokenRepository. The token is marked as used
+// with a conditional update, so concurrent callers cannot both succeed, in
+// the transaction creating its successor.
+func (r *GormRefreshTokenRepository) Rotate(id uint, now time.Time, next *models.RefreshToken) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := rowAffected(tx, tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", id).
+			Update("used_at", now))
+		if err != nil {
+			return err
+		}
+		return tx.Create(next).Error
+	})
+	return translate(r.db, err)
+}
+
+// RevokeFamily implements RefreshTokenRepository.
+func (r *GormRefreshTokenRepository) RevokeFamily(familyID string, now time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+// GormRoleRepository is a RoleRepository backed by the roles, permissions,
+// role_permissions and user_roles tables.
+type GormRoleRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRoleRepository returns a RoleRepository that keeps the roles in db.
+func NewGormRoleRepository(db *gorm.DB) *GormRoleRepository {
+	return &GormRoleRepository{db: db}
+}
+
+// FindByName implements RoleRepository.
+func (r *GormRoleRepository) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Preload("Permissions").Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &role, nil
+}
+
+// List implements RoleRepository.
+func (r *GormRoleRepository) List() ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// ListPermissions implements RoleRepository.
+func (r *GormRoleRepository) ListPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	if err := r.db.Order("name").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// ForUser implements RoleRepository.
+func (r *GormRoleRepository) ForUser(userID uint, names []string) ([]models.Role, error) {
+	query := r.db.Preload("Permissions").
+		Where("id IN (?)", r.db.Table("user_roles").Select("role_id").Where("user_id = ?", userID))
+	if len(names) > 0 {
+		query = query.Or("name IN ?", names)
+	}
+	var roles []models.Role
+	if err := query.Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// findPermissions returns the permissions with the names, creating the ones
+// that do not exist yet.
+func findPermissions(tx *gorm.DB, names []string) ([]models.Permission, error) {
+	found := make([]models.Permission, 0, len(names))
+	for _, name := range names {
+		permission := models.Permission{Name: name}
+		if err := tx.Where(models.Permission{Name: name}).FirstOrCreate(&permission).Error; err != nil {
+			return nil, err
+		}
+		found = append(found, permission)
+	}
+	return found, nil
+}
+
+// Create implements RoleRepository. The role and its new permissions are
+// created in one transaction.
+func (r *GormRoleRepository) Create(role *models.Role, permissions []string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		found, err := findPermissions(tx, permissions)
+		if err != nil {
+			return err
+		}
+		role.Permissions = found
+		return tx.Create(role).Error
+	})
+	return translate(r.db, err)
+}
+
+// Update implements RoleRepository. The changes are made in one
+// transaction.
+func (r *GormRoleRepository) Update(role *models.Role, description *string, permissions *[]string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if description != nil {
+			if err := tx.Model(role).Update("description", *description).Error; err != nil {
+				return err
+			}
+		}
+		if permissions == nil {
+			return nil
+		}
+
+		found, err := findPermissions(tx, *permissions)
+		if err != nil {
+			return err
+		}
+		return tx.Model(role).Association("Permissions").Replace(found)
+	})
+}
+
+// Delete implements RoleRepository. The assignments and the role are deleted
+// in one transaction.
+func (r *GormRoleRepository) Delete(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_roles WHERE role_id = ?", role.ID).Error; err != nil {
+			return err
+		}
+		return tx.Select("Permissions").Delete(role).Error
+	})
+}
+
+// EnsurePermissions implements RoleRepository.
+func (r *GormRoleRepository) EnsurePermissions(permissions []models.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&permissions).Error
+}
+
+// AssignedNames implements RoleRepository.
+func (r *GormRoleRepository) AssignedNames(userID uint) ([]string, error) {
+	names := []string{}
+	err := r.db.Model(&models.Role{}).
+		Where("id IN (?)", r.db.Table("user_roles").Select("role_id").Where("user_id = ?", userID)).
+		Order("name").Pluck("name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// Assign implements RoleRepository.
+func (r *GormRoleRepository) Assign(userID uint, role *models.Role) error {
+	return r.db.Table("user_roles").Clauses(clause.OnConflict{DoNothing: true}).
+		Create(map[string]interface{}{"user_id": userID, "role_id": role.ID}).Error
+}
+
+// Unassign implements RoleRepository.
+func (r *GormRoleRepository) Unassign(userID uint, role *models.Role) error {
+	return r.db.Exec("DELETE FROM user_roles WHERE user_id = ? AND role_id = ?", userID, role.ID).Error
+}
+
+// GormClientRepository is a ClientRepository backed by the oauth_clients
+// table.
+type GormClientRepository struct {
+	db *gorm.DB
+}
+
+// NewGormClientRepository returns a ClientRepository that keeps the clients
+// in db.
+func NewGormClientRepository(db *gorm.DB) *GormClientRepository {
+	return &GormClientRepository{db: db}
+}
+
+// FindByClientID implements ClientRepository.
+func (r *GormClientRepository) FindByClientID(clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	if err := r.db.Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &client, nil
+}
+
+// List implements ClientRepository.
+func (r *GormClientRepository) List() ([]models.OAuthClient, error) {
+	var clients []models.OAuthClient
+	if err := r.db.Order("id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// Create implements ClientRepository.
+func (r *GormClientRepository) Create(client *models.OAuthClient) error {
+	return translate(r.db, r.db.Create(client).Error)
+}
+
+// Update implements ClientRepository.
+func (r *GormClientRepository) Update(id uint, fields map[string]interface{}) error {
+	return rowAffected(r.db, r.db.Model(&models.OAuthClient{}).Where("id = ?", id).Updates(fields))
+}
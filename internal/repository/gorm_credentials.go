@@ -0,0 +1,201 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository
+
+import (
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// GormTOTPRepository is a TOTPRepository backed by the totp_credentials and
+// recovery_codes tables.
+type GormTOTPRepository struct {
+	db *gorm.DB
+}
+
+// NewGormTOTPRepository returns a TOTPRepository that keeps the credentials
+// in db.
+func NewGormTOTPRepository(db *gorm.DB) *GormTOTPRepository {
+	return &GormTOTPRepository{db: db}
+}
+
+// Find implements TOTPRepository.
+func (r *GormTOTPRepository) Find(userID uint, confirmed bool) (*models.TOTPCredential, error) {
+	query := r.db.Where("user_id = ? AND confirmed_at IS NULL", userID)
+	if confirmed {
+		query = r.db.Where("user_id = ? AND confirmed_at IS NOT NULL", userID)
+	}
+
+	var credential models.TOTPCredential
+	if err := query.First(&credential).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &credential, nil
+}
+
+// Enroll implements TOTPRepository. The credentials are replaced in one
+// transaction.
+func (r *GormTOTPRepository) Enroll(userID uint, secret []byte) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TOTPCredential{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.TOTPCredential{UserID: userID, Secret: secret}).Error
+	})
+}
+
+// UseStep implements TOTPRepository. The step is recorded with a single
+// conditional update, so concurrent callers cannot both succeed.
+func (r *GormTOTPRepository) UseStep(id uint, step int64) error {
+	return rowAffected(r.db, r.db.Model(&models.TOTPCredential{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Update("last_used_step", step))
+}
+
+// Confirm implements TOTPRepository. The credential is confirmed and the
+// codes are replaced in one transaction.
+func (r *GormTOTPRepository) Confirm(id uint, now time.Time, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var credential models.TOTPCredential
+		if err := tx.Where("id = ? AND confirmed_at IS NULL", id).First(&credential).Error; err != nil {
+			return translate(tx, err)
+		}
+		err := rowAffected(tx, tx.Model(&models.TOTPCredential{}).
+			Where("id = ? AND confirmed_at IS NULL", id).
+			Update("confirmed_at", now))
+		if err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, credential.UserID, codeHashes)
+	})
+}
+
+// ReplaceRecoveryCodes implements TOTPRepository. The codes are replaced in
+// one transaction.
+func (r *GormTOTPRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// replaceRecoveryCodes replaces the recovery codes of the user in the
+// transaction tx.
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	codes := make([]models.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode implements TOTPRepository.
+func (r *GormTOTPRepository) UseRecoveryCode(userID uint, codeHash string, now time.Time) error {
+	return rowAffected(r.db, r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", now))
+}
+
+// Delete implements TOTPRepository. The credential and the codes are
+// deleted in one transaction.
+func (r *GormTOTPRepository) Delete(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.TOTPCredential{}).Error
+	})
+}
+
+// GormWebAuthnRepository is a WebAuthnRepository backed by the
+// web_authn_credentials and web_authn_challenges tables.
+type GormWebAuthnRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWebAuthnRepository returns a WebAuthnRepository that keeps the
+// credentials and challenges in db.
+func NewGormWebAuthnRepository(db *gorm.DB) *GormWebAuthnRepository {
+	return &GormWebAuthnRepository{db: db}
+}
+
+// CreateChallenge implements WebAuthnRepository.
+func (r *GormWebAuthnRepository) CreateChallenge(challenge *models.WebAuthnChallenge) error {
+	return translate(r.db, r.db.Create(challenge).Error)
+}
+
+// ConsumeChallenge implements WebAuthnRepository. The challenge is deleted
+// with a single statement, so concurrent callers cannot both succeed.
+func (r *GormWebAuthnRepository) ConsumeChallenge(challengeHash, ceremony string, userID *uint, now time.Time) error {
+	query := r.db.Where("challenge_hash = ? AND ceremony = ? AND expires_at > ?", challengeHash, ceremony, now)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	return rowAffected(r.db, query.Delete(&models.WebAuthnChallenge{}))
+}
+
+// DeleteExpiredChallenges implements WebAuthnRepository.
+func (r *GormWebAuthnRepository) DeleteExpiredChallenges(now time.Time) error {
+	return r.db.Where("expires_at <= ?", now).Delete(&models.WebAuthnChallenge{}).Error
+}
+
+// FindCredential implements WebAuthnRepository.
+func (r *GormWebAuthnRepository) FindCredential(credentialID string) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	if err := r.db.Where("credential_id = ?", credentialID).First(&credential).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &credential, nil
+}
+
+// ListCredentials implements WebAuthnRepository.
+func (r *GormWebAuthnRepository) ListCredentials(userID uint) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// CreateCredential implements WebAuthnRepository.
+func (r *GormWebAuthnRepository) CreateCredential(credential *models.WebAuthnCredential) error {
+	return translate(r.db, r.db.Create(credential).Error)
+}
+
+// UseCredential implements WebAuthnRepository.
+func (r *GormWebAuthnRepository) UseCredential(id uint, signCount uint32, now time.Time) error {
+	return rowAffected(r.db, r.db.Model(&models.WebAuthnCredential{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"sign_count": signCount, "last_used_at": now}))
+}
+
+// DeleteCredential implements WebAuthnRepository.
+func (r *GormWebAuthnRepository) DeleteCredential(id, userID uint) error {
+	return rowAffected(r.db, r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.WebAuthnCredential{}))
+}
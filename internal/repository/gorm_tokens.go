@@ -0,0 +1,243 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository
+
+import (
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormConsentRepository is a ConsentRepository backed by the oauth_consents
+// table.
+type GormConsentRepository struct {
+	db *gorm.DB
+}
+
+// NewGormConsentRepository returns a ConsentRepository that keeps the
+// consents in db.
+func NewGormConsentRepository(db *gorm.DB) *GormConsentRepository {
+	return &GormConsentRepository{db: db}
+}
+
+// Find implements ConsentRepository.
+func (r *GormConsentRepository) Find(userID, clientID uint) (*models.OAuthConsent, error) {
+	var consent models.OAuthConsent
+	if err := r.db.Where("user_id = ? AND client_id = ?", userID, clientID).First(&consent).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &consent, nil
+}
+
+// Save implements ConsentRepository.
+func (r *GormConsentRepository) Save(consent *models.OAuthConsent) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scope", "updated_at"}),
+	}).Create(consent).Error
+}
+
+// GormAuthorizationCodeRepository is an AuthorizationCodeRepository backed
+// by the authorization_codes table.
+type GormAuthorizationCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAuthorizationCodeRepository returns an AuthorizationCodeRepository
+// that keeps the codes in db.
+func NewGormAuthorizationCodeRepository(db *gorm.DB) *GormAuthorizationCodeRepository {
+	return &GormAuthorizationCodeRepository{db: db}
+}
+
+// Create implements AuthorizationCodeRepository.
+func (r *GormAuthorizationCodeRepository) Create(code *models.AuthorizationCode) error {
+	return translate(r.db, r.db.Create(code).Error)
+}
+
+// FindByHash implements AuthorizationCodeRepository.
+func (r *GormAuthorizationCodeRepository) FindByHash(hash string) (*models.AuthorizationCode, error) {
+	var code models.AuthorizationCode
+	if err := r.db.Where("code_hash = ?", hash).First(&code).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &code, nil
+}
+
+// Use implements AuthorizationCodeRepository. The code is marked as used
+// with a single conditional update, so concurrent callers cannot both
+// succeed.
+func (r *GormAuthorizationCodeRepository) Use(id uint, accessTokenID string, now time.Time) error {
+	return rowAffected(r.db, r.db.Model(&models.AuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Updates(map[string]interface{}{"used_at": now, "access_token_id": accessTokenID}))
+}
+
+// DeleteExpired implements AuthorizationCodeRepository.
+func (r *GormAuthorizationCodeRepository) DeleteExpired(before time.Time) error {
+	return r.db.Where("expires_at <= ?", before).Delete(&models.AuthorizationCode{}).Error
+}
+
+// GormPasswordResetRepository is a PasswordResetRepository backed by the
+// password_reset_tokens table.
+type GormPasswordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPasswordResetRepository returns a PasswordResetRepository that
+// keeps the tokens in db.
+func NewGormPasswordResetRepository(db *gorm.DB) *GormPasswordResetRepository {
+	return &GormPasswordResetRepository{db: db}
+}
+
+// Replace implements PasswordResetRepository. The tokens are replaced in
+// one transaction.
+func (r *GormPasswordResetRepository) Replace(token *models.PasswordResetToken) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", token.UserID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+	return translate(r.db, err)
+}
+
+// FindActive implements PasswordResetRepository.
+func (r *GormPasswordResetRepository) FindActive(hash string, now time.Time) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).First(&token).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &token, nil
+}
+
+// Use implements PasswordResetRepository. The token is marked as used with
+// a single conditional update, so concurrent callers cannot both succeed.
+func (r *GormPasswordResetRepository) Use(id uint, now time.Time) error {
+	return rowAffected(r.db, r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now))
+}
+
+// GormEmailVerificationRepository is an EmailVerificationRepository backed
+// by the email_verification_tokens table.
+type GormEmailVerificationRepository struct {
+	db *gorm.DB
+}
+
+// NewGormEmailVerificationRepository returns an EmailVerificationRepository
+// that keeps the tokens in db.
+func NewGormEmailVerificationRepository(db *gorm.DB) *GormEmailVerificationRepository {
+	return &GormEmailVerificationRepository{db: db}
+}
+
+// Replace implements EmailVerificationRepository. The tokens are replaced
+// in one transaction.
+func (r *GormEmailVerificationRepository) Replace(token *models.EmailVerificationToken) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", token.UserID).Delete(&models.EmailVerificationToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+	return translate(r.db, err)
+}
+
+// FindActive implements EmailVerificationRepository.
+func (r *GormEmailVerificationRepository) FindActive(hash string, now time.Time) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	if err := r.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).First(&token).Error; err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &token, nil
+}
+
+// FindPending implements EmailVerificationRepository.
+func (r *GormEmailVerificationRepository) FindPending(userID uint, email string, now time.Time) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	err := r.db.Where("user_id = ? AND email <> ? AND used_at IS NULL AND expires_at > ?", userID, email, now).
+		Order("id DESC").First(&token).Error
+	if err != nil {
+		return nil, translate(r.db, err)
+	}
+	return &token, nil
+}
+
+// SentSince implements EmailVerificationRepository.
+func (r *GormEmailVerificationRepository) SentSince(userID uint, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Use implements EmailVerificationRepository. The token is marked as used
+// with a single conditional update, so concurrent callers cannot both
+// succeed.
+func (r *GormEmailVerificationRepository) Use(id uint, now time.Time) error {
+	return rowAffected(r.db, r.db.Model(&models.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now))
+}
+
+// GormPasswordHistoryRepository is a PasswordHistoryRepository backed by
+// the password_histories table.
+type GormPasswordHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPasswordHistoryRepository returns a PasswordHistoryRepository that
+// keeps the former hashes in db.
+func NewGormPasswordHistoryRepository(db *gorm.DB) *GormPasswordHistoryRepository {
+	return &GormPasswordHistoryRepository{db: db}
+}
+
+// Recent implements PasswordHistoryRepository.
+func (r *GormPasswordHistoryRepository) Recent(userID uint, n int) ([]models.PasswordHistory, error) {
+	var history []models.PasswordHistory
+	if err := r.db.Where("user_id = ?", userID).Order("id DESC").Limit(n).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// Add implements PasswordHistoryRepository.
+func (r *GormPasswordHistoryRepository) Add(userID uint, hash []byte) error {
+	return r.db.Create(&models.PasswordHistory{UserID: userID, PasswordHash: hash}).Error
+}
+
+// Prune implements PasswordHistoryRepository.
+func (r *GormPasswordHistoryRepository) Prune(userID uint, keep int) error {
+	var ids []uint
+	if err := r.db.Model(&models.PasswordHistory{}).Where("user_id = ?", userID).Order("id DESC").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	if len(ids) <= keep {
+		return nil
+	}
+	return r.db.Delete(&models.PasswordHistory{}, ids[keep:]).Error
+}
@@ -0,0 +1,879 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Schemas describing the columns of the tables, so the memory repositories
+// apply column maps and defaults the way the database does.
+var (
+	userSchema   = mustParseSchema(&models.User{})
+	clientSchema = mustParseSchema(&models.OAuthClient{})
+)
+
+func mustParseSchema(model interface{}) *schema.Schema {
+	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// applyDefaults gives the zero fields of the record that have a default in
+// the schema their default, like inserting the record does.
+func applyDefaults(s *schema.Schema, record interface{}) error {
+	value := reflect.ValueOf(record).Elem()
+	for _, field := range s.Fields {
+		if field.DefaultValueInterface == nil {
+			continue
+		}
+		if _, zero := field.ValueOf(context.Background(), value); zero {
+			if err := field.Set(context.Background(), value, field.DefaultValueInterface); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setColumns sets the fields of the record named by the columns of fields.
+func setColumns(s *schema.Schema, record interface{}, fields map[string]interface{}) error {
+	value := reflect.ValueOf(record).Elem()
+	for column, v := range fields {
+		field := s.LookUpField(column)
+		if field == nil {
+			return fmt.Errorf("repository: unknown column %q", column)
+		}
+		if err := field.Set(context.Background(), value, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewMemoryRepositories returns empty repositories keeping their records in
+// memory.
+func NewMemoryRepositories() Repositories {
+	refreshTokens := NewMemoryRefreshTokenRepository()
+	return Repositories{
+		Users:              NewMemoryUserRepository(),
+		Sessions:           NewMemorySessionRepository(refreshTokens),
+		RefreshTokens:      refreshTokens,
+		Roles:              NewMemoryRoleRepository(),
+		Clients:            NewMemoryClientRepository(),
+		TOTP:               NewMemoryTOTPRepository(),
+		WebAuthn:           NewMemoryWebAuthnRepository(),
+		Consents:           NewMemoryConsentRepository(),
+		AuthorizationCodes: NewMemoryAuthorizationCodeRepository(),
+		PasswordResets:     NewMemoryPasswordResetRepository(),
+		EmailVerifications: NewMemoryEmailVerificationRepository(),
+		PasswordHistory:    NewMemoryPasswordHistoryRepository(),
+	}
+}
+
+// MemoryUserRepository is a UserRepository that keeps the users in memory.
+// It is meant for tests and single process setups.
+type MemoryUserRepository struct {
+	mu     sync.Mutex
+	users  map[uint]*models.User
+	nextID uint
+}
+
+// NewMemoryUserRepository returns an empty MemoryUserRepository.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: map[uint]*models.User{}, nextID: 1}
+}
+
+// clone returns a copy of a stored user without its associations.
+func clone(user *models.User) *models.User {
+	copied := *user
+	copied.Roles = nil
+	return &copied
+}
+
+func (r *MemoryUserRepository) find(match func(*models.User) bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if !user.DeletedAt.Valid && match(user) {
+			return clone(user), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// FindByID implements UserRepository.
+func (r *MemoryUserRepository) FindByID(id uint, withDeleted bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || (user.DeletedAt.Valid && !withDeleted) {
+		return nil, ErrNotFound
+	}
+	return clone(user), nil
+}
+
+// FindByEmail implements UserRepository.
+func (r *MemoryUserRepository) FindByEmail(email string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.Email == email })
+}
+
+// FindByUsername implements UserRepository.
+func (r *MemoryUserRepository) FindByUsername(username string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.Username == username })
+}
+
+// EmailTaken implements UserRepository.
+func (r *MemoryUserRepository) EmailTaken(email string, exceptID uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.taken(exceptID, func(user *models.User) bool { return user.Email == email }), nil
+}
+
+// UsernameTaken implements UserRepository.
+func (r *MemoryUserRepository) UsernameTaken(username string, exceptID uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.taken(exceptID, func(user *models.User) bool { return user.Username == username }), nil
+}
+
+// taken reports whether a user other than the one with the ID matches.
+// The caller holds the lock.
+func (r *MemoryUserRepository) taken(exceptID uint, match func(*models.User) bool) bool {
+	for id, user := range r.users {
+		if id != exceptID && match(user) {
+			return true
+		}
+	}
+	return false
+}
+
+// conflicts reports whether another user has the username or email of the
+// user, as the unique indexes of the table would. The caller holds the lock.
+func (r *MemoryUserRepository) conflicts(user *models.User) bool {
+	return r.taken(user.ID, func(other *models.User) bool {
+		return other.Username == user.Username || other.Email == user.Email
+	})
+}
+
+// List implements UserRepository.
+func (r *MemoryUserRepository) List(filter UserFilter) ([]models.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	search := strings.ToLower(strings.TrimSpace(filter.Search))
+	flagMatches := func(value *bool, actual bool) bool {
+		return value == nil || *value == actual
+	}
+
+	var users []models.User
+	for _, user := range r.users {
+		switch {
+		case filter.Deleted == ExcludeDeleted && user.DeletedAt.Valid,
+			filter.Deleted == OnlyDeleted && !user.DeletedAt.Valid:
+			continue
+		}
+		if search != "" &&
+			!strings.Contains(strings.ToLower(user.Username), search) &&
+			!strings.Contains(strings.ToLower(user.Email), search) &&
+			!strings.Contains(strings.ToLower(user.Name), search) {
+			continue
+		}
+		if !flagMatches(filter.IsActive, user.IsActive) ||
+			!flagMatches(filter.IsStaff, user.IsStaff) ||
+			!flagMatches(filter.IsSuperuser, user.IsSuperuser) ||
+			!flagMatches(filter.EmailVerified, user.EmailVerified) {
+			continue
+		}
+		users = append(users, *clone(user))
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	total := int64(len(users))
+	if filter.Offset >= len(users) {
+		return []models.User{}, total, nil
+	}
+	users = users[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(users) {
+		users = users[:filter.Limit]
+	}
+	return users, total, nil
+}
+
+// Create implements UserRepository. Zero fields that have a default in the
+// schema get the default, like they do when inserted into the database.
+func (r *MemoryUserRepository) Create(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := clone(user)
+	if err := applyDefaults(userSchema, stored); err != nil {
+		return err
+	}
+
+	stored.ID = r.nextID
+	if r.conflicts(stored) {
+		return ErrDuplicate
+	}
+
+	now := time.Now()
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = now
+	}
+	if stored.UpdatedAt.IsZero() {
+		stored.UpdatedAt = now
+	}
+
+	r.nextID++
+	r.users[stored.ID] = stored
+	*user = *clone(stored)
+	return nil
+}
+
+// Update implements UserRepository.
+func (r *MemoryUserRepository) Update(id uint, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return ErrNotFound
+	}
+
+	updated := clone(user)
+	if err := setColumns(userSchema, updated, fields); err != nil {
+		return err
+	}
+	if r.conflicts(updated) {
+		return ErrDuplicate
+	}
+
+	updated.UpdatedAt = time.Now()
+	r.users[id] = updated
+	return nil
+}
+
+// ReplacePasswordHash implements UserRepository.
+func (r *MemoryUserRepository) ReplacePasswordHash(id uint, current, hash []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid || !bytes.Equal(user.PasswordHash, current) {
+		return ErrNotFound
+	}
+
+	updated := clone(user)
+	updated.PasswordHash = append([]byte(nil), hash...)
+	updated.UpdatedAt = time.Now()
+	r.users[id] = updated
+	return nil
+}
+
+// IncrementTokenVersion implements UserRepository.
+func (r *MemoryUserRepository) IncrementTokenVersion(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	updated := clone(user)
+	updated.TokenVersion++
+	r.users[id] = updated
+	return nil
+}
+
+// Delete implements UserRepository.
+func (r *MemoryUserRepository) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+// Restore implements UserRepository.
+func (r *MemoryUserRepository) Restore(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || !user.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// MemorySessionRepository is a SessionRepository that keeps the sessions in
+// memory. Revoking sessions revokes their refresh tokens in the
+// MemoryRefreshTokenRepository it was created with.
+type MemorySessionRepository struct {
+	mu            sync.Mutex
+	sessions      map[uint]*models.Session
+	nextID        uint
+	refreshTokens *MemoryRefreshTokenRepository
+}
+
+// NewMemorySessionRepository returns an empty MemorySessionRepository
+// revoking the refresh tokens of refreshTokens.
+func NewMemorySessionRepository(refreshTokens *MemoryRefreshTokenRepository) *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: map[uint]*models.Session{}, nextID: 1, refreshTokens: refreshTokens}
+}
+
+// Create implements SessionRepository.
+func (r *MemorySessionRepository) Create(session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.ID = r.nextID
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	r.nextID++
+
+	stored := *session
+	stored.User = models.User{}
+	r.sessions[stored.ID] = &stored
+	return nil
+}
+
+// active reports whether the session belongs to the user and is active at
+// now.
+func (r *MemorySessionRepository) active(session *models.Session, userID uint, now time.Time) bool {
+	return session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now)
+}
+
+// FindActive implements SessionRepository.
+func (r *MemorySessionRepository) FindActive(id, userID uint, now time.Time) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || !r.active(session, userID, now) {
+		return nil, ErrNotFound
+	}
+	found := *session
+	return &found, nil
+}
+
+// ListActive implements SessionRepository.
+func (r *MemorySessionRepository) ListActive(userID uint, now time.Time) ([]models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sessions []models.Session
+	for _, session := range r.sessions {
+		if r.active(session, userID, now) {
+			sessions = append(sessions, *session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	return sessions, nil
+}
+
+// Touch implements SessionRepository.
+func (r *MemorySessionRepository) Touch(id uint, ip string, now, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	session.LastSeenAt, session.IP = now, ip
+	if !expiresAt.IsZero() {
+		session.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+// Revoke implements SessionRepository.
+func (r *MemorySessionRepository) Revoke(id, userID uint, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return ErrNotFound
+	}
+	r.refreshTokens.revoke(func(token *models.RefreshToken) bool {
+		return token.SessionID != nil && *token.SessionID == id && token.UserID == userID
+	}, now)
+	session.RevokedAt = &now
+	return nil
+}
+
+// RevokeAll implements SessionRepository.
+func (r *MemorySessionRepository) RevokeAll(userID uint, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refreshTokens.revoke(func(token *models.RefreshToken) bool { return token.UserID == userID }, now)
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// MemoryRefreshTokenRepository is a RefreshTokenRepository that keeps the
+// refresh tokens in memory.
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[uint]*models.RefreshToken
+	nextID uint
+}
+
+// NewMemoryRefreshTokenRepository returns an empty
+// MemoryRefreshTokenRepository.
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{tokens: map[uint]*models.RefreshToken{}, nextID: 1}
+}
+
+// create stores the token. The caller holds the lock.
+func (r *MemoryRefreshTokenRepository) create(token *models.RefreshToken) error {
+	for _, other := range r.tokens {
+		if other.TokenHash == token.TokenHash {
+			return ErrDuplicate
+		}
+	}
+
+	token.ID = r.nextID
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	r.nextID++
+
+	stored := *token
+	stored.User = models.User{}
+	r.tokens[stored.ID] = &stored
+	return nil
+}
+
+// Create implements RefreshTokenRepository.
+func (r *MemoryRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(token)
+}
+
+// FindByHash implements RefreshTokenRepository.
+func (r *MemoryRefreshTokenRepository) FindByHash(hash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == hash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Rotate implements RefreshTokenRepository.
+func (r *MemoryRefreshTokenRepository) Rotate(id uint, now time.Time, next *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return ErrNotFound
+	}
+	if err := r.create(next); err != nil {
+		return err
+	}
+	token.UsedAt = &now
+	return nil
+}
+
+// RevokeFamily implements RefreshTokenRepository.
+func (r *MemoryRefreshTokenRepository) RevokeFamily(familyID string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revokeLocked(func(token *models.RefreshToken) bool { return token.FamilyID == familyID }, now)
+	return nil
+}
+
+// revoke revokes the tokens that match and are not revoked yet.
+func (r *MemoryRefreshTokenRepository) revoke(match func(*models.RefreshToken) bool, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokeLocked(match, now)
+}
+
+// revokeLocked is revoke for callers holding the lock.
+func (r *MemoryRefreshTokenRepository) revokeLocked(match func(*models.RefreshToken) bool, now time.Time) {
+	for _, token := range r.tokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &now
+		}
+	}
+}
+
+// MemoryRoleRepository is a RoleRepository that keeps the roles, the
+// permissions and the assignments in memory.
+type MemoryRoleRepository struct {
+	mu          sync.Mutex
+	roles       map[uint]*models.Role
+	permissions map[string]*models.Permission
+	assignments map[uint]map[uint]bool // user ID -> role IDs
+	nextID      uint
+}
+
+// NewMemoryRoleRepository returns an empty MemoryRoleRepository.
+func NewMemoryRoleRepository() *MemoryRoleRepository {
+	return &MemoryRoleRepository{
+		roles:       map[uint]*models.Role{},
+		permissions: map[string]*models.Permission{},
+		assignments: map[uint]map[uint]bool{},
+		nextID:      1,
+	}
+}
+
+// cloneRole returns a copy of a stored role with its own permissions.
+func cloneRole(role *models.Role) models.Role {
+	copied := *role
+	copied.Permissions = append([]models.Permission(nil), role.Permissions...)
+	return copied
+}
+
+// ensure returns the permission with the name, creating it if it does not
+// exist yet. The caller holds the lock.
+func (r *MemoryRoleRepository) ensure(permission models.Permission) models.Permission {
+	if existing, ok := r.permissions[permission.Name]; ok {
+		return *existing
+	}
+
+	permission.ID = r.nextID
+	if permission.CreatedAt.IsZero() {
+		permission.CreatedAt = time.Now()
+	}
+	r.nextID++
+	r.permissions[permission.Name] = &permission
+	return permission
+}
+
+// ensureAll returns the permissions with the names, creating the ones that
+// do not exist yet. The caller holds the lock.
+func (r *MemoryRoleRepository) ensureAll(names []string) []models.Permission {
+	found := make([]models.Permission, 0, len(names))
+	for _, name := range names {
+		found = append(found, r.ensure(models.Permission{Name: name}))
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+	return found
+}
+
+// FindByName implements RoleRepository.
+func (r *MemoryRoleRepository) FindByName(name string) (*models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, role := range r.roles {
+		if role.Name == name {
+			found := cloneRole(role)
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// List implements RoleRepository.
+func (r *MemoryRoleRepository) List() ([]models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	roles := make([]models.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, cloneRole(role))
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+// ListPermissions implements RoleRepository.
+func (r *MemoryRoleRepository) ListPermissions() ([]models.Permission, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	permissions := make([]models.Permission, 0, len(r.permissions))
+	for _, permission := range r.permissions {
+		permissions = append(permissions, *permission)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i].Name < permissions[j].Name })
+	return permissions, nil
+}
+
+// ForUser implements RoleRepository.
+func (r *MemoryRoleRepository) ForUser(userID uint, names []string) ([]models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var roles []models.Role
+	for id, role := range r.roles {
+		if r.assignments[userID][id] || contains(names, role.Name) {
+			roles = append(roles, cloneRole(role))
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].ID < roles[j].ID })
+	return roles, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Create implements RoleRepository.
+func (r *MemoryRoleRepository) Create(role *models.Role, permissions []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.roles {
+		if other.Name == role.Name {
+			return ErrDuplicate
+		}
+	}
+
+	role.Permissions = r.ensureAll(permissions)
+	role.ID = r.nextID
+	now := time.Now()
+	if role.CreatedAt.IsZero() {
+		role.CreatedAt = now
+	}
+	if role.UpdatedAt.IsZero() {
+		role.UpdatedAt = now
+	}
+	r.nextID++
+
+	stored := cloneRole(role)
+	r.roles[stored.ID] = &stored
+	return nil
+}
+
+// Update implements RoleRepository.
+func (r *MemoryRoleRepository) Update(role *models.Role, description *string, permissions *[]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.roles[role.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if description != nil {
+		stored.Description = *description
+		role.Description = *description
+	}
+	if permissions != nil {
+		stored.Permissions = r.ensureAll(*permissions)
+		role.Permissions = append([]models.Permission(nil), stored.Permissions...)
+	}
+	stored.UpdatedAt = time.Now()
+	role.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// Delete implements RoleRepository.
+func (r *MemoryRoleRepository) Delete(role *models.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.roles, role.ID)
+	for _, assigned := range r.assignments {
+		delete(assigned, role.ID)
+	}
+	return nil
+}
+
+// EnsurePermissions implements RoleRepository.
+func (r *MemoryRoleRepository) EnsurePermissions(permissions []models.Permission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, permission := range permissions {
+		r.ensure(permission)
+	}
+	return nil
+}
+
+// AssignedNames implements RoleRepository.
+func (r *MemoryRoleRepository) AssignedNames(userID uint) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := []string{}
+	for id := range r.assignments[userID] {
+		if role, ok := r.roles[id]; ok {
+			names = append(names, role.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Assign implements RoleRepository.
+func (r *MemoryRoleRepository) Assign(userID uint, role *models.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[role.ID]; !ok {
+		return ErrNotFound
+	}
+	if r.assignments[userID] == nil {
+		r.assignments[userID] = map[uint]bool{}
+	}
+	r.assignments[userID][role.ID] = true
+	return nil
+}
+
+// Unassign implements RoleRepository.
+func (r *MemoryRoleRepository) Unassign(userID uint, role *models.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.assignments[userID], role.ID)
+	return nil
+}
+
+// MemoryClientRepository is a ClientRepository that keeps the clients in
+// memory.
+type MemoryClientRepository struct {
+	mu      sync.Mutex
+	clients map[uint]*models.OAuthClient
+	nextID  uint
+}
+
+// NewMemoryClientRepository returns an empty MemoryClientRepository.
+func NewMemoryClientRepository() *MemoryClientRepository {
+	return &MemoryClientRepository{clients: map[uint]*models.OAuthClient{}, nextID: 1}
+}
+
+// FindByClientID implements ClientRepository.
+func (r *MemoryClientRepository) FindByClientID(clientID string) (*models.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, client := range r.clients {
+		if client.ClientID == clientID {
+			found := *client
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// List implements ClientRepository.
+func (r *MemoryClientRepository) List() ([]models.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clients := make([]models.OAuthClient, 0, len(r.clients))
+	for _, client := range r.clients {
+		clients = append(clients, *client)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+	return clients, nil
+}
+
+// Create implements ClientRepository. Zero fields that have a default in
+// the schema get the default, like they do when inserted into the database.
+func (r *MemoryClientRepository) Create(client *models.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.clients {
+		if other.ClientID == client.ClientID {
+			return ErrDuplicate
+		}
+	}
+	if err := applyDefaults(clientSchema, client); err != nil {
+		return err
+	}
+
+	client.ID = r.nextID
+	now := time.Now()
+	if client.CreatedAt.IsZero() {
+		client.CreatedAt = now
+	}
+	if client.UpdatedAt.IsZero() {
+		client.UpdatedAt = now
+	}
+	r.nextID++
+
+	stored := *client
+	r.clients[stored.ID] = &stored
+	return nil
+}
+
+// Update implements ClientRepository.
+func (r *MemoryClientRepository) Update(id uint, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	updated := *client
+	if err := setColumns(clientSchema, &updated, fields); err != nil {
+		return err
+	}
+	updated.UpdatedAt = time.Now()
+	r.clients[id] = &updated
+	return nil
+}
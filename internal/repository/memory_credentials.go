@@ -0,0 +1,311 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+)
+
+// MemoryTOTPRepository is a TOTPRepository that keeps the credentials and
+// recovery codes in memory.
+type MemoryTOTPRepository struct {
+	mu          sync.Mutex
+	credentials map[uint]*models.TOTPCredential // user ID -> credential
+	codes       map[uint][]*models.RecoveryCode // user ID -> codes
+	nextID      uint
+}
+
+// NewMemoryTOTPRepository returns an empty MemoryTOTPRepository.
+func NewMemoryTOTPRepository() *MemoryTOTPRepository {
+	return &MemoryTOTPRepository{
+		credentials: map[uint]*models.TOTPCredential{},
+		codes:       map[uint][]*models.RecoveryCode{},
+		nextID:      1,
+	}
+}
+
+// byID returns the credential with the ID. The caller holds the lock.
+func (r *MemoryTOTPRepository) byID(id uint) (*models.TOTPCredential, bool) {
+	for _, credential := range r.credentials {
+		if credential.ID == id {
+			return credential, true
+		}
+	}
+	return nil, false
+}
+
+// Find implements TOTPRepository.
+func (r *MemoryTOTPRepository) Find(userID uint, confirmed bool) (*models.TOTPCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[userID]
+	if !ok || (credential.ConfirmedAt != nil) != confirmed {
+		return nil, ErrNotFound
+	}
+	found := *credential
+	return &found, nil
+}
+
+// Enroll implements TOTPRepository.
+func (r *MemoryTOTPRepository) Enroll(userID uint, secret []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.credentials[userID] = &models.TOTPCredential{
+		ID:        r.nextID,
+		UserID:    userID,
+		Secret:    append([]byte(nil), secret...),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.nextID++
+	return nil
+}
+
+// UseStep implements TOTPRepository.
+func (r *MemoryTOTPRepository) UseStep(id uint, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.byID(id)
+	if !ok || credential.LastUsedStep >= step {
+		return ErrNotFound
+	}
+	credential.LastUsedStep = step
+	credential.UpdatedAt = time.Now()
+	return nil
+}
+
+// Confirm implements TOTPRepository.
+func (r *MemoryTOTPRepository) Confirm(id uint, now time.Time, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.byID(id)
+	if !ok || credential.ConfirmedAt != nil {
+		return ErrNotFound
+	}
+	credential.ConfirmedAt = &now
+	credential.UpdatedAt = now
+	r.replaceCodes(credential.UserID, codeHashes)
+	return nil
+}
+
+// ReplaceRecoveryCodes implements TOTPRepository.
+func (r *MemoryTOTPRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replaceCodes(userID, codeHashes)
+	return nil
+}
+
+// replaceCodes replaces the recovery codes of the user. The caller holds
+// the lock.
+func (r *MemoryTOTPRepository) replaceCodes(userID uint, codeHashes []string) {
+	codes := make([]*models.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = &models.RecoveryCode{ID: r.nextID, UserID: userID, CodeHash: hash, CreatedAt: time.Now()}
+		r.nextID++
+	}
+	r.codes[userID] = codes
+}
+
+// UseRecoveryCode implements TOTPRepository.
+func (r *MemoryTOTPRepository) UseRecoveryCode(userID uint, codeHash string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range r.codes[userID] {
+		if code.CodeHash == codeHash && code.UsedAt == nil {
+			code.UsedAt = &now
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// Delete implements TOTPRepository.
+func (r *MemoryTOTPRepository) Delete(userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.credentials, userID)
+	delete(r.codes, userID)
+	return nil
+}
+
+// MemoryWebAuthnRepository is a WebAuthnRepository that keeps the
+// credentials and challenges in memory.
+type MemoryWebAuthnRepository struct {
+	mu          sync.Mutex
+	credentials map[uint]*models.WebAuthnCredential
+	challenges  map[uint]*models.WebAuthnChallenge
+	nextID      uint
+}
+
+// NewMemoryWebAuthnRepository returns an empty MemoryWebAuthnRepository.
+func NewMemoryWebAuthnRepository() *MemoryWebAuthnRepository {
+	return &MemoryWebAuthnRepository{
+		credentials: map[uint]*models.WebAuthnCredential{},
+		challenges:  map[uint]*models.WebAuthnChallenge{},
+		nextID:      1,
+	}
+}
+
+// CreateChallenge implements WebAuthnRepository.
+func (r *MemoryWebAuthnRepository) CreateChallenge(challenge *models.WebAuthnChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.challenges {
+		if other.ChallengeHash == challenge.ChallengeHash {
+			return ErrDuplicate
+		}
+	}
+
+	challenge.ID = r.nextID
+	if challenge.CreatedAt.IsZero() {
+		challenge.CreatedAt = time.Now()
+	}
+	r.nextID++
+
+	stored := *challenge
+	r.challenges[stored.ID] = &stored
+	return nil
+}
+
+// ConsumeChallenge implements WebAuthnRepository.
+func (r *MemoryWebAuthnRepository) ConsumeChallenge(challengeHash, ceremony string, userID *uint, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, challenge := range r.challenges {
+		if challenge.ChallengeHash != challengeHash || challenge.Ceremony != ceremony || !challenge.ExpiresAt.After(now) {
+			continue
+		}
+		if userID != nil && (challenge.UserID == nil || *challenge.UserID != *userID) {
+			continue
+		}
+		delete(r.challenges, id)
+		return nil
+	}
+	return ErrNotFound
+}
+
+// DeleteExpiredChallenges implements WebAuthnRepository.
+func (r *MemoryWebAuthnRepository) DeleteExpiredChallenges(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, challenge := range r.challenges {
+		if !challenge.ExpiresAt.After(now) {
+			delete(r.challenges, id)
+		}
+	}
+	return nil
+}
+
+// FindCredential implements WebAuthnRepository.
+func (r *MemoryWebAuthnRepository) FindCredential(credentialID string) (*models.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, credential := range r.credentials {
+		if credential.CredentialID == credentialID {
+			found := *credential
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListCredentials implements WebAuthnRepository.
+func (r *MemoryWebAuthnRepository) ListCredentials(userID uint) ([]models.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var credentials []models.WebAuthnCredential
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, *credential)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].ID < credentials[j].ID })
+	return credentials, nil
+}
+
+// CreateCredential implements WebAuthnRepository.
+func (r *MemoryWebAuthnRepository) CreateCredential(credential *models.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.credentials {
+		if other.CredentialID == credential.CredentialID {
+			return ErrDuplicate
+		}
+	}
+
+	credential.ID = r.nextID
+	if credential.CreatedAt.IsZero() {
+		credential.CreatedAt = time.Now()
+	}
+	r.nextID++
+
+	stored := *credential
+	stored.User = models.User{}
+	r.credentials[stored.ID] = &stored
+	return nil
+}
+
+// UseCredential implements WebAuthnRepository.
+func (r *MemoryWebAuthnRepository) UseCredential(id uint, signCount uint32, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok {
+		return ErrNotFound
+	}
+	credential.SignCount = signCount
+	credential.LastUsedAt = &now
+	return nil
+}
+
+// DeleteCredential implements WebAuthnRepository.
+func (r *MemoryWebAuthnRepository) DeleteCredential(id, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok || credential.UserID != userID {
+		return ErrNotFound
+	}
+	delete(r.credentials, id)
+	return nil
+}
@@ -0,0 +1,383 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+)
+
+// MemoryConsentRepository is a ConsentRepository that keeps the consents in
+// memory.
+type MemoryConsentRepository struct {
+	mu       sync.Mutex
+	consents map[[2]uint]*models.OAuthConsent // user and client ID -> consent
+	nextID   uint
+}
+
+// NewMemoryConsentRepository returns an empty MemoryConsentRepository.
+func NewMemoryConsentRepository() *MemoryConsentRepository {
+	return &MemoryConsentRepository{consents: map[[2]uint]*models.OAuthConsent{}, nextID: 1}
+}
+
+// Find implements ConsentRepository.
+func (r *MemoryConsentRepository) Find(userID, clientID uint) (*models.OAuthConsent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	consent, ok := r.consents[[2]uint{userID, clientID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	found := *consent
+	return &found, nil
+}
+
+// Save implements ConsentRepository.
+func (r *MemoryConsentRepository) Save(consent *models.OAuthConsent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	key := [2]uint{consent.UserID, consent.ClientID}
+	if existing, ok := r.consents[key]; ok {
+		existing.Scope = consent.Scope
+		existing.UpdatedAt = now
+		consent.ID, consent.CreatedAt, consent.UpdatedAt = existing.ID, existing.CreatedAt, now
+		return nil
+	}
+
+	consent.ID = r.nextID
+	consent.CreatedAt, consent.UpdatedAt = now, now
+	r.nextID++
+
+	stored := *consent
+	stored.User, stored.Client = models.User{}, models.OAuthClient{}
+	r.consents[key] = &stored
+	return nil
+}
+
+// MemoryAuthorizationCodeRepository is an AuthorizationCodeRepository that
+// keeps the codes in memory.
+type MemoryAuthorizationCodeRepository struct {
+	mu     sync.Mutex
+	codes  map[uint]*models.AuthorizationCode
+	nextID uint
+}
+
+// NewMemoryAuthorizationCodeRepository returns an empty
+// MemoryAuthorizationCodeRepository.
+func NewMemoryAuthorizationCodeRepository() *MemoryAuthorizationCodeRepository {
+	return &MemoryAuthorizationCodeRepository{codes: map[uint]*models.AuthorizationCode{}, nextID: 1}
+}
+
+// Create implements AuthorizationCodeRepository.
+func (r *MemoryAuthorizationCodeRepository) Create(code *models.AuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.codes {
+		if other.CodeHash == code.CodeHash {
+			return ErrDuplicate
+		}
+	}
+
+	code.ID = r.nextID
+	if code.CreatedAt.IsZero() {
+		code.CreatedAt = time.Now()
+	}
+	r.nextID++
+
+	stored := *code
+	stored.User, stored.Client = models.User{}, models.OAuthClient{}
+	r.codes[stored.ID] = &stored
+	return nil
+}
+
+// FindByHash implements AuthorizationCodeRepository.
+func (r *MemoryAuthorizationCodeRepository) FindByHash(hash string) (*models.AuthorizationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range r.codes {
+		if code.CodeHash == hash {
+			found := *code
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Use implements AuthorizationCodeRepository.
+func (r *MemoryAuthorizationCodeRepository) Use(id uint, accessTokenID string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[id]
+	if !ok || code.UsedAt != nil {
+		return ErrNotFound
+	}
+	code.UsedAt = &now
+	code.AccessTokenID = accessTokenID
+	return nil
+}
+
+// DeleteExpired implements AuthorizationCodeRepository.
+func (r *MemoryAuthorizationCodeRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, code := range r.codes {
+		if !code.ExpiresAt.After(before) {
+			delete(r.codes, id)
+		}
+	}
+	return nil
+}
+
+// MemoryPasswordResetRepository is a PasswordResetRepository that keeps the
+// tokens in memory.
+type MemoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	tokens map[uint]*models.PasswordResetToken
+	nextID uint
+}
+
+// NewMemoryPasswordResetRepository returns an empty
+// MemoryPasswordResetRepository.
+func NewMemoryPasswordResetRepository() *MemoryPasswordResetRepository {
+	return &MemoryPasswordResetRepository{tokens: map[uint]*models.PasswordResetToken{}, nextID: 1}
+}
+
+// Replace implements PasswordResetRepository.
+func (r *MemoryPasswordResetRepository) Replace(token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, other := range r.tokens {
+		switch {
+		case other.UserID == token.UserID && other.UsedAt == nil:
+			delete(r.tokens, id)
+		case other.TokenHash == token.TokenHash:
+			return ErrDuplicate
+		}
+	}
+
+	token.ID = r.nextID
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	r.nextID++
+
+	stored := *token
+	stored.User = models.User{}
+	r.tokens[stored.ID] = &stored
+	return nil
+}
+
+// FindActive implements PasswordResetRepository.
+func (r *MemoryPasswordResetRepository) FindActive(hash string, now time.Time) (*models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == hash && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Use implements PasswordResetRepository.
+func (r *MemoryPasswordResetRepository) Use(id uint, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return ErrNotFound
+	}
+	token.UsedAt = &now
+	return nil
+}
+
+// MemoryEmailVerificationRepository is an EmailVerificationRepository that
+// keeps the tokens in memory.
+type MemoryEmailVerificationRepository struct {
+	mu     sync.Mutex
+	tokens map[uint]*models.EmailVerificationToken
+	nextID uint
+}
+
+// NewMemoryEmailVerificationRepository returns an empty
+// MemoryEmailVerificationRepository.
+func NewMemoryEmailVerificationRepository() *MemoryEmailVerificationRepository {
+	return &MemoryEmailVerificationRepository{tokens: map[uint]*models.EmailVerificationToken{}, nextID: 1}
+}
+
+// Replace implements EmailVerificationRepository.
+func (r *MemoryEmailVerificationRepository) Replace(token *models.EmailVerificationToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, other := range r.tokens {
+		switch {
+		case other.UserID == token.UserID && other.UsedAt == nil:
+			delete(r.tokens, id)
+		case other.TokenHash == token.TokenHash:
+			return ErrDuplicate
+		}
+	}
+
+	token.ID = r.nextID
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	r.nextID++
+
+	stored := *token
+	stored.User = models.User{}
+	r.tokens[stored.ID] = &stored
+	return nil
+}
+
+// FindActive implements EmailVerificationRepository.
+func (r *MemoryEmailVerificationRepository) FindActive(hash string, now time.Time) (*models.EmailVerificationToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == hash && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// FindPending implements EmailVerificationRepository.
+func (r *MemoryEmailVerificationRepository) FindPending(userID uint, email string, now time.Time) (*models.EmailVerificationToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pending *models.EmailVerificationToken
+	for _, token := range r.tokens {
+		if token.UserID != userID || token.Email == email || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+			continue
+		}
+		if pending == nil || token.ID > pending.ID {
+			pending = token
+		}
+	}
+	if pending == nil {
+		return nil, ErrNotFound
+	}
+	found := *pending
+	return &found, nil
+}
+
+// SentSince implements EmailVerificationRepository.
+func (r *MemoryEmailVerificationRepository) SentSince(userID uint, since time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.CreatedAt.After(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Use implements EmailVerificationRepository.
+func (r *MemoryEmailVerificationRepository) Use(id uint, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return ErrNotFound
+	}
+	token.UsedAt = &now
+	return nil
+}
+
+// MemoryPasswordHistoryRepository is a PasswordHistoryRepository that keeps
+// the former hashes in memory.
+type MemoryPasswordHistoryRepository struct {
+	mu      sync.Mutex
+	history map[uint][]models.PasswordHistory // user ID -> hashes, oldest first
+	nextID  uint
+}
+
+// NewMemoryPasswordHistoryRepository returns an empty
+// MemoryPasswordHistoryRepository.
+func NewMemoryPasswordHistoryRepository() *MemoryPasswordHistoryRepository {
+	return &MemoryPasswordHistoryRepository{history: map[uint][]models.PasswordHistory{}, nextID: 1}
+}
+
+// Recent implements PasswordHistoryRepository.
+func (r *MemoryPasswordHistoryRepository) Recent(userID uint, n int) ([]models.PasswordHistory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history := append([]models.PasswordHistory(nil), r.history[userID]...)
+	sort.Slice(history, func(i, j int) bool { return history[i].ID > history[j].ID })
+	if n >= 0 && n < len(history) {
+		history = history[:n]
+	}
+	return history, nil
+}
+
+// Add implements PasswordHistoryRepository.
+func (r *MemoryPasswordHistoryRepository) Add(userID uint, hash []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.history[userID] = append(r.history[userID], models.PasswordHistory{
+		ID:           r.nextID,
+		UserID:       userID,
+		PasswordHash: bytes.Clone(hash),
+		CreatedAt:    time.Now(),
+	})
+	r.nextID++
+	return nil
+}
+
+// Prune implements PasswordHistoryRepository.
+func (r *MemoryPasswordHistoryRepository) Prune(userID uint, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if keep < 0 {
+		keep = 0
+	}
+	if history := r.history[userID]; len(history) > keep {
+		r.history[userID] = append([]models.PasswordHistory(nil), history[len(history)-keep:]...)
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository
+
+// repository.go defines the repositories the server keeps its data in, so
+// handlers do not depend on a particular database and tests can run
+// against memory.
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+)
+
+var (
+	// ErrNotFound is returned when no record matches.
+	ErrNotFound = errors.New("repository: record not found")
+	// ErrDuplicate is returned when a record would take a unique value,
+	// such as the username or email of a user, that is already taken.
+	ErrDuplicate = errors.New("repository: duplicate record")
+)
+
+// DeletedFilter selects which soft deleted records a listing includes.
+type DeletedFilter int
+
+const (
+	// ExcludeDeleted leaves soft deleted records out.
+	ExcludeDeleted DeletedFilter = iota
+	// IncludeDeleted lists soft deleted records along with the others.
+	IncludeDeleted
+	// OnlyDeleted lists soft deleted records only.
+	OnlyDeleted
+)
+
+// UserFilter selects the users listed by UserRepository.List. Search
+// matches a part of the username, email or name, ignoring case, and nil
+// flags match either value.
+type UserFilter struct {
+	Search        string
+	IsActive      *bool
+	IsStaff       *bool
+	IsSuperuser   *bool
+	EmailVerified *bool
+	Deleted       DeletedFilter
+	Offset        int
+	Limit         int
+}
+
+// UserRepository stores the users. Lookups leave soft deleted users out
+// unless told otherwise and return ErrNotFound if no user matches. The
+// returned users are copies, changing them does not change the stored
+// users.
+//
+// Fields are changed with Update, which takes a map of column names so zero
+// values are written as well:
+//
+//	err := users.Update(user.ID, map[string]interface{}{"is_active": false})
+type UserRepository interface {
+	// FindByID returns the user with the ID, including soft deleted users
+	// if withDeleted is set.
+	FindByID(id uint, withDeleted bool) (*models.User, error)
+	// FindByEmail returns the user with the email.
+	FindByEmail(email string) (*models.User, error)
+	// FindByUsername returns the user with the username.
+	FindByUsername(username string) (*models.User, error)
+	// EmailTaken reports whether a user other than the one with the ID,
+	// including soft deleted users, has the email.
+	EmailTaken(email string, exceptID uint) (bool, error)
+	// UsernameTaken reports whether a user other than the one with the ID,
+	// including soft deleted users, has the username.
+	UsernameTaken(username string, exceptID uint) (bool, error)
+	// List returns the users matching the filter ordered by ID, along with
+	// how many users match regardless of the offset and limit.
+	List(filter UserFilter) ([]models.User, int64, error)
+	// Create stores a new user and sets its ID. It returns ErrDuplicate if
+	// the username or email is taken.
+	Create(user *models.User) error
+	// Update sets the columns of the user with the ID. It returns
+	// ErrDuplicate if the new username or email is taken.
+	Update(id uint, fields map[string]interface{}) error
+	// ReplacePasswordHash replaces the password hash of the user with the
+	// ID if it is still current. It returns ErrNotFound if there is no
+	// such user or the password hash changed in the meantime.
+	ReplacePasswordHash(id uint, current, hash []byte) error
+	// IncrementTokenVersion raises the token version of the user with the
+	// ID, soft deleted or not, revoking the tokens issued so far.
+	IncrementTokenVersion(id uint) error
+	// Delete soft deletes the user with the ID.
+	Delete(id uint) error
+	// Restore restores the soft deleted user with the ID.
+	Restore(id uint) error
+}
+
+// SessionRepository stores the sessions started by logins. A session is
+// active until it is revoked or expires. Lookups return ErrNotFound if no
+// active session matches.
+type SessionRepository interface {
+	// Create stores a new session and sets its ID.
+	Create(session *models.Session) error
+	// FindActive returns the session with the ID if it belongs to the user
+	// and is active at now.
+	FindActive(id, userID uint, now time.Time) (*models.Session, error)
+	// ListActive returns the sessions of the user active at now, the most
+	// recently seen first.
+	ListActive(userID uint, now time.Time) ([]models.Session, error)
+	// Touch records that the session with the ID was seen from the IP at
+	// now. If expiresAt is not zero the session is extended until then.
+	Touch(id uint, ip string, now, expiresAt time.Time) error
+	// Revoke revokes the active session with the ID of the user along with
+	// its refresh tokens. It returns ErrNotFound if there is no such
+	// session.
+	Revoke(id, userID uint, now time.Time) error
+	// RevokeAll revokes every session and every refresh token of the user,
+	// including the refresh tokens not tied to a session.
+	RevokeAll(userID uint, now time.Time) error
+}
+
+// RefreshTokenRepository stores the refresh tokens. Only the hashes of the
+// tokens are stored.
+type RefreshTokenRepository interface {
+	// Create stores a new refresh token and sets its ID.
+	Create(token *models.RefreshToken) error
+	// FindByHash returns the refresh token with the hash, used and revoked
+	// or not.
+	FindByHash(hash string) (*models.RefreshToken, error)
+	// Rotate marks the unused refresh token with the ID as used at now and
+	// stores next, its successor, and sets its ID, all at once. It returns
+	// ErrNotFound if the token was used already, so only one of several
+	// concurrent callers gets to rotate a token.
+	Rotate(id uint, now time.Time, next *models.RefreshToken) error
+	// RevokeFamily revokes the refresh tokens of the family that are not
+	// revoked yet.
+	RevokeFamily(familyID string, now time.Time) error
+}
+
+// RoleRepository stores the roles, their permissions and their assignment
+// to users. Roles are returned with their permissions, and lookups return
+// ErrNotFound if no role matches.
+type RoleRepository interface {
+	// FindByName returns the role with the name.
+	FindByName(name string) (*models.Role, error)
+	// List returns the roles ordered by name.
+	List() ([]models.Role, error)
+	// ListPermissions returns the permissions ordered by name.
+	ListPermissions() ([]models.Permission, error)
+	// ForUser returns the roles assigned to the user with the ID along
+	// with the roles with the names, such as the ones granted by the flags
+	// of the user.
+	ForUser(userID uint, names []string) ([]models.Role, error)
+	// Create stores a new role with the named permissions, creating the
+	// ones that do not exist yet, and sets its ID and permissions. It
+	// returns ErrDuplicate if the name is taken. Permission names are not
+	// validated.
+	Create(role *models.Role, permissions []string) error
+	// Update changes the description of the role if description is not nil
+	// and replaces its permissions with the named ones if permissions is
+	// not nil. The role is updated in place.
+	Update(role *models.Role, description *string, permissions *[]string) error
+	// Delete deletes the role and removes it from the users it was
+	// assigned to.
+	Delete(role *models.Role) error
+	// EnsurePermissions creates the permissions whose names do not exist
+	// yet. The existing ones are left as they are.
+	EnsurePermissions(permissions []models.Permission) error
+	// AssignedNames returns the names of the roles assigned to the user
+	// with the ID, ordered by name. Roles granted by the flags of the user
+	// are not included.
+	AssignedNames(userID uint) ([]string, error)
+	// Assign assigns the role to the user with the ID. Assigning a role
+	// twice is not an error.
+	Assign(userID uint, role *models.Role) error
+	// Unassign removes the role from the user with the ID.
+	Unassign(userID uint, role *models.Role) error
+}
+
+// ClientRepository stores the OAuth clients. Lookups include disabled
+// clients and return ErrNotFound if no client matches.
+type ClientRepository interface {
+	// FindByClientID returns the client with the client ID.
+	FindByClientID(clientID string) (*models.OAuthClient, error)
+	// List returns the clients ordered by ID.
+	List() ([]models.OAuthClient, error)
+	// Create stores a new client and sets its ID. It returns ErrDuplicate
+	// if the client ID is taken.
+	Create(client *models.OAuthClient) error
+	// Update sets the columns of the client with the ID.
+	Update(id uint, fields map[string]interface{}) error
+}
+
+// TOTPRepository stores the TOTP credentials and the recovery codes of the
+// users. A user has one credential at most, which is pending until it is
+// confirmed. Only the hashes of recovery codes are stored.
+type TOTPRepository interface {
+	// Find returns the confirmed credential of the user if confirmed is
+	// set, or the pending one otherwise.
+	Find(userID uint, confirmed bool) (*models.TOTPCredential, error)
+	// Enroll replaces the credential of the user with a new pending one
+	// with the encrypted secret.
+	Enroll(userID uint, secret []byte) error
+	// UseStep records that a code of the time step was accepted for the
+	// credential with the ID. It returns ErrNotFound if a code of the step
+	// or a later one was accepted already, so a code is only accepted
+	// once.
+	UseStep(id uint, step int64) error
+	// Confirm confirms the pending credential with the ID at now and
+	// replaces the recovery codes of its user with the ones with the
+	// hashes, all at once. It returns ErrNotFound if the credential is not
+	// pending.
+	Confirm(id uint, now time.Time, codeHashes []string) error
+	// ReplaceRecoveryCodes replaces the recovery codes of the user with
+	// the ones with the hashes.
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	// UseRecoveryCode marks the unused recovery code of the user with the
+	// hash as used at now. It returns ErrNotFound if there is none.
+	UseRecoveryCode(userID uint, codeHash string, now time.Time) error
+	// Delete deletes the credential and the recovery codes of the user.
+	Delete(userID uint) error
+}
+
+// WebAuthnRepository stores the WebAuthn credentials of the users and the
+// challenges of the ceremonies in progress. Only the hashes of challenges
+// are stored.
+type WebAuthnRepository interface {
+	// CreateChallenge stores a new challenge and sets its ID.
+	CreateChallenge(challenge *models.WebAuthnChallenge) error
+	// ConsumeChallenge deletes the challenge of the ceremony with the hash
+	// if it did not expire at now and, unless userID is nil, belongs to
+	// the user. It returns ErrNotFound if there is no such challenge, so a
+	// challenge is only consumed once.
+	ConsumeChallenge(challengeHash, ceremony string, userID *uint, now time.Time) error
+	// DeleteExpiredChallenges deletes the challenges that expired at now.
+	DeleteExpiredChallenges(now time.Time) error
+	// FindCredential returns the credential with the credential ID.
+	FindCredential(credentialID string) (*models.WebAuthnCredential, error)
+	// ListCredentials returns the credentials of the user ordered by ID.
+	ListCredentials(userID uint) ([]models.WebAuthnCredential, error)
+	// CreateCredential stores a new credential and sets its ID. It returns
+	// ErrDuplicate if the credential ID is taken.
+	CreateCredential(credential *models.WebAuthnCredential) error
+	// UseCredential records that the credential with the ID was used at
+	// now and reported the signature counter.
+	UseCredential(id uint, signCount uint32, now time.Time) error
+	// DeleteCredential deletes the credential with the ID of the user. It
+	// returns ErrNotFound if the user has no such credential.
+	DeleteCredential(id, userID uint) error
+}
+
+// ConsentRepository stores the scopes the users granted to OAuth clients.
+// Lookups return ErrNotFound if the user granted nothing to the client.
+type ConsentRepository interface {
+	// Find returns the consent of the user to the client with the ID.
+	Find(userID, clientID uint) (*models.OAuthConsent, error)
+	// Save stores the consent, replacing the scope of the one the user
+	// gave the client before.
+	Save(consent *models.OAuthConsent) error
+}
+
+// AuthorizationCodeRepository stores the OAuth authorization codes. Only
+// the hashes of the codes are stored.
+type AuthorizationCodeRepository interface {
+	// Create stores a new code and sets its ID.
+	Create(code *models.AuthorizationCode) error
+	// FindByHash returns the code with the hash, used or not.
+	FindByHash(hash string) (*models.AuthorizationCode, error)
+	// Use marks the unused code with the ID as used at now and exchanged
+	// for the access token with the ID. It returns ErrNotFound if the
+	// code was used already, so only one of several concurrent callers
+	// gets to use a code.
+	Use(id uint, accessTokenID string, now time.Time) error
+	// DeleteExpired deletes the codes that expired before the given time.
+	DeleteExpired(before time.Time) error
+}
+
+// PasswordResetRepository stores the password reset tokens. Only the
+// hashes of the tokens are stored.
+type PasswordResetRepository interface {
+	// Replace deletes the unused tokens of the user of the token and
+	// stores the token, setting its ID.
+	Replace(token *models.PasswordResetToken) error
+	// FindActive returns the token with the hash if it is unused and did
+	// not expire at now.
+	FindActive(hash string, now time.Time) (*models.PasswordResetToken, error)
+	// Use marks the unused token with the ID as used at now. It returns
+	// ErrNotFound if the token was used already.
+	Use(id uint, now time.Time) error
+}
+
+// EmailVerificationRepository stores the email verification tokens. Only
+// the hashes of the tokens are stored.
+type EmailVerificationRepository interface {
+	// Replace deletes the unused tokens of the user of the token and
+	// stores the token, setting its ID.
+	Replace(token *models.EmailVerificationToken) error
+	// FindActive returns the token with the hash if it is unused and did
+	// not expire at now.
+	FindActive(hash string, now time.Time) (*models.EmailVerificationToken, error)
+	// FindPending returns the newest token of the user that is unused,
+	// did not expire at now and was sent to another address than email.
+	FindPending(userID uint, email string, now time.Time) (*models.EmailVerificationToken, error)
+	// SentSince reports whether a token was created for the user after
+	// the given time.
+	SentSince(userID uint, since time.Time) (bool, error)
+	// Use marks the unused token with the ID as used at now. It returns
+	// ErrNotFound if the token was used already.
+	Use(id uint, now time.Time) error
+}
+
+// PasswordHistoryRepository stores the former password hashes of the users.
+type PasswordHistoryRepository interface {
+	// Recent returns the last n former hashes of the user, newest first.
+	Recent(userID uint, n int) ([]models.PasswordHistory, error)
+	// Add stores a former hash of the user.
+	Add(userID uint, hash []byte) error
+	// Prune deletes the former hashes of the user but the keep newest.
+	Prune(userID uint, keep int) error
+}
+
+// Repositories holds a repository of every kind. The repositories of a set
+// share their backing, as the records of one refer to the records of
+// another.
+type Repositories struct {
+	Users              UserRepository
+	Sessions           SessionRepository
+	RefreshTokens      RefreshTokenRepository
+	Roles              RoleRepository
+	Clients            ClientRepository
+	TOTP               TOTPRepository
+	WebAuthn           WebAuthnRepository
+	Consents           ConsentRepository
+	AuthorizationCodes AuthorizationCodeRepository
+	PasswordResets     PasswordResetRepository
+	EmailVerifications EmailVerificationRepository
+	PasswordHistory    PasswordHistoryRepository
+}
+
+// Empty reports whether r holds no repository.
+func (r Repositories) Empty() bool {
+	return r == Repositories{}
+}
+
+// Fill sets the repositories r does not hold to the ones of other.
+func (r *Repositories) Fill(other Repositories) {
+	if r.Users == nil {
+		r.Users = other.Users
+	}
+	if r.Sessions == nil {
+		r.Sessions = other.Sessions
+	}
+	if r.RefreshTokens == nil {
+		r.RefreshTokens = other.RefreshTokens
+	}
+	if r.Roles == nil {
+		r.Roles = other.Roles
+	}
+	if r.Clients == nil {
+		r.Clients = other.Clients
+	}
+	if r.TOTP == nil {
+		r.TOTP = other.TOTP
+	}
+	if r.WebAuthn == nil {
+		r.WebAuthn = other.WebAuthn
+	}
+	if r.Consents == nil {
+		r.Consents = other.Consents
+	}
+	if r.AuthorizationCodes == nil {
+		r.AuthorizationCodes = other.AuthorizationCodes
+	}
+	if r.PasswordResets == nil {
+		r.PasswordResets = other.PasswordResets
+	}
+	if r.EmailVerifications == nil {
+		r.EmailVerifications = other.EmailVerifications
+	}
+	if r.PasswordHistory == nil {
+		r.PasswordHistory = other.PasswordHistory
+	}
+}
@@ -0,0 +1,474 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// userRepositories returns a constructor for every UserRepository
+// implementation.
+func userRepositories() map[string]func(t *testing.T) repository.UserRepository {
+	return map[string]func(t *testing.T) repository.UserRepository{
+		"memory": func(t *testing.T) repository.UserRepository {
+			return repository.NewMemoryUserRepository()
+		},
+		"gorm": func(t *testing.T) repository.UserRepository {
+			return repository.NewGormUserRepository(openDB(t))
+		},
+	}
+}
+
+// repositories returns a constructor for every implementation of the
+// repositories.
+func repositories() map[string]func(t *testing.T) repository.Repositories {
+	return map[string]func(t *testing.T) repository.Repositories{
+		"memory": func(t *testing.T) repository.Repositories {
+			return repository.NewMemoryRepositories()
+		},
+		"gorm": func(t *testing.T) repository.Repositories {
+			return repository.NewGormRepositories(openDB(t))
+		},
+	}
+}
+
+// openDB returns a new database with the tables of the repositories.
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Permission{}, &models.Role{}, &models.User{},
+		&models.Session{}, &models.RefreshToken{}, &models.OAuthClient{},
+		&models.TOTPCredential{}, &models.RecoveryCode{},
+		&models.WebAuthnCredential{}, &models.WebAuthnChallenge{},
+		&models.OAuthConsent{}, &models.AuthorizationCode{},
+		&models.PasswordResetToken{}, &models.EmailVerificationToken{},
+		&models.PasswordHistory{},
+	))
+	return db
+}
+
+func newUser(username string) *models.User {
+	return &models.User{
+		Username:     username,
+		Name:         "Name of " + username,
+		Email:        username + "@example.com",
+		PasswordHash: []byte("hash"),
+	}
+}
+
+func TestUserRepository(t *testing.T) {
+	for name, newRepository := range userRepositories() {
+		t.Run(name, func(t *testing.T) {
+			users := newRepository(t)
+
+			alice := newUser("alice")
+			require.NoError(t, users.Create(alice))
+			require.NotZero(t, alice.ID)
+			require.True(t, alice.IsActive, "defaults apply")
+			require.False(t, alice.CreatedAt.IsZero())
+
+			require.ErrorIs(t, users.Create(newUser("alice")), repository.ErrDuplicate)
+			duplicate := newUser("bob")
+			duplicate.Email = alice.Email
+			require.ErrorIs(t, users.Create(duplicate), repository.ErrDuplicate)
+
+			bob := newUser("bob")
+			require.NoError(t, users.Create(bob))
+			require.NotEqual(t, alice.ID, bob.ID)
+
+			// Lookups
+			found, err := users.FindByEmail("alice@example.com")
+			require.NoError(t, err)
+			require.Equal(t, alice.ID, found.ID)
+			require.Equal(t, []byte("hash"), found.PasswordHash)
+			found, err = users.FindByUsername("bob")
+			require.NoError(t, err)
+			require.Equal(t, bob.ID, found.ID)
+			_, err = users.FindByEmail("nobody@example.com")
+			require.ErrorIs(t, err, repository.ErrNotFound)
+			_, err = users.FindByID(999, true)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+
+			// Returned users are copies
+			found.Name = "changed"
+			found, err = users.FindByID(bob.ID, false)
+			require.NoError(t, err)
+			require.Equal(t, "Name of bob", found.Name)
+
+			// Updates write zero values and keep usernames and emails unique
+			now := time.Now()
+			require.NoError(t, users.Update(bob.ID, map[string]interface{}{
+				"is_active":      false,
+				"email_verified": true,
+				"verified_at":    now,
+				"name":           "Robert",
+			}))
+			found, err = users.FindByID(bob.ID, false)
+			require.NoError(t, err)
+			require.False(t, found.IsActive)
+			require.True(t, found.EmailVerified)
+			require.NotNil(t, found.VerifiedAt)
+			require.Equal(t, "Robert", found.Name)
+			require.ErrorIs(t, users.Update(bob.ID, map[string]interface{}{"email": alice.Email}), repository.ErrDuplicate)
+			require.ErrorIs(t, users.Update(999, map[string]interface{}{"name": "nobody"}), repository.ErrNotFound)
+
+			taken, err := users.EmailTaken(alice.Email, alice.ID)
+			require.NoError(t, err)
+			require.False(t, taken)
+			taken, err = users.EmailTaken(alice.Email, bob.ID)
+			require.NoError(t, err)
+			require.True(t, taken)
+
+			// Password hashes are only replaced while they are current
+			require.NoError(t, users.ReplacePasswordHash(alice.ID, []byte("hash"), []byte("rehashed")))
+			require.ErrorIs(t, users.ReplacePasswordHash(alice.ID, []byte("hash"), []byte("stale")), repository.ErrNotFound)
+			found, err = users.FindByID(alice.ID, false)
+			require.NoError(t, err)
+			require.Equal(t, []byte("rehashed"), found.PasswordHash)
+
+			// Soft deleted users are only found when asked for, but keep
+			// their username and email taken
+			require.NoError(t, users.Delete(bob.ID))
+			require.ErrorIs(t, users.Delete(bob.ID), repository.ErrNotFound)
+			_, err = users.FindByEmail("bob@example.com")
+			require.ErrorIs(t, err, repository.ErrNotFound)
+			found, err = users.FindByID(bob.ID, true)
+			require.NoError(t, err)
+			require.True(t, found.DeletedAt.Valid)
+			taken, err = users.UsernameTaken("bob", alice.ID)
+			require.NoError(t, err)
+			require.True(t, taken)
+			require.ErrorIs(t, users.Update(bob.ID, map[string]interface{}{"name": "Bob"}), repository.ErrNotFound)
+
+			// Token versions are raised for soft deleted users too, so
+			// restoring them does not bring their tokens back
+			require.NoError(t, users.IncrementTokenVersion(bob.ID))
+			require.NoError(t, users.IncrementTokenVersion(bob.ID))
+			require.ErrorIs(t, users.IncrementTokenVersion(999), repository.ErrNotFound)
+			found, err = users.FindByID(bob.ID, true)
+			require.NoError(t, err)
+			require.EqualValues(t, 2, found.TokenVersion)
+
+			require.NoError(t, users.Restore(bob.ID))
+			require.ErrorIs(t, users.Restore(bob.ID), repository.ErrNotFound)
+			_, err = users.FindByEmail("bob@example.com")
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepositoryList(t *testing.T) {
+	for name, newRepository := range userRepositories() {
+		t.Run(name, func(t *testing.T) {
+			users := newRepository(t)
+
+			var ids []uint
+			for _, username := range []string{"carol", "dave", "erin", "frank"} {
+				user := newUser(username)
+				require.NoError(t, users.Create(user))
+				ids = append(ids, user.ID)
+			}
+			require.NoError(t, users.Update(ids[1], map[string]interface{}{"is_staff": true}))
+			require.NoError(t, users.Update(ids[2], map[string]interface{}{"name": "Erin 100%_!"}))
+			require.NoError(t, users.Delete(ids[3]))
+
+			yes := true
+			tests := []struct {
+				name   string
+				filter repository.UserFilter
+				want   []uint
+				total  int64
+			}{
+				{"all", repository.UserFilter{}, ids[:3], 3},
+				{"page", repository.UserFilter{Offset: 1, Limit: 1}, ids[1:2], 3},
+				{"past the end", repository.UserFilter{Offset: 10}, nil, 3},
+				{"search ignores case", repository.UserFilter{Search: "DAVE"}, ids[1:2], 1},
+				{"search escapes wildcards", repository.UserFilter{Search: "0%_!"}, ids[2:3], 1},
+				{"search matches wildcards literally", repository.UserFilter{Search: "n!%"}, nil, 0},
+				{"flag", repository.UserFilter{IsStaff: &yes}, ids[1:2], 1},
+				{"include deleted", repository.UserFilter{Deleted: repository.IncludeDeleted}, ids, 4},
+				{"only deleted", repository.UserFilter{Deleted: repository.OnlyDeleted}, ids[3:], 1},
+			}
+			for _, test := range tests {
+				list, total, err := users.List(test.filter)
+				require.NoError(t, err, test.name)
+				require.Equal(t, test.total, total, test.name)
+
+				var got []uint
+				for _, user := range list {
+					got = append(got, user.ID)
+				}
+				require.Equal(t, test.want, got, test.name)
+			}
+		})
+	}
+}
+
+func TestSessionRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			testSessionRepository(t, newRepositories(t))
+		})
+	}
+}
+
+func testSessionRepository(t *testing.T, repos repository.Repositories) {
+	alice, bob := newUser("alice"), newUser("bob")
+	require.NoError(t, repos.Users.Create(alice))
+	require.NoError(t, repos.Users.Create(bob))
+	sessions, tokens := repos.Sessions, repos.RefreshTokens
+
+	now := time.Now()
+	newSession := func(user *models.User, lastSeen time.Duration) *models.Session {
+		t.Helper()
+
+		session := &models.Session{UserID: user.ID, LastSeenAt: now.Add(lastSeen), ExpiresAt: now.Add(time.Hour)}
+		require.NoError(t, sessions.Create(session))
+		require.NoError(t, tokens.Create(&models.RefreshToken{
+			UserID: user.ID, FamilyID: "family", SessionID: &session.ID,
+			TokenHash: fmt.Sprintf("hash-%d", session.ID), ExpiresAt: session.ExpiresAt,
+		}))
+		return session
+	}
+	older, newer := newSession(alice, -time.Minute), newSession(alice, 0)
+	other := newSession(bob, 0)
+
+	// Sessions are only found for their user while they are active
+	found, err := sessions.FindActive(older.ID, alice.ID, now)
+	require.NoError(t, err)
+	require.Equal(t, older.ID, found.ID)
+	_, err = sessions.FindActive(older.ID, bob.ID, now)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = sessions.FindActive(older.ID, alice.ID, now.Add(2*time.Hour))
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	// The most recently seen come first, and touching extends if asked to
+	require.NoError(t, sessions.Touch(older.ID, "192.0.2.1", now.Add(time.Second), time.Time{}))
+	list, err := sessions.ListActive(alice.ID, now)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, older.ID, list[0].ID)
+	require.Equal(t, "192.0.2.1", list[0].IP)
+	require.NoError(t, sessions.Touch(older.ID, "192.0.2.1", now, now.Add(3*time.Hour)))
+	_, err = sessions.FindActive(older.ID, alice.ID, now.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	// Revoking a session revokes its refresh tokens, but only for its user
+	require.ErrorIs(t, sessions.Revoke(older.ID, bob.ID, now), repository.ErrNotFound)
+	require.NoError(t, sessions.Revoke(older.ID, alice.ID, now))
+	require.ErrorIs(t, sessions.Revoke(older.ID, alice.ID, now), repository.ErrNotFound)
+	token, err := tokens.FindByHash(fmt.Sprintf("hash-%d", older.ID))
+	require.NoError(t, err)
+	require.NotNil(t, token.RevokedAt)
+	token, err = tokens.FindByHash(fmt.Sprintf("hash-%d", newer.ID))
+	require.NoError(t, err)
+	require.Nil(t, token.RevokedAt)
+
+	// Revoking every session leaves the other users alone
+	require.NoError(t, tokens.Create(&models.RefreshToken{UserID: alice.ID, FamilyID: "sessionless", TokenHash: "sessionless", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, sessions.RevokeAll(alice.ID, now))
+	list, err = sessions.ListActive(alice.ID, now)
+	require.NoError(t, err)
+	require.Empty(t, list)
+	token, err = tokens.FindByHash("sessionless")
+	require.NoError(t, err)
+	require.NotNil(t, token.RevokedAt)
+	_, err = sessions.FindActive(other.ID, bob.ID, now)
+	require.NoError(t, err)
+}
+
+func TestRefreshTokenRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			testRefreshTokenRepository(t, newRepositories(t))
+		})
+	}
+}
+
+func testRefreshTokenRepository(t *testing.T, repos repository.Repositories) {
+	user := newUser("alice")
+	require.NoError(t, repos.Users.Create(user))
+	tokens := repos.RefreshTokens
+
+	now := time.Now()
+	first := &models.RefreshToken{UserID: user.ID, FamilyID: "family", TokenHash: "first", ExpiresAt: now.Add(time.Hour)}
+	other := &models.RefreshToken{UserID: user.ID, FamilyID: "other", TokenHash: "other", ExpiresAt: now.Add(time.Hour)}
+	for _, token := range []*models.RefreshToken{first, other} {
+		require.NoError(t, tokens.Create(token))
+	}
+	require.ErrorIs(t, tokens.Create(&models.RefreshToken{UserID: user.ID, FamilyID: "family", TokenHash: "first"}), repository.ErrDuplicate)
+	_, err := tokens.FindByHash("nothing")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	// A token is only rotated once, and a failed rotation uses nothing up
+	second := &models.RefreshToken{UserID: user.ID, FamilyID: "family", TokenHash: "second", ExpiresAt: now.Add(time.Hour)}
+	require.ErrorIs(t, tokens.Rotate(first.ID, now, &models.RefreshToken{UserID: user.ID, FamilyID: "family", TokenHash: "other"}), repository.ErrDuplicate)
+	found, err := tokens.FindByHash("first")
+	require.NoError(t, err)
+	require.Nil(t, found.UsedAt)
+	require.NoError(t, tokens.Rotate(first.ID, now, second))
+	require.NotZero(t, second.ID)
+	require.ErrorIs(t, tokens.Rotate(first.ID, now, &models.RefreshToken{UserID: user.ID, FamilyID: "family", TokenHash: "third"}), repository.ErrNotFound)
+	found, err = tokens.FindByHash("first")
+	require.NoError(t, err)
+	require.NotNil(t, found.UsedAt)
+	_, err = tokens.FindByHash("third")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	require.NoError(t, tokens.RevokeFamily("family", now))
+	for hash, revoked := range map[string]bool{"first": true, "second": true, "other": false} {
+		found, err := tokens.FindByHash(hash)
+		require.NoError(t, err)
+		require.Equal(t, revoked, found.RevokedAt != nil, hash)
+	}
+}
+
+func TestRoleRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			testRoleRepository(t, newRepositories(t))
+		})
+	}
+}
+
+func testRoleRepository(t *testing.T, repos repository.Repositories) {
+	user := newUser("alice")
+	require.NoError(t, repos.Users.Create(user))
+	roles := repos.Roles
+
+	support := &models.Role{Name: "support", Description: "Support"}
+	require.NoError(t, roles.Create(support, []string{"users:read", "users:write"}))
+	require.NotZero(t, support.ID)
+	require.Len(t, support.Permissions, 2)
+	require.ErrorIs(t, roles.Create(&models.Role{Name: "support"}, []string{"billing:read"}), repository.ErrDuplicate)
+	billing := &models.Role{Name: "billing"}
+	require.NoError(t, roles.Create(billing, []string{"users:read", "billing:read"}))
+
+	// Permissions are shared between roles and the failed create left none behind
+	permissions, err := roles.ListPermissions()
+	require.NoError(t, err)
+	require.Len(t, permissions, 3)
+	require.Equal(t, "billing:read", permissions[0].Name)
+
+	// Ensuring permissions creates the missing ones only
+	require.NoError(t, roles.EnsurePermissions([]models.Permission{
+		{Name: "users:read", Description: "changed"},
+		{Name: "audit:read", Description: "Query the audit log"},
+	}))
+	permissions, err = roles.ListPermissions()
+	require.NoError(t, err)
+	require.Len(t, permissions, 4)
+	require.Equal(t, "audit:read", permissions[0].Name)
+	require.Equal(t, "Query the audit log", permissions[0].Description)
+	require.Equal(t, "users:read", permissions[2].Name)
+	require.Empty(t, permissions[2].Description)
+
+	list, err := roles.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "billing", list[0].Name)
+	require.Len(t, list[0].Permissions, 2)
+	_, err = roles.FindByName("nobody")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	description := "Customer support"
+	require.NoError(t, roles.Update(support, &description, &[]string{"audit:read"}))
+	found, err := roles.FindByName("support")
+	require.NoError(t, err)
+	require.Equal(t, "Customer support", found.Description)
+	require.Len(t, found.Permissions, 1)
+	require.Equal(t, "audit:read", found.Permissions[0].Name)
+
+	// Assigning twice is fine, and the flag roles come along
+	assigned, err := roles.AssignedNames(user.ID)
+	require.NoError(t, err)
+	require.Empty(t, assigned)
+	require.NotNil(t, assigned)
+	require.NoError(t, roles.Assign(user.ID, support))
+	require.NoError(t, roles.Assign(user.ID, support))
+	assigned, err = roles.AssignedNames(user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"support"}, assigned)
+	forUser, err := roles.ForUser(user.ID, []string{"billing"})
+	require.NoError(t, err)
+	require.Len(t, forUser, 2)
+
+	require.NoError(t, roles.Unassign(user.ID, support))
+	assigned, err = roles.AssignedNames(user.ID)
+	require.NoError(t, err)
+	require.Empty(t, assigned)
+
+	// Deleting a role removes its assignments
+	require.NoError(t, roles.Assign(user.ID, billing))
+	require.NoError(t, roles.Delete(billing))
+	assigned, err = roles.AssignedNames(user.ID)
+	require.NoError(t, err)
+	require.Empty(t, assigned)
+	_, err = roles.FindByName("billing")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestClientRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			testClientRepository(t, newRepositories(t))
+		})
+	}
+}
+
+func testClientRepository(t *testing.T, repos repository.Repositories) {
+	clients := repos.Clients
+
+	first := &models.OAuthClient{ClientID: "first", Name: "First"}
+	second := &models.OAuthClient{ClientID: "second", Name: "Second"}
+	require.NoError(t, clients.Create(first))
+	require.NoError(t, clients.Create(second))
+	require.ErrorIs(t, clients.Create(&models.OAuthClient{ClientID: "first", Name: "Again"}), repository.ErrDuplicate)
+
+	now := time.Now()
+	require.NoError(t, clients.Update(first.ID, map[string]interface{}{"disabled_at": now}))
+	require.ErrorIs(t, clients.Update(999, map[string]interface{}{"name": "nobody"}), repository.ErrNotFound)
+
+	// Disabled clients are still found
+	found, err := clients.FindByClientID("first")
+	require.NoError(t, err)
+	require.NotNil(t, found.DisabledAt)
+	_, err = clients.FindByClientID("nothing")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	list, err := clients.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "first", list[0].ClientID)
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsentRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepositories(t)
+			user := newUser("alice")
+			require.NoError(t, repos.Users.Create(user))
+			client := &models.OAuthClient{ClientID: "client", Name: "Client"}
+			require.NoError(t, repos.Clients.Create(client))
+			consents := repos.Consents
+
+			_, err := consents.Find(user.ID, client.ID)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+
+			// Saving again replaces the scope
+			require.NoError(t, consents.Save(&models.OAuthConsent{UserID: user.ID, ClientID: client.ID, Scope: "openid"}))
+			require.NoError(t, consents.Save(&models.OAuthConsent{UserID: user.ID, ClientID: client.ID, Scope: "openid email"}))
+			found, err := consents.Find(user.ID, client.ID)
+			require.NoError(t, err)
+			require.Equal(t, "openid email", found.Scope)
+		})
+	}
+}
+
+func TestAuthorizationCodeRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepositories(t)
+			user := newUser("alice")
+			require.NoError(t, repos.Users.Create(user))
+			client := &models.OAuthClient{ClientID: "client", Name: "Client"}
+			require.NoError(t, repos.Clients.Create(client))
+			codes := repos.AuthorizationCodes
+
+			now := time.Now()
+			newCode := func(hash string, expiresAt time.Time) *models.AuthorizationCode {
+				return &models.AuthorizationCode{
+					CodeHash: hash, ClientID: client.ID, UserID: user.ID, RedirectURI: "https://example.com",
+					CodeChallenge: "challenge", CodeChallengeMethod: "S256", ExpiresAt: expiresAt,
+				}
+			}
+			code := newCode("code", now.Add(time.Minute))
+			require.NoError(t, codes.Create(code))
+			require.NoError(t, codes.Create(newCode("expired", now.Add(-2*time.Hour))))
+			require.ErrorIs(t, codes.Create(newCode("code", now)), repository.ErrDuplicate)
+
+			// A code is only used once
+			require.NoError(t, codes.Use(code.ID, "token", now))
+			require.ErrorIs(t, codes.Use(code.ID, "other", now), repository.ErrNotFound)
+			found, err := codes.FindByHash("code")
+			require.NoError(t, err)
+			require.NotNil(t, found.UsedAt)
+			require.Equal(t, "token", found.AccessTokenID)
+
+			require.NoError(t, codes.DeleteExpired(now.Add(-time.Hour)))
+			_, err = codes.FindByHash("expired")
+			require.ErrorIs(t, err, repository.ErrNotFound)
+			_, err = codes.FindByHash("code")
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPasswordResetRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepositories(t)
+			user := newUser("alice")
+			require.NoError(t, repos.Users.Create(user))
+			resets := repos.PasswordResets
+
+			// A new token replaces the unused ones
+			now := time.Now()
+			require.NoError(t, resets.Replace(&models.PasswordResetToken{UserID: user.ID, TokenHash: "first", ExpiresAt: now.Add(time.Hour)}))
+			second := &models.PasswordResetToken{UserID: user.ID, TokenHash: "second", ExpiresAt: now.Add(time.Hour)}
+			require.NoError(t, resets.Replace(second))
+			_, err := resets.FindActive("first", now)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+			found, err := resets.FindActive("second", now)
+			require.NoError(t, err)
+			require.Equal(t, second.ID, found.ID)
+			_, err = resets.FindActive("second", now.Add(2*time.Hour))
+			require.ErrorIs(t, err, repository.ErrNotFound)
+
+			// A token is only used once
+			require.NoError(t, resets.Use(second.ID, now))
+			require.ErrorIs(t, resets.Use(second.ID, now), repository.ErrNotFound)
+			_, err = resets.FindActive("second", now)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+		})
+	}
+}
+
+func TestEmailVerificationRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepositories(t)
+			user := newUser("alice")
+			require.NoError(t, repos.Users.Create(user))
+			verifications := repos.EmailVerifications
+
+			now := time.Now()
+			sent, err := verifications.SentSince(user.ID, now.Add(-time.Minute))
+			require.NoError(t, err)
+			require.False(t, sent)
+
+			// A new token replaces the unused ones
+			require.NoError(t, verifications.Replace(&models.EmailVerificationToken{UserID: user.ID, Email: user.Email, TokenHash: "first", ExpiresAt: now.Add(time.Hour)}))
+			change := &models.EmailVerificationToken{UserID: user.ID, Email: "new@example.com", TokenHash: "second", ExpiresAt: now.Add(time.Hour)}
+			require.NoError(t, verifications.Replace(change))
+			_, err = verifications.FindActive("first", now)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+			sent, err = verifications.SentSince(user.ID, now.Add(-time.Minute))
+			require.NoError(t, err)
+			require.True(t, sent)
+
+			// Only tokens for another address are pending
+			pending, err := verifications.FindPending(user.ID, user.Email, now)
+			require.NoError(t, err)
+			require.Equal(t, "new@example.com", pending.Email)
+			_, err = verifications.FindPending(user.ID, "new@example.com", now)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+
+			require.NoError(t, verifications.Use(change.ID, now))
+			require.ErrorIs(t, verifications.Use(change.ID, now), repository.ErrNotFound)
+			_, err = verifications.FindActive("second", now)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+			_, err = verifications.FindPending(user.ID, user.Email, now)
+			require.ErrorIs(t, err, repository.ErrNotFound)
+		})
+	}
+}
+
+func TestPasswordHistoryRepository(t *testing.T) {
+	for name, newRepositories := range repositories() {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepositories(t)
+			user := newUser("alice")
+			require.NoError(t, repos.Users.Create(user))
+			history := repos.PasswordHistory
+
+			for _, hash := range []string{"first", "second", "third"} {
+				require.NoError(t, history.Add(user.ID, []byte(hash)))
+			}
+			recent, err := history.Recent(user.ID, 2)
+			require.NoError(t, err)
+			require.Len(t, recent, 2)
+			require.Equal(t, []byte("third"), recent[0].PasswordHash)
+			require.Equal(t, []byte("second"), recent[1].PasswordHash)
+
+			require.NoError(t, history.Prune(user.ID, 1))
+			recent, err = history.Recent(user.ID, 10)
+			require.NoError(t, err)
+			require.Len(t, recent, 1)
+			require.Equal(t, []byte("third"), recent[0].PasswordHash)
+
+			require.NoError(t, history.Prune(user.ID, 0))
+			recent, err = history.Recent(user.ID, 10)
+			require.NoError(t, err)
+			require.Empty(t, recent)
+		})
+	}
+}
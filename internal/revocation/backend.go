@@ -0,0 +1,130 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package revocation
+
+// backend.go persists revocations.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// Backend persists the revocations of a Store.
+type Backend interface {
+	// Unexpired returns the revocations of single tokens that expire after
+	// now.
+	Unexpired(now time.Time) ([]models.RevokedToken, error)
+	// Create stores the revocation.
+	Create(entry *models.RevokedToken) error
+	// DeleteExpired deletes the revocations that expired at now and
+	// returns how many it deleted.
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// MemoryBackend keeps the revocations in memory. They are lost when the
+// process exits and are not shared between instances.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	entries []models.RevokedToken
+}
+
+// NewMemoryBackend creates an empty in-memory backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+// Unexpired returns the unexpired revocations.
+func (b *MemoryBackend) Unexpired(now time.Time) ([]models.RevokedToken, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var entries []models.RevokedToken
+	for _, entry := range b.entries {
+		if entry.ExpiresAt.After(now) && entry.JTI != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Create stores a copy of the revocation.
+func (b *MemoryBackend) Create(entry *models.RevokedToken) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry.ID = uint(len(b.entries) + 1)
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	b.entries = append(b.entries, *entry)
+	return nil
+}
+
+// DeleteExpired deletes the expired revocations.
+func (b *MemoryBackend) DeleteExpired(now time.Time) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	kept := b.entries[:0]
+	for _, entry := range b.entries {
+		if entry.ExpiresAt.After(now) {
+			kept = append(kept, entry)
+		}
+	}
+	deleted := int64(len(b.entries) - len(kept))
+	b.entries = kept
+	return deleted, nil
+}
+
+// DBBackend keeps the revocations in the revoked_tokens table, so they are
+// shared by every instance using the same database.
+type DBBackend struct {
+	db *gorm.DB
+}
+
+// NewDBBackend creates a backend that persists the revocations to db.
+func NewDBBackend(db *gorm.DB) *DBBackend {
+	return &DBBackend{db: db}
+}
+
+// Unexpired returns the unexpired revocations.
+func (b *DBBackend) Unexpired(now time.Time) ([]models.RevokedToken, error) {
+	var entries []models.RevokedToken
+	if err := b.db.Where("expires_at > ? AND jti <> ''", now).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Create inserts the revocation.
+func (b *DBBackend) Create(entry *models.RevokedToken) error {
+	return b.db.Create(entry).Error
+}
+
+// DeleteExpired deletes the expired revocations.
+func (b *DBBackend) DeleteExpired(now time.Time) (int64, error) {
+	result := b.db.Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
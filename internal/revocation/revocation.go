@@ -0,0 +1,156 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package revocation
+
+// revocation.go keeps track of revoked access tokens. Revocations are
+// persisted by a Backend and cached in memory, so checking a token on every
+// request does not hit the database. Every token of a user is revoked at
+// once by raising the token version of the user instead.
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+)
+
+// Store is a revocation list kept by a Backend with an in-memory cache in
+// front of it.
+type Store struct {
+	backend Backend
+
+	mu     sync.RWMutex
+	tokens map[string]time.Time // jti -> expiry of the revocation
+}
+
+// NewStore creates a revocation store that persists revocations to backend.
+// Call Load to warm the cache with the revocations already in the backend.
+func NewStore(backend Backend) *Store {
+	return &Store{
+		backend: backend,
+		tokens:  map[string]time.Time{},
+	}
+}
+
+// Load replaces the cache with the unexpired revocations from the backend.
+// The unexpired revocations of the cache are kept as well, so the ones made
+// while the backend is read are not lost.
+func (s *Store) Load() error {
+	now := time.Now()
+	entries, err := s.backend.Unexpired(now)
+	if err != nil {
+		return err
+	}
+
+	tokens := make(map[string]time.Time, len(entries))
+	for _, entry := range entries {
+		tokens[entry.JTI] = entry.ExpiresAt
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti, expiresAt := range s.tokens {
+		if expiresAt.After(now) {
+			tokens[jti] = expiresAt
+		}
+	}
+	s.tokens = tokens
+	return nil
+}
+
+// Revoke revokes the token with the given jti. expiresAt is the expiry of
+// the token itself, after which the revocation can be pruned.
+func (s *Store) Revoke(jti string, userID uint, expiresAt time.Time) error {
+	if jti == "" {
+		return errors.New("jti cannot be empty")
+	}
+
+	entry := &models.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt}
+	if err := s.backend.Create(entry); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.tokens[jti] = expiresAt
+	s.mu.Unlock()
+	return nil
+}
+
+// IsRevoked reports whether the token with the given jti was revoked.
+func (s *Store) IsRevoked(jti string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.tokens[jti]
+	return ok && jti != ""
+}
+
+// Prune deletes the revocations that expired before now from the backend
+// and the cache. It returns the number of revocations deleted from the
+// backend.
+func (s *Store) Prune(now time.Time) (int64, error) {
+	deleted, err := s.backend.DeleteExpired(now)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti, expiresAt := range s.tokens {
+		if !expiresAt.After(now) {
+			delete(s.tokens, jti)
+		}
+	}
+	return deleted, nil
+}
+
+// Run reloads the cache every syncInterval and prunes expired revocations
+// every pruneInterval until ctx is canceled. Reloading picks up revocations
+// made by other instances and commands sharing the same database, so
+// syncInterval bounds how long a revoked token is still accepted elsewhere
+// and has to be much shorter than the lifetime of access tokens. Token
+// versions are not cached, so revoking every token of a user is not
+// delayed.
+func (s *Store) Run(ctx context.Context, syncInterval, pruneInterval time.Duration) {
+	syncTicker := time.NewTicker(syncInterval)
+	defer syncTicker.Stop()
+	pruneTicker := time.NewTicker(pruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-syncTicker.C:
+			if err := s.Load(); err != nil {
+				log.Printf("could not reload revoked tokens: %v", err)
+			}
+		case now := <-pruneTicker.C:
+			if _, err := s.Prune(now); err != nil {
+				log.Printf("could not prune revoked tokens: %v", err)
+			}
+		}
+	}
+}
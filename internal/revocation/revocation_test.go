@@ -0,0 +1,107 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package revocation_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/revocation"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newStore(t *testing.T) (*revocation.Store, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.RevokedToken{}))
+	return revocation.NewStore(revocation.NewDBBackend(db)), db
+}
+
+func TestRevoke(t *testing.T) {
+	store, db := newStore(t)
+	now := time.Now()
+
+	require.Error(t, store.Revoke("", 1, now.Add(time.Hour)))
+	require.NoError(t, store.Revoke("jti-1", 1, now.Add(time.Hour)))
+
+	require.True(t, store.IsRevoked("jti-1"))
+	require.False(t, store.IsRevoked("jti-2"))
+	require.False(t, store.IsRevoked(""))
+
+	// A new store sharing the database picks the revocation up on load
+	other := revocation.NewStore(revocation.NewDBBackend(db))
+	require.False(t, other.IsRevoked("jti-1"))
+	require.NoError(t, other.Load())
+	require.True(t, other.IsRevoked("jti-1"))
+
+	// Loading keeps the revocations made in the meantime
+	require.NoError(t, store.Revoke("jti-3", 1, now.Add(time.Hour)))
+	require.NoError(t, db.Where("jti = ?", "jti-3").Delete(&models.RevokedToken{}).Error)
+	require.NoError(t, store.Load())
+	require.True(t, store.IsRevoked("jti-3"))
+}
+
+func TestPrune(t *testing.T) {
+	store, db := newStore(t)
+	now := time.Now()
+
+	require.NoError(t, store.Revoke("expired", 1, now.Add(-time.Minute)))
+	require.NoError(t, store.Revoke("active", 1, now.Add(time.Hour)))
+
+	deleted, err := store.Prune(now)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, deleted)
+	require.False(t, store.IsRevoked("expired"))
+	require.True(t, store.IsRevoked("active"))
+
+	var count int64
+	require.NoError(t, db.Model(&models.RevokedToken{}).Count(&count).Error)
+	require.EqualValues(t, 1, count)
+}
+
+func TestMemoryBackend(t *testing.T) {
+	backend := revocation.NewMemoryBackend()
+	store := revocation.NewStore(backend)
+	now := time.Now()
+
+	require.NoError(t, store.Revoke("expired", 1, now.Add(-time.Minute)))
+	require.NoError(t, store.Revoke("active", 1, now.Add(time.Hour)))
+
+	// A store sharing the backend picks the active revocation up on load
+	other := revocation.NewStore(backend)
+	require.NoError(t, other.Load())
+	require.True(t, other.IsRevoked("active"))
+	require.False(t, other.IsRevoked("expired"))
+
+	deleted, err := store.Prune(now)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, deleted)
+	entries, err := backend.Unexpired(now)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
@@ -0,0 +1,371 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// admin.go contains the admin routes managing users.
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/rbac"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultUsersPerPage = 20
+	maxUsersPerPage     = 100
+)
+
+// adminUserResponse is the JSON representation of a user for admins.
+func adminUserResponse(user *models.User) gin.H {
+	return gin.H{
+		"id":                      user.ID,
+		"username":                user.Username,
+		"name":                    user.Name,
+		"email":                   user.Email,
+		"email_verified":          user.EmailVerified,
+		"verified_at":             user.VerifiedAt,
+		"is_active":               user.IsActive,
+		"is_staff":                user.IsStaff,
+		"is_superuser":            user.IsSuperuser,
+		"password_reset_required": user.PasswordResetRequired,
+		"created_at":              user.CreatedAt,
+		"updated_at":              user.UpdatedAt,
+		"deleted_at":              user.DeletedAt,
+	}
+}
+
+// userParam loads the user identified by the id route parameter, including
+// soft deleted users if withDeleted is set. It responds with a 404 status
+// code and returns nil if there is no such user.
+func (s *Server) userParam(c *gin.Context, withDeleted bool) *models.User {
+	var user *models.User
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err == nil {
+		user, err = s.users.FindByID(uint(id), withDeleted)
+	}
+	if err != nil {
+		respondError(c, http.StatusNotFound, validation.MessageUserNotFound)
+		return nil
+	}
+	return user
+}
+
+// canManage checks that the principal may change the user: nobody can act
+// on their own account here, or on a user with permissions they do not
+// hold. It responds with an error and returns false otherwise.
+func (s *Server) canManage(c *gin.Context, user *models.User) bool {
+	if principal := MustPrincipal(c); principal.User != nil && principal.User.ID == user.ID {
+		respondError(c, http.StatusBadRequest, validation.MessageOwnAccount)
+		return false
+	}
+
+	grants, err := rbac.Resolve(s.roles, user)
+	if err != nil {
+		respondInternal(c, fmt.Errorf("could not resolve permissions of user %d: %w", user.ID, err))
+		return false
+	}
+	return s.holdsAll(c, grants.Permissions, validation.MessageCannotManageUser)
+}
+
+// flagRolePermissions returns the permissions of the role granted by a flag.
+func (s *Server) flagRolePermissions(name string) ([]string, error) {
+	role, err := s.roles.FindByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return permissionNames(role), nil
+}
+
+// ListUsers handles the /admin/users route.
+//
+// It returns a page of users ordered by ID. The query parameters page and
+// per_page select the page, q searches the username, email and name, and
+// is_active, is_staff, is_superuser and email_verified filter on the flags.
+// Soft deleted users are left out unless deleted is include or only.
+func (s *Server) ListUsers(c *gin.Context) {
+	var input inputs.ListUsersInput
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if input.Page == 0 {
+		input.Page = 1
+	}
+	if input.PerPage == 0 {
+		input.PerPage = defaultUsersPerPage
+	}
+	var errs validation.Errors
+	if input.Page < 1 {
+		errs.Add("page", validation.CodeInvalid)
+	}
+	if input.PerPage < 1 || input.PerPage > maxUsersPerPage {
+		errs.Add("per_page", validation.CodeInvalid)
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	filter := repository.UserFilter{
+		Search:        input.Search,
+		IsActive:      input.IsActive,
+		IsStaff:       input.IsStaff,
+		IsSuperuser:   input.IsSuperuser,
+		EmailVerified: input.EmailVerified,
+		Offset:        (input.Page - 1) * input.PerPage,
+		Limit:         input.PerPage,
+	}
+	switch input.Deleted {
+	case "", "exclude":
+	case "include":
+		filter.Deleted = repository.IncludeDeleted
+	case "only":
+		filter.Deleted = repository.OnlyDeleted
+	default:
+		respondInvalid(c, validation.Errors{{Field: "deleted", Code: validation.CodeInvalid}})
+		return
+	}
+
+	users, total, err := s.users.List(filter)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(users))
+	for i := range users {
+		response = append(response, adminUserResponse(&users[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"users":    response,
+		"page":     input.Page,
+		"per_page": input.PerPage,
+		"total":    total,
+	})
+}
+
+// GetUser handles the /admin/users/:id route. Soft deleted users are
+// returned as well, and locked_until tells until when failed logins locked
+// the account.
+func (s *Server) GetUser(c *gin.Context) {
+	user := s.userParam(c, true)
+	if user == nil {
+		return
+	}
+
+	lockedUntil, err := s.loginGuard.LockedUntil(user.Email, time.Now())
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	response := adminUserResponse(user)
+	response["locked_until"] = nil
+	if !lockedUntil.IsZero() {
+		response["locked_until"] = lockedUntil
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateUser handles the /admin/users/:id route.
+//
+// It takes a JSON payload with any of is_active, is_staff and is_superuser.
+// Changing a flag that grants a role requires holding the permissions of
+// that role. Deactivated users are signed out everywhere.
+func (s *Server) UpdateUser(c *gin.Context) {
+	var input inputs.UpdateUserInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	user := s.userParam(c, false)
+	if user == nil || !s.canManage(c, user) {
+		return
+	}
+
+	// A map is used so false values are written as well
+	updates := map[string]interface{}{}
+	if input.IsActive != nil && *input.IsActive != user.IsActive {
+		updates["is_active"] = *input.IsActive
+	}
+
+	flags := []struct {
+		column  string
+		role    string
+		value   *bool
+		current bool
+	}{
+		{"is_staff", rbac.RoleStaff, input.IsStaff, user.IsStaff},
+		{"is_superuser", rbac.RoleSuperuser, input.IsSuperuser, user.IsSuperuser},
+	}
+	for _, flag := range flags {
+		if flag.value == nil || *flag.value == flag.current {
+			continue
+		}
+
+		permissions, err := s.flagRolePermissions(flag.role)
+		if err != nil {
+			respondInternal(c, fmt.Errorf("could not load role %s: %w", flag.role, err))
+			return
+		}
+		if !s.canGrant(c, permissions) {
+			return
+		}
+		updates[flag.column] = *flag.value
+	}
+
+	if len(updates) > 0 {
+		if err := s.users.Update(user.ID, updates); err != nil {
+			respondInternal(c, fmt.Errorf("could not update user %d: %w", user.ID, err))
+			return
+		}
+		s.recordAdminAction(c, audit.ActionUserUpdate, audit.TypeUser, userID(user), audit.Metadata(updates))
+	}
+
+	if input.IsActive != nil && !*input.IsActive && user.IsActive {
+		if err := s.revokeUserTokens(user.ID); err != nil {
+			respondInternal(c, err)
+			return
+		}
+	}
+
+	user, err := s.users.FindByID(user.ID, false)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+// DeleteUser handles the /admin/users/:id route.
+//
+// The user is soft deleted, so it can be restored, and signed out
+// everywhere.
+func (s *Server) DeleteUser(c *gin.Context) {
+	user := s.userParam(c, false)
+	if user == nil || !s.canManage(c, user) {
+		return
+	}
+
+	if err := s.deleteUser(user); err != nil {
+		respondInternal(c, fmt.Errorf("could not delete user %d: %w", user.ID, err))
+		return
+	}
+
+	s.recordAdminAction(c, audit.ActionUserDelete, audit.TypeUser, userID(user), nil)
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// deleteUser soft deletes the user and signs it out everywhere.
+func (s *Server) deleteUser(user *models.User) error {
+	if err := s.users.Delete(user.ID); err != nil {
+		return err
+	}
+	return s.revokeUserTokens(user.ID)
+}
+
+// RestoreUser handles the /admin/users/:id/restore route.
+//
+// It restores a soft deleted user.
+func (s *Server) RestoreUser(c *gin.Context) {
+	user := s.userParam(c, true)
+	if user == nil {
+		return
+	}
+	if !user.DeletedAt.Valid {
+		respondError(c, http.StatusBadRequest, validation.MessageUserNotDeleted)
+		return
+	}
+	if !s.canManage(c, user) {
+		return
+	}
+
+	if err := s.users.Restore(user.ID); err != nil {
+		respondInternal(c, fmt.Errorf("could not restore user %d: %w", user.ID, err))
+		return
+	}
+	user.DeletedAt.Valid = false
+	s.recordAdminAction(c, audit.ActionUserRestore, audit.TypeUser, userID(user), nil)
+
+	c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+// UnlockUser handles the /admin/users/:id/unlock route.
+//
+// It forgets the failed logins of the user and lifts the lockout, so the
+// user can log in again right away.
+func (s *Server) UnlockUser(c *gin.Context) {
+	user := s.userParam(c, false)
+	if user == nil || !s.canManage(c, user) {
+		return
+	}
+
+	if err := s.loginGuard.Unlock(user.Email); err != nil {
+		respondInternal(c, fmt.Errorf("could not unlock user %d: %w", user.ID, err))
+		return
+	}
+
+	s.recordAdminAction(c, audit.ActionUserUnlock, audit.TypeUser, userID(user), nil)
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
+// ForcePasswordReset handles the /admin/users/:id/password-reset route.
+//
+// The user is signed out everywhere, cannot log in with the current
+// password anymore and is mailed a password reset link.
+func (s *Server) ForcePasswordReset(c *gin.Context) {
+	user := s.userParam(c, false)
+	if user == nil || !s.canManage(c, user) {
+		return
+	}
+
+	if err := s.users.Update(user.ID, map[string]interface{}{"password_reset_required": true}); err != nil {
+		respondInternal(c, fmt.Errorf("could not update user %d: %w", user.ID, err))
+		return
+	}
+
+	if err := s.revokeUserTokens(user.ID); err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	if err := s.sendPasswordReset(c, user); err != nil {
+		respondInternal(c, fmt.Errorf("could not send password reset to user %d: %w", user.ID, err))
+		return
+	}
+
+	s.recordAdminAction(c, audit.ActionUserPasswordReset, audit.TypeUser, userID(user), nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset required, a reset link has been sent"})
+}
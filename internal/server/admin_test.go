@@ -0,0 +1,163 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminUsers(t *testing.T) {
+	admin := accessCookie(signUpSuperuser(t, "adminusers", "adminusers@example.com")["access_token"])
+	first := signUpAndLogin(t, "adminlist1", "adminlist1@example.com")
+	signUpAndLogin(t, "adminlist2", "adminlist2@example.com")
+	signUpAndLogin(t, "adminlist3", "adminlist3@example.com")
+
+	rec, resp := doJSON(t, http.MethodGet, "/admin/users?q=adminlist&per_page=2", nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, float64(3), resp["total"])
+	require.Len(t, resp["users"], 2)
+	require.Nil(t, resp["users"].([]interface{})[0].(map[string]interface{})["password_hash"])
+
+	rec, resp = doJSON(t, http.MethodGet, "/admin/users?q=adminlist&per_page=2&page=2", nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, resp["users"], 1)
+	require.Equal(t, "adminlist3", resp["users"].([]interface{})[0].(map[string]interface{})["username"])
+
+	for _, query := range []string{"per_page=1000", "page=-1", "deleted=maybe", "is_active=perhaps"} {
+		rec, resp = doJSON(t, http.MethodGet, "/admin/users?"+query, nil, admin)
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+		require.NotEmpty(t, resp["message"])
+	}
+
+	rec, resp = doJSON(t, http.MethodGet, "/admin/users/999999", nil, admin)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, "User not found", resp["message"])
+
+	// Deactivated users are signed out and cannot log in
+	rec, resp = doJSON(t, http.MethodPatch, userPath(t, "adminlist1@example.com", ""), gin.H{"is_active": false}, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, false, resp["is_active"])
+	rec, _ = doJSON(t, http.MethodPost, "/auth/logout", nil, accessCookie(first["access_token"]))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "adminlist1@example.com", "password": "Password123"})
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, resp = doJSON(t, http.MethodGet, "/admin/users?q=adminlist&is_active=false", nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, float64(1), resp["total"])
+
+	rec, _ = doJSON(t, http.MethodPatch, userPath(t, "adminlist1@example.com", ""), gin.H{"is_active": true, "is_staff": true}, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	loginAgain(t, "adminlist1@example.com")
+	rec, resp = doJSON(t, http.MethodGet, "/admin/users?q=adminlist&is_staff=true", nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, float64(1), resp["total"])
+
+	rec, _ = doJSON(t, http.MethodPatch, userPath(t, "adminusers@example.com", ""), gin.H{"is_active": false}, admin)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Soft deleted users can be restored
+	rec, _ = doJSON(t, http.MethodDelete, userPath(t, "adminlist2@example.com", ""), nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "adminlist2@example.com", "password": "Password123"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	var deleted models.User
+	require.NoError(t, testDB.Unscoped().Where("email = ?", "adminlist2@example.com").First(&deleted).Error)
+	deletedPath := fmt.Sprintf("/admin/users/%d", deleted.ID)
+
+	rec, resp = doJSON(t, http.MethodGet, deletedPath, nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, resp["deleted_at"])
+	_, resp = doJSON(t, http.MethodGet, "/admin/users?q=adminlist", nil, admin)
+	require.Equal(t, float64(2), resp["total"])
+	_, resp = doJSON(t, http.MethodGet, "/admin/users?q=adminlist&deleted=only", nil, admin)
+	require.Equal(t, float64(1), resp["total"])
+	rec, _ = doJSON(t, http.MethodPatch, deletedPath, gin.H{"is_active": false}, admin)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec, resp = doJSON(t, http.MethodPost, deletedPath+"/restore", nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Nil(t, resp["deleted_at"])
+	loginAgain(t, "adminlist2@example.com")
+	rec, _ = doJSON(t, http.MethodPost, deletedPath+"/restore", nil, admin)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// A forced reset blocks the password until a new one is chosen
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "adminlist3@example.com", "/password-reset"), nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "adminlist3@example.com", "password": "Password123"})
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/password/reset", gin.H{
+		"token":            mailToken(t, "adminlist3@example.com"),
+		"password":         "Password456",
+		"confirm_password": "Password456",
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "adminlist3@example.com", "password": "Password456"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestAdminUsersPermissions(t *testing.T) {
+	admin := accessCookie(signUpSuperuser(t, "adminperms", "adminperms@example.com")["access_token"])
+	signUpAndLogin(t, "adminpermsstaff", "adminpermsstaff@example.com")
+	signUpAndLogin(t, "adminpermssupport", "adminpermssupport@example.com")
+	signUpAndLogin(t, "adminpermsmember", "adminpermsmember@example.com")
+
+	require.NoError(t, testDB.Model(&models.User{}).Where("email = ?", "adminpermsstaff@example.com").Update("is_staff", true).Error)
+	staff := accessCookie(loginAgain(t, "adminpermsstaff@example.com")["access_token"])
+
+	// Staff members may look but not touch
+	rec, _ := doJSON(t, http.MethodGet, "/admin/users", nil, staff)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = doJSON(t, http.MethodPatch, userPath(t, "adminpermsmember@example.com", ""), gin.H{"is_active": false}, staff)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/admin/roles", gin.H{"name": "user-admin", "permissions": []string{"users:read", "users:write"}}, admin)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "adminpermssupport@example.com", "/roles"), gin.H{"role": "user-admin"}, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	support := accessCookie(loginAgain(t, "adminpermssupport@example.com")["access_token"])
+
+	rec, _ = doJSON(t, http.MethodPatch, userPath(t, "adminpermsmember@example.com", ""), gin.H{"is_active": false}, support)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Nobody can promote past their own permissions or act on a more
+	// privileged user
+	rec, _ = doJSON(t, http.MethodPatch, userPath(t, "adminpermsmember@example.com", ""), gin.H{"is_superuser": true}, support)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = doJSON(t, http.MethodPatch, userPath(t, "adminpermsmember@example.com", ""), gin.H{"is_staff": true}, support)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = doJSON(t, http.MethodDelete, userPath(t, "adminperms@example.com", ""), nil, support)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "adminpermsstaff@example.com", "/password-reset"), nil, support)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "adminperms@example.com", "/unlock"), nil, support)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
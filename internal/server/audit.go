@@ -0,0 +1,263 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// audit.go records the audit events of the requests and serves the audit
+// log to admins.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// maxUserAgentLength is the length user agents are truncated to.
+const maxUserAgentLength = 512
+
+// Login methods recorded in the metadata of login events.
+const (
+	loginMethodPassword = "password"
+	loginMethodTOTP     = "totp"
+	loginMethodWebAuthn = "webauthn"
+	loginMethodOIDC     = "oidc"
+)
+
+func userID(user *models.User) string {
+	return strconv.FormatUint(uint64(user.ID), 10)
+}
+
+// Reasons recorded for failed logins.
+const (
+	loginFailureInvalidCredentials = "invalid_credentials"
+	loginFailureThrottled          = "throttled"
+	loginFailureDisabled           = "account_disabled"
+	loginFailurePasswordReset      = "password_reset_required"
+	loginFailureUnverified         = "email_unverified"
+	loginFailureInvalidCode        = "invalid_code"
+)
+
+// userAgent returns the user agent of the request, truncated to
+// maxUserAgentLength.
+func userAgent(c *gin.Context) string {
+	agent := c.Request.UserAgent()
+	if len(agent) > maxUserAgentLength {
+		agent = agent[:maxUserAgentLength]
+	}
+	return agent
+}
+
+// recordEvent queues an audit event about the request. The client IP and
+// user agent are filled in, and so is the actor from the authenticated
+// principal unless the event names one.
+func (s *Server) recordEvent(c *gin.Context, event *models.AuditEvent, metadata audit.Metadata) {
+	if principal, ok := CurrentPrincipal(c); ok && event.ActorType == "" {
+		if principal.User != nil {
+			event.ActorType, event.ActorID = audit.TypeUser, userID(principal.User)
+		} else {
+			event.ActorType, event.ActorID = audit.TypeClient, principal.Client.ClientID
+		}
+	}
+
+	event.IP = c.ClientIP()
+	event.UserAgent = userAgent(c)
+	s.auditLog.Write(event, metadata)
+}
+
+// recordAdminAction records an action the principal performed successfully
+// on the target.
+func (s *Server) recordAdminAction(c *gin.Context, action, targetType, targetID string, metadata audit.Metadata) {
+	s.recordEvent(c, &models.AuditEvent{
+		Action:     action,
+		Outcome:    audit.OutcomeSuccess,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}, metadata)
+}
+
+// recordLogin records a login attempt with the email. An empty reason means
+// the login succeeded and user is the actor, otherwise it says why it
+// failed. Failed attempts have no actor, but target the account of the email
+// if there is one, which is looked up when user is nil.
+func (s *Server) recordLogin(c *gin.Context, method, email string, user *models.User, reason string) {
+	event := &models.AuditEvent{Action: audit.ActionLogin, Outcome: audit.OutcomeSuccess}
+	metadata := audit.Metadata{"method": method, "email": email}
+	if reason != "" {
+		event.Outcome = audit.OutcomeFailure
+		metadata["reason"] = reason
+
+		if user == nil {
+			if found, err := s.users.FindByEmail(email); err == nil {
+				user = found
+			}
+		}
+	} else {
+		event.ActorType, event.ActorID = audit.TypeUser, userID(user)
+	}
+	if user != nil {
+		event.TargetType, event.TargetID = audit.TypeUser, userID(user)
+	}
+	s.recordEvent(c, event, metadata)
+}
+
+// auditEventResponse is the JSON representation of an audit event.
+func auditEventResponse(event *models.AuditEvent) gin.H {
+	var metadata json.RawMessage
+	if event.Metadata != "" {
+		metadata = json.RawMessage(event.Metadata)
+	}
+	return gin.H{
+		"id":          event.ID,
+		"created_at":  event.CreatedAt,
+		"actor_type":  event.ActorType,
+		"actor_id":    event.ActorID,
+		"target_type": event.TargetType,
+		"target_id":   event.TargetID,
+		"action":      event.Action,
+		"outcome":     event.Outcome,
+		"ip":          event.IP,
+		"user_agent":  event.UserAgent,
+		"metadata":    metadata,
+	}
+}
+
+// auditQuery binds the query parameters of the audit routes. It responds
+// with a 400 status code and returns false if they are invalid.
+func auditQuery(c *gin.Context) (audit.Query, bool) {
+	var input inputs.ListAuditEventsInput
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		respondBindError(c, err)
+		return audit.Query{}, false
+	}
+	if input.Limit < 0 || input.Limit > audit.MaxLimit {
+		respondInvalid(c, validation.Errors{{Field: "limit", Code: validation.CodeInvalid}})
+		return audit.Query{}, false
+	}
+
+	query := audit.Query{UserID: input.UserID, Action: input.Action, Cursor: input.Cursor, Limit: input.Limit}
+	times := []struct {
+		name  string
+		value string
+		time  *time.Time
+	}{
+		{"since", input.Since, &query.Since},
+		{"until", input.Until, &query.Until},
+	}
+	for _, t := range times {
+		if t.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, t.value)
+		if err != nil {
+			respondInvalid(c, validation.Errors{{Field: t.name, Code: validation.CodeInvalid}})
+			return audit.Query{}, false
+		}
+		*t.time = parsed
+	}
+	return query, true
+}
+
+// ListAuditEvents handles the /admin/audit-events route.
+//
+// It returns a page of events, newest first. The query parameters user_id,
+// action, since and until (RFC 3339 times) filter the events and limit sets
+// the size of the page. The response contains next_cursor, which is passed
+// as cursor to get the next page, until the last page.
+func (s *Server) ListAuditEvents(c *gin.Context) {
+	query, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+
+	events, next, err := audit.Find(s.auditEvents, query)
+	if errors.Is(err, audit.ErrInvalidCursor) {
+		respondInvalid(c, validation.Errors{{Field: "cursor", Code: validation.CodeInvalid}})
+		return
+	}
+	if err != nil {
+		respondInternal(c, fmt.Errorf("could not list audit events: %w", err))
+		return
+	}
+
+	response := make([]gin.H, 0, len(events))
+	for i := range events {
+		response = append(response, auditEventResponse(&events[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"events": response, "next_cursor": next})
+}
+
+// ExportAuditEvents handles the /admin/audit-events/export route.
+//
+// It takes the same filters as ListAuditEvents and streams every matching
+// event as JSON Lines, one event per line, newest first.
+func (s *Server) ExportAuditEvents(c *gin.Context) {
+	query, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+	query.Limit = audit.MaxLimit
+
+	// Check the cursor before the response is started
+	events, next, err := audit.Find(s.auditEvents, query)
+	if errors.Is(err, audit.ErrInvalidCursor) {
+		respondInvalid(c, validation.Errors{{Field: "cursor", Code: validation.CodeInvalid}})
+		return
+	}
+	if err != nil {
+		respondInternal(c, fmt.Errorf("could not export audit events: %w", err))
+		return
+	}
+
+	c.Header("Content-Type", "application/jsonl")
+	c.Header("Content-Disposition", `attachment; filename="audit-events.jsonl"`)
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	for {
+		for i := range events {
+			if err := encoder.Encode(auditEventResponse(&events[i])); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+		if next == "" {
+			return
+		}
+
+		query.Cursor = next
+		if events, next, err = audit.Find(s.auditEvents, query); err != nil {
+			// The status was sent already, so the export just ends early
+			log.Printf("could not export audit events: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// auditEvents polls the audit log until the query returns at least n
+// events, since events are written in the background.
+func auditEvents(t *testing.T, cookie *http.Cookie, query url.Values, n int) []map[string]interface{} {
+	t.Helper()
+
+	var events []map[string]interface{}
+	require.Eventually(t, func() bool {
+		rec, resp := doJSON(t, http.MethodGet, "/admin/audit-events?"+query.Encode(), nil, cookie)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		events = nil
+		for _, event := range resp["events"].([]interface{}) {
+			events = append(events, event.(map[string]interface{}))
+		}
+		return len(events) >= n
+	}, 5*time.Second, 50*time.Millisecond)
+	return events
+}
+
+func TestAuditLog(t *testing.T) {
+	admin := accessCookie(signUpSuperuser(t, "auditadmin", "auditadmin@example.com")["access_token"])
+	signUpAndLogin(t, "audituser", "audituser@example.com")
+	target := strings.TrimPrefix(userPath(t, "audituser@example.com", ""), "/admin/users/")
+
+	rec, _ := doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "audituser@example.com", "password": "Wrong1234"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = doJSON(t, http.MethodPatch, userPath(t, "audituser@example.com", ""), gin.H{"is_active": false}, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Newest first: the deactivation, the failed and successful logins and
+	// the signup
+	events := auditEvents(t, admin, url.Values{"user_id": {target}}, 4)
+	require.Len(t, events, 4)
+
+	actions := []string{}
+	for _, event := range events {
+		actions = append(actions, event["action"].(string)+"/"+event["outcome"].(string))
+	}
+	require.Equal(t, []string{"user.update/success", "user.login/failure", "user.login/success", "user.signup/success"}, actions)
+
+	update := events[0]
+	require.Equal(t, "user", update["actor_type"])
+	require.Equal(t, strings.TrimPrefix(userPath(t, "auditadmin@example.com", ""), "/admin/users/"), update["actor_id"])
+	require.Equal(t, "user", update["target_type"])
+	require.Equal(t, target, update["target_id"])
+	require.Equal(t, map[string]interface{}{"is_active": false}, update["metadata"])
+	require.Equal(t, "192.0.2.1", update["ip"])
+
+	failure := events[1]
+	require.Equal(t, "invalid_credentials", failure["metadata"].(map[string]interface{})["reason"])
+	require.Equal(t, "password", failure["metadata"].(map[string]interface{})["method"])
+
+	// Failed logins for unknown emails have no actor but keep the email
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "auditnobody@example.com", "password": "Wrong1234"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Eventually(t, func() bool {
+		for _, event := range auditEvents(t, admin, url.Values{"action": {"user.login"}, "limit": {"20"}}, 1) {
+			metadata := event["metadata"].(map[string]interface{})
+			if metadata["email"] == "auditnobody@example.com" {
+				return event["actor_id"] == "" && event["outcome"] == "failure"
+			}
+		}
+		return false
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// Pages are chained with the cursor
+	rec, resp := doJSON(t, http.MethodGet, "/admin/audit-events?limit=2&user_id="+target, nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, resp["events"], 2)
+	require.NotEmpty(t, resp["next_cursor"])
+	rec, resp = doJSON(t, http.MethodGet, "/admin/audit-events?limit=2&user_id="+target+"&cursor="+resp["next_cursor"].(string), nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, events[2]["id"], resp["events"].([]interface{})[0].(map[string]interface{})["id"])
+
+	// Time ranges
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	rec, resp = doJSON(t, http.MethodGet, "/admin/audit-events?since="+future, nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, resp["events"])
+	require.Empty(t, resp["next_cursor"])
+
+	for _, query := range []string{"since=yesterday", "until=1", "limit=1000", "cursor=nope!"} {
+		rec, resp = doJSON(t, http.MethodGet, "/admin/audit-events?"+query, nil, admin)
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+		require.NotEmpty(t, resp["message"])
+	}
+
+	// Export as JSON Lines
+	req := httptest.NewRequest(http.MethodGet, "/admin/audit-events/export?user_id="+target, nil)
+	req.AddCookie(admin)
+	exported := httptest.NewRecorder()
+	handler.ServeHTTP(exported, req)
+	require.Equal(t, http.StatusOK, exported.Code)
+	require.Equal(t, "application/jsonl", exported.Header().Get("Content-Type"))
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(exported.Body)
+	for scanner.Scan() {
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		lines = append(lines, event)
+	}
+	require.Equal(t, events, lines)
+}
+
+func TestAuditLogPermissions(t *testing.T) {
+	user := accessCookie(signUpAndLogin(t, "auditnosy", "auditnosy@example.com")["access_token"])
+	admin := accessCookie(signUpSuperuser(t, "auditwatcher", "auditwatcher@example.com")["access_token"])
+
+	for _, path := range []string{"/admin/audit-events", "/admin/audit-events/export"} {
+		rec, _ := doJSON(t, http.MethodGet, path, nil, user)
+		require.Equal(t, http.StatusForbidden, rec.Code, path)
+	}
+
+	// Denials are recorded too
+	actor := strings.TrimPrefix(userPath(t, "auditnosy@example.com", ""), "/admin/users/")
+	events := auditEvents(t, admin, url.Values{"user_id": {actor}, "action": {"access.denied"}}, 2)
+	require.Equal(t, "failure", events[0]["outcome"])
+	require.Equal(t, map[string]interface{}{
+		"permission": "audit:read",
+		"method":     "GET",
+		"route":      "/admin/audit-events/export",
+	}, events[0]["metadata"])
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// clients.go contains the admin routes managing OAuth clients and service
+// accounts.
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/oidc"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// clientResponse is the JSON representation of a client. The secret hash is
+// never included.
+func clientResponse(client *models.OAuthClient) gin.H {
+	return gin.H{
+		"client_id":         client.ClientID,
+		"name":              client.Name,
+		"redirect_uris":     strings.Fields(client.RedirectURIs),
+		"scopes":            oidc.ClientScopes(client),
+		"grant_types":       oidc.ClientGrantTypes(client),
+		"public":            client.Public,
+		"skip_consent":      client.SkipConsent,
+		"disabled":          client.DisabledAt != nil,
+		"disabled_at":       client.DisabledAt,
+		"secret_rotated_at": client.SecretRotatedAt,
+		"created_at":        client.CreatedAt,
+	}
+}
+
+// clientParam loads the client named by the client_id route parameter,
+// including disabled clients. It responds with a 404 status code and returns
+// nil if there is no such client.
+func (s *Server) clientParam(c *gin.Context) *models.OAuthClient {
+	client, err := s.clients.FindByClientID(c.Param("client_id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, validation.MessageClientNotFound)
+		return nil
+	}
+	return client
+}
+
+// ListClients handles the /admin/clients route.
+//
+// It returns every registered client.
+func (s *Server) ListClients(c *gin.Context) {
+	clients, err := s.clients.List()
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(clients))
+	for i := range clients {
+		response = append(response, clientResponse(&clients[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"clients": response})
+}
+
+// CreateClient handles the /admin/clients route.
+//
+// It registers a client from a JSON payload. Service accounts are created
+// with the client_credentials grant type and the scopes they may request.
+// The client secret is part of the response and cannot be retrieved later.
+// Scopes that name permissions can only be given by someone who holds them.
+func (s *Server) CreateClient(c *gin.Context) {
+	var input inputs.CreateClientInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if !s.canGrant(c, input.Scopes) {
+		return
+	}
+
+	client, secret, err := oidc.RegisterClient(s.clients, oidc.ClientRegistration{
+		Name:         input.Name,
+		RedirectURIs: input.RedirectURIs,
+		Scopes:       input.Scopes,
+		GrantTypes:   input.GrantTypes,
+		Public:       input.Public,
+		SkipConsent:  input.SkipConsent,
+	})
+	if errors.Is(err, oidc.ErrInvalidRegistration) {
+		log.Printf("could not register client: %v", err)
+		respondError(c, http.StatusBadRequest, validation.MessageInvalidRegistration)
+		return
+	}
+	if err != nil {
+		respondInternal(c, fmt.Errorf("could not register client: %w", err))
+		return
+	}
+
+	s.recordAdminAction(c, audit.ActionClientCreate, audit.TypeClient, client.ClientID, audit.Metadata{
+		"name":        client.Name,
+		"scopes":      client.Scopes,
+		"grant_types": client.GrantTypes,
+	})
+
+	response := clientResponse(client)
+	if secret != "" {
+		response["client_secret"] = secret
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetClient handles the /admin/clients/:client_id route.
+func (s *Server) GetClient(c *gin.Context) {
+	client := s.clientParam(c)
+	if client == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, clientResponse(client))
+}
+
+// RotateClientSecret handles the /admin/clients/:client_id/rotate-secret
+// route.
+//
+// It replaces the secret of a confidential client and returns the new one.
+// The old secret stops working immediately.
+func (s *Server) RotateClientSecret(c *gin.Context) {
+	client := s.clientParam(c)
+	if client == nil {
+		return
+	}
+	if client.Public {
+		respondError(c, http.StatusBadRequest, validation.MessagePublicClientSecret)
+		return
+	}
+
+	secret, err := oidc.RotateClientSecret(s.clients, client)
+	if err != nil {
+		respondInternal(c, fmt.Errorf("could not rotate client secret: %w", err))
+		return
+	}
+
+	s.recordAdminAction(c, audit.ActionClientRotateSecret, audit.TypeClient, client.ClientID, nil)
+	c.JSON(http.StatusOK, gin.H{"client_id": client.ClientID, "client_secret": secret})
+}
+
+// DisableClient handles the /admin/clients/:client_id/disable route.
+//
+// A disabled client can no longer obtain tokens and the access tokens it
+// holds are rejected by RequireAuth.
+func (s *Server) DisableClient(c *gin.Context) {
+	s.setClientDisabled(c, true)
+}
+
+// EnableClient handles the /admin/clients/:client_id/enable route.
+func (s *Server) EnableClient(c *gin.Context) {
+	s.setClientDisabled(c, false)
+}
+
+func (s *Server) setClientDisabled(c *gin.Context, disabled bool) {
+	client := s.clientParam(c)
+	if client == nil {
+		return
+	}
+
+	var disabledAt *time.Time
+	if disabled {
+		if client.DisabledAt != nil {
+			c.JSON(http.StatusOK, clientResponse(client))
+			return
+		}
+		now := time.Now()
+		disabledAt = &now
+	}
+
+	if err := s.clients.Update(client.ID, map[string]interface{}{"disabled_at": disabledAt}); err != nil {
+		respondInternal(c, fmt.Errorf("could not update client: %w", err))
+		return
+	}
+	client.DisabledAt = disabledAt
+
+	action := audit.ActionClientEnable
+	if disabled {
+		action = audit.ActionClientDisable
+	}
+	s.recordAdminAction(c, action, audit.TypeClient, client.ClientID, nil)
+
+	c.JSON(http.StatusOK, clientResponse(client))
+}
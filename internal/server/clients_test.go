@@ -0,0 +1,177 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// signUpSuperuser creates a user like signUpAndLogin and makes it a
+// superuser.
+func signUpSuperuser(t *testing.T, username, email string) map[string]interface{} {
+	t.Helper()
+
+	login := signUpAndLogin(t, username, email)
+	require.NoError(t, testDB.Model(&models.User{}).Where("email = ?", email).Update("is_superuser", true).Error)
+	return login
+}
+
+// clientCredentials requests a token with the client_credentials grant.
+func clientCredentials(t *testing.T, clientID, secret, scope string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	values := url.Values{"grant_type": {"client_credentials"}}
+	if scope != "" {
+		values.Set("scope", scope)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(secret))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	resp := map[string]interface{}{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+func TestServiceAccounts(t *testing.T) {
+	user := signUpAndLogin(t, "clientsuser", "clientsuser@example.com")
+	admin := signUpSuperuser(t, "clientsadmin", "clientsadmin@example.com")
+	adminCookie := accessCookie(admin["access_token"])
+
+	rec, _ := doJSON(t, http.MethodPost, "/admin/clients", gin.H{"name": "Nope"}, accessCookie(user["access_token"]))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, created := doJSON(t, http.MethodPost, "/admin/clients", gin.H{
+		"name":        "Billing",
+		"grant_types": []string{"client_credentials"},
+		"scopes":      []string{"orders:read", "orders:write"},
+	}, adminCookie)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	clientID, secret := created["client_id"].(string), created["client_secret"].(string)
+	require.NotEmpty(t, secret)
+	require.Equal(t, []interface{}{"orders:read", "orders:write"}, created["scopes"])
+
+	rec, resp := doJSON(t, http.MethodGet, "/admin/clients/"+clientID, nil, adminCookie)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Nil(t, resp["client_secret"])
+
+	// Scopes are limited to the registered ones
+	rec, resp = clientCredentials(t, clientID, secret, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "orders:read orders:write", resp["scope"])
+	require.Nil(t, resp["refresh_token"])
+
+	rec, resp = clientCredentials(t, clientID, secret, "orders:read")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "orders:read", resp["scope"])
+	serviceToken := resp["access_token"]
+
+	rec, resp = clientCredentials(t, clientID, secret, "orders:read users:write")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "invalid_scope", resp["error"])
+
+	rec, resp = clientCredentials(t, clientID, "wrong", "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, "invalid_client", resp["error"])
+
+	// The token authenticates, but user and admin routes are off limits
+	rec, _ = doJSON(t, http.MethodGet, "/oauth/userinfo", nil, accessCookie(serviceToken))
+	require.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	rec, _ = doJSON(t, http.MethodGet, "/admin/clients", nil, accessCookie(serviceToken))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Clients for the authorization code grant cannot use client_credentials
+	rec, webApp := doJSON(t, http.MethodPost, "/admin/clients", gin.H{
+		"name":          "Web App",
+		"redirect_uris": []string{"https://app.example.com/callback"},
+	}, adminCookie)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec, resp = clientCredentials(t, webApp["client_id"].(string), webApp["client_secret"].(string), "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "unauthorized_client", resp["error"])
+
+	// Rotation invalidates the old secret
+	rec, rotated := doJSON(t, http.MethodPost, "/admin/clients/"+clientID+"/rotate-secret", nil, adminCookie)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = clientCredentials(t, clientID, secret, "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	secret = rotated["client_secret"].(string)
+	rec, _ = clientCredentials(t, clientID, secret, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Disabling rejects the client and the tokens it holds
+	rec, resp = doJSON(t, http.MethodPost, "/admin/clients/"+clientID+"/disable", nil, adminCookie)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, true, resp["disabled"])
+	rec, _ = clientCredentials(t, clientID, secret, "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = doJSON(t, http.MethodGet, "/oauth/userinfo", nil, accessCookie(serviceToken))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/admin/clients/"+clientID+"/enable", nil, adminCookie)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = clientCredentials(t, clientID, secret, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec, resp = doJSON(t, http.MethodGet, "/admin/clients", nil, adminCookie)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.GreaterOrEqual(t, len(resp["clients"].([]interface{})), 2)
+
+	rec, _ = doJSON(t, http.MethodPost, "/admin/clients/unknown/disable", nil, adminCookie)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCreateClientValidation(t *testing.T) {
+	admin := signUpSuperuser(t, "clientsvalid", "clientsvalid@example.com")
+
+	tests := []struct {
+		name  string
+		input gin.H
+	}{
+		{"missing name", gin.H{"grant_types": []string{"client_credentials"}}},
+		{"public service account", gin.H{"name": "x", "public": true, "grant_types": []string{"client_credentials"}}},
+		{"unknown grant type", gin.H{"name": "x", "grant_types": []string{"password"}}},
+		{"missing redirect URI", gin.H{"name": "x"}},
+		{"relative redirect URI", gin.H{"name": "x", "redirect_uris": []string{"/callback"}}},
+		{"invalid scope", gin.H{"name": "x", "grant_types": []string{"client_credentials"}, "scopes": []string{`a"b`}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec, resp := doJSON(t, http.MethodPost, "/admin/clients", test.input, accessCookie(admin["access_token"]))
+			require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+			require.Equal(t, "The client registration is invalid", resp["message"])
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// errors.go writes the error responses. Every error response has the same
+// envelope:
+//
+//	{"code": "validation_failed", "message": "Some fields are invalid",
+//	 "fields": {"email": [{"code": "invalid", "message": "..."}]}}
+//
+// where code tells the kind of error, message describes it and fields,
+// only present for invalid input, lists the problems with each field. The
+// messages are in the language of the Accept-Language header, see the
+// catalogs of package validation.
+// Failures of the server are logged and answered with a generic message, so
+// their details never reach the client. The OAuth endpoints keep the error
+// responses of RFC 6749 instead.
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// Codes of the error responses besides validation.CodeFailed.
+const (
+	codeInvalidRequest  = validation.CodeInvalidRequest
+	codeUnauthorized    = "unauthorized"
+	codeForbidden       = "forbidden"
+	codeNotFound        = "not_found"
+	codeConflict        = "conflict"
+	codeTooManyRequests = "too_many_requests"
+	codeInternal        = validation.CodeInternal
+)
+
+// errorResponse is the envelope of the error responses.
+type errorResponse struct {
+	Code    string                  `json:"code"`
+	Message string                  `json:"message"`
+	Fields  map[string][]fieldError `json:"fields,omitempty"`
+}
+
+// fieldError is a problem with a field of the input.
+type fieldError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// statusCode returns the code of the error responses with the status.
+func statusCode(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return codeUnauthorized
+	case http.StatusForbidden:
+		return codeForbidden
+	case http.StatusNotFound:
+		return codeNotFound
+	case http.StatusConflict:
+		return codeConflict
+	case http.StatusTooManyRequests:
+		return codeTooManyRequests
+	}
+	if status >= http.StatusInternalServerError {
+		return codeInternal
+	}
+	return codeInvalidRequest
+}
+
+// respondError responds with an error of the status, coded after the
+// status and described by the message of key, such as
+// validation.MessageInvalidCredentials, in the language of the user.
+func respondError(c *gin.Context, status int, key string) {
+	c.JSON(status, localizedError(c, status, key))
+}
+
+// abortWithError is like respondError but also stops the pending handlers.
+func abortWithError(c *gin.Context, status int, key string) {
+	c.AbortWithStatusJSON(status, localizedError(c, status, key))
+}
+
+// localizedError returns the error response of the status described by the
+// message of key in the language of the user.
+func localizedError(c *gin.Context, status int, key string) errorResponse {
+	lang := validation.Language(c.GetHeader("Accept-Language"))
+	return errorResponse{Code: statusCode(status), Message: validation.Message(lang, "", key, nil)}
+}
+
+// respondInvalid responds with a 400 status code and the problems with the
+// fields of the input, described in the language of the user.
+func respondInvalid(c *gin.Context, errs validation.Errors) {
+	lang := validation.Language(c.GetHeader("Accept-Language"))
+	resp := errorResponse{
+		Code:    validation.CodeFailed,
+		Message: validation.Message(lang, "", validation.CodeFailed, nil),
+		Fields:  map[string][]fieldError{},
+	}
+	for _, err := range errs {
+		resp.Fields[err.Field] = append(resp.Fields[err.Field], fieldError{Code: err.Code, Message: err.Message(lang)})
+	}
+	c.AbortWithStatusJSON(http.StatusBadRequest, resp)
+}
+
+// respondLocalized responds with an error of the status, coded after the
+// status and described by the generic message of the code.
+func respondLocalized(c *gin.Context, status int) {
+	abortWithError(c, status, statusCode(status))
+}
+
+// respondInternal logs the error and responds with a 500 status code and a
+// generic message, so the details of the failure stay out of the response.
+func respondInternal(c *gin.Context, err error) {
+	log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	respondLocalized(c, http.StatusInternalServerError)
+}
+
+// respondBindError responds to an input that could not be bound. A value
+// of the wrong type is reported as an invalid field, like the other
+// problems with the fields of the input, and a body that cannot be read at
+// all with a 400 status code.
+func respondBindError(c *gin.Context, err error) {
+	if field := bindErrorField(c, err); field != "" {
+		var errs validation.Errors
+		errs.Add(field, validation.CodeInvalid)
+		respondInvalid(c, errs)
+		return
+	}
+	respondLocalized(c, http.StatusBadRequest)
+}
+
+// bindErrorField returns the name of the field a bind error is about, or an
+// empty string if it is not about a single field. JSON decoding names the
+// field, while query parameters are found by the value that failed to
+// parse.
+func bindErrorField(c *gin.Context, err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Field
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		for name, values := range c.Request.URL.Query() {
+			for _, value := range values {
+				if value == numErr.Num {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
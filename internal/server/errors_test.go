@@ -0,0 +1,141 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/server"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	signUpAndLogin(t, "envelopetaken", "envelopetaken@example.com")
+
+	// Every problem with the signup input is reported at once
+	rec, resp := doJSON(t, http.MethodPost, "/auth/signup", gin.H{
+		"username":         "envelopetaken",
+		"name":             "x",
+		"email":            "not an email",
+		"password":         "short",
+		"confirm_password": "shorter",
+	})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "validation_failed", resp["code"])
+	require.Equal(t, "Some fields are invalid", resp["message"])
+	require.Equal(t, []string{"taken"}, fieldCodes(resp, "username"))
+	require.Equal(t, []string{"invalid"}, fieldCodes(resp, "name"))
+	require.Equal(t, []string{"invalid"}, fieldCodes(resp, "email"))
+	require.Equal(t, []string{"mismatch"}, fieldCodes(resp, "confirm_password"))
+	require.Equal(t, []string{"min_length"}, fieldCodes(resp, "password"))
+
+	rec, resp = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "", "password": ""})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "validation_failed", resp["code"])
+	require.Equal(t, []string{"required"}, fieldCodes(resp, "email"))
+	require.Equal(t, []string{"required"}, fieldCodes(resp, "password"))
+
+	// The messages follow the language of the user
+	body, err := json.Marshal(gin.H{"email": "envelopetaken@example.com"})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9,en;q=0.5")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.JSONEq(t, `{
+		"code": "validation_failed",
+		"message": "Certains champs sont invalides",
+		"fields": {"password": [{"code": "required", "message": "Le mot de passe est obligatoire"}]}
+	}`, rec.Body.String())
+
+	// Other errors are coded after their status
+	rec, resp = doJSON(t, http.MethodGet, "/me", nil)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, map[string]interface{}{"code": "unauthorized", "message": "Missing access token"}, resp)
+	rec, resp = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "envelopetaken@example.com", "password": "Password124"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, map[string]interface{}{"code": "unauthorized", "message": "Invalid email or password"}, resp)
+	body, err = json.Marshal(gin.H{"email": "envelopetaken@example.com", "password": "Password124"})
+	require.NoError(t, err)
+	req = httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "fr")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.JSONEq(t, `{"code": "unauthorized", "message": "Adresse e-mail ou mot de passe incorrect"}`, rec.Body.String())
+	rec, resp = doJSON(t, http.MethodGet, "/no/such/route", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, map[string]interface{}{"code": "not_found", "message": "Not found"}, resp)
+
+	// Values of the wrong type are invalid fields, in the body and in the
+	// query, and unreadable bodies get a message of their own
+	rec, resp = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": 5, "password": "Password123"})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "validation_failed", resp["code"])
+	require.Equal(t, []string{"invalid"}, fieldCodes(resp, "email"))
+	admin := accessCookie(signUpSuperuser(t, "envelopeadmin", "envelopeadmin@example.com")["access_token"])
+	rec, resp = doJSON(t, http.MethodGet, "/admin/users?page=2&is_staff=maybe", nil, admin)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, []string{"invalid"}, fieldCodes(resp, "is_staff"))
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/signup", strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.JSONEq(t, `{"code": "invalid_request", "message": "The request could not be read"}`, rec.Body.String())
+}
+
+func TestInternalErrorsAreHidden(t *testing.T) {
+	db, err := server.OpenDatabase(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	broken := server.NewServer(testConfig(), server.WithDatabase(db))
+	t.Cleanup(func() { broken.Shutdown(context.Background()) })
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	// The error is logged, the response only says something went wrong
+	body, err := json.Marshal(gin.H{"refresh_token": "anything"})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "fr")
+	rec := httptest.NewRecorder()
+	broken.Handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.JSONEq(t, `{
+		"code": "internal_error",
+		"message": "Une erreur s'est produite, veuillez réessayer plus tard"
+	}`, rec.Body.String())
+}
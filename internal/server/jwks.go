@@ -0,0 +1,130 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/keys"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// jwksMaxAge is how long clients may cache the key set. Verifiers should
+// fetch it again when they see a token with an unknown kid.
+const jwksMaxAge = 5 * time.Minute
+
+// accessTokenType is the typ header of access tokens, see RFC 9068.
+const accessTokenType = "at+jwt"
+
+// newKeyManager creates the signing key manager, persisting keys to the
+// database so every instance signs with the same keys. The private keys are
+// encrypted with the key derived from secret. Without a database the keys
+// are kept in memory.
+func newKeyManager(db *gorm.DB, cfg config.JWT, secret []byte) (*keys.Manager, error) {
+	algorithm, err := keys.ParseAlgorithm(cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var store keys.Store = keys.NewMemoryStore()
+	if db != nil {
+		store = keys.NewDBStore(db, secret)
+	}
+	return keys.NewManager(store, keys.Config{
+		Algorithm:        algorithm,
+		RotationInterval: cfg.KeyRotationInterval,
+		Overlap:          cfg.KeyOverlap,
+	})
+}
+
+// createToken signs new claims of the given type for the user with the
+// keys of the server.
+func (s *Server) createToken(user *models.User, tokenType string, ttl time.Duration) (string, error) {
+	claims, err := s.userClaims(user, tokenType, ttl)
+	if err != nil {
+		return "", err
+	}
+	return s.signToken(claims)
+}
+
+// userClaims returns new claims of the given type the server issues to the
+// user, naming the user by ID and carrying its token version.
+func (s *Server) userClaims(user *models.User, tokenType string, ttl time.Duration) (utils.Claims, error) {
+	claims, err := utils.NewClaims(s.issuer, user.Email, tokenType, ttl)
+	if err != nil {
+		return claims, err
+	}
+	claims.UserID = user.ID
+	claims.Version = user.TokenVersion
+	return claims, nil
+}
+
+// signToken signs claims with the keys of the server.
+func (s *Server) signToken(claims jwt.Claims) (string, error) {
+	return s.keys.Sign(claims)
+}
+
+// signAccessToken signs the claims of an access token with the keys of the
+// server. Its at+jwt typ header (RFC 9068) keeps services verifying tokens
+// with the JWKS from taking the other tokens of the server, such as MFA
+// challenges and provider sessions, for access tokens.
+func (s *Server) signAccessToken(claims jwt.Claims) (string, error) {
+	return s.keys.SignWithType(claims, accessTokenType)
+}
+
+// parseToken verifies a token signed by the server and returns its claims.
+func (s *Server) parseToken(token string) (*utils.Claims, error) {
+	claims := &utils.Claims{}
+	if err := s.parseClaims(token, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// parseClaims verifies a token signed by the server and decodes its claims
+// into claims.
+func (s *Server) parseClaims(token string, claims jwt.Claims) error {
+	return utils.ParseClaimsWith(s.keys, token, claims)
+}
+
+// JWKS handles the /.well-known/jwks.json route.
+//
+// It returns the public keys that verify the tokens issued by the server as
+// a JSON Web Key Set, so other services can verify tokens without sharing a
+// secret.
+func (s *Server) JWKS(c *gin.Context) {
+	set, err := s.keys.JWKS()
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(jwksMaxAge.Seconds())))
+	c.JSON(http.StatusOK, set)
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/keys"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKS(t *testing.T) {
+	login := signUpAndLogin(t, "jwks", "jwks@example.com")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Cache-Control"), "max-age")
+
+	var set keys.JWKSet
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	require.NotEmpty(t, set.Keys)
+
+	// The access token verifies with the published key alone
+	token, err := jwt.Parse(login["access_token"].(string), func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		jwk := set.Key(kid)
+		require.NotNil(t, jwk)
+		require.Equal(t, "sig", jwk.Use)
+		return jwk.PublicKey()
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	require.NoError(t, err)
+
+	subject, err := token.Claims.GetSubject()
+	require.NoError(t, err)
+	require.Equal(t, "jwks@example.com", subject)
+
+	// It names the issuer and tells itself apart from the other tokens
+	issuer, err := token.Claims.GetIssuer()
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost", issuer)
+	require.Equal(t, "at+jwt", token.Header["typ"])
+}
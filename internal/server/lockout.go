@@ -0,0 +1,165 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// lockout.go protects the password logins against guessing.
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/lockout"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loginAttemptPruneInterval is how often the expired failed login counters
+// are pruned.
+const loginAttemptPruneInterval = time.Hour
+
+// dummyPasswordHash returns a hash that is compared against when the email
+// is unknown, so those logins take as long as the ones with a wrong
+// password. It is made with the configured algorithm on first use.
+func (s *Server) dummyPasswordHash() []byte {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = s.hashPassword("not a password")
+	})
+	return s.dummyHash
+}
+
+// newLoginGuard creates the guard counting failed logins, keeping the
+// counters in the database or in memory as the config selects. Without a
+// database the counters are kept in memory.
+func newLoginGuard(db *gorm.DB, cfg config.Lockout) (*lockout.Guard, error) {
+	var store lockout.Store
+	switch cfg.Store {
+	case "", "database":
+		if db == nil {
+			store = lockout.NewMemoryStore()
+			break
+		}
+		store = lockout.NewDBStore(db)
+	case "memory":
+		store = lockout.NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("invalid LOGIN_ATTEMPT_STORE %q: must be database or memory", cfg.Store)
+	}
+
+	return lockout.NewGuard(store, lockout.Config{
+		MaxAccountFailures: cfg.MaxAccountFailures,
+		MaxIPFailures:      cfg.MaxIPFailures,
+		FreeFailures:       cfg.FreeFailures,
+		Window:             cfg.FailureWindow,
+		LockoutDuration:    cfg.LockoutDuration,
+	}), nil
+}
+
+// verifyPassword checks the password of the user with the given email,
+// counting the failures of the account and the client IP. It returns the
+// user if the password is correct. Otherwise the user is nil and wait is
+// how long the client has to wait before trying again, which is 0 if only
+// the password was wrong. Unknown emails and wrong passwords cannot be told
+// apart.
+func (s *Server) verifyPassword(c *gin.Context, email, password string) (user *models.User, wait time.Duration, err error) {
+	now := time.Now()
+	if wait, err = s.loginGuard.Check(email, c.ClientIP(), now); err != nil || wait > 0 {
+		return nil, wait, err
+	}
+
+	hash := s.dummyPasswordHash()
+	found, err := s.users.FindByEmail(email)
+	exists := err == nil
+	if exists {
+		hash = found.PasswordHash
+	}
+
+	ok, rehash := s.passwords.Verify(password, hash)
+	if !ok || !exists {
+		return nil, 0, s.loginGuard.Fail(email, c.ClientIP(), now)
+	}
+	if rehash {
+		s.rehashPassword(found, password)
+	}
+	return found, 0, s.loginGuard.Succeed(email)
+}
+
+// confirmPassword checks the password of the signed in user before a
+// sensitive change. Wrong passwords count as failed logins and are delayed
+// and locked out the same way. Unless the password is correct, it responds
+// with an error, with status and the message of key for a wrong password,
+// and returns false.
+func (s *Server) confirmPassword(c *gin.Context, user *models.User, password string, status int, key string) bool {
+	verified, wait, err := s.verifyPassword(c, user.Email, password)
+	if err != nil {
+		respondInternal(c, err)
+		return false
+	}
+	if wait > 0 {
+		tooManyLoginAttempts(c, wait)
+		return false
+	}
+	if verified == nil || verified.ID != user.ID {
+		respondError(c, status, key)
+		return false
+	}
+	return true
+}
+
+// rehashPassword replaces the password hash of the user, made with another
+// algorithm or other parameters, with a hash made with the configured ones.
+// The hash is left alone if the password changed in the meantime. Failures
+// are only logged, the old hash keeps working.
+func (s *Server) rehashPassword(user *models.User, password string) {
+	hash, err := s.hashPassword(password)
+	if err == nil {
+		err = s.users.ReplacePasswordHash(user.ID, user.PasswordHash, hash)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("could not rehash the password of user %d: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
+// tooManyLoginAttempts responds that the client has to wait before trying
+// to log in again.
+func tooManyLoginAttempts(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+	respondError(c, http.StatusTooManyRequests, validation.MessageTooManyLoginAttempts)
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds.
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
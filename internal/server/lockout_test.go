@@ -0,0 +1,202 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loginFrom logs in from the given client IP, so the failures of a test are
+// not counted against the IP the other tests use.
+func loginFrom(t *testing.T, ip, email, password string, header ...string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	body, err := json.Marshal(gin.H{"email": email, "password": password})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":1234"
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	resp := map[string]interface{}{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+func TestLoginUniformErrors(t *testing.T) {
+	signUpAndLogin(t, "lockuniform", "lockuniform@example.com")
+
+	wrongPassword, _ := loginFrom(t, "198.51.100.1", "lockuniform@example.com", "Wrong1234")
+	unknownEmail, _ := loginFrom(t, "198.51.100.1", "lockunknown@example.com", "Wrong1234")
+	require.Equal(t, http.StatusUnauthorized, wrongPassword.Code)
+	require.Equal(t, wrongPassword.Code, unknownEmail.Code)
+	require.Equal(t, wrongPassword.Body.String(), unknownEmail.Body.String())
+}
+
+func TestLoginDelay(t *testing.T) {
+	signUpAndLogin(t, "lockdelay", "lockdelay@example.com")
+
+	accounts := []string{"lockdelay@example.com", "lockdelayunknown@example.com"}
+	for _, email := range accounts {
+		// The first failures are answered right away
+		for i := 0; i < 4; i++ {
+			rec, _ := loginFrom(t, "198.51.100.2", email, "Wrong1234")
+			require.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		}
+
+		// Then the account has to wait, even with the right password and
+		// from another IP, and whether the account exists or not
+		rec, resp := loginFrom(t, "198.51.100.3", email, "Password123")
+		require.Equal(t, http.StatusTooManyRequests, rec.Code, rec.Body.String())
+		require.Equal(t, "1", rec.Header().Get("Retry-After"))
+		require.Equal(t, "Too many failed login attempts, try again later", resp["message"])
+	}
+
+	time.Sleep(time.Second)
+	rec, _ := loginFrom(t, "198.51.100.3", "lockdelay@example.com", "Password123")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// A successful login resets the count
+	rec, _ = loginFrom(t, "198.51.100.3", "lockdelay@example.com", "Wrong1234")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = loginFrom(t, "198.51.100.3", "lockdelay@example.com", "Password123")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestLoginIgnoresForwardedFor(t *testing.T) {
+	signUpAndLogin(t, "lockforwarded", "lockforwarded@example.com")
+	lockedUntil := time.Now().Add(time.Hour)
+	require.NoError(t, testDB.Create(&models.LoginAttempt{
+		Key: "ip:198.51.100.4", Failures: 100, LastFailureAt: time.Now(), LockedUntil: &lockedUntil,
+	}).Error)
+
+	rec, _ := loginFrom(t, "198.51.100.4", "lockforwarded@example.com", "Password123", "X-Forwarded-For", "203.0.113.9")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code, rec.Body.String())
+	rec, _ = loginFrom(t, "198.51.100.5", "lockforwarded@example.com", "Password123")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestUnlockUser(t *testing.T) {
+	admin := accessCookie(signUpSuperuser(t, "lockadmin", "lockadmin@example.com")["access_token"])
+	signUpAndLogin(t, "lockunlock", "lockunlock@example.com")
+
+	lockedUntil := time.Now().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, testDB.Create(&models.LoginAttempt{
+		Key: "account:lockunlock@example.com", Failures: 10, LastFailureAt: time.Now(), LockedUntil: &lockedUntil,
+	}).Error)
+
+	rec, _ := loginFrom(t, "198.51.100.6", "lockunlock@example.com", "Password123")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "3600", rec.Header().Get("Retry-After"))
+
+	rec, resp := doJSON(t, http.MethodGet, userPath(t, "lockunlock@example.com", ""), nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, lockedUntil.Format(time.RFC3339), resp["locked_until"])
+
+	signUpAndLogin(t, "lockstaff", "lockstaff@example.com")
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "lockunlock@example.com", "/unlock"), nil,
+		accessCookie(loginAgain(t, "lockstaff@example.com")["access_token"]))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "lockunlock@example.com", "/unlock"), nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec, resp = doJSON(t, http.MethodGet, userPath(t, "lockunlock@example.com", ""), nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Nil(t, resp["locked_until"])
+
+	rec, _ = loginFrom(t, "198.51.100.6", "lockunlock@example.com", "Password123")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestLoginRehashesPassword(t *testing.T) {
+	signUpAndLogin(t, "lockrehash", "lockrehash@example.com")
+	storedHash := func() string {
+		var user models.User
+		require.NoError(t, testDB.Where("email = ?", "lockrehash@example.com").First(&user).Error)
+		return string(user.PasswordHash)
+	}
+	require.True(t, strings.HasPrefix(storedHash(), "$argon2id$v=19$m=19456,t=2,p=1$"), storedHash())
+
+	// A hash made by bcrypt, the former default, is upgraded at login
+	legacy, err := bcrypt.GenerateFromPassword([]byte("Password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, testDB.Model(&models.User{}).Where("email = ?", "lockrehash@example.com").Update("password_hash", legacy).Error)
+
+	rec, _ := loginFrom(t, "198.51.100.7", "lockrehash@example.com", "Password124")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, string(legacy), storedHash(), "kept after a failed login")
+
+	rec, _ = loginFrom(t, "198.51.100.7", "lockrehash@example.com", "Password123")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.True(t, strings.HasPrefix(storedHash(), "$argon2id$"), storedHash())
+	rec, _ = loginFrom(t, "198.51.100.7", "lockrehash@example.com", "Password123")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestPasswordConfirmationLockout(t *testing.T) {
+	token := accessCookie(signUpAndLogin(t, "lockconfirm", "lockconfirm@example.com")["access_token"])
+
+	changePassword := func(current string) *httptest.ResponseRecorder {
+		rec, _ := doJSON(t, http.MethodPost, "/me/password", gin.H{
+			"current_password": current, "password": "Password456", "confirm_password": "Password456",
+		}, token)
+		return rec
+	}
+	changeEmail := func(password string) *httptest.ResponseRecorder {
+		rec, _ := doJSON(t, http.MethodPost, "/me/email", gin.H{"email": "lockconfirmnew@example.com", "password": password}, token)
+		return rec
+	}
+
+	// Wrong passwords count as failed logins of the account
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusForbidden, changePassword("Wrong1234").Code)
+		require.Equal(t, http.StatusForbidden, changeEmail("Wrong1234").Code)
+	}
+	rec := changeEmail("Password123")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code, rec.Body.String())
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusTooManyRequests, changePassword("Password123").Code)
+	rec, _ = loginFrom(t, "198.51.100.8", "lockconfirm@example.com", "Password123")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	time.Sleep(time.Second)
+	rec = changePassword("Password123")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Logout handles the /auth/logout route.
+//
+// It revokes the access token the request was authenticated with and its
+// session and, if a refresh token is sent in the RefreshToken cookie, its
+// refresh token family. The session with the OpenID Connect provider is
+// ended as well. The token
+// cookies are cleared and a 200 status code is returned.
+func (s *Server) Logout(c *gin.Context) {
+	principal := MustPrincipal(c)
+
+	expiresAt := time.Now().Add(s.config.Tokens.AccessTTL)
+	if principal.Claims.ExpiresAt != nil {
+		expiresAt = principal.Claims.ExpiresAt.Time
+	}
+
+	if principal.Claims.ID != "" {
+		if err := s.revocations.Revoke(principal.Claims.ID, principal.User.ID, expiresAt); err != nil {
+			respondInternal(c, err)
+			return
+		}
+	}
+
+	if sessionID, err := parseSessionID(principal.Claims.SessionID); err == nil {
+		err := s.sessions.Revoke(sessionID, principal.User.ID, time.Now())
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			respondInternal(c, err)
+			return
+		}
+	}
+
+	if refreshToken, _ := c.Cookie(refreshTokenCookie); refreshToken != "" {
+		if err := s.revokeTokenFamily(refreshToken); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			respondInternal(c, err)
+			return
+		}
+	}
+
+	if err := s.endSession(c); err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	s.clearTokenCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
+}
+
+// LogoutAll handles the /auth/logout-all route.
+//
+// It revokes every access token issued to the authenticated user so far and
+// all of the user's refresh tokens, signing the user out on every device.
+// The token cookies are cleared and a 200 status code is returned.
+func (s *Server) LogoutAll(c *gin.Context) {
+	principal := MustPrincipal(c)
+
+	if err := s.revokeUserTokens(principal.User.ID); err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	s.clearTokenCookies(c)
+	c.SetCookie(sessionCookie, "", -1, "/", "", false, true)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out from all devices"})
+}
+
+// revokeUserTokens revokes all sessions, access and refresh tokens of a user.
+func (s *Server) revokeUserTokens(userID uint) error {
+	if err := s.sessions.RevokeAll(userID, time.Now()); err != nil {
+		return err
+	}
+
+	// The version is checked against the database on every request, so
+	// every instance rejects the tokens right away
+	return s.users.IncrementTokenVersion(userID)
+}
+
+// endSession revokes the session token in the session cookie, if any, and
+// clears the cookie.
+func (s *Server) endSession(c *gin.Context) error {
+	token, _ := c.Cookie(sessionCookie)
+	if token == "" {
+		return nil
+	}
+	c.SetCookie(sessionCookie, "", -1, "/", "", false, true)
+
+	claims, err := s.parseToken(token)
+	if err != nil || claims.Type != utils.TokenTypeSession || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	principal := MustPrincipal(c)
+	if claims.UserID != principal.User.ID {
+		return nil
+	}
+	return s.revocations.Revoke(claims.ID, principal.User.ID, claims.ExpiresAt.Time)
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func accessCookie(token interface{}) *http.Cookie {
+	return &http.Cookie{Name: "Authorization", Value: token.(string)}
+}
+
+func TestLogout(t *testing.T) {
+	login := signUpAndLogin(t, "logoutuser", "logout@example.com")
+
+	rec, _ := doJSON(t, http.MethodPost, "/auth/logout", nil)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/logout", nil,
+		accessCookie(login["access_token"]),
+		&http.Cookie{Name: "RefreshToken", Value: login["refresh_token"].(string)})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Both the access token and the refresh token are revoked
+	rec, _ = doJSON(t, http.MethodPost, "/auth/logout", nil, accessCookie(login["access_token"]))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": login["refresh_token"]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestLogoutAll(t *testing.T) {
+	first := signUpAndLogin(t, "logoutall", "logoutall@example.com")
+
+	rec, second := doJSON(t, http.MethodPost, "/auth/login", gin.H{
+		"email":    "logoutall@example.com",
+		"password": "Password123",
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/logout-all", nil, accessCookie(first["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/logout", nil, accessCookie(second["access_token"]))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": second["refresh_token"]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Logging in again right away, usually in the same second, works
+	rec, third := doJSON(t, http.MethodPost, "/auth/login", gin.H{
+		"email":    "logoutall@example.com",
+		"password": "Password123",
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, accessCookie(third["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
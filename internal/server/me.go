@@ -0,0 +1,242 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// me.go contains the self-service routes of the authenticated user.
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/mailer"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// profileResponse is the JSON representation of the authenticated user.
+// pending_email is the address waiting to be verified after an email
+// change, if any.
+func (s *Server) profileResponse(user *models.User) gin.H {
+	var pendingEmail *string
+	token, err := s.emailVerifications.FindPending(user.ID, user.Email, time.Now())
+	if err == nil {
+		pendingEmail = &token.Email
+	}
+
+	return gin.H{
+		"id":             user.ID,
+		"username":       user.Username,
+		"name":           user.Name,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"pending_email":  pendingEmail,
+		"created_at":     user.CreatedAt,
+		"updated_at":     user.UpdatedAt,
+	}
+}
+
+// GetProfile handles the /me route.
+func (s *Server) GetProfile(c *gin.Context) {
+	c.JSON(http.StatusOK, s.profileResponse(MustPrincipal(c).User))
+}
+
+// UpdateProfile handles the /me route.
+//
+// It takes a JSON payload with a new username, a new name or both. They are
+// validated like in SignUp and the username must not be taken.
+func (s *Server) UpdateProfile(c *gin.Context) {
+	var input inputs.UpdateProfileInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	user := MustPrincipal(c).User
+	updates := map[string]interface{}{}
+
+	if input.Username != nil && *input.Username != user.Username {
+		if err := utils.ValidateUsername(*input.Username); err != nil {
+			respondInvalid(c, validation.Errors{{Field: "username", Code: validation.CodeInvalid}})
+			return
+		}
+
+		taken, err := s.users.UsernameTaken(*input.Username, user.ID)
+		if err != nil {
+			respondInternal(c, err)
+			return
+		}
+		if taken {
+			respondInvalid(c, validation.Errors{{Field: "username", Code: validation.CodeTaken}})
+			return
+		}
+		updates["username"] = *input.Username
+	}
+
+	if input.Name != nil && *input.Name != user.Name {
+		if err := utils.ValidateName(*input.Name); err != nil {
+			respondInvalid(c, validation.Errors{{Field: "name", Code: validation.CodeInvalid}})
+			return
+		}
+		updates["name"] = *input.Name
+	}
+
+	if len(updates) > 0 {
+		err := s.users.Update(user.ID, updates)
+		if errors.Is(err, repository.ErrDuplicate) {
+			respondInvalid(c, validation.Errors{{Field: "username", Code: validation.CodeTaken}})
+			return
+		}
+		if err != nil {
+			respondInternal(c, fmt.Errorf("could not update user %d: %w", user.ID, err))
+			return
+		}
+		if user, err = s.users.FindByID(user.ID, false); err != nil {
+			respondInternal(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, s.profileResponse(user))
+}
+
+// ChangePassword handles the /me/password route.
+//
+// It takes a JSON payload with the current password, the new password and
+// its confirmation. The new password follows the password policy and must
+// differ from the last passwords of the user. The user
+// is signed out everywhere, including the current session. Wrong current
+// passwords count as failed logins and are delayed and locked out the same
+// way.
+func (s *Server) ChangePassword(c *gin.Context) {
+	var input inputs.ChangePasswordInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	user := MustPrincipal(c).User
+	if !s.confirmPassword(c, user, input.CurrentPassword, http.StatusForbidden, validation.MessageIncorrectPassword) {
+		return
+	}
+
+	var errs validation.Errors
+	if input.Password != input.ConfirmPassword {
+		errs.Add("confirm_password", validation.CodeMismatch)
+	}
+	if err := s.validatePassword(&errs, user, input.Password); err != nil {
+		respondInternal(c, fmt.Errorf("could not check the password history of user %d: %w", user.ID, err))
+		return
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	hash, err := s.hashPassword(input.Password)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	if err := s.setPassword(user, hash); err != nil {
+		respondInternal(c, fmt.Errorf("could not update user %d: %w", user.ID, err))
+		return
+	}
+
+	if err := s.revokeUserTokens(user.ID); err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	s.clearTokenCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed, please log in again"})
+}
+
+// ChangeEmail handles the /me/email route.
+//
+// It takes a JSON payload with the new email and the current password. A
+// verification link is mailed to the new address, which replaces the
+// current one once verified, and the current address is notified. Until
+// then nothing changes. Wrong passwords count as failed logins.
+func (s *Server) ChangeEmail(c *gin.Context) {
+	var input inputs.ChangeEmailInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	user := MustPrincipal(c).User
+	if !s.confirmPassword(c, user, input.Password, http.StatusForbidden, validation.MessageIncorrectPassword) {
+		return
+	}
+
+	if err := utils.ValidateEmail(input.Email); err != nil {
+		respondInvalid(c, validation.Errors{{Field: "email", Code: validation.CodeInvalid}})
+		return
+	}
+	if input.Email == user.Email {
+		respondError(c, http.StatusBadRequest, validation.MessageEmailUnchanged)
+		return
+	}
+
+	taken, err := s.users.EmailTaken(input.Email, user.ID)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+	if taken {
+		respondInvalid(c, validation.Errors{{Field: "email", Code: validation.CodeTaken}})
+		return
+	}
+
+	if s.verificationThrottled(user.ID) {
+		respondError(c, http.StatusTooManyRequests, validation.MessageVerificationThrottled)
+		return
+	}
+
+	if err := s.sendVerification(c, user, input.Email); err != nil {
+		respondInternal(c, fmt.Errorf("could not send verification to user %d: %w", user.ID, err))
+		return
+	}
+
+	err = s.mailer.Send(c.Request.Context(), mailer.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nA change of the email address of your account to %s was requested. It takes effect once the new address is verified.\n\nIf you did not ask for this change, reset your password right away.",
+			user.Username, input.Email),
+	})
+	if err != nil {
+		log.Printf("could not notify user %d of the email change: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "A verification link has been sent to the new address"})
+}
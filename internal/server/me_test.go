@@ -0,0 +1,191 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// allowVerificationEmail lets the user be sent another verification email
+// right away.
+func allowVerificationEmail(t *testing.T, email string) {
+	t.Helper()
+
+	var user models.User
+	require.NoError(t, testDB.Where("email = ?", email).First(&user).Error)
+	require.NoError(t, testDB.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ?", user.ID).
+		Update("created_at", time.Now().Add(-time.Hour)).Error)
+}
+
+func TestProfile(t *testing.T) {
+	signUpAndLogin(t, "metaken", "metaken@example.com")
+	token := accessCookie(signUpAndLogin(t, "meuser", "meuser@example.com")["access_token"])
+
+	rec, _ := doJSON(t, http.MethodGet, "/me", nil)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, resp := doJSON(t, http.MethodGet, "/me", nil, token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "meuser", resp["username"])
+	require.Equal(t, "meuser@example.com", resp["email"])
+	require.Nil(t, resp["pending_email"])
+	require.Nil(t, resp["password_hash"])
+
+	tests := []struct {
+		name     string
+		input    gin.H
+		wantCode int
+	}{
+		{"invalid username", gin.H{"username": "x"}, http.StatusBadRequest},
+		{"invalid name", gin.H{"name": "<>"}, http.StatusBadRequest},
+		{"taken username", gin.H{"username": "metaken"}, http.StatusBadRequest},
+		{"valid", gin.H{"username": "merenamed", "name": "Renamed"}, http.StatusOK},
+		{"unchanged", gin.H{"username": "merenamed"}, http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec, resp := doJSON(t, http.MethodPatch, "/me", test.input, token)
+			require.Equal(t, test.wantCode, rec.Code, rec.Body.String())
+			require.Equal(t, test.wantCode != http.StatusOK, resp["message"] != nil)
+		})
+	}
+
+	_, resp = doJSON(t, http.MethodPatch, "/me", gin.H{"username": "metaken"}, token)
+	require.Equal(t, []string{validation.CodeTaken}, fieldCodes(resp, "username"))
+
+	_, resp = doJSON(t, http.MethodGet, "/me", nil, token)
+	require.Equal(t, "merenamed", resp["username"])
+	require.Equal(t, "Renamed", resp["name"])
+}
+
+func TestChangePassword(t *testing.T) {
+	token := accessCookie(signUpAndLogin(t, "mepassword", "mepassword@example.com")["access_token"])
+
+	rec, _ := doJSON(t, http.MethodPost, "/me/password", gin.H{
+		"current_password": "Wrong1234", "password": "NewPassword1", "confirm_password": "NewPassword1",
+	}, token)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/me/password", gin.H{
+		"current_password": "Password123", "password": "NewPassword1", "confirm_password": "NewPassword2",
+	}, token)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/me/password", gin.H{
+		"current_password": "Password123", "password": "weak", "confirm_password": "weak",
+	}, token)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/me/password", gin.H{
+		"current_password": "Password123", "password": "NewPassword1", "confirm_password": "NewPassword1",
+	}, token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Every session is signed out and only the new password works
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, token)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "mepassword@example.com", "password": "Password123"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, login := doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "mepassword@example.com", "password": "NewPassword1"})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// The token of the new login works, even within the same second
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, accessCookie(login["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestChangeEmail(t *testing.T) {
+	signUpAndLogin(t, "meemailtaken", "meemailtaken@example.com")
+	token := accessCookie(signUpAndLogin(t, "meemail", "meemail@example.com")["access_token"])
+	allowVerificationEmail(t, "meemail@example.com")
+
+	tests := []struct {
+		name     string
+		input    gin.H
+		wantCode int
+	}{
+		{"wrong password", gin.H{"email": "menew@example.com", "password": "Wrong1234"}, http.StatusForbidden},
+		{"invalid email", gin.H{"email": "nope", "password": "Password123"}, http.StatusBadRequest},
+		{"same email", gin.H{"email": "meemail@example.com", "password": "Password123"}, http.StatusBadRequest},
+		{"taken email", gin.H{"email": "meemailtaken@example.com", "password": "Password123"}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec, _ := doJSON(t, http.MethodPost, "/me/email", test.input, token)
+			require.Equal(t, test.wantCode, rec.Code, rec.Body.String())
+		})
+	}
+	rec, resp := doJSON(t, http.MethodPost, "/me/email", gin.H{"email": "meemailtaken@example.com", "password": "Password123"}, token)
+	require.Equal(t, []string{validation.CodeTaken}, fieldCodes(resp, "email"), rec.Body.String())
+
+	rec, _ = doJSON(t, http.MethodPost, "/me/email", gin.H{"email": "menew@example.com", "password": "Password123"}, token)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	require.Contains(t, lastMail(t, "meemail@example.com"), "menew@example.com")
+
+	// Nothing changes until the new address is verified
+	rec, resp = doJSON(t, http.MethodGet, "/me", nil, token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "meemail@example.com", resp["email"])
+	require.Equal(t, "menew@example.com", resp["pending_email"])
+
+	rec, _ = doJSON(t, http.MethodPost, "/me/email", gin.H{"email": "menewer@example.com", "password": "Password123"}, token)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/verify-email", gin.H{"token": mailToken(t, "menew@example.com")})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Tokens issued for the old address stop working, even once someone
+	// else signs up with it right away, as tokens name users by ID
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, token)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	signUpAndLogin(t, "meemailreuse", "meemail@example.com")
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, token)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, resp = doJSON(t, http.MethodGet, "/me", nil, accessCookie(loginAgain(t, "menew@example.com")["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "menew@example.com", resp["email"])
+	require.Equal(t, true, resp["email_verified"])
+	require.Nil(t, resp["pending_email"])
+}
+
+func TestChangeEmailTakenBeforeVerification(t *testing.T) {
+	token := accessCookie(signUpAndLogin(t, "merace", "merace@example.com")["access_token"])
+	allowVerificationEmail(t, "merace@example.com")
+
+	rec, _ := doJSON(t, http.MethodPost, "/me/email", gin.H{"email": "meracenew@example.com", "password": "Password123"}, token)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	verification := mailToken(t, "meracenew@example.com")
+
+	signUpAndLogin(t, "meracewinner", "meracenew@example.com")
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/verify-email", gin.H{"token": verification})
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+}
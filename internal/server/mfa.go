@@ -0,0 +1,409 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/totp"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// totpSkew is the number of time steps a code may be off by.
+	totpSkew = 1
+	// recoveryCodeCount is the number of recovery codes handed out at once.
+	recoveryCodeCount = 10
+	// maxChallengeFailures is how many wrong codes revoke an MFA token, so
+	// the password has to be entered again.
+	maxChallengeFailures = 3
+)
+
+var errInvalidCode = errors.New("invalid code")
+
+// confirmedTOTP returns the confirmed TOTP credential of the user, or
+// repository.ErrNotFound if the user did not enable two-factor
+// authentication.
+func (s *Server) confirmedTOTP(userID uint) (*models.TOTPCredential, error) {
+	return s.totp.Find(userID, true)
+}
+
+// verifyTOTP checks the code against the credential. A code is accepted at
+// most once, codes from the time step of the last accepted code or before
+// are rejected.
+func (s *Server) verifyTOTP(credential *models.TOTPCredential, code string) (bool, error) {
+	secret, err := utils.DecryptWith(s.encryptionKey, credential.Secret)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := totp.Validate(string(secret), code, time.Now(), totpSkew)
+	if !ok || step <= credential.LastUsedStep {
+		return false, nil
+	}
+
+	err = s.totp.UseStep(credential.ID, step)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// useRecoveryCode consumes an unused recovery code of the user. It reports
+// whether the code was valid.
+func (s *Server) useRecoveryCode(userID uint, code string) (bool, error) {
+	err := s.totp.UseRecoveryCode(userID, recoveryCodeHash(code), time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// recoveryCodeHash returns the hash a recovery code is stored by.
+func recoveryCodeHash(code string) string {
+	return utils.HashToken(totp.NormalizeRecoveryCode(code))
+}
+
+// verifySecondFactor checks the TOTP code, or the recovery code if recovery
+// is set, of the user. The failures of the user and the client IP are
+// counted by the login guard like wrong passwords, but apart from them. It
+// reports whether the code was valid, otherwise wait is how long the client
+// has to wait before trying again, which is 0 if only the code was wrong.
+func (s *Server) verifySecondFactor(c *gin.Context, user *models.User, credential *models.TOTPCredential, code string, recovery bool) (ok bool, wait time.Duration, err error) {
+	now := time.Now()
+	account := fmt.Sprintf("mfa:%d", user.ID)
+	if wait, err = s.loginGuard.Check(account, c.ClientIP(), now); err != nil || wait > 0 {
+		return false, wait, err
+	}
+
+	if recovery {
+		ok, err = s.useRecoveryCode(user.ID, code)
+	} else {
+		ok, err = s.verifyTOTP(credential, code)
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	if !ok {
+		return false, 0, s.loginGuard.Fail(account, c.ClientIP(), now)
+	}
+	return true, 0, s.loginGuard.Succeed(account)
+}
+
+// failChallenge counts a wrong code entered for the MFA token and revokes
+// the token once it has had maxChallengeFailures. It reports whether the
+// token was revoked.
+func (s *Server) failChallenge(claims *utils.Claims, user *models.User) (bool, error) {
+	failures, err := s.loginGuard.FailAccount("mfa-token:"+claims.ID, time.Now())
+	if err != nil || failures < maxChallengeFailures {
+		return false, err
+	}
+	return true, s.revocations.Revoke(claims.ID, user.ID, claims.ExpiresAt.Time)
+}
+
+// generateRecoveryCodes returns a new set of recovery codes in clear text,
+// so they can be shown once, along with the hashes they are stored by.
+func generateRecoveryCodes() (codes, hashes []string, err error) {
+	codes, err = totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes = make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = recoveryCodeHash(code)
+	}
+	return codes, hashes, nil
+}
+
+// checkTOTP runs change if the code is valid for the credential, returning
+// what it returns, or errInvalidCode otherwise.
+func (s *Server) checkTOTP(credential *models.TOTPCredential, code string, change func() ([]string, error)) ([]string, error) {
+	ok, err := s.verifyTOTP(credential, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errInvalidCode
+	}
+	return change()
+}
+
+// EnrollTOTP handles the /auth/2fa/totp/enroll route.
+//
+// It generates a new TOTP secret for the authenticated user and returns it
+// along with its otpauth:// URI. Two-factor authentication is only enabled
+// once the enrollment is confirmed with ConfirmTOTP. If it is already
+// enabled, it returns a 409 status code.
+func (s *Server) EnrollTOTP(c *gin.Context) {
+	user := MustPrincipal(c).User
+
+	if _, err := s.confirmedTOTP(user.ID); err == nil {
+		respondError(c, http.StatusConflict, validation.MessageMFAEnabled)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	encrypted, err := utils.EncryptWith(s.encryptionKey, []byte(secret))
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	// Replace a previous enrollment that was never confirmed
+	if err := s.totp.Enroll(user.ID, encrypted); err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret": secret,
+		"uri":    totp.URI(secret, s.totpIssuer, user.Email),
+	})
+}
+
+// ConfirmTOTP handles the /auth/2fa/totp/confirm route.
+//
+// It takes a JSON payload with a code from the authenticator app. If the
+// code matches the pending enrollment, two-factor authentication is enabled
+// and a new set of recovery codes is returned. The recovery codes are only
+// shown once.
+func (s *Server) ConfirmTOTP(c *gin.Context) {
+	var input inputs.TOTPCodeInput
+	user := MustPrincipal(c).User
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	credential, err := s.totp.Find(user.ID, false)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, validation.MessageNoPendingEnrollment)
+		return
+	}
+
+	codes, err := s.checkTOTP(credential, input.Code, func() ([]string, error) {
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			return nil, err
+		}
+		// A concurrent confirmation beat this one to it
+		if err := s.totp.Confirm(credential.ID, time.Now(), hashes); errors.Is(err, repository.ErrNotFound) {
+			return nil, errInvalidCode
+		} else if err != nil {
+			return nil, err
+		}
+		return codes, nil
+	})
+
+	if errors.Is(err, errInvalidCode) {
+		respondError(c, http.StatusBadRequest, validation.MessageInvalidCode)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTP handles the /auth/2fa/totp/disable route.
+//
+// It takes a JSON payload with the user's password and a current code. If
+// both are valid, the TOTP credential and the recovery codes are deleted.
+// Wrong passwords count as failed logins.
+func (s *Server) DisableTOTP(c *gin.Context) {
+	var input inputs.DisableTOTPInput
+	user := MustPrincipal(c).User
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if !s.confirmPassword(c, user, input.Password, http.StatusUnauthorized, validation.MessageInvalidPassword) {
+		return
+	}
+
+	credential, err := s.confirmedTOTP(user.ID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, validation.MessageMFANotEnabled)
+		return
+	}
+
+	_, err = s.checkTOTP(credential, input.Code, func() ([]string, error) {
+		return nil, s.totp.Delete(user.ID)
+	})
+
+	if errors.Is(err, errInvalidCode) {
+		respondError(c, http.StatusBadRequest, validation.MessageInvalidCode)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes handles the /auth/2fa/recovery-codes route.
+//
+// It takes a JSON payload with a current code and replaces the recovery
+// codes of the user with a new set, which is returned once.
+func (s *Server) RegenerateRecoveryCodes(c *gin.Context) {
+	var input inputs.TOTPCodeInput
+	user := MustPrincipal(c).User
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	credential, err := s.confirmedTOTP(user.ID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, validation.MessageMFANotEnabled)
+		return
+	}
+
+	codes, err := s.checkTOTP(credential, input.Code, func() ([]string, error) {
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			return nil, err
+		}
+		return codes, s.totp.ReplaceRecoveryCodes(user.ID, hashes)
+	})
+
+	if errors.Is(err, errInvalidCode) {
+		respondError(c, http.StatusBadRequest, validation.MessageInvalidCode)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// MFAChallenge handles the /auth/2fa/challenge route.
+//
+// It takes a JSON payload with the mfa_token returned by Login and either a
+// code from the authenticator app or a recovery_code. If the second factor
+// is valid, the challenge token is consumed and the same tokens and cookies
+// as a regular Login are issued. Otherwise it returns a 401 status code, and
+// the challenge token is revoked after maxChallengeFailures wrong codes.
+// Wrong codes of the user are delayed and locked out like wrong passwords,
+// with a 429 status code.
+func (s *Server) MFAChallenge(c *gin.Context) {
+	var input inputs.MFAChallengeInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	claims, err := s.parseToken(input.MFAToken)
+	if err != nil || claims.Type != utils.TokenTypeMFA {
+		respondError(c, http.StatusUnauthorized, validation.MessageInvalidMFAToken)
+		return
+	}
+
+	user, err := s.tokenUser(claims)
+	if err != nil || !user.IsActive {
+		respondError(c, http.StatusUnauthorized, validation.MessageInvalidMFAToken)
+		return
+	}
+
+	if s.tokenRevoked(claims, user) {
+		respondError(c, http.StatusUnauthorized, validation.MessageInvalidMFAToken)
+		return
+	}
+
+	credential, err := s.confirmedTOTP(user.ID)
+	if err != nil {
+		respondError(c, http.StatusUnauthorized, validation.MessageInvalidMFAToken)
+		return
+	}
+
+	code, recovery := input.Code, input.RecoveryCode != ""
+	if recovery {
+		code = input.RecoveryCode
+	}
+	ok, wait, err := s.verifySecondFactor(c, user, credential, code, recovery)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+	if wait > 0 {
+		s.recordLogin(c, loginMethodTOTP, user.Email, user, loginFailureThrottled)
+		tooManyLoginAttempts(c, wait)
+		return
+	}
+	if !ok {
+		s.recordLogin(c, loginMethodTOTP, user.Email, user, loginFailureInvalidCode)
+		revoked, err := s.failChallenge(claims, user)
+		if err != nil {
+			respondInternal(c, err)
+			return
+		}
+		if revoked {
+			respondError(c, http.StatusUnauthorized, validation.MessageTooManyInvalidCodes)
+			return
+		}
+		respondError(c, http.StatusUnauthorized, validation.MessageInvalidCode)
+		return
+	}
+
+	// The challenge can only be completed once
+	if err := s.revocations.Revoke(claims.ID, user.ID, claims.ExpiresAt.Time); err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	tokens, err := s.issueTokens(c, user, nil)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	s.recordLogin(c, loginMethodTOTP, user.Email, user, "")
+	c.JSON(http.StatusOK, s.tokenResponse("Login successful", tokens))
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/oidc"
+	"github.com/Maro1O9/goauth/internal/oidc/oidctest"
+	"github.com/Maro1O9/goauth/internal/totp"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTP(t *testing.T) {
+	login := signUpAndLogin(t, "twofactor", "twofactor@example.com")
+	access := accessCookie(login["access_token"])
+
+	rec, enroll := doJSON(t, http.MethodPost, "/auth/2fa/totp/enroll", nil, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	secret := enroll["secret"].(string)
+	require.Contains(t, enroll["uri"], "otpauth://totp/")
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/totp/confirm", gin.H{"code": "000000"}, access)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+	rec, confirm := doJSON(t, http.MethodPost, "/auth/2fa/totp/confirm", gin.H{"code": code}, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	recoveryCodes := confirm["recovery_codes"].([]interface{})
+	require.Len(t, recoveryCodes, 10)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/totp/enroll", nil, access)
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	// Login now asks for the second factor
+	credentials := gin.H{"email": "twofactor@example.com", "password": "Password123"}
+	rec, challenge := doJSON(t, http.MethodPost, "/auth/login", credentials)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, true, challenge["mfa_required"])
+	require.Nil(t, challenge["access_token"])
+	require.Empty(t, rec.Result().Cookies())
+
+	// The challenge token is not an access token
+	rec, _ = doJSON(t, http.MethodPost, "/auth/logout", nil, accessCookie(challenge["mfa_token"]))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	parsed, _, err := jwt.NewParser().ParseUnverified(challenge["mfa_token"].(string), jwt.MapClaims{})
+	require.NoError(t, err)
+	require.NotEqual(t, "at+jwt", parsed.Header["typ"])
+
+	// The code used to confirm the enrollment cannot be replayed
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/challenge", gin.H{"mfa_token": challenge["mfa_token"], "code": code})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	next, err := totp.Code(secret, time.Now().Add(totp.Period*time.Second))
+	require.NoError(t, err)
+	rec, resp := doJSON(t, http.MethodPost, "/auth/2fa/challenge", gin.H{"mfa_token": challenge["mfa_token"], "code": next})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotEmpty(t, resp["access_token"])
+
+	// The challenge token is single use
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/challenge", gin.H{"mfa_token": challenge["mfa_token"], "recovery_code": recoveryCodes[0]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Recovery codes replace the TOTP code once
+	_, challenge = doJSON(t, http.MethodPost, "/auth/login", credentials)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/challenge", gin.H{"mfa_token": challenge["mfa_token"], "recovery_code": recoveryCodes[0]})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	_, challenge = doJSON(t, http.MethodPost, "/auth/login", credentials)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/challenge", gin.H{"mfa_token": challenge["mfa_token"], "recovery_code": recoveryCodes[0]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestTOTPGuessing(t *testing.T) {
+	access := accessCookie(signUpAndLogin(t, "twofactorguess", "twofactorguess@example.com")["access_token"])
+	_, enroll := doJSON(t, http.MethodPost, "/auth/2fa/totp/enroll", nil, access)
+	secret := enroll["secret"].(string)
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+	rec, _ := doJSON(t, http.MethodPost, "/auth/2fa/totp/confirm", gin.H{"code": code}, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	next, err := totp.Code(secret, time.Now().Add(totp.Period*time.Second))
+	require.NoError(t, err)
+
+	credentials := gin.H{"email": "twofactorguess@example.com", "password": "Password123"}
+	_, challenge := doJSON(t, http.MethodPost, "/auth/login", credentials)
+
+	// A few wrong codes revoke the challenge, even the right code is then
+	// refused
+	for i := 0; i < 3; i++ {
+		rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/challenge", gin.H{"mfa_token": challenge["mfa_token"], "code": "000000"})
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	require.Contains(t, rec.Body.String(), "log in again")
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/challenge", gin.H{"mfa_token": challenge["mfa_token"], "code": next})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// New challenges do not start over, the wrong codes of the user are
+	// delayed
+	_, challenge = doJSON(t, http.MethodPost, "/auth/login", credentials)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/challenge", gin.H{"mfa_token": challenge["mfa_token"], "recovery_code": "AAAA-BBBB"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/challenge", gin.H{"mfa_token": challenge["mfa_token"], "code": next})
+	require.Equal(t, http.StatusTooManyRequests, rec.Code, rec.Body.String())
+	require.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// The login form of the provider counts the same failures
+	rp := newRelyingParty(t, oidc.ClientRegistration{Name: "Guessing App"})
+	browser := newBrowser(t)
+	resp, err := browser.Get(rp.AuthCodeURL("state", "nonce", oidctest.NewCodeVerifier(), "openid"))
+	require.NoError(t, err)
+	resp = submitForm(t, browser, readBody(t, resp), url.Values{
+		"email": {"twofactorguess@example.com"}, "password": {"Password123"}, "code": {next},
+	})
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
@@ -0,0 +1,310 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/oidc"
+	"github.com/Maro1O9/goauth/internal/rbac"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the gin.Context key the authenticated principal is stored under.
+const principalKey = "goauth.principal"
+
+// Principal is the authenticated caller of a request. It is stored in the
+// gin.Context by RequireAuth.
+//
+// The caller is either a user, or a service account authenticated with a
+// token from the client_credentials grant, in which case User is nil and
+// Client is set.
+type Principal struct {
+	User   *models.User
+	Client *models.OAuthClient
+	Claims *utils.Claims
+
+	// roles is the repository of the server the user's roles are loaded from
+	roles      repository.RoleRepository
+	userGrants *rbac.Grants
+}
+
+// RequireAuth returns a middleware that authenticates the request with the
+// access token from the Authorization header (as a Bearer token) or from the
+// Authorization cookie.
+//
+// The token is verified, checked against the revocation list and the user it
+// was issued to is loaded from the database. Inactive and deleted users are
+// rejected, and so are tokens of a session that was revoked. Tokens of
+// service accounts load the client instead. Tokens issued to a client that
+// has since been disabled are rejected. On success the
+// Principal is stored in the context and can be retrieved with
+// CurrentPrincipal, otherwise the request is aborted with a 401 status code.
+//
+// Route groups opt in with a single call:
+//
+//	protected := r.Group("/", s.RequireAuth())
+//
+// Routes that only make sense for users add RequireUser, which also keeps
+// the tokens of OAuth clients out of them.
+func (s *Server) RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := extractToken(c)
+		if tokenString == "" {
+			abortWithError(c, http.StatusUnauthorized, validation.MessageMissingAccessToken)
+			return
+		}
+
+		claims, err := s.parseToken(tokenString)
+		if err != nil || claims.Type != utils.TokenTypeAccess {
+			abortWithError(c, http.StatusUnauthorized, validation.MessageInvalidAccessToken)
+			return
+		}
+
+		var principal *Principal
+		switch claims.SubjectType {
+		case "", utils.SubjectTypeUser:
+			user, err := s.tokenUser(claims)
+			if err != nil || !user.IsActive || !s.checkSession(c, claims, user) {
+				abortWithError(c, http.StatusUnauthorized, validation.MessageInvalidAccessToken)
+				return
+			}
+			principal = &Principal{User: user, Claims: claims, roles: s.roles}
+
+			// Tokens obtained through a client die with the client
+			if claims.ClientID != "" {
+				if _, err := oidc.FindClient(s.clients, claims.ClientID); err != nil {
+					abortWithError(c, http.StatusUnauthorized, validation.MessageInvalidAccessToken)
+					return
+				}
+			}
+		case utils.SubjectTypeClient:
+			client, err := oidc.FindClient(s.clients, claims.Subject)
+			if err != nil || !oidc.AllowsGrantType(client, oidc.GrantTypeClientCredentials) {
+				abortWithError(c, http.StatusUnauthorized, validation.MessageInvalidAccessToken)
+				return
+			}
+			principal = &Principal{Client: client, Claims: claims, roles: s.roles}
+		default:
+			abortWithError(c, http.StatusUnauthorized, validation.MessageInvalidAccessToken)
+			return
+		}
+
+		// Service accounts have no user, so only their token can be revoked
+		revoked := s.revocations.IsRevoked(claims.ID)
+		if principal.User != nil {
+			revoked = s.tokenRevoked(claims, principal.User)
+		}
+		if revoked {
+			abortWithError(c, http.StatusUnauthorized, validation.MessageRevokedAccessToken)
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireUser returns a middleware that rejects service accounts and the
+// tokens OAuth clients obtained on behalf of users with a 403 status code,
+// so only the tokens users got from the /auth routes manage their account.
+// It must be registered after RequireAuth.
+func (s *Server) RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := MustPrincipal(c)
+		if principal.User == nil {
+			abortWithError(c, http.StatusForbidden, validation.MessageUsersOnly)
+			return
+		}
+		if issuedToClient(principal.Claims) {
+			abortWithError(c, http.StatusForbidden, validation.MessageClientTokenRefused)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireUserSubject returns a middleware that rejects service accounts with
+// a 403 status code but lets the tokens OAuth clients obtained on behalf of
+// users through. It is meant for the routes serving OAuth clients, like
+// /oauth/userinfo, and must be registered after RequireAuth.
+func (s *Server) RequireUserSubject() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if MustPrincipal(c).User == nil {
+			abortWithError(c, http.StatusForbidden, validation.MessageUsersOnly)
+			return
+		}
+		c.Next()
+	}
+}
+
+// issuedToClient reports whether the token was issued to an OAuth client,
+// which tokens from the /auth routes never are.
+func issuedToClient(claims *utils.Claims) bool {
+	return claims.ClientID != "" || len(claims.Audience) > 0
+}
+
+// RequirePermission returns a middleware that only lets principals with the
+// given permission through and rejects everyone else with a 403 status
+// code, recording the denial in the audit log. It must be registered after
+// RequireAuth.
+//
+// Users get their permissions from their roles. Service accounts get the
+// scopes of their token instead, and user tokens obtained by an OAuth client
+// are further limited to their scopes.
+//
+//	admin.POST("/users", s.RequirePermission("users:write"), s.CreateUser)
+func (s *Server) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, err := MustPrincipal(c).allows(permission)
+		if err != nil {
+			respondInternal(c, fmt.Errorf("could not resolve permissions: %w", err))
+			return
+		}
+		if !allowed {
+			s.recordEvent(c, &models.AuditEvent{Action: audit.ActionAccessDenied, Outcome: audit.OutcomeFailure}, audit.Metadata{
+				"permission": permission,
+				"method":     c.Request.Method,
+				"route":      c.FullPath(),
+			})
+			abortWithError(c, http.StatusForbidden, validation.MessagePermissionDenied)
+			return
+		}
+		c.Next()
+	}
+}
+
+// grants returns the roles and permissions of the user, loading them once
+// per request.
+func (p *Principal) grants() (*rbac.Grants, error) {
+	if p.userGrants == nil {
+		grants, err := rbac.Resolve(p.roles, p.User)
+		if err != nil {
+			return nil, err
+		}
+		p.userGrants = grants
+	}
+	return p.userGrants, nil
+}
+
+// allowsAll reports whether the principal has every one of permissions.
+func (p *Principal) allowsAll(permissions []string) (bool, error) {
+	for _, permission := range permissions {
+		if allowed, err := p.allows(permission); err != nil || !allowed {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// allows reports whether the principal has the permission.
+func (p *Principal) allows(permission string) (bool, error) {
+	if p.Claims.ClientID != "" && !rbac.Allows(oidc.ParseScope(p.Claims.Scope), permission) {
+		return false, nil
+	}
+	if p.User == nil {
+		return p.Client != nil, nil
+	}
+
+	grants, err := p.grants()
+	if err != nil {
+		return false, err
+	}
+	return grants.Allows(permission), nil
+}
+
+// tokenRevoked reports whether the token of the user was revoked, on its own
+// or along with every token of the user.
+func (s *Server) tokenRevoked(claims *utils.Claims, user *models.User) bool {
+	return claims.Version != user.TokenVersion || s.revocations.IsRevoked(claims.ID)
+}
+
+// issuedAt returns the issue time of the token, or the zero time if it has
+// none. It only tells when a session was started: revocation goes by the
+// token version and ID, see tokenRevoked.
+func issuedAt(claims *utils.Claims) time.Time {
+	if claims.IssuedAt == nil {
+		return time.Time{}
+	}
+	return claims.IssuedAt.Time
+}
+
+// tokenUser returns the user the token was issued to. The user is looked up
+// by ID, as an email address given up by one user can be taken by another,
+// and the token must still name the current email of the user. Soft deleted
+// users are not found.
+func (s *Server) tokenUser(claims *utils.Claims) (*models.User, error) {
+	if claims.UserID == 0 {
+		return nil, repository.ErrNotFound
+	}
+	user, err := s.users.FindByID(claims.UserID, false)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email != claims.Subject {
+		return nil, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+// extractToken returns the access token from the Authorization header or,
+// if there is no Bearer token, from the Authorization cookie.
+func extractToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	token, _ := c.Cookie(accessTokenCookie)
+	return token
+}
+
+// CurrentPrincipal returns the principal stored in the context by
+// RequireAuth. The second return value is false if the request was not
+// authenticated.
+func CurrentPrincipal(c *gin.Context) (*Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok
+}
+
+// MustPrincipal returns the principal stored in the context by RequireAuth.
+// It panics if the request was not authenticated, so it must only be used by
+// handlers registered behind RequireAuth.
+func MustPrincipal(c *gin.Context) *Principal {
+	principal, ok := CurrentPrincipal(c)
+	if !ok {
+		panic("server: no principal in context, is the route behind RequireAuth?")
+	}
+	return principal
+}
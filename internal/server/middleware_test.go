@@ -0,0 +1,178 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/oidc"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/revocation"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireAuth(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+
+	active := &models.User{Username: "mwactive", Email: "mwactive@example.com", PasswordHash: []byte("x"), IsActive: true}
+	inactive := &models.User{Username: "mwinactive", Email: "mwinactive@example.com", PasswordHash: []byte("x")}
+	deleted := &models.User{Username: "mwdeleted", Email: "mwdeleted@example.com", PasswordHash: []byte("x"), IsActive: true}
+	revoked := &models.User{Username: "mwrevoked", Email: "mwrevoked@example.com", PasswordHash: []byte("x"), IsActive: true}
+	for _, user := range []*models.User{active, inactive, deleted, revoked} {
+		require.NoError(t, db.Create(user).Error)
+	}
+	require.NoError(t, db.Model(inactive).Update("is_active", false).Error)
+	require.NoError(t, db.Delete(deleted).Error)
+
+	clients := repository.NewGormClientRepository(db)
+	service, _, err := oidc.RegisterClient(clients, oidc.ClientRegistration{Name: "mwservice", GrantTypes: []string{oidc.GrantTypeClientCredentials}})
+	require.NoError(t, err)
+	disabled, _, err := oidc.RegisterClient(clients, oidc.ClientRegistration{Name: "mwdisabled", GrantTypes: []string{oidc.GrantTypeClientCredentials}})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(disabled).Update("disabled_at", time.Now()).Error)
+
+	cfg := config.Default()
+	keyManager, err := newKeyManager(db, cfg.JWT, []byte(cfg.SecretKey))
+	require.NoError(t, err)
+	s := &Server{
+		config:      cfg,
+		db:          db,
+		users:       repository.NewGormUserRepository(db),
+		sessions:    repository.NewGormSessionRepository(db),
+		roles:       repository.NewGormRoleRepository(db),
+		clients:     clients,
+		keys:        keyManager,
+		revocations: revocation.NewStore(revocation.NewDBBackend(db)),
+	}
+
+	r := gin.New()
+	r.GET("/protected", s.RequireAuth(), func(c *gin.Context) {
+		principal := MustPrincipal(c)
+		if principal.Client != nil {
+			c.String(http.StatusOK, principal.Client.ClientID)
+			return
+		}
+		c.String(http.StatusOK, principal.User.Username)
+	})
+
+	token := func(user *models.User) string {
+		tokenString, err := s.createToken(user, utils.TokenTypeAccess, time.Minute)
+		require.NoError(t, err)
+		return tokenString
+	}
+
+	// Raising the token version revokes the tokens issued before
+	revokedToken := token(revoked)
+	require.NoError(t, s.users.IncrementTokenVersion(revoked.ID))
+
+	// Tokens name users by ID, so an email address taken over right away by
+	// someone else does not carry the tokens issued to its former owner
+	former := &models.User{Username: "mwformer", Email: "mwformer@example.com", PasswordHash: []byte("x"), IsActive: true}
+	require.NoError(t, db.Create(former).Error)
+	formerToken := token(former)
+	require.NoError(t, db.Model(former).Update("email", "mwformer2@example.com").Error)
+	require.NoError(t, db.Create(&models.User{Username: "mwtaker", Email: "mwformer@example.com", PasswordHash: []byte("x"), IsActive: true}).Error)
+
+	clientToken := func(subject, subjectType string) string {
+		claims, err := utils.NewClaims("http://localhost", subject, utils.TokenTypeAccess, time.Minute)
+		require.NoError(t, err)
+		claims.SubjectType = subjectType
+		tokenString, err := s.signToken(claims)
+		require.NoError(t, err)
+		return tokenString
+	}
+
+	expired, err := s.signToken(jwt.MapClaims{
+		"sub": active.Email,
+		"uid": active.ID,
+		"typ": utils.TokenTypeAccess,
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		header   string
+		cookie   string
+		wantCode int
+		want     string
+	}{
+		{"bearer token", "Bearer " + token(active), "", http.StatusOK, active.Username},
+		{"cookie", "", token(active), http.StatusOK, active.Username},
+		{"service account", "Bearer " + clientToken(service.ClientID, utils.SubjectTypeClient), "", http.StatusOK, service.ClientID},
+		{"missing token", "", "", http.StatusUnauthorized, ""},
+		{"invalid token", "Bearer invalid", "", http.StatusUnauthorized, ""},
+		{"expired token", "Bearer " + expired, "", http.StatusUnauthorized, ""},
+		{"unknown user", "Bearer " + token(&models.User{Email: "unknown@example.com"}), "", http.StatusUnauthorized, ""},
+		{"inactive user", "Bearer " + token(inactive), "", http.StatusUnauthorized, ""},
+		{"deleted user", "Bearer " + token(deleted), "", http.StatusUnauthorized, ""},
+		{"revoked user tokens", "Bearer " + revokedToken, "", http.StatusUnauthorized, ""},
+		{"reused email", "Bearer " + formerToken, "", http.StatusUnauthorized, ""},
+		{"email without user ID", "Bearer " + clientToken(active.Email, utils.SubjectTypeUser), "", http.StatusUnauthorized, ""},
+		{"disabled client", "Bearer " + clientToken(disabled.ClientID, utils.SubjectTypeClient), "", http.StatusUnauthorized, ""},
+		{"client ID as user", "Bearer " + clientToken(service.ClientID, utils.SubjectTypeUser), "", http.StatusUnauthorized, ""},
+		{"email as client", "Bearer " + clientToken(active.Email, utils.SubjectTypeClient), "", http.StatusUnauthorized, ""},
+		{"unknown subject type", "Bearer " + clientToken(service.ClientID, "robot"), "", http.StatusUnauthorized, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+			if test.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: accessTokenCookie, Value: test.cookie})
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			require.Equal(t, test.wantCode, rec.Code, rec.Body.String())
+			if test.wantCode == http.StatusOK {
+				require.Equal(t, test.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCurrentPrincipal(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	_, ok := CurrentPrincipal(c)
+	require.False(t, ok)
+	require.Panics(t, func() { MustPrincipal(c) })
+
+	principal := &Principal{User: &models.User{Username: "test"}}
+	c.Set(principalKey, principal)
+	got, ok := CurrentPrincipal(c)
+	require.True(t, ok)
+	require.Same(t, principal, got)
+}
@@ -0,0 +1,804 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/oidc"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// pages are the HTML pages of the authorization endpoint.
+var pages = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// sessionCookie holds the session token that keeps the user logged in to
+// the OpenID Connect provider.
+const sessionCookie = "goauth_session"
+
+// consentTokenType and consentTokenTTL describe the token embedded in the
+// consent page. It ties the form to the user and the authorization request,
+// so other sites cannot submit it on the user's behalf.
+const (
+	consentTokenType = "consent"
+	consentTokenTTL  = 10 * time.Minute
+)
+
+// scopeDescriptions are shown on the consent page.
+var scopeDescriptions = map[string]string{
+	oidc.ScopeOpenID:  "Sign you in with your account",
+	oidc.ScopeProfile: "See your name and username",
+	oidc.ScopeEmail:   "See your email address",
+}
+
+// pageParam is a hidden form field carrying an authorization parameter.
+type pageParam struct {
+	Name  string
+	Value string
+}
+
+// pageData is passed to the page templates.
+type pageData struct {
+	Title        string
+	AppName      string
+	Error        string
+	Action       string
+	ClientName   string
+	Email        string
+	CodeRequired bool
+	Scopes       []string
+	ConsentToken string
+	Params       []pageParam
+}
+
+// consentClaims are the claims of a consent token. Request is the hash of
+// the authorization request the consent was asked for.
+type consentClaims struct {
+	utils.Claims
+	Request string `json:"req"`
+}
+
+// loginFailure describes why the login form was rejected.
+type loginFailure struct {
+	Message      string
+	CodeRequired bool
+	// RetryAfter is set if the login was throttled.
+	RetryAfter time.Duration
+}
+
+// renderServerError logs the error and renders the error page without
+// revealing it.
+func (s *Server) renderServerError(c *gin.Context, err error) {
+	log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	s.renderPage(c, http.StatusInternalServerError, "error.html", &pageData{Title: "Something went wrong", Error: "Please try again later"})
+}
+
+// renderPage renders an HTML page. Pages are never cached and cannot be
+// framed, to protect the forms against clickjacking.
+func (s *Server) renderPage(c *gin.Context, status int, name string, data *pageData) {
+	data.AppName = s.totpIssuer
+	data.Action = "/oauth/authorize"
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := pages.ExecuteTemplate(c.Writer, name, data); err != nil {
+		log.Printf("could not render %s: %v", name, err)
+	}
+}
+
+// authorizeValues returns the parameters of the authorization request.
+func authorizeValues(input *inputs.AuthorizeInput) url.Values {
+	values := url.Values{}
+	for _, param := range authorizeParams(input) {
+		values.Set(param.Name, param.Value)
+	}
+	return values
+}
+
+// authorizeParams returns the non empty authorization parameters, so the
+// login and consent forms can send them back.
+func authorizeParams(input *inputs.AuthorizeInput) []pageParam {
+	var params []pageParam
+	for _, param := range []pageParam{
+		{"response_type", input.ResponseType},
+		{"client_id", input.ClientID},
+		{"redirect_uri", input.RedirectURI},
+		{"scope", input.Scope},
+		{"state", input.State},
+		{"nonce", input.Nonce},
+		{"code_challenge", input.CodeChallenge},
+		{"code_challenge_method", input.CodeChallengeMethod},
+		{"prompt", input.Prompt},
+	} {
+		if param.Value != "" {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// validateAuthorization checks the authorization request of a known client
+// with a registered redirect URI and returns the requested scopes.
+func validateAuthorization(input *inputs.AuthorizeInput, client *models.OAuthClient) ([]string, *oidc.Error) {
+	if input.ResponseType != "code" {
+		return nil, oidc.Errorf(oidc.ErrorUnsupportedResponseType, "only the code response type is supported")
+	}
+	if !oidc.AllowsGrantType(client, oidc.GrantTypeAuthorizationCode) {
+		return nil, oidc.Errorf(oidc.ErrorUnauthorizedClient, "the client may not use the authorization code grant")
+	}
+
+	scopes := oidc.ParseScope(input.Scope)
+	if len(scopes) == 0 {
+		return nil, oidc.Errorf(oidc.ErrorInvalidScope, "scope is required")
+	}
+	if !oidc.ContainsAll(oidc.ClientScopes(client), scopes) {
+		return nil, oidc.Errorf(oidc.ErrorInvalidScope, "the client may not request scope %q", input.Scope)
+	}
+
+	if len(input.CodeChallenge) < 43 || len(input.CodeChallenge) > 128 {
+		return nil, oidc.Errorf(oidc.ErrorInvalidRequest, "a PKCE code_challenge is required")
+	}
+	if input.CodeChallengeMethod != oidc.CodeChallengeMethodS256 {
+		return nil, oidc.Errorf(oidc.ErrorInvalidRequest, "code_challenge_method must be S256")
+	}
+
+	prompts := strings.Fields(input.Prompt)
+	if oidc.HasScope(prompts, "none") && len(prompts) > 1 {
+		return nil, oidc.Errorf(oidc.ErrorInvalidRequest, "prompt none cannot be combined with other values")
+	}
+	return scopes, nil
+}
+
+// redirectAuthorization sends the user agent back to the redirect URI of
+// the client with the given response parameters, the state and the issuer.
+func (s *Server) redirectAuthorization(c *gin.Context, input *inputs.AuthorizeInput, values url.Values) {
+	target, err := url.Parse(input.RedirectURI)
+	if err != nil {
+		s.renderPage(c, http.StatusBadRequest, "error.html", &pageData{Title: "Invalid request", Error: "Invalid redirect URI"})
+		return
+	}
+
+	query := target.Query()
+	for name := range values {
+		query.Set(name, values.Get(name))
+	}
+	if input.State != "" {
+		query.Set("state", input.State)
+	}
+	query.Set("iss", s.issuer)
+	target.RawQuery = query.Encode()
+
+	// 303 makes sure the browser does not send the login form again
+	status := http.StatusFound
+	if c.Request.Method == http.MethodPost {
+		status = http.StatusSeeOther
+	}
+	c.Redirect(status, target.String())
+}
+
+// redirectAuthorizationError reports an error to the client.
+func (s *Server) redirectAuthorizationError(c *gin.Context, input *inputs.AuthorizeInput, oauthErr *oidc.Error) {
+	values := url.Values{"error": {oauthErr.Code}}
+	if oauthErr.Description != "" {
+		values.Set("error_description", oauthErr.Description)
+	}
+	s.redirectAuthorization(c, input, values)
+}
+
+// sessionUser returns the user logged in to the provider and when they
+// logged in, or nil if there is no valid session.
+func (s *Server) sessionUser(c *gin.Context) (*models.User, time.Time) {
+	token, err := c.Cookie(sessionCookie)
+	if err != nil || token == "" {
+		return nil, time.Time{}
+	}
+
+	claims, err := s.parseToken(token)
+	if err != nil || claims.Type != utils.TokenTypeSession {
+		return nil, time.Time{}
+	}
+
+	user, err := s.tokenUser(claims)
+	if err != nil || !user.IsActive {
+		return nil, time.Time{}
+	}
+	if s.tokenRevoked(claims, user) || !s.allowsLogin(user, time.Now()) {
+		return nil, time.Time{}
+	}
+	return user, issuedAt(claims)
+}
+
+// startSession logs the user in to the provider.
+func (s *Server) startSession(c *gin.Context, user *models.User) error {
+	token, err := s.createToken(user, utils.TokenTypeSession, s.config.Tokens.SessionTTL)
+	if err != nil {
+		return err
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, token, int(s.config.Tokens.SessionTTL.Seconds()), "/", "", strings.HasPrefix(s.issuer, "https://"), true)
+	return nil
+}
+
+// authorizeLogin checks the login form of the authorization endpoint. Users
+// with two-factor authentication have to enter a TOTP or recovery code too,
+// whose failures are limited apart from the ones of the password. Every
+// attempt is recorded in the audit log.
+func (s *Server) authorizeLogin(c *gin.Context) (*models.User, *loginFailure, error) {
+	var input inputs.AuthorizeLoginInput
+	if err := c.ShouldBind(&input); err != nil {
+		return nil, &loginFailure{Message: "Invalid email or password"}, nil
+	}
+
+	user, wait, err := s.verifyPassword(c, input.Email, input.Password)
+	if err != nil {
+		return nil, nil, err
+	}
+	if wait > 0 {
+		s.recordLogin(c, loginMethodOIDC, input.Email, nil, loginFailureThrottled)
+		return nil, &loginFailure{Message: "Too many failed login attempts, try again later", RetryAfter: wait}, nil
+	}
+	if user == nil {
+		s.recordLogin(c, loginMethodOIDC, input.Email, nil, loginFailureInvalidCredentials)
+		return nil, &loginFailure{Message: "Invalid email or password"}, nil
+	}
+	if !user.IsActive {
+		s.recordLogin(c, loginMethodOIDC, input.Email, user, loginFailureDisabled)
+		return nil, &loginFailure{Message: "Invalid email or password"}, nil
+	}
+	if user.PasswordResetRequired {
+		s.recordLogin(c, loginMethodOIDC, input.Email, user, loginFailurePasswordReset)
+		return nil, &loginFailure{Message: "Password reset required, check your email for a reset link"}, nil
+	}
+	if !s.allowsLogin(user, time.Now()) {
+		s.recordLogin(c, loginMethodOIDC, input.Email, user, loginFailureUnverified)
+		return nil, &loginFailure{Message: "Email address is not verified"}, nil
+	}
+
+	credential, err := s.confirmedTOTP(user.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		s.recordLogin(c, loginMethodOIDC, input.Email, user, "")
+		return user, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	code := strings.TrimSpace(input.Code)
+	if code == "" {
+		return nil, &loginFailure{Message: "Enter the code from your authenticator app", CodeRequired: true}, nil
+	}
+
+	ok, wait, err := s.verifySecondFactor(c, user, credential, code, len(code) != 6)
+	if err != nil {
+		return nil, nil, err
+	}
+	if wait > 0 {
+		s.recordLogin(c, loginMethodOIDC, input.Email, user, loginFailureThrottled)
+		return nil, &loginFailure{Message: "Too many failed login attempts, try again later", RetryAfter: wait, CodeRequired: true}, nil
+	}
+	if !ok {
+		s.recordLogin(c, loginMethodOIDC, input.Email, user, loginFailureInvalidCode)
+		return nil, &loginFailure{Message: "Invalid authentication code", CodeRequired: true}, nil
+	}
+	s.recordLogin(c, loginMethodOIDC, input.Email, user, "")
+	return user, nil, nil
+}
+
+// hasConsent reports whether the user already granted the scopes to the
+// client.
+func (s *Server) hasConsent(userID, clientID uint, scopes []string) bool {
+	consent, err := s.consents.Find(userID, clientID)
+	if err != nil {
+		return false
+	}
+	return oidc.ContainsAll(oidc.ParseScope(consent.Scope), scopes)
+}
+
+// saveConsent adds the scopes to the ones the user granted to the client.
+func (s *Server) saveConsent(userID, clientID uint, scopes []string) error {
+	consent, err := s.consents.Find(userID, clientID)
+	if errors.Is(err, repository.ErrNotFound) {
+		consent = &models.OAuthConsent{UserID: userID, ClientID: clientID}
+	} else if err != nil {
+		return err
+	}
+
+	consent.Scope = oidc.FormatScope(oidc.ParseScope(consent.Scope + " " + oidc.FormatScope(scopes)))
+	return s.consents.Save(consent)
+}
+
+// consentToken creates the token embedded in the consent page.
+func (s *Server) consentToken(user *models.User, input *inputs.AuthorizeInput) (string, error) {
+	claims, err := s.userClaims(user, consentTokenType, consentTokenTTL)
+	if err != nil {
+		return "", err
+	}
+	return s.signToken(&consentClaims{Claims: claims, Request: utils.HashToken(authorizeValues(input).Encode())})
+}
+
+// validConsentToken reports whether the consent token was issued to the
+// user for this authorization request.
+func (s *Server) validConsentToken(token string, user *models.User, input *inputs.AuthorizeInput) bool {
+	var claims consentClaims
+	if err := s.parseClaims(token, &claims); err != nil {
+		return false
+	}
+	return claims.Type == consentTokenType &&
+		claims.UserID == user.ID &&
+		claims.Request == utils.HashToken(authorizeValues(input).Encode())
+}
+
+// Authorize handles the /oauth/authorize route.
+//
+// It implements the authorization endpoint of the OpenID Connect
+// authorization code flow. Clients must use PKCE with the S256 method.
+// Requests from unknown clients or with an unregistered redirect URI are
+// rejected with an error page, other errors are reported to the redirect
+// URI.
+//
+// Users that are not logged in to the provider get a login form, which
+// asks for a TOTP or recovery code as well if they enabled two-factor
+// authentication. Then, unless the client skips consent or the user
+// already granted the requested scopes, a consent page is shown. Finally
+// the user is redirected to the client with an authorization code.
+//
+// The prompt parameter supports none, login and consent.
+func (s *Server) Authorize(c *gin.Context) {
+	var input inputs.AuthorizeInput
+
+	if err := c.ShouldBind(&input); err != nil {
+		s.renderPage(c, http.StatusBadRequest, "error.html", &pageData{Title: "Invalid request", Error: "The request could not be read"})
+		return
+	}
+
+	client, err := oidc.FindClient(s.clients, input.ClientID)
+	if err != nil || !oidc.AllowsRedirectURI(client, input.RedirectURI) {
+		s.renderPage(c, http.StatusBadRequest, "error.html", &pageData{Title: "Invalid request", Error: "Unknown client or redirect URI"})
+		return
+	}
+
+	scopes, oauthErr := validateAuthorization(&input, client)
+	if oauthErr != nil {
+		s.redirectAuthorizationError(c, &input, oauthErr)
+		return
+	}
+
+	prompts := strings.Fields(input.Prompt)
+	action := ""
+	if c.Request.Method == http.MethodPost {
+		action = c.PostForm("action")
+	}
+
+	page := &pageData{
+		ClientName: client.Name,
+		Params:     authorizeParams(&input),
+	}
+
+	// Authenticate the user
+	user, authTime := s.sessionUser(c)
+	switch {
+	case action == "login":
+		var failure *loginFailure
+		user, failure, err = s.authorizeLogin(c)
+		if err != nil {
+			s.renderServerError(c, err)
+			return
+		}
+		if failure != nil {
+			page.Title, page.Error = "Sign in", failure.Message
+			page.Email, page.CodeRequired = c.PostForm("email"), failure.CodeRequired
+			status := http.StatusUnauthorized
+			if failure.RetryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(failure.RetryAfter)))
+				status = http.StatusTooManyRequests
+			}
+			s.renderPage(c, status, "login.html", page)
+			return
+		}
+		if err := s.startSession(c, user); err != nil {
+			s.renderServerError(c, err)
+			return
+		}
+		authTime = time.Now()
+
+	case user == nil || (action == "" && oidc.HasScope(prompts, "login")):
+		if oidc.HasScope(prompts, "none") {
+			s.redirectAuthorizationError(c, &input, oidc.Errorf(oidc.ErrorLoginRequired, "the user is not logged in"))
+			return
+		}
+		page.Title = "Sign in"
+		s.renderPage(c, http.StatusOK, "login.html", page)
+		return
+	}
+
+	// Ask for consent
+	switch {
+	case action == "consent":
+		var consent inputs.AuthorizeConsentInput
+		if err := c.ShouldBind(&consent); err != nil || !s.validConsentToken(consent.ConsentToken, user, &input) {
+			s.renderPage(c, http.StatusBadRequest, "error.html", &pageData{Title: "Invalid request", Error: "The consent form expired"})
+			return
+		}
+		if consent.Decision != "allow" {
+			s.redirectAuthorizationError(c, &input, oidc.Errorf(oidc.ErrorAccessDenied, "the user denied the request"))
+			return
+		}
+		if err := s.saveConsent(user.ID, client.ID, scopes); err != nil {
+			s.renderServerError(c, err)
+			return
+		}
+
+	case !client.SkipConsent && (oidc.HasScope(prompts, "consent") || !s.hasConsent(user.ID, client.ID, scopes)):
+		if oidc.HasScope(prompts, "none") {
+			s.redirectAuthorizationError(c, &input, oidc.Errorf(oidc.ErrorConsentRequired, "the user has not granted access to the client"))
+			return
+		}
+
+		token, err := s.consentToken(user, &input)
+		if err != nil {
+			s.renderServerError(c, err)
+			return
+		}
+		page.Title, page.Email, page.ConsentToken = "Allow access", user.Email, token
+		for _, scope := range scopes {
+			if description, ok := scopeDescriptions[scope]; ok {
+				page.Scopes = append(page.Scopes, description)
+			} else {
+				page.Scopes = append(page.Scopes, scope)
+			}
+		}
+		s.renderPage(c, http.StatusOK, "consent.html", page)
+		return
+	}
+
+	code, err := s.issueAuthorizationCode(client, user, &input, scopes, authTime)
+	if err != nil {
+		s.redirectAuthorizationError(c, &input, oidc.Errorf(oidc.ErrorServerError, "could not issue an authorization code"))
+		return
+	}
+	s.redirectAuthorization(c, &input, url.Values{"code": {code}})
+}
+
+// issueAuthorizationCode stores a new authorization code for the request
+// and returns it. Expired codes are pruned on the way.
+func (s *Server) issueAuthorizationCode(client *models.OAuthClient, user *models.User, input *inputs.AuthorizeInput, scopes []string, authTime time.Time) (string, error) {
+	code, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.authorizationCodes.DeleteExpired(time.Now().Add(-time.Hour)); err != nil {
+		return "", err
+	}
+
+	err = s.authorizationCodes.Create(&models.AuthorizationCode{
+		CodeHash:            utils.HashToken(code),
+		ClientID:            client.ID,
+		UserID:              user.ID,
+		RedirectURI:         input.RedirectURI,
+		Scope:               oidc.FormatScope(scopes),
+		Nonce:               input.Nonce,
+		CodeChallenge:       input.CodeChallenge,
+		CodeChallengeMethod: input.CodeChallengeMethod,
+		AuthTime:            authTime,
+		ExpiresAt:           time.Now().Add(s.config.Tokens.AuthorizationCodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// oauthError responds with an OAuth error.
+func oauthError(c *gin.Context, status int, err *oidc.Error) {
+	c.JSON(status, err)
+}
+
+// oauthServerError logs the error and responds with a server_error that
+// does not reveal it.
+func oauthServerError(c *gin.Context, err error) {
+	log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	oauthError(c, http.StatusInternalServerError, oidc.Errorf(oidc.ErrorServerError, "the request could not be completed"))
+}
+
+// tokenClient authenticates the client calling the token endpoint, with
+// HTTP Basic authentication or with the client_id and client_secret
+// parameters.
+func (s *Server) tokenClient(c *gin.Context, input *inputs.TokenInput) (*models.OAuthClient, *oidc.Error) {
+	clientID, secret := input.ClientID, input.ClientSecret
+
+	if id, password, ok := c.Request.BasicAuth(); ok {
+		if input.ClientSecret != "" {
+			return nil, oidc.Errorf(oidc.ErrorInvalidRequest, "multiple client authentication methods")
+		}
+
+		// Credentials are form encoded before they are put in the header
+		var err error
+		if clientID, err = url.QueryUnescape(id); err != nil {
+			return nil, oidc.Errorf(oidc.ErrorInvalidClient, "invalid client credentials")
+		}
+		if secret, err = url.QueryUnescape(password); err != nil {
+			return nil, oidc.Errorf(oidc.ErrorInvalidClient, "invalid client credentials")
+		}
+		if input.ClientID != "" && input.ClientID != clientID {
+			return nil, oidc.Errorf(oidc.ErrorInvalidRequest, "client_id does not match the credentials")
+		}
+	}
+
+	client, err := oidc.AuthenticateClient(s.clients, clientID, secret)
+	if err != nil {
+		if !errors.Is(err, oidc.ErrInvalidClient) {
+			log.Printf("could not authenticate client: %v", err)
+		}
+		return nil, oidc.Errorf(oidc.ErrorInvalidClient, "invalid client credentials")
+	}
+	return client, nil
+}
+
+// clientAccessToken creates an access token for the user on behalf of the
+// client and returns it with its claims.
+func (s *Server) clientAccessToken(user *models.User, client *models.OAuthClient, scope string) (string, *utils.Claims, error) {
+	claims, err := s.userClaims(user, utils.TokenTypeAccess, s.config.Tokens.AccessTTL)
+	if err != nil {
+		return "", nil, err
+	}
+	claims.Audience = jwt.ClaimStrings{client.ClientID}
+	claims.ClientID = client.ClientID
+	claims.Scope = scope
+
+	token, err := s.signAccessToken(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &claims, nil
+}
+
+// Token handles the /oauth/token route.
+//
+// It implements the token endpoint. Clients authenticate with HTTP Basic
+// authentication or with the client_id and client_secret parameters,
+// public clients only send their client_id. The authorization_code grant
+// exchanges an authorization code and its PKCE code verifier for an access
+// token and, if the openid scope was granted, an ID token. The
+// client_credentials grant issues service accounts an access token for
+// themselves, limited to the scopes they are registered with.
+//
+// Errors are returned as described in RFC 6749, section 5.2.
+func (s *Server) Token(c *gin.Context) {
+	var input inputs.TokenInput
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	if err := c.ShouldBind(&input); err != nil {
+		oauthError(c, http.StatusBadRequest, oidc.Errorf(oidc.ErrorInvalidRequest, "the request could not be read"))
+		return
+	}
+
+	client, oauthErr := s.tokenClient(c, &input)
+	if oauthErr != nil {
+		status := http.StatusBadRequest
+		if oauthErr.Code == oidc.ErrorInvalidClient {
+			status = http.StatusUnauthorized
+			if _, _, ok := c.Request.BasicAuth(); ok {
+				c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+		}
+		oauthError(c, status, oauthErr)
+		return
+	}
+
+	switch input.GrantType {
+	case oidc.GrantTypeAuthorizationCode, oidc.GrantTypeClientCredentials:
+		if !oidc.AllowsGrantType(client, input.GrantType) {
+			oauthError(c, http.StatusBadRequest, oidc.Errorf(oidc.ErrorUnauthorizedClient, "the client may not use the %s grant", input.GrantType))
+			return
+		}
+	}
+
+	switch input.GrantType {
+	case oidc.GrantTypeAuthorizationCode:
+		s.authorizationCodeGrant(c, client, &input)
+	case oidc.GrantTypeClientCredentials:
+		s.clientCredentialsGrant(c, client, &input)
+	default:
+		oauthError(c, http.StatusBadRequest, oidc.Errorf(oidc.ErrorUnsupportedGrantType, "unsupported grant type %q", input.GrantType))
+	}
+}
+
+// authorizationCodeGrant exchanges an authorization code for tokens. Codes
+// are single use: if a code is presented again, the access token it was
+// exchanged for is revoked.
+func (s *Server) authorizationCodeGrant(c *gin.Context, client *models.OAuthClient, input *inputs.TokenInput) {
+	invalidGrant := oidc.Errorf(oidc.ErrorInvalidGrant, "invalid, expired or used authorization code")
+
+	code, err := s.authorizationCodes.FindByHash(utils.HashToken(input.Code))
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && code.ClientID != client.ID) {
+		oauthError(c, http.StatusBadRequest, invalidGrant)
+		return
+	}
+	if err != nil {
+		oauthServerError(c, err)
+		return
+	}
+	// The user of a deleted account is not found and stays inactive
+	if user, err := s.users.FindByID(code.UserID, false); err == nil {
+		code.User = *user
+	}
+
+	if code.UsedAt != nil {
+		if code.AccessTokenID != "" {
+			if err := s.revocations.Revoke(code.AccessTokenID, code.UserID, code.UsedAt.Add(s.config.Tokens.AccessTTL)); err != nil {
+				log.Printf("could not revoke the token of a reused authorization code: %v", err)
+			}
+		}
+		oauthError(c, http.StatusBadRequest, invalidGrant)
+		return
+	}
+
+	if !time.Now().Before(code.ExpiresAt) || code.RedirectURI != input.RedirectURI ||
+		!oidc.VerifyCodeChallenge(code.CodeChallenge, code.CodeChallengeMethod, input.CodeVerifier) ||
+		!code.User.IsActive {
+		oauthError(c, http.StatusBadRequest, invalidGrant)
+		return
+	}
+
+	accessToken, claims, err := s.clientAccessToken(&code.User, client, code.Scope)
+	if err != nil {
+		oauthServerError(c, err)
+		return
+	}
+
+	// Mark the code as used, unless a concurrent request was faster
+	err = s.authorizationCodes.Use(code.ID, claims.ID, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		oauthError(c, http.StatusBadRequest, invalidGrant)
+		return
+	}
+	if err != nil {
+		oauthServerError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(s.config.Tokens.AccessTTL.Seconds()),
+		"scope":        code.Scope,
+	}
+
+	scopes := oidc.ParseScope(code.Scope)
+	if oidc.HasScope(scopes, oidc.ScopeOpenID) {
+		idToken, err := s.idToken(code, client, scopes)
+		if err != nil {
+			oauthServerError(c, err)
+			return
+		}
+		response["id_token"] = idToken
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// clientCredentialsGrant issues an access token to a service account. The
+// requested scopes must be a subset of the scopes of the client, all of them
+// are granted if none are requested. No refresh token is issued, the client
+// can simply authenticate again.
+func (s *Server) clientCredentialsGrant(c *gin.Context, client *models.OAuthClient, input *inputs.TokenInput) {
+	scopes := oidc.ClientScopes(client)
+	if input.Scope != "" {
+		requested := oidc.ParseScope(input.Scope)
+		if !oidc.ContainsAll(scopes, requested) {
+			oauthError(c, http.StatusBadRequest, oidc.Errorf(oidc.ErrorInvalidScope, "the client may not request scope %q", input.Scope))
+			return
+		}
+		scopes = requested
+	}
+
+	claims, err := utils.NewClaims(s.issuer, client.ClientID, utils.TokenTypeAccess, s.config.Tokens.AccessTTL)
+	if err != nil {
+		oauthServerError(c, err)
+		return
+	}
+	claims.SubjectType = utils.SubjectTypeClient
+	claims.ClientID = client.ClientID
+	claims.Scope = oidc.FormatScope(scopes)
+
+	accessToken, err := s.signAccessToken(claims)
+	if err != nil {
+		oauthServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(s.config.Tokens.AccessTTL.Seconds()),
+		"scope":        claims.Scope,
+	})
+}
+
+// idToken creates the ID token for an authorization code.
+func (s *Server) idToken(code *models.AuthorizationCode, client *models.OAuthClient, scopes []string) (string, error) {
+	now := time.Now()
+
+	claims := jwt.MapClaims(oidc.UserClaims(&code.User, scopes))
+	claims["iss"] = s.issuer
+	claims["aud"] = client.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(s.config.Tokens.IDTokenTTL).Unix()
+	claims["auth_time"] = code.AuthTime.Unix()
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+	return s.signToken(claims)
+}
+
+// UserInfo handles the /oauth/userinfo route.
+//
+// It returns the claims about the authenticated user that were granted to
+// the client the access token was issued to. Tokens issued to an OAuth
+// client must include the openid scope. Tokens from the /auth routes get
+// every claim.
+func (s *Server) UserInfo(c *gin.Context) {
+	principal := MustPrincipal(c)
+
+	scopes := oidc.DefaultScopes
+	if principal.Claims.ClientID != "" {
+		scopes = oidc.ParseScope(principal.Claims.Scope)
+	}
+	if !oidc.HasScope(scopes, oidc.ScopeOpenID) {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		oauthError(c, http.StatusForbidden, oidc.Errorf(oidc.ErrorInsufficientScope, "the openid scope is required"))
+		return
+	}
+
+	c.JSON(http.StatusOK, oidc.UserClaims(principal.User, scopes))
+}
+
+// OpenIDConfiguration handles the /.well-known/openid-configuration route.
+//
+// It returns the OpenID Provider Metadata, so relying parties can discover
+// the endpoints and capabilities of the provider.
+func (s *Server) OpenIDConfiguration(c *gin.Context) {
+	c.JSON(http.StatusOK, oidc.NewConfiguration(s.issuer, string(s.keys.Algorithm())))
+}
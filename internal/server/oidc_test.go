@@ -0,0 +1,275 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"html"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/oidc"
+	"github.com/Maro1O9/goauth/internal/oidc/oidctest"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURI = "https://app.example.com/callback"
+
+// handlerTransport sends requests to the test handler in process.
+type handlerTransport struct{}
+
+func (handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// newBrowser returns a client keeping cookies like a browser. Redirects are
+// not followed, so the test can inspect them.
+func newBrowser(t *testing.T) *http.Client {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	return &http.Client{
+		Transport: handlerTransport{},
+		Jar:       jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var hiddenInput = regexp.MustCompile(`<input type="hidden" name="([^"]+)" value="([^"]*)">`)
+
+// submitForm posts the hidden fields of the page along with extra fields to
+// the authorization endpoint.
+func submitForm(t *testing.T, browser *http.Client, page string, fields url.Values) *http.Response {
+	t.Helper()
+
+	values := url.Values{}
+	for _, match := range hiddenInput.FindAllStringSubmatch(page, -1) {
+		values.Set(html.UnescapeString(match[1]), html.UnescapeString(match[2]))
+	}
+	for name := range fields {
+		values.Set(name, fields.Get(name))
+	}
+
+	resp, err := browser.PostForm("http://localhost/oauth/authorize", values)
+	require.NoError(t, err)
+	return resp
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+// callback returns the parameters the provider redirected to the client with.
+func callback(t *testing.T, resp *http.Response) url.Values {
+	t.Helper()
+
+	require.Contains(t, []int{http.StatusFound, http.StatusSeeOther}, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(location.String(), redirectURI), location.String())
+	return location.Query()
+}
+
+func newRelyingParty(t *testing.T, registration oidc.ClientRegistration) *oidctest.RelyingParty {
+	t.Helper()
+
+	registration.RedirectURIs = []string{redirectURI}
+	client, secret, err := oidc.RegisterClient(repository.NewGormClientRepository(testDB), registration)
+	require.NoError(t, err)
+
+	rp := &oidctest.RelyingParty{
+		Issuer:       "http://localhost",
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		RedirectURI:  redirectURI,
+		Client:       &http.Client{Transport: handlerTransport{}},
+	}
+	require.NoError(t, rp.Discover())
+	return rp
+}
+
+func TestOIDC(t *testing.T) {
+	signUpAndLogin(t, "oidcuser", "oidc@example.com")
+	rp := newRelyingParty(t, oidc.ClientRegistration{Name: "Example App"})
+	browser := newBrowser(t)
+
+	verifier := oidctest.NewCodeVerifier()
+	resp, err := browser.Get(rp.AuthCodeURL("state-1", "nonce-1", verifier, "openid", "profile", "email"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	page := readBody(t, resp)
+	require.Contains(t, page, "Example App")
+	require.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+
+	resp = submitForm(t, browser, page, url.Values{"email": {"oidc@example.com"}, "password": {"wrong"}})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	page = readBody(t, resp)
+	require.Contains(t, page, "Invalid email or password")
+
+	resp = submitForm(t, browser, page, url.Values{"email": {"oidc@example.com"}, "password": {"Password123"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	page = readBody(t, resp)
+	require.Contains(t, page, "See your email address")
+
+	// The consent form cannot be submitted without its token
+	forged := submitForm(t, browser, page, url.Values{"decision": {"allow"}, "consent_token": {"forged"}})
+	require.Equal(t, http.StatusBadRequest, forged.StatusCode)
+
+	params := callback(t, submitForm(t, browser, page, url.Values{"decision": {"allow"}}))
+	require.Equal(t, "state-1", params.Get("state"))
+	require.Equal(t, "http://localhost", params.Get("iss"))
+	code := params.Get("code")
+	require.NotEmpty(t, code)
+
+	// The code verifier must match the challenge
+	_, err = rp.Exchange(code, oidctest.NewCodeVerifier())
+	require.Error(t, err)
+
+	tokens, err := rp.Exchange(code, verifier)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer", tokens.TokenType)
+	require.Equal(t, "openid profile email", tokens.Scope)
+
+	claims, err := rp.VerifyIDToken(tokens.IDToken, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, "oidc@example.com", claims["email"])
+	require.Equal(t, "oidcuser", claims["preferred_username"])
+	require.NotEmpty(t, claims["sub"])
+	require.NotNil(t, claims["auth_time"])
+
+	info, err := rp.UserInfo(tokens.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, claims["sub"], info["sub"])
+	require.Equal(t, "oidc@example.com", info["email"])
+
+	// Tokens issued to clients do not manage the account of the user
+	for _, route := range []string{"/me", "/auth/logout-all", "/auth/2fa/totp/enroll", "/auth/webauthn/register/begin"} {
+		method := http.MethodPost
+		if route == "/me" {
+			method = http.MethodGet
+		}
+		rec, _ := doJSON(t, method, route, nil, accessCookie(tokens.AccessToken))
+		require.Equal(t, http.StatusForbidden, rec.Code, route)
+	}
+	info, err = rp.UserInfo(tokens.AccessToken)
+	require.NoError(t, err)
+
+	// Using the code again revokes the tokens it was exchanged for
+	_, err = rp.Exchange(code, verifier)
+	require.ErrorContains(t, err, oidc.ErrorInvalidGrant)
+	_, err = rp.UserInfo(tokens.AccessToken)
+	require.Error(t, err)
+
+	// With a session and consent, the user is sent back right away
+	verifier = oidctest.NewCodeVerifier()
+	resp, err = browser.Get(rp.AuthCodeURL("state-2", "nonce-2", verifier, "openid", "email") + "&prompt=none")
+	require.NoError(t, err)
+	params = callback(t, resp)
+	require.Equal(t, "state-2", params.Get("state"))
+
+	tokens, err = rp.Exchange(params.Get("code"), verifier)
+	require.NoError(t, err)
+	claims, err = rp.VerifyIDToken(tokens.IDToken, "nonce-2")
+	require.NoError(t, err)
+	require.Nil(t, claims["preferred_username"])
+
+	// Only the granted claims are released
+	info, err = rp.UserInfo(tokens.AccessToken)
+	require.NoError(t, err)
+	require.Nil(t, info["name"])
+	require.Equal(t, "oidc@example.com", info["email"])
+}
+
+func TestOIDCErrors(t *testing.T) {
+	signUpAndLogin(t, "oidcerrors", "oidcerrors@example.com")
+	rp := newRelyingParty(t, oidc.ClientRegistration{Name: "Public App", Public: true, SkipConsent: true})
+	verifier := oidctest.NewCodeVerifier()
+	authURL := rp.AuthCodeURL("state", "nonce", verifier, "openid")
+
+	// Unknown redirect URIs are never redirected to
+	browser := newBrowser(t)
+	resp, err := browser.Get(strings.Replace(authURL, url.QueryEscape(redirectURI), url.QueryEscape("https://evil.example.com/"), 1))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	tests := []struct {
+		name      string
+		authURL   string
+		wantError string
+	}{
+		{"missing PKCE", strings.Replace(authURL, "code_challenge_method=S256", "code_challenge_method=plain", 1), oidc.ErrorInvalidRequest},
+		{"unknown scope", strings.Replace(authURL, "scope=openid", "scope=openid+admin", 1), oidc.ErrorInvalidScope},
+		{"implicit flow", strings.Replace(authURL, "response_type=code", "response_type=token", 1), oidc.ErrorUnsupportedResponseType},
+		{"no session", authURL + "&prompt=none", oidc.ErrorLoginRequired},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := newBrowser(t).Get(test.authURL)
+			require.NoError(t, err)
+			params := callback(t, resp)
+			require.Equal(t, test.wantError, params.Get("error"))
+			require.Equal(t, "state", params.Get("state"))
+		})
+	}
+
+	// First party clients skip the consent page
+	resp, err = browser.Get(authURL)
+	require.NoError(t, err)
+	resp = submitForm(t, browser, readBody(t, resp), url.Values{"email": {"oidcerrors@example.com"}, "password": {"Password123"}})
+	params := callback(t, resp)
+
+	// Public clients authenticate with PKCE alone
+	tokens, err := rp.Exchange(params.Get("code"), verifier)
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.IDToken)
+
+	// Logging out ends the provider session, with a token of the user as the
+	// ones issued to clients cannot log out
+	logout, err := http.NewRequest(http.MethodPost, "http://localhost/auth/logout", nil)
+	require.NoError(t, err)
+	logout.Header.Set("Authorization", "Bearer "+loginAgain(t, "oidcerrors@example.com")["access_token"].(string))
+	resp, err = browser.Do(logout)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = browser.Get(authURL + "&prompt=none")
+	require.NoError(t, err)
+	require.Equal(t, oidc.ErrorLoginRequired, callback(t, resp).Get("error"))
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// operator.go contains the user and token management run by operators from
+// the command line. It shares the validation and side effects of the admin
+// routes, and records the same audit events with the operator as the actor.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
+)
+
+// Operator manages the users and tokens of a server outside of HTTP. It is
+// created with NewOperator and must be closed so its audit events are
+// written.
+//
+// Signing a user out revokes the sessions and refresh tokens of the user and
+// raises its token version in the database, which running servers check on
+// every request, so they reject the tokens of the user right away. The
+// operator does not revoke single tokens, which running servers would only
+// pick up when they next reload their revocation list.
+type Operator struct {
+	s       *Server
+	name    string
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewOperator creates an operator named name, usually the system user, from
+// a validated configuration and the same options as NewServer.
+func NewOperator(cfg *config.Config, name string, options ...Option) (*Operator, error) {
+	s, err := newServer(cfg, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	op := &Operator{s: s, name: name, stop: stop, stopped: make(chan struct{})}
+	go func() {
+		defer close(op.stopped)
+		s.auditLog.Run(ctx)
+	}()
+	return op, nil
+}
+
+// Close writes the queued audit events.
+func (op *Operator) Close() error {
+	op.stop()
+	<-op.stopped
+	return nil
+}
+
+// record queues an audit event of an action the operator performed
+// successfully on the user.
+func (op *Operator) record(action string, user *models.User, metadata audit.Metadata) {
+	op.s.auditLog.Write(&models.AuditEvent{
+		Action:     action,
+		Outcome:    audit.OutcomeSuccess,
+		ActorType:  audit.TypeOperator,
+		ActorID:    op.name,
+		TargetType: audit.TypeUser,
+		TargetID:   userID(user),
+	}, metadata)
+}
+
+// CreateSuperuser creates an active superuser with a verified email. The
+// input is validated like in SignUp.
+func (op *Operator) CreateSuperuser(input inputs.InputUser) (*models.User, error) {
+	user, err := op.s.createUser(&input)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = op.s.users.Update(user.ID, map[string]interface{}{
+		"is_superuser":   true,
+		"is_staff":       true,
+		"email_verified": true,
+		"verified_at":    now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	op.record(audit.ActionUserCreate, user, audit.Metadata{"username": user.Username, "email": user.Email, "is_superuser": true})
+
+	return op.s.users.FindByID(user.ID, false)
+}
+
+// FindUser returns the user identified by ref, which is an ID, a username
+// or an email. Soft deleted users are found by their ID if withDeleted is
+// set.
+func (op *Operator) FindUser(ref string, withDeleted bool) (*models.User, error) {
+	if id, err := strconv.ParseUint(ref, 10, 0); err == nil {
+		user, err := op.s.users.FindByID(uint(id), withDeleted)
+		if !errors.Is(err, repository.ErrNotFound) {
+			return user, err
+		}
+	}
+
+	// Usernames may look like emails, so both are tried
+	user, err := op.s.users.FindByUsername(ref)
+	if errors.Is(err, repository.ErrNotFound) {
+		user, err = op.s.users.FindByEmail(ref)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("user %q not found", ref)
+	}
+	return user, err
+}
+
+// ListUsers lists the users matching the filter and returns how many match
+// in total.
+func (op *Operator) ListUsers(filter repository.UserFilter) ([]models.User, int64, error) {
+	return op.s.users.List(filter)
+}
+
+// SetActive activates or deactivates the user. A deactivated user is
+// signed out of every running server.
+func (op *Operator) SetActive(user *models.User, active bool) error {
+	if user.IsActive == active {
+		return nil
+	}
+
+	updates := map[string]interface{}{"is_active": active}
+	if err := op.s.users.Update(user.ID, updates); err != nil {
+		return err
+	}
+	op.record(audit.ActionUserUpdate, user, audit.Metadata(updates))
+
+	if !active {
+		return op.s.revokeUserTokens(user.ID)
+	}
+	return nil
+}
+
+// DeleteUser soft deletes the user and signs it out everywhere, like the
+// admin route.
+func (op *Operator) DeleteUser(user *models.User) error {
+	if err := op.s.deleteUser(user); err != nil {
+		return err
+	}
+	op.record(audit.ActionUserDelete, user, nil)
+	return nil
+}
+
+// SetPassword replaces the password of the user, checked against the
+// password policy and history, clears a forced password reset and signs the user out
+// of every running server.
+func (op *Operator) SetPassword(user *models.User, password string) error {
+	var errs validation.Errors
+	if err := op.s.validatePassword(&errs, user, password); err != nil {
+		return err
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	hash, err := op.s.hashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := op.s.setPassword(user, hash); err != nil {
+		return err
+	}
+	op.record(audit.ActionUserSetPassword, user, nil)
+
+	return op.s.revokeUserTokens(user.ID)
+}
+
+// MintToken issues an access token of the user lasting for ttl, or the
+// configured access token lifetime if ttl is zero. It carries the roles and
+// permissions of the user like the tokens issued at login, but no session.
+func (op *Operator) MintToken(user *models.User, ttl time.Duration) (string, error) {
+	if !user.IsActive {
+		return "", errors.New("user is not active")
+	}
+	if ttl == 0 {
+		ttl = op.s.config.Tokens.AccessTTL
+	}
+
+	claims, err := op.s.accessClaims(op.s.roles, user, ttl)
+	if err != nil {
+		return "", err
+	}
+	token, err := op.s.signAccessToken(claims)
+	if err != nil {
+		return "", err
+	}
+	op.record(audit.ActionTokenMint, user, audit.Metadata{"jti": claims.ID, "expires_at": claims.ExpiresAt.Time})
+	return token, nil
+}
+
+// TokenInfo describes a token signed by the server.
+type TokenInfo struct {
+	Claims *utils.Claims
+	// User is the user the token was issued to, nil if the token was issued
+	// to a client or the user does not exist anymore.
+	User *models.User
+	// Revoked reports whether the token, or every token of its user, was
+	// revoked.
+	Revoked bool
+}
+
+// InspectToken verifies a token signed by the server and describes it.
+// Expired tokens are invalid.
+func (op *Operator) InspectToken(token string) (*TokenInfo, error) {
+	claims, err := op.s.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TokenInfo{Claims: claims, Revoked: op.s.revocations.IsRevoked(claims.ID)}
+	if claims.SubjectType == "" || claims.SubjectType == utils.SubjectTypeUser {
+		if user, err := op.s.tokenUser(claims); err == nil {
+			info.User, info.Revoked = user, op.s.tokenRevoked(claims, user)
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/server"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperator(t *testing.T) {
+	operator, err := server.NewOperator(testConfig(), "root", server.WithDatabase(testDB))
+	require.NoError(t, err)
+
+	// Superusers are validated like signups
+	input := inputs.InputUser{
+		Username:        "operatorroot",
+		Name:            "operatorroot",
+		Email:           "operatorroot@example.com",
+		Password:        "Password123",
+		ConfirmPassword: "Password123",
+	}
+	user, err := operator.CreateSuperuser(input)
+	require.NoError(t, err)
+	require.True(t, user.IsSuperuser)
+	require.True(t, user.EmailVerified)
+
+	_, err = operator.CreateSuperuser(input)
+	require.EqualError(t, err, "Username is already taken; Email is already registered")
+	invalid := input
+	invalid.Username, invalid.ConfirmPassword = "operatorroot2", "Password124"
+	_, err = operator.CreateSuperuser(invalid)
+	require.EqualError(t, err, "Passwords do not match; Email is already registered")
+
+	for _, ref := range []string{fmt.Sprint(user.ID), "operatorroot", "operatorroot@example.com"} {
+		found, err := operator.FindUser(ref, false)
+		require.NoError(t, err, ref)
+		require.Equal(t, user.ID, found.ID)
+	}
+	_, err = operator.FindUser("nobody", false)
+	require.EqualError(t, err, `user "nobody" not found`)
+
+	users, total, err := operator.ListUsers(repository.UserFilter{Search: "operatorroot"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Equal(t, "operatorroot", users[0].Username)
+
+	// Minted tokens are accepted by the server and carry the grants
+	token, err := operator.MintToken(user, time.Minute)
+	require.NoError(t, err)
+	rec, _ := doJSON(t, http.MethodGet, "/admin/users?q=operatorroot", nil, accessCookie(token))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	info, err := operator.InspectToken(token)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, info.User.ID)
+	require.Contains(t, info.Claims.Roles, "superuser")
+	require.False(t, info.Revoked)
+	_, err = operator.InspectToken(token + "x")
+	require.Error(t, err)
+
+	// Setting the password signs the user out, and the running server
+	// rejects the token right away as the operator is a separate server
+	require.NoError(t, operator.SetPassword(user, "Password456"))
+	info, err = operator.InspectToken(token)
+	require.NoError(t, err)
+	require.True(t, info.Revoked)
+	rec, _ = doJSON(t, http.MethodGet, "/admin/users?q=operatorroot", nil, accessCookie(token))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Error(t, operator.SetPassword(user, "short"))
+	rec, login := doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "operatorroot@example.com", "password": "Password456"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// So does deactivating the user, for the tokens of logins as well
+	require.NoError(t, operator.SetActive(user, false))
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, accessCookie(login["access_token"]))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": login["refresh_token"]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	user, err = operator.FindUser("operatorroot", false)
+	require.NoError(t, err)
+	require.False(t, user.IsActive)
+	_, err = operator.MintToken(user, 0)
+	require.EqualError(t, err, "user is not active")
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "operatorroot@example.com", "password": "Password456"})
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	require.NoError(t, operator.DeleteUser(user))
+	_, err = operator.FindUser("operatorroot", false)
+	require.Error(t, err)
+	deleted, err := operator.FindUser(fmt.Sprint(user.ID), true)
+	require.NoError(t, err)
+	require.True(t, deleted.DeletedAt.Valid)
+
+	// Closing writes the audit events, with the operator as the actor
+	require.NoError(t, operator.Close())
+	var actions []string
+	require.NoError(t, testDB.Model(&models.AuditEvent{}).
+		Where("actor_type = ? AND actor_id = ?", audit.TypeOperator, "root").
+		Order("id").Pluck("action", &actions).Error)
+	require.Equal(t, []string{
+		audit.ActionUserCreate,
+		audit.ActionTokenMint,
+		audit.ActionUserSetPassword,
+		audit.ActionUserUpdate,
+		audit.ActionUserDelete,
+	}, actions)
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/mailer"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidResetToken = errors.New("invalid or expired reset token")
+
+// ForgotPassword handles the /auth/password/forgot route.
+//
+// It takes a JSON payload with an email. If an active user with that email
+// exists, a single use password reset token is created and mailed to the
+// user. Any reset token the user was sent before is invalidated.
+//
+// To avoid disclosing which emails are registered, it always returns a 200
+// status code with the same message once the input is valid.
+func (s *Server) ForgotPassword(c *gin.Context) {
+	var input inputs.ForgotPasswordInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := utils.ValidateEmail(input.Email); err != nil {
+		respondInvalid(c, validation.Errors{{Field: "email", Code: validation.CodeInvalid}})
+		return
+	}
+
+	if user, err := s.users.FindByEmail(input.Email); err == nil && user.IsActive {
+		if err := s.sendPasswordReset(c, user); err != nil {
+			// The response must not depend on the outcome
+			log.Printf("could not send password reset to user %d: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the email is registered, a password reset link has been sent"})
+}
+
+// sendPasswordReset replaces the outstanding reset tokens of the user with
+// a new one and mails it to the user.
+func (s *Server) sendPasswordReset(c *gin.Context, user *models.User) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.passwordResets.Replace(&models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.config.Tokens.PasswordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(c.Request.Context(), mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s.\n\n%s/reset-password?token=%s\n\nIf you did not ask for a password reset you can ignore this email.",
+			user.Username, s.config.Tokens.PasswordResetTTL, s.appURL, token),
+	})
+}
+
+// ResetPassword handles the /auth/password/reset route.
+//
+// It takes a JSON payload with the reset token, the new password and its
+// confirmation. The password follows the password policy and must differ
+// from the last passwords of the user. If the token is valid and unused, the
+// password is replaced, the token is consumed and all of the user's access
+// and refresh tokens are revoked.
+//
+// An invalid, expired or already used token results in a 400 status code.
+func (s *Server) ResetPassword(c *gin.Context) {
+	var input inputs.ResetPasswordInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	// The password is checked against the history of the user the token
+	// belongs to, so the token is looked up first and consumed once the
+	// password is accepted
+	token, err := s.passwordResets.FindActive(utils.HashToken(input.Token), time.Now())
+	var user *models.User
+	if err == nil {
+		user, err = s.users.FindByID(token.UserID, false)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		respondError(c, http.StatusBadRequest, validation.MessageInvalidResetToken)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	var errs validation.Errors
+	if input.Password != input.ConfirmPassword {
+		errs.Add("confirm_password", validation.CodeMismatch)
+	}
+	if err := s.validatePassword(&errs, user, input.Password); err != nil {
+		respondInternal(c, fmt.Errorf("could not check the password history of user %d: %w", user.ID, err))
+		return
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	hash, err := s.hashPassword(input.Password)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	// Consuming the token fails if a concurrent request used it meanwhile
+	err = s.passwordResets.Use(token.ID, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		err = errInvalidResetToken
+	}
+	if err == nil {
+		err = s.setPassword(user, hash)
+	}
+
+	if errors.Is(err, errInvalidResetToken) {
+		respondError(c, http.StatusBadRequest, validation.MessageInvalidResetToken)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	// Sign the user out everywhere, the old password may have leaked
+	if err := s.revokeUserTokens(user.ID); err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
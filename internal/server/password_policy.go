@@ -0,0 +1,106 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// password_policy.go checks new passwords against the password policy and
+// the password history of their user.
+
+import (
+	"log"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/password"
+	"github.com/Maro1O9/goauth/internal/validation"
+)
+
+// validatePassword checks a new password of the user against the password
+// policy and, if the user exists already, the passwords it had before. The
+// rules the password breaks are added to errs as problems with the password
+// field. The error is only set if the history cannot be checked.
+func (s *Server) validatePassword(errs *validation.Errors, user *models.User, newPassword string) error {
+	for _, violation := range s.passwordPolicy.Check(newPassword, user.Username, user.Name, user.Email) {
+		errs.AddParams("password", violation.Rule, violation.Params)
+	}
+
+	if user.ID != 0 {
+		reused, err := s.passwordReused(user, newPassword)
+		if err != nil {
+			return err
+		}
+		if reused {
+			errs.AddParams("password", password.RuleReused, validation.Params{"count": s.config.PasswordPolicy.History})
+		}
+	}
+	return nil
+}
+
+// passwordReused reports whether the password is the current password of
+// the user or one of the former ones the history remembers.
+func (s *Server) passwordReused(user *models.User, newPassword string) (bool, error) {
+	n := s.config.PasswordPolicy.History
+	if n < 1 {
+		return false, nil
+	}
+	if s.checkPassword(user, newPassword) {
+		return true, nil
+	}
+	if n == 1 {
+		return false, nil
+	}
+
+	history, err := s.passwordHistory.Recent(user.ID, n-1)
+	if err != nil {
+		return false, err
+	}
+	for _, former := range history {
+		if ok, _ := s.passwords.Verify(newPassword, former.PasswordHash); ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// setPassword replaces the password hash of the user, clearing a forced
+// password reset, and remembers the former hash in the password history.
+func (s *Server) setPassword(user *models.User, hash []byte) error {
+	if err := s.users.Update(user.ID, map[string]interface{}{"password_hash": hash, "password_reset_required": false}); err != nil {
+		return err
+	}
+	s.rememberPassword(user.ID, user.PasswordHash)
+	return nil
+}
+
+// rememberPassword adds a former password hash of the user to the password
+// history and forgets the ones beyond PASSWORD_HISTORY. The password is
+// changed already, so errors are only logged.
+func (s *Server) rememberPassword(userID uint, hash []byte) {
+	keep := s.config.PasswordPolicy.History - 1
+	if keep > 0 && len(hash) > 0 {
+		if err := s.passwordHistory.Add(userID, hash); err != nil {
+			log.Printf("could not record the password history of user %d: %v", userID, err)
+			return
+		}
+	}
+	if err := s.passwordHistory.Prune(userID, keep); err != nil {
+		log.Printf("could not prune the password history of user %d: %v", userID, err)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/password"
+	"github.com/Maro1O9/goauth/internal/server"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha1.Sum([]byte("Password1!"))
+	breached := filepath.Join(dir, "breached.txt")
+	require.NoError(t, os.WriteFile(breached, []byte(strings.ToUpper(hex.EncodeToString(sum[:]))+":3\n"), 0o600))
+
+	db, err := server.OpenDatabase(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+	cfg := testConfig()
+	cfg.PasswordPolicy.MinStrength = 3
+	cfg.PasswordPolicy.RequireDigit = true
+	cfg.PasswordPolicy.History = 2
+	cfg.PasswordPolicy.BreachedFile = breached
+	strict := server.NewServer(cfg, server.WithDatabase(db))
+	t.Cleanup(func() { strict.Shutdown(context.Background()) })
+
+	signUp := func(password string) (int, []string) {
+		t.Helper()
+
+		rec, resp := serveJSON(t, strict.Handler, http.MethodPost, "/auth/signup", gin.H{
+			"username":         "policyuser",
+			"name":             "Policy",
+			"email":            "policy@example.com",
+			"password":         password,
+			"confirm_password": password,
+		})
+		return rec.Code, fieldCodes(resp, "password")
+	}
+
+	// Every broken rule is listed
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"", []string{"required"}},
+		{"short1", []string{"min_length", "strength"}},
+		{"Password1!", []string{"strength", "breached"}},
+		{"tulip ocean marble", []string{"digit"}},
+		{"tulip policyuser 9", []string{"personal_information", "strength"}},
+		{strings.Repeat("tulip 9 ", 17), []string{"max_length"}},
+	}
+	for _, test := range tests {
+		code, rules := signUp(test.password)
+		require.Equal(t, http.StatusBadRequest, code, test.password)
+		require.Equal(t, test.want, rules, test.password)
+	}
+
+	// Passphrases with spaces and non ASCII letters are fine
+	code, _ := signUp("tulip océan marble 9")
+	require.Equal(t, http.StatusCreated, code)
+
+	// The current and former passwords cannot be reused
+	change := func(current, password string) (int, []string) {
+		t.Helper()
+
+		rec, login := serveJSON(t, strict.Handler, http.MethodPost, "/auth/login", gin.H{"email": "policy@example.com", "password": current})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, resp := serveJSON(t, strict.Handler, http.MethodPost, "/me/password", gin.H{
+			"current_password": current, "password": password, "confirm_password": password,
+		}, accessCookie(login["access_token"]))
+		return rec.Code, fieldCodes(resp, "password")
+	}
+
+	code, rules := change("tulip océan marble 9", "tulip océan marble 9")
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, []string{"reused"}, rules)
+	code, _ = change("tulip océan marble 9", "maple river stone 7")
+	require.Equal(t, http.StatusOK, code)
+
+	// The operator is held to the same history
+	operator, err := server.NewOperator(cfg, "root", server.WithDatabase(db))
+	require.NoError(t, err)
+	t.Cleanup(func() { operator.Close() })
+	user, err := operator.FindUser("policyuser", false)
+	require.NoError(t, err)
+
+	var errs validation.Errors
+	err = operator.SetPassword(user, "tulip océan marble 9")
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, password.RuleReused, errs[0].Code)
+
+	// With a history of 2 the oldest password is forgotten
+	user, err = operator.FindUser("policyuser", false)
+	require.NoError(t, err)
+	require.NoError(t, operator.SetPassword(user, "cedar lantern brook 4"))
+	user, err = operator.FindUser("policyuser", false)
+	require.NoError(t, err)
+	require.NoError(t, operator.SetPassword(user, "tulip océan marble 9"))
+}
+
+// fieldCodes returns the codes of the problems with a field listed in an
+// error response.
+func fieldCodes(resp map[string]interface{}, field string) []string {
+	fields, _ := resp["fields"].(map[string]interface{})
+	problems, _ := fields[field].([]interface{})
+	var codes []string
+	for _, problem := range problems {
+		codes = append(codes, problem.(map[string]interface{})["code"].(string))
+	}
+	return codes
+}
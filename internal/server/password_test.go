@@ -0,0 +1,82 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordReset(t *testing.T) {
+	login := signUpAndLogin(t, "forgetful", "forgetful@example.com")
+
+	// Unknown emails get the same response
+	rec, unknown := doJSON(t, http.MethodPost, "/auth/password/forgot", gin.H{"email": "nobody@example.com"})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec, resp := doJSON(t, http.MethodPost, "/auth/password/forgot", gin.H{"email": "forgetful@example.com"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, unknown, resp)
+
+	token := mailToken(t, "forgetful@example.com")
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/password/reset", gin.H{
+		"token":            token,
+		"password":         "NewPassword123",
+		"confirm_password": "Mismatch123",
+	})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	reset := gin.H{
+		"token":            token,
+		"password":         "NewPassword123",
+		"confirm_password": "NewPassword123",
+	}
+	rec, _ = doJSON(t, http.MethodPost, "/auth/password/reset", reset)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// The token is single use
+	rec, _ = doJSON(t, http.MethodPost, "/auth/password/reset", reset)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Existing sessions are revoked
+	rec, _ = doJSON(t, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": login["refresh_token"]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "forgetful@example.com", "password": "Password123"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": "forgetful@example.com", "password": "NewPassword123"})
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPasswordResetInvalidToken(t *testing.T) {
+	rec, _ := doJSON(t, http.MethodPost, "/auth/password/reset", gin.H{
+		"token":            "invalid",
+		"password":         "NewPassword123",
+		"confirm_password": "NewPassword123",
+	})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
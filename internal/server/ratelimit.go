@@ -0,0 +1,166 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// ratelimit.go limits how often clients may call the routes.
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/ratelimit"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimits are the limits of the route groups.
+type rateLimits struct {
+	// auth limits the /auth routes per client IP.
+	auth ratelimit.Limit
+	// oauth limits the /oauth routes per client IP.
+	oauth ratelimit.Limit
+	// token limits the token endpoint per client, and per client IP while
+	// the client is not authenticated.
+	token ratelimit.Limit
+	// api limits the /me and /admin routes per user or service account.
+	api ratelimit.Limit
+}
+
+// newRateLimiter creates the rate limiter, keeping the counters in memory
+// or in Redis as the config selects, and parses the limits of the route
+// groups.
+func newRateLimiter(cfg config.RateLimit) (*ratelimit.Limiter, rateLimits, error) {
+	var limits rateLimits
+	settings := []struct {
+		name  string
+		value string
+		limit *ratelimit.Limit
+	}{
+		{"RATE_LIMIT_AUTH", cfg.Auth, &limits.auth},
+		{"RATE_LIMIT_OAUTH", cfg.OAuth, &limits.oauth},
+		{"RATE_LIMIT_TOKEN", cfg.Token, &limits.token},
+		{"RATE_LIMIT_API", cfg.API, &limits.api},
+	}
+	for _, setting := range settings {
+		limit, err := ratelimit.ParseLimit(setting.value)
+		if err != nil {
+			return nil, limits, fmt.Errorf("invalid %s: %w", setting.name, err)
+		}
+		*setting.limit = limit
+	}
+
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "", "memory":
+		store = ratelimit.NewMemoryStore()
+	case "redis":
+		redis, err := ratelimit.NewRedisStore(cfg.RedisURL)
+		if err != nil {
+			return nil, limits, fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		store = redis
+	default:
+		return nil, limits, fmt.Errorf("invalid RATE_LIMIT_STORE %q: must be memory or redis", cfg.Store)
+	}
+
+	return ratelimit.NewLimiter(store), limits, nil
+}
+
+// RateLimitKey returns who a request is counted for.
+type RateLimitKey func(c *gin.Context) string
+
+// KeyByIP counts requests per client IP.
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser counts requests per authenticated user or service account. It
+// must be registered after RequireAuth, and counts per client IP otherwise.
+func KeyByUser(c *gin.Context) string {
+	principal, ok := CurrentPrincipal(c)
+	switch {
+	case !ok:
+		return KeyByIP(c)
+	case principal.User != nil:
+		return fmt.Sprintf("user:%d", principal.User.ID)
+	default:
+		return "client:" + principal.Client.ClientID
+	}
+}
+
+// KeyByAPIKey counts requests per client ID, taken from HTTP Basic
+// authentication or the client_id parameter, and client IP. It runs before
+// the client is authenticated, so the IP is part of the key, or anyone could
+// use up the quota of a client by sending its ID. Requests without a client
+// ID are counted per client IP.
+func KeyByAPIKey(c *gin.Context) string {
+	if id, _, ok := c.Request.BasicAuth(); ok {
+		if clientID, err := url.QueryUnescape(id); err == nil && clientID != "" {
+			return "client:" + clientID + ":" + KeyByIP(c)
+		}
+	}
+	if clientID := c.PostForm("client_id"); clientID != "" {
+		return "client:" + clientID + ":" + KeyByIP(c)
+	}
+	return KeyByIP(c)
+}
+
+// RateLimit returns a middleware that allows limit requests per period for
+// each key. name keeps the counters of route groups apart, so groups sharing
+// a name share the quota.
+//
+// Responses carry the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset
+// and RateLimit-Policy headers. Requests over the limit are aborted with a
+// 429 status code and a Retry-After header. If the counters cannot be
+// reached, requests are let through rather than locking everyone out.
+func (s *Server) RateLimit(name string, limit ratelimit.Limit, key RateLimitKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		result, err := s.rateLimiter.Allow(name+":"+key(c), limit, time.Now())
+		if err != nil {
+			log.Printf("could not check rate limit %s: %v", name, err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(retryAfterSeconds(result.Reset)))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Period.Seconds())))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(result.RetryAfter)))
+			abortWithError(c, http.StatusTooManyRequests, validation.MessageTooManyRequests)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  func(cfg *config.RateLimit)
+		wantErr bool
+	}{
+		{"defaults", func(cfg *config.RateLimit) {}, false},
+		{"limits", func(cfg *config.RateLimit) { cfg.Auth, cfg.API = "5/1s", "off" }, false},
+		{"redis", func(cfg *config.RateLimit) { cfg.Store, cfg.RedisURL = "redis", "redis://localhost:6379/0" }, false},
+		{"invalid limit", func(cfg *config.RateLimit) { cfg.Token = "lots" }, true},
+		{"invalid store", func(cfg *config.RateLimit) { cfg.Store = "disk" }, true},
+		{"invalid redis URL", func(cfg *config.RateLimit) { cfg.Store, cfg.RedisURL = "redis", "localhost" }, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.Default().RateLimit
+			test.config(&cfg)
+			limiter, limits, err := newRateLimiter(cfg)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, limiter)
+			require.True(t, limits.auth.Enabled())
+			require.Equal(t, cfg.API != "off", limits.api.Enabled())
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{rateLimiter: ratelimit.NewLimiter(ratelimit.NewMemoryStore())}
+	limit := ratelimit.Limit{Requests: 2, Period: time.Hour}
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/ip", s.RateLimit("ip", limit, KeyByIP), ok)
+	r.GET("/off", s.RateLimit("off", ratelimit.Limit{}, KeyByIP), ok)
+	r.GET("/user", func(c *gin.Context) {
+		// Stands in for RequireAuth
+		if id := c.Query("user"); id != "" {
+			c.Set(principalKey, &Principal{User: &models.User{ID: uint(len(id))}})
+		}
+		if id := c.Query("client"); id != "" {
+			c.Set(principalKey, &Principal{Client: &models.OAuthClient{ClientID: id}})
+		}
+	}, s.RateLimit("user", limit, KeyByUser), ok)
+	r.POST("/token", s.RateLimit("token", limit, KeyByAPIKey), ok)
+
+	do := func(req *http.Request, ip string) *httptest.ResponseRecorder {
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	get := func(path, ip string) *httptest.ResponseRecorder {
+		return do(httptest.NewRequest(http.MethodGet, path, nil), ip)
+	}
+
+	rec := get("/ip", "192.0.2.1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	require.NotEmpty(t, rec.Header().Get("RateLimit-Reset"))
+	require.Equal(t, "2;w=3600", rec.Header().Get("RateLimit-Policy"))
+
+	require.Equal(t, http.StatusOK, get("/ip", "192.0.2.1").Code)
+	rec = get("/ip", "192.0.2.1")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	require.NotEmpty(t, rec.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusOK, get("/ip", "192.0.2.2").Code)
+
+	for i := 0; i < 5; i++ {
+		rec = get("/off", "192.0.2.1")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Header().Get("RateLimit-Limit"))
+	}
+
+	// Users and service accounts have their own quota, whatever their IP
+	require.Equal(t, http.StatusOK, get("/user?user=a", "192.0.2.3").Code)
+	require.Equal(t, http.StatusOK, get("/user?user=a", "192.0.2.4").Code)
+	require.Equal(t, http.StatusTooManyRequests, get("/user?user=a", "192.0.2.5").Code)
+	require.Equal(t, http.StatusOK, get("/user?user=bb", "192.0.2.5").Code)
+	require.Equal(t, http.StatusOK, get("/user?client=svc", "192.0.2.5").Code)
+	require.Equal(t, http.StatusOK, get("/user", "192.0.2.5").Code)
+
+	// Clients are counted by the ID they authenticate with, along with their
+	// IP as the ID is not checked yet
+	token := func(clientID string, basic bool, ip string) *httptest.ResponseRecorder {
+		form := url.Values{}
+		if !basic {
+			form.Set("client_id", clientID)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basic {
+			req.SetBasicAuth(url.QueryEscape(clientID), "secret")
+		}
+		return do(req, ip)
+	}
+	require.Equal(t, http.StatusOK, token("app", true, "192.0.2.6").Code)
+	require.Equal(t, http.StatusOK, token("app", false, "192.0.2.6").Code)
+	require.Equal(t, http.StatusTooManyRequests, token("app", true, "192.0.2.6").Code)
+	require.Equal(t, http.StatusOK, token("other app", true, "192.0.2.6").Code)
+	require.Equal(t, http.StatusOK, token("app", true, "192.0.2.7").Code)
+}
@@ -0,0 +1,355 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// roles.go contains the admin routes managing roles and their assignment
+// to users.
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/rbac"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// permissionNames returns the names of the permissions of a role.
+func permissionNames(role *models.Role) []string {
+	names := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		names = append(names, permission.Name)
+	}
+	return names
+}
+
+// roleResponse is the JSON representation of a role.
+func roleResponse(role *models.Role) gin.H {
+	return gin.H{
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": permissionNames(role),
+		"default":     rbac.IsDefaultRole(role.Name),
+		"created_at":  role.CreatedAt,
+	}
+}
+
+// roleParam loads the role named by the name route parameter with its
+// permissions. It responds with a 404 status code and returns nil if there
+// is no such role.
+func (s *Server) roleParam(c *gin.Context) *models.Role {
+	role, err := s.roles.FindByName(c.Param("name"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, validation.MessageRoleNotFound)
+		return nil
+	}
+	return role
+}
+
+// canGrant checks that the principal holds every permission granted by
+// permissions, so nobody can hand out more than they have. It responds with
+// an error and returns false otherwise.
+func (s *Server) canGrant(c *gin.Context, permissions []string) bool {
+	return s.holdsAll(c, permissions, validation.MessageCannotGrant)
+}
+
+// holdsAll checks that the principal holds every permission granted by
+// permissions. It responds with a 403 status code and the message of key
+// and returns false otherwise.
+func (s *Server) holdsAll(c *gin.Context, permissions []string, key string) bool {
+	covered, err := rbac.Covered(s.roles, permissions)
+	if err == nil {
+		var allowed bool
+		if allowed, err = MustPrincipal(c).allowsAll(covered); err == nil && !allowed {
+			respondError(c, http.StatusForbidden, key)
+			return false
+		}
+	}
+	if err != nil {
+		respondInternal(c, fmt.Errorf("could not check granted permissions: %w", err))
+		return false
+	}
+	return true
+}
+
+// validPermissions reports whether every permission name is valid.
+func validPermissions(permissions []string) bool {
+	for _, permission := range permissions {
+		if rbac.ValidatePermission(permission) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ListRoles handles the /admin/roles route.
+func (s *Server) ListRoles(c *gin.Context) {
+	roles, err := s.roles.List()
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(roles))
+	for i := range roles {
+		response = append(response, roleResponse(&roles[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": response})
+}
+
+// ListPermissions handles the /admin/permissions route.
+func (s *Server) ListPermissions(c *gin.Context) {
+	permissions, err := s.roles.ListPermissions()
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(permissions))
+	for _, permission := range permissions {
+		response = append(response, gin.H{"name": permission.Name, "description": permission.Description})
+	}
+	c.JSON(http.StatusOK, gin.H{"permissions": response})
+}
+
+// CreateRole handles the /admin/roles route.
+//
+// It takes a JSON payload with the name, description and permissions of the
+// role. Permissions that do not exist yet are created.
+func (s *Server) CreateRole(c *gin.Context) {
+	var input inputs.CreateRoleInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	var errs validation.Errors
+	if rbac.ValidateRole(input.Name) != nil {
+		errs.Add("name", validation.CodeInvalid)
+	}
+	if !validPermissions(input.Permissions) {
+		errs.Add("permissions", validation.CodeInvalid)
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+	if !s.canGrant(c, input.Permissions) {
+		return
+	}
+
+	role := models.Role{Name: input.Name, Description: input.Description}
+	err := s.roles.Create(&role, input.Permissions)
+	if errors.Is(err, repository.ErrDuplicate) {
+		respondError(c, http.StatusConflict, validation.MessageRoleExists)
+		return
+	}
+	if err != nil {
+		respondInternal(c, fmt.Errorf("could not create role: %w", err))
+		return
+	}
+
+	s.recordAdminAction(c, audit.ActionRoleCreate, audit.TypeRole, role.Name, audit.Metadata{"permissions": input.Permissions})
+	c.JSON(http.StatusCreated, roleResponse(&role))
+}
+
+// GetRole handles the /admin/roles/:name route.
+func (s *Server) GetRole(c *gin.Context) {
+	role := s.roleParam(c)
+	if role == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, roleResponse(role))
+}
+
+// UpdateRole handles the /admin/roles/:name route.
+//
+// It takes a JSON payload with an optional description and an optional list
+// of permissions that replaces the current one. Default roles cannot be
+// changed.
+func (s *Server) UpdateRole(c *gin.Context) {
+	var input inputs.UpdateRoleInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	role := s.roleParam(c)
+	if role == nil {
+		return
+	}
+	if rbac.IsDefaultRole(role.Name) {
+		respondError(c, http.StatusBadRequest, validation.MessageDefaultRoleChanged)
+		return
+	}
+
+	if input.Permissions != nil {
+		if !validPermissions(*input.Permissions) {
+			respondInvalid(c, validation.Errors{{Field: "permissions", Code: validation.CodeInvalid}})
+			return
+		}
+		if !s.canGrant(c, *input.Permissions) {
+			return
+		}
+	}
+
+	if err := s.roles.Update(role, input.Description, input.Permissions); err != nil {
+		respondInternal(c, fmt.Errorf("could not update role: %w", err))
+		return
+	}
+
+	metadata := audit.Metadata{}
+	if input.Description != nil {
+		metadata["description"] = *input.Description
+	}
+	if input.Permissions != nil {
+		metadata["permissions"] = *input.Permissions
+	}
+	s.recordAdminAction(c, audit.ActionRoleUpdate, audit.TypeRole, role.Name, metadata)
+
+	c.JSON(http.StatusOK, roleResponse(role))
+}
+
+// DeleteRole handles the /admin/roles/:name route.
+//
+// The role is removed from every user it was assigned to. Default roles
+// cannot be deleted.
+func (s *Server) DeleteRole(c *gin.Context) {
+	role := s.roleParam(c)
+	if role == nil {
+		return
+	}
+	if rbac.IsDefaultRole(role.Name) {
+		respondError(c, http.StatusBadRequest, validation.MessageDefaultRoleDeleted)
+		return
+	}
+
+	if err := s.roles.Delete(role); err != nil {
+		respondInternal(c, fmt.Errorf("could not delete role: %w", err))
+		return
+	}
+
+	s.recordAdminAction(c, audit.ActionRoleDelete, audit.TypeRole, role.Name, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted"})
+}
+
+// GetUserRoles handles the /admin/users/:id/roles route.
+//
+// It returns the roles assigned to the user, its effective roles including
+// the ones granted by its flags, and its permissions.
+func (s *Server) GetUserRoles(c *gin.Context) {
+	user := s.userParam(c, false)
+	if user == nil {
+		return
+	}
+
+	assigned, err := s.roles.AssignedNames(user.ID)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	grants, err := rbac.Resolve(s.roles, user)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assigned":    assigned,
+		"roles":       grants.Roles,
+		"permissions": grants.Permissions,
+	})
+}
+
+// AssignRole handles the /admin/users/:id/roles route.
+//
+// It takes a JSON payload with the name of the role to assign. Assigning a
+// role requires holding all of its permissions.
+func (s *Server) AssignRole(c *gin.Context) {
+	var input inputs.AssignRoleInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	user := s.userParam(c, false)
+	if user == nil {
+		return
+	}
+
+	role, err := s.roles.FindByName(input.Role)
+	if errors.Is(err, repository.ErrNotFound) {
+		respondError(c, http.StatusBadRequest, validation.MessageRoleNotFound)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+	if !s.canGrant(c, permissionNames(role)) {
+		return
+	}
+
+	if err := s.roles.Assign(user.ID, role); err != nil {
+		respondInternal(c, fmt.Errorf("could not assign role: %w", err))
+		return
+	}
+
+	s.recordAdminAction(c, audit.ActionRoleAssign, audit.TypeUser, userID(user), audit.Metadata{"role": role.Name})
+	c.JSON(http.StatusOK, gin.H{"message": "Role assigned"})
+}
+
+// UnassignRole handles the /admin/users/:id/roles/:name route.
+//
+// Removing a role requires holding all of its permissions, like assigning
+// it. Only the assignment is removed, a role granted by the flags of the
+// user stays in effect until the flag is cleared.
+func (s *Server) UnassignRole(c *gin.Context) {
+	user := s.userParam(c, false)
+	if user == nil {
+		return
+	}
+	role := s.roleParam(c)
+	if role == nil {
+		return
+	}
+	if !s.canGrant(c, permissionNames(role)) {
+		return
+	}
+
+	if err := s.roles.Unassign(user.ID, role); err != nil {
+		respondInternal(c, fmt.Errorf("could not unassign role: %w", err))
+		return
+	}
+
+	s.recordAdminAction(c, audit.ActionRoleUnassign, audit.TypeUser, userID(user), audit.Metadata{"role": role.Name})
+	c.JSON(http.StatusOK, gin.H{"message": "Role unassigned"})
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// userPath returns the path of an admin route for the user with the email.
+func userPath(t *testing.T, email, suffix string) string {
+	t.Helper()
+
+	var user models.User
+	require.NoError(t, testDB.Where("email = ?", email).First(&user).Error)
+	return fmt.Sprintf("/admin/users/%d%s", user.ID, suffix)
+}
+
+// loginAgain logs in with the password used by signUpAndLogin.
+func loginAgain(t *testing.T, email string) map[string]interface{} {
+	t.Helper()
+
+	rec, login := doJSON(t, http.MethodPost, "/auth/login", gin.H{"email": email, "password": "Password123"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return login
+}
+
+func TestRoles(t *testing.T) {
+	admin := accessCookie(signUpSuperuser(t, "rolesadmin", "rolesadmin@example.com")["access_token"])
+	signUpAndLogin(t, "rolesstaff", "rolesstaff@example.com")
+	signUpAndLogin(t, "rolesmanager", "rolesmanager@example.com")
+	member := signUpAndLogin(t, "rolesmember", "rolesmember@example.com")
+
+	rec, _ := doJSON(t, http.MethodGet, "/admin/roles", nil, accessCookie(member["access_token"]))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	// The staff flag grants read access
+	require.NoError(t, testDB.Model(&models.User{}).Where("email = ?", "rolesstaff@example.com").Update("is_staff", true).Error)
+	staff := accessCookie(loginAgain(t, "rolesstaff@example.com")["access_token"])
+	rec, resp := doJSON(t, http.MethodGet, "/admin/roles", nil, staff)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.GreaterOrEqual(t, len(resp["roles"].([]interface{})), 2)
+	rec, _ = doJSON(t, http.MethodPost, "/admin/roles", gin.H{"name": "nope"}, staff)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, resp = doJSON(t, http.MethodPost, "/admin/roles", gin.H{
+		"name":        "support",
+		"description": "Helps users",
+		"permissions": []string{"users:read", "users:write", "tickets:*"},
+	}, admin)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Equal(t, []interface{}{"users:read", "users:write", "tickets:*"}, resp["permissions"])
+
+	rec, _ = doJSON(t, http.MethodPost, "/admin/roles", gin.H{"name": "support"}, admin)
+	require.Equal(t, http.StatusConflict, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, "/admin/roles", gin.H{"name": "bad", "permissions": []string{"users"}}, admin)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, _ = doJSON(t, http.MethodPatch, "/admin/roles/superuser", gin.H{"permissions": []string{}}, admin)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "rolesmember@example.com", "/roles"), gin.H{"role": "support"}, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec, resp = doJSON(t, http.MethodGet, userPath(t, "rolesmember@example.com", "/roles"), nil, staff)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, []interface{}{"support"}, resp["assigned"])
+
+	// Tokens issued at login carry the roles and permissions
+	claims := &utils.Claims{}
+	_, _, err := jwt.NewParser().ParseUnverified(loginAgain(t, "rolesmember@example.com")["access_token"].(string), claims)
+	require.NoError(t, err)
+	require.Equal(t, []string{"support"}, claims.Roles)
+	require.Equal(t, []string{"tickets:*", "users:read", "users:write"}, claims.Permissions)
+
+	// Nobody can hand out permissions they do not have
+	rec, _ = doJSON(t, http.MethodPost, "/admin/roles", gin.H{
+		"name":        "role-manager",
+		"permissions": []string{"roles:read", "roles:write", "tickets:read"},
+	}, admin)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "rolesmanager@example.com", "/roles"), gin.H{"role": "role-manager"}, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	manager := accessCookie(loginAgain(t, "rolesmanager@example.com")["access_token"])
+
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "rolesmanager@example.com", "/roles"), gin.H{"role": "superuser"}, manager)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "rolesmanager@example.com", "/roles"), gin.H{"role": "support"}, manager)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, "/admin/roles", gin.H{"name": "reader", "permissions": []string{"tickets:read"}}, manager)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec, _ = doJSON(t, http.MethodPost, "/admin/roles", gin.H{"name": "escalate", "permissions": []string{"users:*"}}, manager)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	// The same goes for the scopes of service accounts
+	rec, _ = doJSON(t, http.MethodPost, "/admin/roles", gin.H{"name": "client-manager", "permissions": []string{"clients:write"}}, admin)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec, _ = doJSON(t, http.MethodPost, userPath(t, "rolesstaff@example.com", "/roles"), gin.H{"role": "client-manager"}, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	for scope, wantCode := range map[string]int{"roles:read": http.StatusCreated, "orders:read": http.StatusCreated, "users:write": http.StatusForbidden, "*": http.StatusForbidden} {
+		rec, _ = doJSON(t, http.MethodPost, "/admin/clients", gin.H{
+			"name":        "Service " + scope,
+			"grant_types": []string{"client_credentials"},
+			"scopes":      []string{scope},
+		}, staff)
+		require.Equal(t, wantCode, rec.Code, scope)
+	}
+
+	// Removing a role takes effect immediately
+	rec, _ = doJSON(t, http.MethodGet, "/admin/roles", nil, manager)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = doJSON(t, http.MethodDelete, "/admin/roles/role-manager", nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = doJSON(t, http.MethodGet, "/admin/roles", nil, manager)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodDelete, userPath(t, "rolesmember@example.com", "/roles/support"), nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec, resp = doJSON(t, http.MethodGet, userPath(t, "rolesmember@example.com", "/roles"), nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, resp["assigned"])
+	require.Empty(t, resp["permissions"])
+}
+
+func TestServiceAccountPermissions(t *testing.T) {
+	admin := accessCookie(signUpSuperuser(t, "scopesadmin", "scopesadmin@example.com")["access_token"])
+
+	rec, created := doJSON(t, http.MethodPost, "/admin/clients", gin.H{
+		"name":        "Directory",
+		"grant_types": []string{"client_credentials"},
+		"scopes":      []string{"roles:read"},
+	}, admin)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec, resp := clientCredentials(t, created["client_id"].(string), created["client_secret"].(string), "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	service := accessCookie(resp["access_token"])
+
+	// Service accounts get the permissions named by their scopes
+	rec, _ = doJSON(t, http.MethodGet, "/admin/roles", nil, service)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec, _ = doJSON(t, http.MethodPost, "/admin/roles", gin.H{"name": "nope"}, service)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -29,6 +29,8 @@ import (
 	"log"
 	"time"
 
+	"github.com/Maro1O9/goauth/internal/rbac"
+	"github.com/Maro1O9/goauth/internal/validation"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
@@ -37,16 +39,90 @@ import (
 
 func (s *Server) RegisterRoutes() http.Handler {
 	r := gin.Default()
+	if err := r.SetTrustedProxies(s.config.HTTP.TrustedProxies); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"}, // Add your frontend URL
+		AllowOrigins:     s.config.CORS.AllowOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
 		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type"},
-		AllowCredentials: true, // Enable cookies/auth
+		AllowCredentials: s.config.CORS.AllowCredentials,
+		MaxAge:           s.config.CORS.MaxAge,
 	}))
-	auth := r.Group("/auth")
+	r.NoRoute(func(c *gin.Context) {
+		respondError(c, http.StatusNotFound, validation.MessageNotFound)
+	})
+	r.GET("/.well-known/jwks.json", s.JWKS)
+	r.GET("/.well-known/openid-configuration", s.OpenIDConfiguration)
+
+	oauth := r.Group("/oauth", s.RateLimit("oauth", s.rateLimits.oauth, KeyByIP))
+	oauth.GET("/authorize", s.Authorize)
+	oauth.POST("/authorize", s.Authorize)
+	oauth.POST("/token", s.RateLimit("token", s.rateLimits.token, KeyByAPIKey), s.Token)
+	oauth.GET("/userinfo", s.RequireAuth(), s.RequireUserSubject(), s.UserInfo)
+	oauth.POST("/userinfo", s.RequireAuth(), s.RequireUserSubject(), s.UserInfo)
+
+	auth := r.Group("/auth", s.RateLimit("auth", s.rateLimits.auth, KeyByIP))
 	auth.POST("/signup", s.SignUp)
 	auth.POST("/login", s.Login)
+	auth.POST("/refresh", s.Refresh)
+	auth.POST("/password/forgot", s.ForgotPassword)
+	auth.POST("/password/reset", s.ResetPassword)
+	auth.GET("/verify-email", s.VerifyEmail)
+	auth.POST("/verify-email", s.VerifyEmail)
+	auth.POST("/verify-email/resend", s.ResendVerification)
+	auth.POST("/2fa/challenge", s.MFAChallenge)
+	auth.POST("/webauthn/login/begin", s.BeginWebAuthnLogin)
+	auth.POST("/webauthn/login/finish", s.FinishWebAuthnLogin)
+
+	authenticated := auth.Group("", s.RequireAuth(), s.RequireUser())
+	authenticated.POST("/logout", s.Logout)
+	authenticated.POST("/logout-all", s.LogoutAll)
+	authenticated.POST("/2fa/totp/enroll", s.EnrollTOTP)
+	authenticated.POST("/2fa/totp/confirm", s.ConfirmTOTP)
+	authenticated.POST("/2fa/totp/disable", s.DisableTOTP)
+	authenticated.POST("/2fa/recovery-codes", s.RegenerateRecoveryCodes)
+	authenticated.POST("/webauthn/register/begin", s.BeginWebAuthnRegistration)
+	authenticated.POST("/webauthn/register/finish", s.FinishWebAuthnRegistration)
+	authenticated.GET("/webauthn/credentials", s.ListWebAuthnCredentials)
+	authenticated.DELETE("/webauthn/credentials/:id", s.DeleteWebAuthnCredential)
+
+	me := r.Group("/me", s.RequireAuth(), s.RequireUser(), s.RateLimit("api", s.rateLimits.api, KeyByUser))
+	me.GET("", s.GetProfile)
+	me.PATCH("", s.UpdateProfile)
+	me.POST("/password", s.ChangePassword)
+	me.POST("/email", s.ChangeEmail)
+	me.GET("/sessions", s.ListSessions)
+	me.DELETE("/sessions/:id", s.RevokeSession)
+
+	admin := r.Group("/admin", s.RequireAuth(), s.RateLimit("api", s.rateLimits.api, KeyByUser))
+	admin.GET("/clients", s.RequirePermission(rbac.ClientsRead), s.ListClients)
+	admin.POST("/clients", s.RequirePermission(rbac.ClientsWrite), s.CreateClient)
+	admin.GET("/clients/:client_id", s.RequirePermission(rbac.ClientsRead), s.GetClient)
+	admin.POST("/clients/:client_id/rotate-secret", s.RequirePermission(rbac.ClientsWrite), s.RotateClientSecret)
+	admin.POST("/clients/:client_id/disable", s.RequirePermission(rbac.ClientsWrite), s.DisableClient)
+	admin.POST("/clients/:client_id/enable", s.RequirePermission(rbac.ClientsWrite), s.EnableClient)
+	admin.GET("/roles", s.RequirePermission(rbac.RolesRead), s.ListRoles)
+	admin.POST("/roles", s.RequirePermission(rbac.RolesWrite), s.CreateRole)
+	admin.GET("/roles/:name", s.RequirePermission(rbac.RolesRead), s.GetRole)
+	admin.PATCH("/roles/:name", s.RequirePermission(rbac.RolesWrite), s.UpdateRole)
+	admin.DELETE("/roles/:name", s.RequirePermission(rbac.RolesWrite), s.DeleteRole)
+	admin.GET("/permissions", s.RequirePermission(rbac.RolesRead), s.ListPermissions)
+	admin.GET("/users", s.RequirePermission(rbac.UsersRead), s.ListUsers)
+	admin.GET("/users/:id", s.RequirePermission(rbac.UsersRead), s.GetUser)
+	admin.PATCH("/users/:id", s.RequirePermission(rbac.UsersWrite), s.UpdateUser)
+	admin.DELETE("/users/:id", s.RequirePermission(rbac.UsersWrite), s.DeleteUser)
+	admin.POST("/users/:id/restore", s.RequirePermission(rbac.UsersWrite), s.RestoreUser)
+	admin.POST("/users/:id/password-reset", s.RequirePermission(rbac.UsersWrite), s.ForcePasswordReset)
+	admin.POST("/users/:id/unlock", s.RequirePermission(rbac.UsersWrite), s.UnlockUser)
+	admin.GET("/users/:id/sessions", s.RequirePermission(rbac.UsersRead), s.ListUserSessions)
+	admin.DELETE("/users/:id/sessions/:session_id", s.RequirePermission(rbac.UsersWrite), s.RevokeUserSession)
+	admin.GET("/users/:id/roles", s.RequirePermission(rbac.RolesRead), s.GetUserRoles)
+	admin.POST("/users/:id/roles", s.RequirePermission(rbac.RolesWrite), s.AssignRole)
+	admin.DELETE("/users/:id/roles/:name", s.RequirePermission(rbac.RolesWrite), s.UnassignRole)
+	admin.GET("/audit-events", s.RequirePermission(rbac.AuditRead), s.ListAuditEvents)
+	admin.GET("/audit-events/export", s.RequirePermission(rbac.AuditRead), s.ExportAuditEvents)
 
 	r.GET("/websocket", s.websocketHandler)
 
@@ -22,36 +22,397 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
-	"os"
-	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/config"
 	"github.com/Maro1O9/goauth/internal/database"
-	"github.com/Maro1O9/goauth/internal/database/models"
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/Maro1O9/goauth/internal/keys"
+	"github.com/Maro1O9/goauth/internal/lockout"
+	"github.com/Maro1O9/goauth/internal/mailer"
+	"github.com/Maro1O9/goauth/internal/migrate"
+	"github.com/Maro1O9/goauth/internal/password"
+	"github.com/Maro1O9/goauth/internal/ratelimit"
+	"github.com/Maro1O9/goauth/internal/rbac"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/revocation"
+	"github.com/Maro1O9/goauth/internal/webauthn"
+	"gorm.io/gorm"
 )
 
+// revocationSyncInterval is how often the single tokens revoked by other
+// instances are picked up, and so how long such a token may still be
+// accepted. Signing a user out everywhere raises its token version instead,
+// which takes effect on every instance right away.
+const revocationSyncInterval = 15 * time.Second
+
+// revocationPruneInterval is how often expired revocations are pruned.
+const revocationPruneInterval = time.Hour
+
+// keyMaintenanceInterval is how often the signing keys are reloaded and
+// rotated when due.
+const keyMaintenanceInterval = 10 * time.Minute
+
 type Server struct {
-	port int
+	config             *config.Config
+	db                 *gorm.DB
+	users              repository.UserRepository
+	sessions           repository.SessionRepository
+	refreshTokens      repository.RefreshTokenRepository
+	roles              repository.RoleRepository
+	clients            repository.ClientRepository
+	totp               repository.TOTPRepository
+	passkeys           repository.WebAuthnRepository
+	consents           repository.ConsentRepository
+	authorizationCodes repository.AuthorizationCodeRepository
+	passwordResets     repository.PasswordResetRepository
+	emailVerifications repository.EmailVerificationRepository
+	passwordHistory    repository.PasswordHistoryRepository
+	appURL             string
+	issuer             string
+	mailer             mailer.Mailer
+	revocations        *revocation.Store
+	verificationPolicy VerificationPolicy
+	totpIssuer         string
+	webauthn           *webauthn.RelyingParty
+	keys               *keys.Manager
+	loginGuard         *lockout.Guard
+	rateLimiter        *ratelimit.Limiter
+	rateLimits         rateLimits
+	auditLog           *audit.Writer
+	auditEvents        audit.Store
+	passwords          *password.Manager
+	passwordPolicy     *password.Policy
+	// encryptionKey is the secret the key encrypting secrets at rest, such
+	// as TOTP secrets and signing keys, is derived from.
+	encryptionKey []byte
+
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+}
+
+// OpenDatabase opens the database at dsn, using the default connection
+// pool, and applies the pending migrations to it. The driver is picked from
+// the scheme of dsn, see database.Config.
+func OpenDatabase(dsn string) (*gorm.DB, error) {
+	db, err := database.Open(database.Config{URL: dsn})
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := migrate.ForDatabase(db)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := migrator.Up(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// prepareDatabase applies the pending migrations to db if migrateOnStart
+// is set, and otherwise fails if any migration is pending, as the server
+// cannot run on an outdated schema.
+func prepareDatabase(db *gorm.DB, migrateOnStart bool) error {
+	migrator, err := migrate.ForDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	if migrateOnStart {
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			log.Printf("applied migration %d_%s", migration.Version, migration.Name)
+		}
+		return err
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d database migrations are pending, run the migrate up command or set DB_MIGRATE_ON_START", len(pending))
+	}
+	return nil
+}
+
+// Option configures a server created by NewServer.
+type Option func(*Server)
+
+// WithDatabase makes the server keep its data in db instead of opening the
+// database of its configuration. The migrations must be
+// applied, see OpenDatabase.
+func WithDatabase(db *gorm.DB) Option {
+	return func(s *Server) {
+		s.db = db
+	}
+}
+
+// WithRepositories makes the server keep its records in the non-nil
+// repositories of repos.
+//
+// Records refer to each other, such as sessions to their users, so the
+// repositories not given share the backing of the database given with
+// WithDatabase, or of memory if no database is given. A server given
+// repositories but no database does not open the database of its
+// configuration, and keeps its signing keys, failed logins, revocations and
+// audit events in memory as well.
+func WithRepositories(repos repository.Repositories) Option {
+	return func(s *Server) {
+		s.setRepositories(repos)
+	}
 }
 
-func NewServer() *http.Server {
-	database.MakeDb(&models.User{})
-	port, _ := strconv.Atoi(os.Getenv("PORT"))
-	NewServer := &Server{
-		port: port,
+// WithUserRepository makes the server keep its users in users.
+func WithUserRepository(users repository.UserRepository) Option {
+	return func(s *Server) {
+		s.users = users
+	}
+}
+
+// WithSessionRepository makes the server keep its sessions in sessions.
+// Revoking a session revokes its refresh tokens, so sessions has to share
+// its backing with the refresh token repository.
+func WithSessionRepository(sessions repository.SessionRepository) Option {
+	return func(s *Server) {
+		s.sessions = sessions
+	}
+}
+
+// WithRefreshTokenRepository makes the server keep its refresh tokens in
+// refreshTokens.
+func WithRefreshTokenRepository(refreshTokens repository.RefreshTokenRepository) Option {
+	return func(s *Server) {
+		s.refreshTokens = refreshTokens
+	}
+}
+
+// WithRoleRepository makes the server keep its roles in roles.
+func WithRoleRepository(roles repository.RoleRepository) Option {
+	return func(s *Server) {
+		s.roles = roles
+	}
+}
+
+// WithClientRepository makes the server keep its OAuth clients in clients.
+func WithClientRepository(clients repository.ClientRepository) Option {
+	return func(s *Server) {
+		s.clients = clients
+	}
+}
+
+// WithTOTPRepository makes the server keep its TOTP credentials and
+// recovery codes in totp.
+func WithTOTPRepository(totp repository.TOTPRepository) Option {
+	return func(s *Server) {
+		s.totp = totp
+	}
+}
+
+// WithWebAuthnRepository makes the server keep its passkeys and WebAuthn
+// challenges in passkeys.
+func WithWebAuthnRepository(passkeys repository.WebAuthnRepository) Option {
+	return func(s *Server) {
+		s.passkeys = passkeys
+	}
+}
+
+// WithConsentRepository makes the server keep its OAuth consents in
+// consents.
+func WithConsentRepository(consents repository.ConsentRepository) Option {
+	return func(s *Server) {
+		s.consents = consents
+	}
+}
+
+// WithAuthorizationCodeRepository makes the server keep its authorization
+// codes in codes.
+func WithAuthorizationCodeRepository(codes repository.AuthorizationCodeRepository) Option {
+	return func(s *Server) {
+		s.authorizationCodes = codes
+	}
+}
+
+// WithPasswordResetRepository makes the server keep its password reset
+// tokens in resets.
+func WithPasswordResetRepository(resets repository.PasswordResetRepository) Option {
+	return func(s *Server) {
+		s.passwordResets = resets
+	}
+}
+
+// WithEmailVerificationRepository makes the server keep its email
+// verification tokens in verifications.
+func WithEmailVerificationRepository(verifications repository.EmailVerificationRepository) Option {
+	return func(s *Server) {
+		s.emailVerifications = verifications
+	}
+}
+
+// WithPasswordHistoryRepository makes the server keep its password history
+// in history.
+func WithPasswordHistoryRepository(history repository.PasswordHistoryRepository) Option {
+	return func(s *Server) {
+		s.passwordHistory = history
+	}
+}
+
+// repositories returns the repositories of the server.
+func (s *Server) repositories() repository.Repositories {
+	return repository.Repositories{
+		Users:              s.users,
+		Sessions:           s.sessions,
+		RefreshTokens:      s.refreshTokens,
+		Roles:              s.roles,
+		Clients:            s.clients,
+		TOTP:               s.totp,
+		WebAuthn:           s.passkeys,
+		Consents:           s.consents,
+		AuthorizationCodes: s.authorizationCodes,
+		PasswordResets:     s.passwordResets,
+		EmailVerifications: s.emailVerifications,
+		PasswordHistory:    s.passwordHistory,
+	}
+}
+
+// setRepositories replaces the repositories of the server with the non-nil
+// ones of repos.
+func (s *Server) setRepositories(repos repository.Repositories) {
+	repos.Fill(s.repositories())
+	s.users, s.sessions, s.refreshTokens = repos.Users, repos.Sessions, repos.RefreshTokens
+	s.roles, s.clients, s.totp, s.passkeys = repos.Roles, repos.Clients, repos.TOTP, repos.WebAuthn
+	s.consents, s.authorizationCodes = repos.Consents, repos.AuthorizationCodes
+	s.passwordResets, s.emailVerifications = repos.PasswordResets, repos.EmailVerifications
+	s.passwordHistory = repos.PasswordHistory
+}
+
+// NewServer creates the HTTP server from a validated configuration, see
+// config.Load, and the options. Every server has its own database, signing
+// keys and background jobs, so several servers can run in one process.
+func NewServer(cfg *config.Config, options ...Option) *http.Server {
+	NewServer, err := newServer(cfg, options...)
+	if err != nil {
+		log.Fatalln(err.Error())
 	}
 
 	// Declare Server config
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", NewServer.port),
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
 		Handler:      NewServer.RegisterRoutes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
 	}
 
+	// Background jobs run until the server is shut down
+	ctx, cancel := context.WithCancel(context.Background())
+	server.RegisterOnShutdown(cancel)
+	go NewServer.revocations.Run(ctx, revocationSyncInterval, revocationPruneInterval)
+	go NewServer.keys.Run(ctx, keyMaintenanceInterval)
+	go NewServer.loginGuard.Run(ctx, loginAttemptPruneInterval)
+	go NewServer.auditLog.Run(ctx)
+
 	return server
 }
+
+// newServer creates the server without starting its background jobs.
+func newServer(cfg *config.Config, options ...Option) (*Server, error) {
+	s := &Server{config: cfg}
+	for _, option := range options {
+		option(s)
+	}
+
+	// Secrets at rest are encrypted with the secret key unless a separate
+	// encryption key is set
+	s.encryptionKey = []byte(cfg.EncryptionKey)
+	if len(s.encryptionKey) == 0 {
+		s.encryptionKey = []byte(cfg.SecretKey)
+	}
+
+	var err error
+	given := s.repositories()
+	if s.db == nil && given.Empty() {
+		if s.db, err = database.Open(cfg.Database.Config()); err != nil {
+			return nil, err
+		}
+		if err := prepareDatabase(s.db, cfg.Database.MigrateOnStart); err != nil {
+			return nil, err
+		}
+	}
+
+	// The repositories not given share the backing of the other records
+	if s.db != nil {
+		given.Fill(repository.NewGormRepositories(s.db))
+		s.revocations = revocation.NewStore(revocation.NewDBBackend(s.db))
+		s.auditEvents = audit.NewDBStore(s.db)
+	} else {
+		given.Fill(repository.NewMemoryRepositories())
+		s.revocations = revocation.NewStore(revocation.NewMemoryBackend())
+		s.auditEvents = audit.NewMemoryStore()
+	}
+	s.setRepositories(given)
+
+	if s.verificationPolicy, err = ParseVerificationPolicy(cfg.Verification.Policy); err != nil {
+		return nil, err
+	}
+
+	if s.keys, err = newKeyManager(s.db, cfg.JWT, s.encryptionKey); err != nil {
+		return nil, err
+	}
+
+	if s.loginGuard, err = newLoginGuard(s.db, cfg.Lockout); err != nil {
+		return nil, err
+	}
+
+	if s.rateLimiter, s.rateLimits, err = newRateLimiter(cfg.RateLimit); err != nil {
+		return nil, err
+	}
+
+	if s.passwordPolicy, err = cfg.PasswordPolicy.Policy(); err != nil {
+		return nil, err
+	}
+	// bcrypt counts bytes, so multibyte characters shorten the longest
+	// password it hashes
+	if cfg.Password.Algorithm == password.AlgorithmBcrypt {
+		s.passwordPolicy.MaxBytes = password.BcryptMaxLength
+	}
+
+	s.appURL = cfg.AppURL
+	s.issuer = cfg.OIDC.Issuer
+	s.mailer = mailer.New(cfg.Mail.Dir)
+	s.totpIssuer = cfg.TOTP.Issuer
+	s.auditLog = audit.NewWriter(s.auditEvents, 0)
+	s.passwords = cfg.Password.Manager()
+
+	// The issuer defaults to the public URL of the application
+	if s.issuer == "" {
+		s.issuer = s.appURL
+	}
+	if s.issuer == "" {
+		s.issuer = "http://localhost"
+	}
+	s.issuer = strings.TrimSuffix(s.issuer, "/")
+
+	if s.totpIssuer == "" {
+		s.totpIssuer = "goAuth"
+	}
+
+	if s.webauthn, err = newRelyingParty(cfg.WebAuthn, s.totpIssuer, s.appURL); err != nil {
+		return nil, err
+	}
+
+	if err := s.revocations.Load(); err != nil {
+		return nil, err
+	}
+
+	if err := rbac.Seed(s.roles); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/database"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareDatabase(t *testing.T) {
+	db, err := database.Open(database.Config{URL: filepath.Join(t.TempDir(), "test.db")})
+	require.NoError(t, err)
+
+	// Pending migrations are only applied when asked to
+	require.ErrorContains(t, prepareDatabase(db, false), "pending")
+	require.False(t, db.Migrator().HasTable(&models.User{}))
+
+	require.NoError(t, prepareDatabase(db, true))
+	require.True(t, db.Migrator().HasTable(&models.User{}))
+
+	require.NoError(t, prepareDatabase(db, false))
+}
@@ -0,0 +1,290 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/server"
+	"github.com/Maro1O9/goauth/internal/totp"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var (
+	handler http.Handler
+	mailDir string
+	testDB  *gorm.DB
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "goauth-server-test")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	testDB, err = server.OpenDatabase(filepath.Join(dir, "test.db"))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	mailDir = filepath.Join(dir, "mail")
+	gin.SetMode(gin.TestMode)
+	handler = server.NewServer(testConfig(), server.WithDatabase(testDB)).Handler
+
+	exitCode := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(exitCode)
+}
+
+// testConfig returns the configuration of the test server.
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.SecretKey = "a test secret key of at least 32 characters"
+	cfg.Mail.Dir = mailDir
+	// Every test shares the same client IP
+	cfg.RateLimit.Auth = "100000/1m"
+	cfg.RateLimit.OAuth = "100000/1m"
+	// The tests share simple passwords like Password123
+	cfg.PasswordPolicy.MinStrength = 0
+	return cfg
+}
+
+// doJSON sends a request with an optional JSON body to the test server and
+// decodes the JSON response into a map.
+func doJSON(t *testing.T, method, path string, body interface{}, cookies ...*http.Cookie) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	return serveJSON(t, handler, method, path, body, cookies...)
+}
+
+// serveJSON is like doJSON but sends the request to h.
+func serveJSON(t *testing.T, h http.Handler, method, path string, body interface{}, cookies ...*http.Cookie) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	resp := map[string]interface{}{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+// signUpAndLogin creates a user through the /auth/signup route and logs in
+// with it, returning the login response.
+func signUpAndLogin(t *testing.T, username, email string) map[string]interface{} {
+	t.Helper()
+
+	rec, resp := doJSON(t, http.MethodPost, "/auth/signup", gin.H{
+		"username":         username,
+		"name":             username,
+		"email":            email,
+		"password":         "Password123",
+		"confirm_password": "Password123",
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Equal(t, "Signup successful", resp["message"])
+
+	rec, resp = doJSON(t, http.MethodPost, "/auth/login", gin.H{
+		"email":    email,
+		"password": "Password123",
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return resp
+}
+
+// lastMail returns the content of the most recent mail sent to the recipient.
+func lastMail(t *testing.T, to string) string {
+	t.Helper()
+
+	entries, err := os.ReadDir(mailDir)
+	require.NoError(t, err)
+
+	// File names start with the send time, so the last match is the newest
+	for i := len(entries) - 1; i >= 0; i-- {
+		if strings.HasSuffix(entries[i].Name(), "-"+to+".eml") {
+			content, err := os.ReadFile(filepath.Join(mailDir, entries[i].Name()))
+			require.NoError(t, err)
+			return string(content)
+		}
+	}
+	t.Fatalf("no mail sent to %s", to)
+	return ""
+}
+
+var mailTokenRe = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// mailToken extracts the token from the link in the most recent mail sent
+// to the recipient.
+func mailToken(t *testing.T, to string) string {
+	t.Helper()
+
+	match := mailTokenRe.FindStringSubmatch(lastMail(t, to))
+	require.NotNil(t, match, "no token in mail")
+	return match[1]
+}
+
+func TestServersAreIsolated(t *testing.T) {
+	access := accessCookie(signUpAndLogin(t, "isolatedtotp", "isolatedtotp@example.com")["access_token"])
+	rec, enroll := doJSON(t, http.MethodPost, "/auth/2fa/totp/enroll", nil, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	users := repository.NewMemoryUserRepository()
+	cfg := testConfig()
+	cfg.Tokens.AccessTTL = 5 * time.Minute
+	cfg.Cookies = config.Cookies{Domain: "auth.example.com", Secure: true, SameSite: "strict"}
+	cfg.EncryptionKey = "another encryption key"
+	other := server.NewServer(cfg, server.WithUserRepository(users))
+	t.Cleanup(func() { other.Shutdown(context.Background()) })
+
+	signup := gin.H{
+		"username":         "isolated",
+		"name":             "isolated",
+		"email":            "isolated@example.com",
+		"password":         "Password123",
+		"confirm_password": "Password123",
+	}
+	login := gin.H{"email": "isolated@example.com", "password": "Password123"}
+
+	// The test server still decrypts the secrets it encrypted
+	code, err := totp.Code(enroll["secret"].(string), time.Now())
+	require.NoError(t, err)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/2fa/totp/confirm", gin.H{"code": code}, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec, _ = serveJSON(t, other.Handler, http.MethodPost, "/auth/signup", signup)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec, resp := serveJSON(t, other.Handler, http.MethodPost, "/auth/login", login)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// The tokens and cookies follow the configuration of the other server
+	require.EqualValues(t, 300, resp["expires_in"])
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 2)
+	for _, cookie := range cookies {
+		require.Equal(t, "auth.example.com", cookie.Domain)
+		require.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+		require.True(t, cookie.Secure)
+	}
+	require.Equal(t, 300, cookies[0].MaxAge)
+	rec, _ = serveJSON(t, other.Handler, http.MethodGet, "/me", nil, accessCookie(resp["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// The user lives in the repository of the other server only
+	_, err = users.FindByEmail("isolated@example.com")
+	require.NoError(t, err)
+
+	// The test server neither knows the user nor accepts its tokens
+	rec, _ = doJSON(t, http.MethodPost, "/auth/login", login)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, accessCookie(resp["access_token"]))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Signing up the same user on the test server does not clash
+	rec, _ = doJSON(t, http.MethodPost, "/auth/signup", signup)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+}
+
+func TestServerWithoutDatabase(t *testing.T) {
+	cfg := testConfig()
+	// The server must not open the database of its configuration
+	cfg.Database.URL = "unsupported://"
+	srv := server.NewServer(cfg, server.WithRepositories(repository.NewMemoryRepositories()))
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+
+	rec, _ := serveJSON(t, srv.Handler, http.MethodPost, "/auth/signup", gin.H{
+		"username":         "memory",
+		"name":             "memory",
+		"email":            "memory@example.com",
+		"password":         "Password123",
+		"confirm_password": "Password123",
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	login := gin.H{"email": "memory@example.com", "password": "Password123"}
+	rec, tokens := serveJSON(t, srv.Handler, http.MethodPost, "/auth/login", login)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	access := accessCookie(tokens["access_token"])
+
+	rec, _ = serveJSON(t, srv.Handler, http.MethodGet, "/me", nil, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec, sessions := serveJSON(t, srv.Handler, http.MethodGet, "/me/sessions", nil, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Len(t, sessions["sessions"], 1)
+
+	// Refresh tokens rotate, and a replay revokes the family
+	rec, refreshed := serveJSON(t, srv.Handler, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": tokens["refresh_token"]})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec, _ = serveJSON(t, srv.Handler, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": tokens["refresh_token"]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = serveJSON(t, srv.Handler, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": refreshed["refresh_token"]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Two-factor authentication with a recovery code
+	rec, enroll := serveJSON(t, srv.Handler, http.MethodPost, "/auth/2fa/totp/enroll", nil, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	code, err := totp.Code(enroll["secret"].(string), time.Now())
+	require.NoError(t, err)
+	rec, confirm := serveJSON(t, srv.Handler, http.MethodPost, "/auth/2fa/totp/confirm", gin.H{"code": code}, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	recoveryCode := confirm["recovery_codes"].([]interface{})[0]
+
+	rec, challenge := serveJSON(t, srv.Handler, http.MethodPost, "/auth/login", login)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	challengeInput := gin.H{"mfa_token": challenge["mfa_token"], "recovery_code": recoveryCode}
+	rec, _ = serveJSON(t, srv.Handler, http.MethodPost, "/auth/2fa/challenge", challengeInput)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// A password reset revokes the tokens issued before
+	rec, _ = serveJSON(t, srv.Handler, http.MethodPost, "/auth/password/forgot", gin.H{"email": "memory@example.com"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	reset := gin.H{"token": mailToken(t, "memory@example.com"), "password": "Password456", "confirm_password": "Password456"}
+	rec, _ = serveJSON(t, srv.Handler, http.MethodPost, "/auth/password/reset", reset)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec, _ = serveJSON(t, srv.Handler, http.MethodPost, "/auth/password/reset", reset)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, _ = serveJSON(t, srv.Handler, http.MethodGet, "/me", nil, access)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
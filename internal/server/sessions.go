@@ -0,0 +1,265 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+// sessions.go contains the sessions started by a login and the routes that
+// list and revoke them.
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// sessionTouchInterval is how often the last seen time of a session is
+// updated while its access tokens are used, so not every request writes.
+const sessionTouchInterval = time.Minute
+
+// userAgentBrowsers and userAgentSystems map user agent tokens to the
+// browser and operating system they stand for. The first match wins, so
+// tokens that appear alongside others come first.
+var (
+	userAgentBrowsers = [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	userAgentSystems = [][2]string{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"CrOS", "ChromeOS"},
+		{"Macintosh", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// describeDevice returns a short description of the device a user agent
+// belongs to, such as "Firefox on Linux".
+func describeDevice(userAgent string) string {
+	match := func(tokens [][2]string) string {
+		for _, token := range tokens {
+			if strings.Contains(userAgent, token[0]) {
+				return token[1]
+			}
+		}
+		return ""
+	}
+
+	browser, system := match(userAgentBrowsers), match(userAgentSystems)
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	return "Unknown device"
+}
+
+// startSession creates the session of a login of the user from the device
+// of the request, lasting for ttl unless it is extended.
+func startSession(c *gin.Context, sessions repository.SessionRepository, user *models.User, ttl time.Duration) (*models.Session, error) {
+	now := time.Now()
+	agent := userAgent(c)
+	session := &models.Session{
+		UserID:     user.ID,
+		Device:     describeDevice(agent),
+		UserAgent:  agent,
+		IP:         c.ClientIP(),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if err := sessions.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// extendSession keeps a session alive for ttl, the lifetime of its newest
+// refresh token, when the tokens are refreshed.
+func extendSession(c *gin.Context, sessions repository.SessionRepository, id uint, ttl time.Duration) error {
+	now := time.Now()
+	return sessions.Touch(id, c.ClientIP(), now, now.Add(ttl))
+}
+
+// parseSessionID parses the ID of a session from a token or a route
+// parameter. IDs that do not parse name no session and return
+// repository.ErrNotFound.
+func parseSessionID(id string) (uint, error) {
+	parsed, err := strconv.ParseUint(id, 10, 0)
+	if err != nil {
+		return 0, repository.ErrNotFound
+	}
+	return uint(parsed), nil
+}
+
+// checkSession reports whether the session an access token was issued for
+// is still active, and records that it was seen. Tokens without a session
+// were not issued at login and are not tied to one.
+func (s *Server) checkSession(c *gin.Context, claims *utils.Claims, user *models.User) bool {
+	if claims.SessionID == "" {
+		return true
+	}
+
+	now := time.Now()
+	id, err := parseSessionID(claims.SessionID)
+	var session *models.Session
+	if err == nil {
+		session, err = s.sessions.FindActive(id, user.ID, now)
+	}
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("could not load session %s: %v", claims.SessionID, err)
+		}
+		return false
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.sessions.Touch(session.ID, c.ClientIP(), now, time.Time{}); err != nil {
+			log.Printf("could not update session %d: %v", session.ID, err)
+		}
+	}
+	return true
+}
+
+// sessionResponse is the JSON representation of a session. current tells
+// whether the request was made with a token of the session.
+func sessionResponse(session *models.Session, current bool) gin.H {
+	return gin.H{
+		"id":           session.ID,
+		"device":       session.Device,
+		"user_agent":   session.UserAgent,
+		"ip":           session.IP,
+		"created_at":   session.CreatedAt,
+		"last_seen_at": session.LastSeenAt,
+		"expires_at":   session.ExpiresAt,
+		"current":      current,
+	}
+}
+
+// listSessions responds with the active sessions of the user, the most
+// recently seen first.
+func (s *Server) listSessions(c *gin.Context, user *models.User) {
+	sessions, err := s.sessions.ListActive(user.ID, time.Now())
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	currentID := MustPrincipal(c).Claims.SessionID
+	response := make([]gin.H, 0, len(sessions))
+	for i := range sessions {
+		current := strconv.FormatUint(uint64(sessions[i].ID), 10) == currentID
+		response = append(response, sessionResponse(&sessions[i], current))
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": response})
+}
+
+// revokeSession revokes the session of the user identified by the route
+// parameter and records it in the audit log. It responds with a 404 status
+// code and returns false if the user has no such active session.
+func (s *Server) revokeSession(c *gin.Context, user *models.User, param string) bool {
+	id := c.Param(param)
+	sessionID, err := parseSessionID(id)
+	if err == nil {
+		err = s.sessions.Revoke(sessionID, user.ID, time.Now())
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		respondError(c, http.StatusNotFound, validation.MessageSessionNotFound)
+		return false
+	}
+	if err != nil {
+		respondInternal(c, fmt.Errorf("could not revoke session %s: %w", id, err))
+		return false
+	}
+
+	s.recordAdminAction(c, audit.ActionSessionRevoke, audit.TypeUser, userID(user), audit.Metadata{"session_id": id})
+	return true
+}
+
+// ListSessions handles the /me/sessions route.
+//
+// It returns the devices the authenticated user is logged in on. The
+// session of the token the request was made with is marked as current.
+func (s *Server) ListSessions(c *gin.Context) {
+	s.listSessions(c, MustPrincipal(c).User)
+}
+
+// RevokeSession handles the /me/sessions/:id route.
+//
+// It signs the authenticated user out of one device: the tokens of the
+// session stop working right away. Revoking the current session also
+// clears the token cookies.
+func (s *Server) RevokeSession(c *gin.Context) {
+	principal := MustPrincipal(c)
+	if !s.revokeSession(c, principal.User, "id") {
+		return
+	}
+
+	if c.Param("id") == principal.Claims.SessionID {
+		s.clearTokenCookies(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// ListUserSessions handles the /admin/users/:id/sessions route.
+func (s *Server) ListUserSessions(c *gin.Context) {
+	user := s.userParam(c, false)
+	if user == nil {
+		return
+	}
+	s.listSessions(c, user)
+}
+
+// RevokeUserSession handles the /admin/users/:id/sessions/:session_id route.
+//
+// It signs the user out of one device, for example when a device was
+// stolen.
+func (s *Server) RevokeUserSession(c *gin.Context) {
+	user := s.userParam(c, false)
+	if user == nil || !s.canManage(c, user) {
+		return
+	}
+	if !s.revokeSession(c, user, "session_id") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
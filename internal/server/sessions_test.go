@@ -0,0 +1,201 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/server"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const firefoxOnLinux = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+
+// tokenClaims returns the claims of a token issued by the test server. The
+// signature was checked by the server already.
+func tokenClaims(t *testing.T, token string) *utils.Claims {
+	t.Helper()
+
+	claims := &utils.Claims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	return claims
+}
+
+// sessionID returns the session ID carried by an access token.
+func sessionID(t *testing.T, token interface{}) string {
+	t.Helper()
+
+	claims := tokenClaims(t, token.(string))
+	require.NotEmpty(t, claims.SessionID)
+	return claims.SessionID
+}
+
+// listSessions returns the sessions listed at path.
+func listSessions(t *testing.T, path string, token interface{}) []interface{} {
+	t.Helper()
+
+	rec, resp := doJSON(t, http.MethodGet, path, nil, accessCookie(token))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return resp["sessions"].([]interface{})
+}
+
+func TestSessions(t *testing.T) {
+	laptop := signUpAndLogin(t, "sessionuser", "session@example.com")
+	rec, phone := loginFrom(t, "198.51.100.20", "session@example.com", "Password123", "User-Agent", firefoxOnLinux)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	laptopID, phoneID := sessionID(t, laptop["access_token"]), sessionID(t, phone["access_token"])
+	require.NotEqual(t, laptopID, phoneID)
+
+	// The most recently seen session comes first
+	sessions := listSessions(t, "/me/sessions", laptop["access_token"])
+	require.Len(t, sessions, 2)
+	first, second := sessions[0].(map[string]interface{}), sessions[1].(map[string]interface{})
+	require.Equal(t, phoneID, fmt.Sprint(first["id"]))
+	require.Equal(t, "Firefox on Linux", first["device"])
+	require.Equal(t, firefoxOnLinux, first["user_agent"])
+	require.Equal(t, "198.51.100.20", first["ip"])
+	require.Equal(t, false, first["current"])
+	require.Equal(t, laptopID, fmt.Sprint(second["id"]))
+	require.Equal(t, "Unknown device", second["device"])
+	require.Equal(t, true, second["current"])
+
+	// Refreshing keeps the session
+	rec, refreshed := doJSON(t, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": phone["refresh_token"]})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, phoneID, sessionID(t, refreshed["access_token"]))
+
+	// Revoking the stolen phone signs it out right away
+	rec, _ = doJSON(t, http.MethodDelete, "/me/sessions/"+phoneID, nil, accessCookie(laptop["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	for _, token := range []interface{}{phone["access_token"], refreshed["access_token"]} {
+		rec, _ = doJSON(t, http.MethodGet, "/me", nil, accessCookie(token))
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	rec, _ = doJSON(t, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": refreshed["refresh_token"]})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Len(t, listSessions(t, "/me/sessions", laptop["access_token"]), 1)
+
+	rec, _ = doJSON(t, http.MethodDelete, "/me/sessions/"+phoneID, nil, accessCookie(laptop["access_token"]))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Sessions of other users cannot be revoked
+	other := signUpAndLogin(t, "sessionother", "sessionother@example.com")
+	rec, _ = doJSON(t, http.MethodDelete, "/me/sessions/"+sessionID(t, other["access_token"]), nil, accessCookie(laptop["access_token"]))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, accessCookie(other["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Revoking the current session logs out
+	rec, _ = doJSON(t, http.MethodDelete, "/me/sessions/"+laptopID, nil, accessCookie(laptop["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, accessCookie(laptop["access_token"]))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Logging out everywhere revokes every session
+	loginAgain(t, "session@example.com")
+	login := loginAgain(t, "session@example.com")
+	require.Len(t, listSessions(t, "/me/sessions", login["access_token"]), 2)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/logout-all", nil, accessCookie(login["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var active int64
+	require.NoError(t, testDB.Model(&models.Session{}).
+		Joins("JOIN users ON users.id = sessions.user_id").
+		Where("users.email = ? AND sessions.revoked_at IS NULL", "session@example.com").
+		Count(&active).Error)
+	require.Zero(t, active)
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	login := signUpAndLogin(t, "sessionlogout", "sessionlogout@example.com")
+	other := loginAgain(t, "sessionlogout@example.com")
+
+	rec, _ := doJSON(t, http.MethodPost, "/auth/logout", nil, accessCookie(login["access_token"]))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	sessions := listSessions(t, "/me/sessions", other["access_token"])
+	require.Len(t, sessions, 1)
+	require.Equal(t, sessionID(t, other["access_token"]), fmt.Sprint(sessions[0].(map[string]interface{})["id"]))
+}
+
+func TestSessionlessTokenAfterEmailReuse(t *testing.T) {
+	signUpAndLogin(t, "sessionless", "sessionless@example.com")
+	operator, err := server.NewOperator(testConfig(), "sessionless", server.WithDatabase(testDB))
+	require.NoError(t, err)
+	t.Cleanup(func() { operator.Close() })
+	user, err := operator.FindUser("sessionless", false)
+	require.NoError(t, err)
+
+	// Minted tokens, like the ones issued to OAuth clients, have no session
+	// to check, so only the user ID they carry ties them to their user
+	token, err := operator.MintToken(user, time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, tokenClaims(t, token).SessionID)
+
+	require.NoError(t, testDB.Model(user).Update("email", "sessionless2@example.com").Error)
+	signUpAndLogin(t, "sessionlesstaker", "sessionless@example.com")
+	rec, _ := doJSON(t, http.MethodGet, "/me", nil, accessCookie(token))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAdminSessions(t *testing.T) {
+	admin := accessCookie(signUpSuperuser(t, "sessionadmin", "sessionadmin@example.com")["access_token"])
+	login := signUpAndLogin(t, "sessiontarget", "sessiontarget@example.com")
+	id := sessionID(t, login["access_token"])
+	path := userPath(t, "sessiontarget@example.com", "/sessions")
+
+	rec, resp := doJSON(t, http.MethodGet, path, nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	sessions := resp["sessions"].([]interface{})
+	require.Len(t, sessions, 1)
+	require.Equal(t, id, fmt.Sprint(sessions[0].(map[string]interface{})["id"]))
+	require.Equal(t, false, sessions[0].(map[string]interface{})["current"])
+
+	// Users cannot manage the sessions of others
+	rec, _ = doJSON(t, http.MethodGet, path, nil, accessCookie(login["access_token"]))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = doJSON(t, http.MethodDelete, path+"/"+id, nil, accessCookie(login["access_token"]))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodDelete, path+"/"+id, nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec, _ = doJSON(t, http.MethodGet, "/me", nil, accessCookie(login["access_token"]))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodDelete, path+"/"+id, nil, admin)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	// The session has to belong to the user of the path
+	id = sessionID(t, loginAgain(t, "sessiontarget@example.com")["access_token"])
+	signUpAndLogin(t, "sessionbystander", "sessionbystander@example.com")
+	rec, _ = doJSON(t, http.MethodDelete, userPath(t, "sessionbystander@example.com", "/sessions/"+id), nil, admin)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	rec, _ = doJSON(t, http.MethodDelete, path+"/"+id, nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,273 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/rbac"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	accessTokenCookie  = "Authorization"
+	refreshTokenCookie = "RefreshToken"
+	refreshTokenPath   = "/auth"
+)
+
+var errRefreshTokenReuse = errors.New("refresh token reuse detected")
+
+// tokenPair is the set of tokens handed to a client after a successful login
+// or refresh.
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// issueTokens creates an access token and a refresh token for the user and
+// sets both as cookies. The access token carries the roles and permissions
+// of the user. If current is nil this is a login: a new session and a new
+// refresh token family are started. Otherwise current is the refresh token
+// being rotated, and the new tokens continue its session and family. Only
+// one caller may rotate a token, the others get errRefreshTokenReuse.
+func (s *Server) issueTokens(c *gin.Context, user *models.User, current *models.RefreshToken) (*tokenPair, error) {
+	claims, err := s.accessClaims(s.roles, user, s.config.Tokens.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	var familyID string
+	var sessionID *uint
+	if current == nil {
+		if familyID, err = utils.GenerateRandomToken(24); err != nil {
+			return nil, err
+		}
+		session, err := startSession(c, s.sessions, user, s.config.Tokens.RefreshTTL)
+		if err != nil {
+			return nil, err
+		}
+		sessionID = &session.ID
+	} else {
+		familyID, sessionID = current.FamilyID, current.SessionID
+		if sessionID != nil {
+			if err := extendSession(c, s.sessions, *sessionID, s.config.Tokens.RefreshTTL); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if sessionID != nil {
+		claims.SessionID = strconv.FormatUint(uint64(*sessionID), 10)
+	}
+
+	access, err := s.signAccessToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		SessionID: sessionID,
+		TokenHash: utils.HashToken(refresh),
+		ExpiresAt: time.Now().Add(s.config.Tokens.RefreshTTL),
+	}
+	if current == nil {
+		err = s.refreshTokens.Create(record)
+	} else {
+		err = s.refreshTokens.Rotate(current.ID, time.Now(), record)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errRefreshTokenReuse
+	} else if err != nil {
+		return nil, err
+	}
+
+	s.setTokenCookies(c, access, refresh)
+	return &tokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// accessClaims returns the claims of an access token of the user lasting
+// for ttl, carrying the roles and permissions of the user.
+func (s *Server) accessClaims(roles repository.RoleRepository, user *models.User, ttl time.Duration) (utils.Claims, error) {
+	claims, err := s.userClaims(user, utils.TokenTypeAccess, ttl)
+	if err != nil {
+		return claims, err
+	}
+
+	grants, err := rbac.Resolve(roles, user)
+	if err != nil {
+		return claims, err
+	}
+	claims.Roles, claims.Permissions = grants.Roles, grants.Permissions
+	return claims, nil
+}
+
+// setTokenCookies sets the access and refresh token cookies. The refresh
+// token cookie is HTTP only and scoped to the /auth routes.
+func (s *Server) setTokenCookies(c *gin.Context, access, refresh string) {
+	s.setCookie(c, accessTokenCookie, access, s.config.Tokens.AccessTTL, "/", false)
+	s.setCookie(c, refreshTokenCookie, refresh, s.config.Tokens.RefreshTTL, refreshTokenPath, true)
+}
+
+// clearTokenCookies expires the access and refresh token cookies.
+func (s *Server) clearTokenCookies(c *gin.Context) {
+	s.setCookie(c, accessTokenCookie, "", -1, "/", false)
+	s.setCookie(c, refreshTokenCookie, "", -1, refreshTokenPath, true)
+}
+
+// setCookie sets a token cookie with the domain, Secure and SameSite
+// attributes of the configuration. A negative ttl expires the cookie.
+func (s *Server) setCookie(c *gin.Context, name, value string, ttl time.Duration, path string, httpOnly bool) {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+	cookies := s.config.Cookies
+	switch strings.ToLower(cookies.SameSite) {
+	case "lax":
+		c.SetSameSite(http.SameSiteLaxMode)
+	case "strict":
+		c.SetSameSite(http.SameSiteStrictMode)
+	case "none":
+		c.SetSameSite(http.SameSiteNoneMode)
+	default:
+		c.SetSameSite(http.SameSiteDefaultMode)
+	}
+	c.SetCookie(name, value, maxAge, path, cookies.Domain, cookies.Secure, httpOnly)
+}
+
+// tokenResponse builds the JSON body returned along with the token cookies,
+// so clients that do not use cookies can read the tokens as well.
+func (s *Server) tokenResponse(message string, tokens *tokenPair) gin.H {
+	return gin.H{
+		"message":       message,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(s.config.Tokens.AccessTTL.Seconds()),
+	}
+}
+
+// Refresh handles the /auth/refresh route.
+//
+// It reads the refresh token from the RefreshToken cookie or from a JSON
+// payload with a refresh_token field. A valid token is marked as used and
+// exchanged for a new access token and a new refresh token in the same
+// family. If a token that was already used is presented again, the whole
+// family is revoked and the client has to log in again. Tokens of a revoked
+// session are rejected.
+func (s *Server) Refresh(c *gin.Context) {
+	var input inputs.RefreshInput
+
+	// The body is optional when the token is sent as a cookie
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			respondBindError(c, err)
+			return
+		}
+	}
+
+	if input.RefreshToken == "" {
+		input.RefreshToken, _ = c.Cookie(refreshTokenCookie)
+	}
+	if input.RefreshToken == "" {
+		respondError(c, http.StatusUnauthorized, validation.MessageMissingRefreshToken)
+		return
+	}
+
+	tokens, err := s.refresh(c, input.RefreshToken)
+
+	switch {
+	case errors.Is(err, errRefreshTokenReuse):
+		if err := s.revokeTokenFamily(input.RefreshToken); err != nil {
+			respondInternal(c, err)
+			return
+		}
+		s.clearTokenCookies(c)
+		respondError(c, http.StatusUnauthorized, validation.MessageRefreshTokenReused)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		s.clearTokenCookies(c)
+		respondError(c, http.StatusUnauthorized, validation.MessageInvalidRefreshToken)
+		return
+	case err != nil:
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.tokenResponse("Token refreshed", tokens))
+}
+
+// refresh exchanges the raw refresh token for new tokens. A concurrent
+// request that loses the race to rotate the token is treated as a replay.
+func (s *Server) refresh(c *gin.Context, refreshToken string) (*tokenPair, error) {
+	current, err := s.refreshTokens.FindByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if current.UsedAt != nil || current.RevokedAt != nil {
+		return nil, errRefreshTokenReuse
+	}
+
+	// The user of a deleted account is not found and stays inactive
+	if user, err := s.users.FindByID(current.UserID, false); err == nil {
+		current.User = *user
+	}
+
+	if time.Now().After(current.ExpiresAt) || !current.User.IsActive || !s.allowsLogin(&current.User, time.Now()) {
+		return nil, repository.ErrNotFound
+	}
+
+	// Refresh tokens die with their session
+	if current.SessionID != nil {
+		if _, err := s.sessions.FindActive(*current.SessionID, current.UserID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.issueTokens(c, &current.User, current)
+}
+
+// revokeTokenFamily revokes every refresh token in the family of the given
+// raw refresh token.
+func (s *Server) revokeTokenFamily(refreshToken string) error {
+	token, err := s.refreshTokens.FindByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	return s.refreshTokens.RevokeFamily(token.FamilyID, time.Now())
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefresh(t *testing.T) {
+	login := signUpAndLogin(t, "refresher", "refresher@example.com")
+	first := login["refresh_token"].(string)
+	require.NotEmpty(t, login["access_token"])
+
+	// A valid refresh token is rotated
+	rec, resp := doJSON(t, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": first})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	second := resp["refresh_token"].(string)
+	require.NotEqual(t, first, second)
+
+	// The refresh token can be sent as a cookie too
+	rec, resp = doJSON(t, http.MethodPost, "/auth/refresh", nil, &http.Cookie{Name: "RefreshToken", Value: second})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	third := resp["refresh_token"].(string)
+
+	// Replaying a used token revokes the whole family
+	rec, _ = doJSON(t, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": first})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/refresh", gin.H{"refresh_token": third})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRefreshInvalidToken(t *testing.T) {
+	tests := []struct {
+		name string
+		body interface{}
+	}{
+		{"missing token", nil},
+		{"unknown token", gin.H{"refresh_token": "unknown"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec, _ := doJSON(t, http.MethodPost, "/auth/refresh", test.body)
+			require.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+	}
+}
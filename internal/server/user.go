@@ -24,15 +24,18 @@ package server
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"time"
 
-	"github.com/Maro1O9/goauth/internal/database"
+	"github.com/Maro1O9/goauth/internal/audit"
+	"github.com/Maro1O9/goauth/internal/repository"
 	"github.com/Maro1O9/goauth/internal/database/models"
 	"github.com/Maro1O9/goauth/internal/inputs"
 	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
 // SignUp handles the /signup route.
@@ -44,109 +47,193 @@ import (
 // - password: string
 // - confirm_password: string
 //
-// It validates the input fields, hashes the password and creates a new user
-// in the database. If any of the input fields are invalid, it will return
-// a 400 error with the specific error message. If the passwords do not match,
-// it will return a 400 error with the message "passwords do not match".
+// It validates the input fields, checks the password against the password
+// policy, hashes the password and creates a new user in the database. If
+// any of the input fields are invalid, the username or email is taken, the
+// passwords do not match or the password breaks the policy, it will return
+// a 400 error listing every problem under fields.
 //
-// If the user is created successfully, it will return a 201 status code with
-// the newly created user as JSON.
+// If the user is created successfully, a verification email is sent to the
+// email address and it will return a 201 status code with a success message.
 func (s *Server) SignUp(c *gin.Context) {
 	var input inputs.InputUser
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	// Validate the input
-	if err := utils.ValidateSignupData(&input); err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+	user, err := s.createUser(&input)
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		respondInvalid(c, errs)
 		return
 	}
-
-	// Check if username already exists
-	if err := database.DB.First(&models.User{}).Where("username = ?", input.Username).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"Error": "Username Already Taken"})
+	if err != nil {
+		respondInternal(c, fmt.Errorf("could not create user: %w", err))
 		return
 	}
 
-	// Check if email already exists
-	if err := database.DB.First(&models.User{}).Where("email = ?", input.Email).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
-		return
+	s.recordEvent(c, &models.AuditEvent{
+		Action:     audit.ActionSignup,
+		Outcome:    audit.OutcomeSuccess,
+		ActorType:  audit.TypeUser,
+		ActorID:    userID(user),
+		TargetType: audit.TypeUser,
+		TargetID:   userID(user),
+	}, audit.Metadata{"username": user.Username, "email": user.Email})
+
+	// Ask the user to verify the email address
+	if err := s.sendVerification(c, user, user.Email); err != nil {
+		log.Printf("could not send verification to user %d: %v", user.ID, err)
 	}
 
-	// Hashing the password
-	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
-		return
+	c.JSON(http.StatusCreated, gin.H{"message": "Signup successful"})
+}
+
+// createUser validates the signup input and creates the user it describes.
+// Invalid input, taken usernames and emails included, is reported as a
+// validation.Errors listing every problem.
+func (s *Server) createUser(input *inputs.InputUser) (*models.User, error) {
+	errs := utils.ValidateSignupData(input)
+	if _, err := s.users.FindByUsername(input.Username); !errors.Is(err, repository.ErrNotFound) {
+		errs.Add("username", validation.CodeTaken)
+	}
+	if _, err := s.users.FindByEmail(input.Email); !errors.Is(err, repository.ErrNotFound) {
+		errs.Add("email", validation.CodeTaken)
 	}
 
-	// Creating the user
 	user := &models.User{
-		Username:     input.Username,
-		Name:         input.Name,
-		Email:        input.Email,
-		PasswordHash: hash,
+		Username: input.Username,
+		Name:     input.Name,
+		Email:    input.Email,
+	}
+	if input.Password != "" {
+		if err := s.validatePassword(&errs, user, input.Password); err != nil {
+			return nil, err
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
 	}
 
-	// Create the user
-	if err := database.Create(&models.User{}, &user); err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
-		return
+	hash, err := s.hashPassword(input.Password)
+	if err != nil {
+		return nil, err
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"Success": "Signup successful"})
+	user.PasswordHash = hash
+	if err := s.users.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// hashPassword hashes a password with the configured algorithm.
+func (s *Server) hashPassword(password string) ([]byte, error) {
+	return s.passwords.Hash(password)
+}
+
+// checkPassword reports whether password is the password of the user.
+func (s *Server) checkPassword(user *models.User, password string) bool {
+	ok, _ := s.passwords.Verify(password, user.PasswordHash)
+	return ok
 }
 
 // Login handles the /login route.
 //
 // It takes a JSON payload with an email and password. If the email and password
 // are valid, it returns a 200 status code with a JSON response containing a
-// success message and sets a cookie with a JWT token. If the email or password
+// success message and the issued tokens, and sets a cookie with a short lived
+// JWT access token and a cookie with a refresh token. If the email or password
 // is invalid, it returns a 401 status code with a JSON response containing an
-// error message.
+// error message, whether the email is unknown or the password is wrong. If the
+// account is disabled, an admin required a password reset, or the email
+// address is not verified and the verification policy does not allow the user
+// to log in, it returns a 403 status code.
+//
+// Repeated failures for an account delay and then lock further attempts, and
+// so do too many failures from the same client IP. Those attempts get a 429
+// status code with a Retry-After header.
+//
+// If the user enabled two-factor authentication, no cookies are set. Instead
+// the response contains a short lived mfa_token that has to be sent to
+// /auth/2fa/challenge along with a code to complete the login.
 func (s *Server) Login(c *gin.Context) {
 	var input inputs.LoginUser
 
 	// Bind JSON payload to LoginUser struct
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
 	// validate the input
-	if err := utils.ValidateLoginData(&input); err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+	if errs := utils.ValidateLoginData(&input); len(errs) > 0 {
+		respondInvalid(c, errs)
 		return
 	}
 
-	var user models.User
+	// Check the password, throttling repeated failures
+	user, wait, err := s.verifyPassword(c, input.Email, input.Password)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+	if wait > 0 {
+		s.recordLogin(c, loginMethodPassword, input.Email, nil, loginFailureThrottled)
+		tooManyLoginAttempts(c, wait)
+		return
+	}
+	if user == nil {
+		s.recordLogin(c, loginMethodPassword, input.Email, nil, loginFailureInvalidCredentials)
+		respondError(c, http.StatusUnauthorized, validation.MessageInvalidCredentials)
+		return
+	}
+
+	if !user.IsActive {
+		s.recordLogin(c, loginMethodPassword, input.Email, user, loginFailureDisabled)
+		respondError(c, http.StatusForbidden, validation.MessageAccountDisabled)
+		return
+	}
+	if user.PasswordResetRequired {
+		s.recordLogin(c, loginMethodPassword, input.Email, user, loginFailurePasswordReset)
+		respondError(c, http.StatusForbidden, validation.MessagePasswordResetRequired)
+		return
+	}
 
-	// Check if user with the given email exists
-	if err := database.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email"})
+	// Apply the email verification policy
+	if !s.allowsLogin(user, time.Now()) {
+		s.recordLogin(c, loginMethodPassword, input.Email, user, loginFailureUnverified)
+		respondError(c, http.StatusForbidden, validation.MessageEmailNotVerified)
 		return
 	}
 
-	// Compare provided password with the stored password hash
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+	// Ask for the second factor if the user enabled two-factor authentication
+	if _, err := s.confirmedTOTP(user.ID); err == nil {
+		challenge, err := s.createToken(user, utils.TokenTypeMFA, s.config.Tokens.MFATTL)
+		if err != nil {
+			respondInternal(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Two-factor authentication required",
+			"mfa_required": true,
+			"mfa_token":    challenge,
+		})
 		return
 	}
 
-	// Generate a JWT token for the authenticated user
-	token, err := utils.CreateToken(user.Email)
+	// Generate an access token and start a new refresh token family
+	tokens, err := s.issueTokens(c, user, nil)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternal(c, err)
 		return
 	}
 
-	// Set the JWT token as a cookie
-	c.SetCookie("Authorization", token, 3600*24*7, "/", "localhost", true, false)
+	s.recordLogin(c, loginMethodPassword, input.Email, user, "")
 
 	// Respond with a success message
-	c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
+	c.JSON(http.StatusOK, s.tokenResponse("Login successful", tokens))
 }
@@ -0,0 +1,218 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/mailer"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// VerificationPolicy decides whether users whose email is not verified yet
+// may log in.
+type VerificationPolicy string
+
+const (
+	// VerificationPolicyAllow lets unverified users log in.
+	VerificationPolicyAllow VerificationPolicy = "allow"
+	// VerificationPolicyDeny refuses to log in unverified users.
+	VerificationPolicyDeny VerificationPolicy = "deny"
+	// VerificationPolicyGrace lets unverified users log in for a grace
+	// period after signing up.
+	VerificationPolicyGrace VerificationPolicy = "grace"
+)
+
+var (
+	errInvalidVerificationToken = errors.New("invalid or expired verification token")
+	errEmailTaken               = errors.New("email already registered")
+)
+
+// ParseVerificationPolicy parses the value of EMAIL_VERIFICATION_POLICY.
+// An empty value defaults to VerificationPolicyAllow.
+func ParseVerificationPolicy(value string) (VerificationPolicy, error) {
+	switch policy := VerificationPolicy(value); policy {
+	case "":
+		return VerificationPolicyAllow, nil
+	case VerificationPolicyAllow, VerificationPolicyDeny, VerificationPolicyGrace:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid email verification policy %q", value)
+	}
+}
+
+// allowsLogin reports whether the verification policy lets the user log in.
+func (s *Server) allowsLogin(user *models.User, now time.Time) bool {
+	if user.EmailVerified {
+		return true
+	}
+
+	switch s.verificationPolicy {
+	case VerificationPolicyDeny:
+		return false
+	case VerificationPolicyGrace:
+		return now.Before(user.CreatedAt.Add(s.config.Verification.Grace))
+	default:
+		return true
+	}
+}
+
+// sendVerification replaces the outstanding verification tokens of the user
+// with a new one for the given address and mails it there.
+func (s *Server) sendVerification(c *gin.Context, user *models.User, email string) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.emailVerifications.Replace(&models.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.config.Tokens.EmailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(c.Request.Context(), mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to verify your email address. It expires in %s.\n\n%s/auth/verify-email?token=%s\n\nIf you did not create an account you can ignore this email.",
+			user.Username, s.config.Tokens.EmailVerificationTTL, s.appURL, token),
+	})
+}
+
+// VerifyEmail handles the /auth/verify-email route.
+//
+// It accepts the verification token either as a token query parameter, so
+// the link in the email can be opened directly, or as a JSON payload with a
+// token field. If the token is valid and unused, the address it was sent to
+// is marked as verified and becomes the user's email. Access tokens issued
+// for the previous address stop working, as tokens name users by email.
+//
+// An invalid, expired or already used token results in a 400 status code.
+func (s *Server) VerifyEmail(c *gin.Context) {
+	var input inputs.VerifyEmailInput
+
+	if err := c.ShouldBind(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	// Using the token fails if a concurrent request used it meanwhile
+	token, err := s.emailVerifications.FindActive(utils.HashToken(input.Token), time.Now())
+	if err == nil {
+		err = s.emailVerifications.Use(token.ID, time.Now())
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		err = errInvalidVerificationToken
+	}
+	if err == nil {
+		err = s.confirmEmail(token)
+	}
+
+	if errors.Is(err, errInvalidVerificationToken) {
+		respondError(c, http.StatusBadRequest, validation.MessageInvalidVerificationToken)
+		return
+	}
+	if errors.Is(err, errEmailTaken) {
+		respondError(c, http.StatusConflict, validation.MessageEmailRegistered)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// confirmEmail makes the address a verification token was sent to the
+// verified email of its user.
+func (s *Server) confirmEmail(token *models.EmailVerificationToken) error {
+	// Another user may have taken the address since it was requested
+	taken, err := s.users.EmailTaken(token.Email, token.UserID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return errEmailTaken
+	}
+
+	err = s.users.Update(token.UserID, map[string]interface{}{
+		"email":          token.Email,
+		"email_verified": true,
+		"verified_at":    time.Now(),
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return errEmailTaken
+	}
+	return err
+}
+
+// ResendVerification handles the /auth/verify-email/resend route.
+//
+// It takes a JSON payload with an email. If an active user with that email
+// exists and is not verified yet, a new verification email is sent, unless
+// one was sent less than s.config.Verification.ResendInterval ago.
+//
+// To avoid disclosing which emails are registered, it always returns a 200
+// status code with the same message once the input is valid.
+func (s *Server) ResendVerification(c *gin.Context) {
+	var input inputs.ResendVerificationInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := utils.ValidateEmail(input.Email); err != nil {
+		respondInvalid(c, validation.Errors{{Field: "email", Code: validation.CodeInvalid}})
+		return
+	}
+
+	user, err := s.users.FindByEmail(input.Email)
+	if err == nil && user.IsActive && !user.EmailVerified && !s.verificationThrottled(user.ID) {
+		if err := s.sendVerification(c, user, user.Email); err != nil {
+			log.Printf("could not send verification to user %d: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the email is registered and not verified yet, a verification link has been sent"})
+}
+
+// verificationThrottled reports whether a verification email was sent to
+// the user less than s.config.Verification.ResendInterval ago.
+func (s *Server) verificationThrottled(userID uint) bool {
+	sent, err := s.emailVerifications.SentSince(userID, time.Now().Add(-s.config.Verification.ResendInterval))
+	return err != nil || sent
+}
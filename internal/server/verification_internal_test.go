@@ -0,0 +1,83 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVerificationPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    VerificationPolicy
+		wantErr bool
+	}{
+		{"", VerificationPolicyAllow, false},
+		{"allow", VerificationPolicyAllow, false},
+		{"deny", VerificationPolicyDeny, false},
+		{"grace", VerificationPolicyGrace, false},
+		{"invalid", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			policy, err := ParseVerificationPolicy(test.value)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, policy)
+		})
+	}
+}
+
+func TestAllowsLogin(t *testing.T) {
+	now := time.Now()
+	fresh := &models.User{CreatedAt: now.Add(-time.Hour)}
+	old := &models.User{CreatedAt: now.Add(-96 * time.Hour)}
+	verified := &models.User{CreatedAt: now.Add(-96 * time.Hour), EmailVerified: true}
+
+	tests := []struct {
+		name   string
+		policy VerificationPolicy
+		user   *models.User
+		want   bool
+	}{
+		{"allow unverified", VerificationPolicyAllow, old, true},
+		{"deny unverified", VerificationPolicyDeny, fresh, false},
+		{"deny verified", VerificationPolicyDeny, verified, true},
+		{"grace within period", VerificationPolicyGrace, fresh, true},
+		{"grace after period", VerificationPolicyGrace, old, false},
+		{"grace verified", VerificationPolicyGrace, verified, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Server{config: config.Default(), verificationPolicy: test.policy}
+			require.Equal(t, test.want, s.allowsLogin(test.user, now))
+		})
+	}
+}
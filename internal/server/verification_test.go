@@ -0,0 +1,82 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// countMails returns the number of mails sent to the recipient so far.
+func countMails(t *testing.T, to string) int {
+	t.Helper()
+
+	entries, err := os.ReadDir(mailDir)
+	require.NoError(t, err)
+
+	count := 0
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), "-"+to+".eml") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestVerifyEmail(t *testing.T) {
+	signUpAndLogin(t, "verifier", "verifier@example.com")
+	token := mailToken(t, "verifier@example.com")
+
+	rec, _ := doJSON(t, http.MethodGet, "/auth/verify-email?token=invalid", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = doJSON(t, http.MethodGet, "/auth/verify-email?token="+token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// The token is single use
+	rec, _ = doJSON(t, http.MethodPost, "/auth/verify-email", gin.H{"token": token})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Verified users do not get new verification emails
+	sent := countMails(t, "verifier@example.com")
+	rec, _ = doJSON(t, http.MethodPost, "/auth/verify-email/resend", gin.H{"email": "verifier@example.com"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, sent, countMails(t, "verifier@example.com"))
+}
+
+func TestResendVerificationThrottled(t *testing.T) {
+	signUpAndLogin(t, "resender", "resender@example.com")
+	require.Equal(t, 1, countMails(t, "resender@example.com"))
+
+	// The signup email was sent too recently
+	rec, _ := doJSON(t, http.MethodPost, "/auth/verify-email/resend", gin.H{"email": "resender@example.com"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 1, countMails(t, "resender@example.com"))
+
+	rec, _ = doJSON(t, http.MethodPost, "/auth/verify-email/resend", gin.H{"email": "invalid"})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,402 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/config"
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/inputs"
+	"github.com/Maro1O9/goauth/internal/repository"
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/Maro1O9/goauth/internal/webauthn"
+	"github.com/gin-gonic/gin"
+)
+
+// WebAuthn ceremonies a challenge can be issued for.
+const (
+	ceremonyRegistration = "registration"
+	ceremonyLogin        = "login"
+)
+
+var errInvalidChallenge = errors.New("invalid or expired challenge")
+
+// newRelyingParty configures the WebAuthn relying party. The name defaults
+// to name and the origins to appURL, or http://localhost if that is not set.
+func newRelyingParty(cfg config.WebAuthn, name, appURL string) (*webauthn.RelyingParty, error) {
+	rpID := cfg.RPID
+	if rpID == "" {
+		rpID = "localhost"
+	}
+
+	rpName := cfg.RPName
+	if rpName == "" {
+		rpName = name
+	}
+
+	origins := cfg.Origins
+	if len(origins) == 0 {
+		if appURL != "" {
+			origins = []string{appURL}
+		} else {
+			origins = []string{"http://localhost"}
+		}
+	}
+
+	return webauthn.New(webauthn.Config{RPID: rpID, RPName: rpName, Origins: origins})
+}
+
+// createChallenge stores a new challenge for the ceremony. userID is nil
+// for login ceremonies. Expired challenges are pruned on the way.
+func (s *Server) createChallenge(ceremony string, userID *uint) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.passkeys.DeleteExpiredChallenges(time.Now()); err != nil {
+		return nil, err
+	}
+
+	err = s.passkeys.CreateChallenge(&models.WebAuthnChallenge{
+		UserID:        userID,
+		Ceremony:      ceremony,
+		ChallengeHash: utils.HashToken(string(challenge)),
+		ExpiresAt:     time.Now().Add(s.webauthn.Timeout()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// consumeChallenge looks up the challenge the client signed in its client
+// data and deletes it, so it can only be used once. Registration challenges
+// must belong to userID.
+func (s *Server) consumeChallenge(clientDataJSON []byte, ceremony string, userID *uint) ([]byte, error) {
+	clientData, err := webauthn.ParseClientData(clientDataJSON)
+	if err != nil {
+		return nil, errInvalidChallenge
+	}
+	challenge, err := clientData.ChallengeBytes()
+	if err != nil {
+		return nil, errInvalidChallenge
+	}
+
+	err = s.passkeys.ConsumeChallenge(utils.HashToken(string(challenge)), ceremony, userID, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errInvalidChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// credentialIDs returns the IDs of the WebAuthn credentials of the user.
+func (s *Server) credentialIDs(userID uint) ([][]byte, error) {
+	credentials, err := s.passkeys.ListCredentials(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([][]byte, 0, len(credentials))
+	for _, credential := range credentials {
+		id, err := webauthn.URLEncoding.DecodeString(credential.CredentialID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// BeginWebAuthnRegistration handles the /auth/webauthn/register/begin route.
+//
+// It takes a JSON payload with the user's password, so a stolen access token
+// is not enough to add a passkey to the account, and returns the options for
+// navigator.credentials.create, under the publicKey key, to register a new
+// passkey for the authenticated user. Credentials the user already
+// registered are excluded. Wrong passwords count as failed logins and are
+// delayed and locked out the same way.
+func (s *Server) BeginWebAuthnRegistration(c *gin.Context) {
+	var input inputs.WebAuthnRegistrationBeginInput
+	user := MustPrincipal(c).User
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if !s.confirmPassword(c, user, input.Password, http.StatusUnauthorized, validation.MessageInvalidPassword) {
+		return
+	}
+
+	exclude, err := s.credentialIDs(user.ID)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	challenge, err := s.createChallenge(ceremonyRegistration, &user.ID)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	options := s.webauthn.BeginRegistration(challenge, webauthn.User{
+		ID:          []byte(strconv.FormatUint(uint64(user.ID), 10)),
+		Name:        user.Email,
+		DisplayName: user.Name,
+	}, exclude)
+
+	c.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+// FinishWebAuthnRegistration handles the /auth/webauthn/register/finish route.
+//
+// It takes a JSON payload with the credential returned by
+// navigator.credentials.create and an optional name for it. The attestation
+// is verified against the pending challenge and the credential is stored.
+// It returns a 201 status code with the stored credential.
+func (s *Server) FinishWebAuthnRegistration(c *gin.Context) {
+	var input inputs.WebAuthnRegistrationInput
+	user := MustPrincipal(c).User
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	challenge, err := s.consumeChallenge(input.Credential.Response.ClientDataJSON, ceremonyRegistration, &user.ID)
+	if errors.Is(err, errInvalidChallenge) {
+		respondError(c, http.StatusBadRequest, validation.MessageInvalidChallenge)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	credential, err := s.webauthn.FinishRegistration(challenge, &input.Credential)
+	if err != nil {
+		log.Printf("could not register passkey of user %d: %v", user.ID, err)
+		respondError(c, http.StatusBadRequest, validation.MessageInvalidPasskey)
+		return
+	}
+
+	record := &models.WebAuthnCredential{
+		UserID:          user.ID,
+		CredentialID:    webauthn.URLEncoding.EncodeToString(credential.ID),
+		PublicKey:       credential.PublicKey,
+		SignCount:       credential.SignCount,
+		AAGUID:          credential.AAGUID,
+		AttestationType: credential.AttestationType,
+		Name:            input.Name,
+	}
+
+	err = s.passkeys.CreateCredential(record)
+	if errors.Is(err, repository.ErrDuplicate) {
+		respondError(c, http.StatusConflict, validation.MessageCredentialRegistered)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webAuthnCredentialResponse(record))
+}
+
+// BeginWebAuthnLogin handles the /auth/webauthn/login/begin route.
+//
+// It takes an optional JSON payload with an email and returns the options
+// for navigator.credentials.get under the publicKey key. If the email
+// belongs to a user, the user's credentials are allowed, otherwise any
+// discoverable credential can be used.
+func (s *Server) BeginWebAuthnLogin(c *gin.Context) {
+	var input inputs.WebAuthnLoginBeginInput
+
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			respondBindError(c, err)
+			return
+		}
+	}
+
+	var allow [][]byte
+	if input.Email != "" {
+		if user, err := s.users.FindByEmail(input.Email); err == nil {
+			ids, err := s.credentialIDs(user.ID)
+			if err != nil {
+				respondInternal(c, err)
+				return
+			}
+			allow = ids
+		}
+	}
+
+	challenge, err := s.createChallenge(ceremonyLogin, nil)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"publicKey": s.webauthn.BeginLogin(challenge, allow)})
+}
+
+// FinishWebAuthnLogin handles the /auth/webauthn/login/finish route.
+//
+// It takes the credential returned by navigator.credentials.get as JSON.
+// If the assertion is valid for a stored credential of an active user, the
+// same tokens and cookies as Login are issued. The email verification
+// policy applies like in Login. A signature counter that did not increase
+// is rejected as a possibly cloned authenticator.
+func (s *Server) FinishWebAuthnLogin(c *gin.Context) {
+	var input webauthn.AssertionResponse
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	challenge, err := s.consumeChallenge(input.Response.ClientDataJSON, ceremonyLogin, nil)
+	if errors.Is(err, errInvalidChallenge) {
+		respondError(c, http.StatusUnauthorized, validation.MessageInvalidChallenge)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	record, err := s.passkeys.FindCredential(webauthn.URLEncoding.EncodeToString(input.RawID))
+	if err == nil {
+		// The user of a deleted account is not found and stays inactive
+		if user, err := s.users.FindByID(record.UserID, false); err == nil {
+			record.User = *user
+		}
+	}
+	if err != nil || !record.User.IsActive {
+		respondError(c, http.StatusUnauthorized, validation.MessageUnknownCredential)
+		return
+	}
+
+	signCount, err := s.webauthn.FinishLogin(challenge, &input, &webauthn.Credential{
+		ID:        input.RawID,
+		PublicKey: record.PublicKey,
+		SignCount: record.SignCount,
+	})
+	if err != nil {
+		log.Printf("could not verify passkey of user %d: %v", record.UserID, err)
+		s.recordLogin(c, loginMethodWebAuthn, record.User.Email, &record.User, loginFailureInvalidCredentials)
+		respondError(c, http.StatusUnauthorized, validation.MessageInvalidPasskey)
+		return
+	}
+
+	if record.User.PasswordResetRequired {
+		s.recordLogin(c, loginMethodWebAuthn, record.User.Email, &record.User, loginFailurePasswordReset)
+		respondError(c, http.StatusForbidden, validation.MessagePasswordResetRequired)
+		return
+	}
+	if !s.allowsLogin(&record.User, time.Now()) {
+		s.recordLogin(c, loginMethodWebAuthn, record.User.Email, &record.User, loginFailureUnverified)
+		respondError(c, http.StatusForbidden, validation.MessageEmailNotVerified)
+		return
+	}
+
+	if err := s.passkeys.UseCredential(record.ID, signCount, time.Now()); err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	tokens, err := s.issueTokens(c, &record.User, nil)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+	s.recordLogin(c, loginMethodWebAuthn, record.User.Email, &record.User, "")
+
+	c.JSON(http.StatusOK, s.tokenResponse("Login successful", tokens))
+}
+
+// webAuthnCredentialResponse is the JSON representation of a credential.
+func webAuthnCredentialResponse(credential *models.WebAuthnCredential) gin.H {
+	return gin.H{
+		"id":               credential.ID,
+		"name":             credential.Name,
+		"credential_id":    credential.CredentialID,
+		"attestation_type": credential.AttestationType,
+		"created_at":       credential.CreatedAt,
+		"last_used_at":     credential.LastUsedAt,
+	}
+}
+
+// ListWebAuthnCredentials handles the /auth/webauthn/credentials route.
+//
+// It returns the WebAuthn credentials of the authenticated user.
+func (s *Server) ListWebAuthnCredentials(c *gin.Context) {
+	user := MustPrincipal(c).User
+
+	credentials, err := s.passkeys.ListCredentials(user.ID)
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(credentials))
+	for i := range credentials {
+		response = append(response, webAuthnCredentialResponse(&credentials[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"credentials": response})
+}
+
+// DeleteWebAuthnCredential handles the /auth/webauthn/credentials/:id route.
+//
+// It deletes a WebAuthn credential of the authenticated user. If the user
+// has no credential with that ID, it returns a 404 status code.
+func (s *Server) DeleteWebAuthnCredential(c *gin.Context) {
+	user := MustPrincipal(c).User
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err == nil {
+		err = s.passkeys.DeleteCredential(uint(id), user.ID)
+	} else {
+		err = repository.ErrNotFound
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		respondError(c, http.StatusNotFound, validation.MessageCredentialNotFound)
+		return
+	}
+	if err != nil {
+		respondInternal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Credential deleted"})
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/database/models"
+	"github.com/Maro1O9/goauth/internal/webauthn"
+	"github.com/Maro1O9/goauth/internal/webauthn/webauthntest"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// publicKeyOptions decodes the publicKey options of a begin response.
+func publicKeyOptions(t *testing.T, resp map[string]interface{}, options interface{}) {
+	t.Helper()
+
+	data, err := json.Marshal(resp["publicKey"])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, options))
+}
+
+func TestWebAuthn(t *testing.T) {
+	login := signUpAndLogin(t, "passkey", "passkey@example.com")
+	access := accessCookie(login["access_token"])
+	authenticator := webauthntest.New()
+
+	// Registering a passkey takes the password, not just an access token
+	rec, _ := doJSON(t, http.MethodPost, "/auth/webauthn/register/begin", gin.H{}, access)
+	require.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+	rec, _ = doJSON(t, http.MethodPost, "/auth/webauthn/register/begin", gin.H{"password": "Wrong1234"}, access)
+	require.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+
+	rec, begin := doJSON(t, http.MethodPost, "/auth/webauthn/register/begin", gin.H{"password": "Password123"}, access)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var creation webauthn.CreationOptions
+	publicKeyOptions(t, begin, &creation)
+	require.Equal(t, "localhost", creation.RP.ID)
+
+	attestation, err := authenticator.Create(&creation, "http://localhost")
+	require.NoError(t, err)
+
+	// The attestation must come from an allowed origin
+	rec, _ = doJSON(t, http.MethodPost, "/auth/webauthn/register/finish", gin.H{"name": "Laptop", "credential": attestation})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, credential := doJSON(t, http.MethodPost, "/auth/webauthn/register/finish", gin.H{"name": "Laptop", "credential": attestation}, access)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Equal(t, "Laptop", credential["name"])
+
+	// The registration challenge is single use
+	rec, _ = doJSON(t, http.MethodPost, "/auth/webauthn/register/finish", gin.H{"credential": attestation}, access)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Login with the passkey
+	rec, begin = doJSON(t, http.MethodPost, "/auth/webauthn/login/begin", gin.H{"email": "passkey@example.com"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var request webauthn.RequestOptions
+	publicKeyOptions(t, begin, &request)
+	require.Len(t, request.AllowCredentials, 1)
+
+	assertion, err := authenticator.Get(&request, "http://evil.example")
+	require.NoError(t, err)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/webauthn/login/finish", assertion)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Discoverable login without an email
+	_, begin = doJSON(t, http.MethodPost, "/auth/webauthn/login/begin", nil)
+	request = webauthn.RequestOptions{}
+	publicKeyOptions(t, begin, &request)
+	require.Empty(t, request.AllowCredentials)
+
+	assertion, err = authenticator.Get(&request, "http://localhost")
+	require.NoError(t, err)
+	rec, resp := doJSON(t, http.MethodPost, "/auth/webauthn/login/finish", assertion)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotEmpty(t, resp["access_token"])
+
+	// The login challenge is single use
+	rec, _ = doJSON(t, http.MethodPost, "/auth/webauthn/login/finish", assertion)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// A forced password reset blocks passkey logins too
+	require.NoError(t, testDB.Model(&models.User{}).Where("email = ?", "passkey@example.com").Update("password_reset_required", true).Error)
+	_, begin = doJSON(t, http.MethodPost, "/auth/webauthn/login/begin", nil)
+	request = webauthn.RequestOptions{}
+	publicKeyOptions(t, begin, &request)
+	assertion, err = authenticator.Get(&request, "http://localhost")
+	require.NoError(t, err)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/webauthn/login/finish", assertion)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.NoError(t, testDB.Model(&models.User{}).Where("email = ?", "passkey@example.com").Update("password_reset_required", false).Error)
+
+	rec, list := doJSON(t, http.MethodGet, "/auth/webauthn/credentials", nil, access)
+	require.Equal(t, http.StatusOK, rec.Code)
+	credentials := list["credentials"].([]interface{})
+	require.Len(t, credentials, 1)
+	require.NotNil(t, credentials[0].(map[string]interface{})["last_used_at"])
+
+	path := fmt.Sprintf("/auth/webauthn/credentials/%v", credential["id"])
+	rec, _ = doJSON(t, http.MethodDelete, path, nil, access)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = doJSON(t, http.MethodDelete, path, nil, access)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	// The deleted credential can no longer log in
+	_, begin = doJSON(t, http.MethodPost, "/auth/webauthn/login/begin", nil)
+	request = webauthn.RequestOptions{}
+	publicKeyOptions(t, begin, &request)
+	assertion, err = authenticator.Get(&request, "http://localhost")
+	require.NoError(t, err)
+	rec, _ = doJSON(t, http.MethodPost, "/auth/webauthn/login/finish", assertion)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
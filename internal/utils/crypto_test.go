@@ -0,0 +1,57 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptWith(t *testing.T) {
+	plaintext := []byte("JBSWY3DPEHPK3PXP")
+
+	ciphertext, err := utils.EncryptWith([]byte("a key"), plaintext)
+	require.NoError(t, err)
+	require.NotContains(t, string(ciphertext), string(plaintext))
+
+	other, err := utils.EncryptWith([]byte("a key"), plaintext)
+	require.NoError(t, err)
+	require.NotEqual(t, ciphertext, other)
+
+	decrypted, err := utils.DecryptWith([]byte("a key"), ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decrypted)
+
+	// Another key cannot decrypt the ciphertext
+	_, err = utils.DecryptWith([]byte("another key"), ciphertext)
+	require.Error(t, err)
+
+	// Tampered ciphertexts are rejected
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	_, err = utils.DecryptWith([]byte("a key"), ciphertext)
+	require.Error(t, err)
+
+	_, err = utils.DecryptWith([]byte("a key"), []byte("short"))
+	require.Error(t, err)
+}
@@ -23,66 +23,56 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"os"
 	"regexp"
 	"time"
 
 	"github.com/Maro1O9/goauth/internal/inputs"
-	"github.com/dlclark/regexp2"
+	"github.com/Maro1O9/goauth/internal/validation"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// ValidateSignupData compins all the input validation
-// in on function
-func ValidateSignupData(user *inputs.InputUser) error {
-
-	// Validate username
-	if err := ValidateUsername(user.Username); err != nil {
-		return err
-	}
-
-	// Validate name
-	if err := ValidateName(user.Name); err != nil {
-		return err
-	}
-
-	// Validate email
-	if err := ValidateEmail(user.Email); err != nil {
-		return err
-	}
-
-	// Validate password
-	if err := ValidatePassword(user.Password); err != nil {
-		return err
-	}
-
-	// Validate confirm password
-	if err := ValidatePassword(user.ConfirmPassword); err != nil {
-		return err
-	}
-
-	// Check if passwords match
-	if user.Password != user.ConfirmPassword {
-		return errors.New("Passwords do not match")
-	}
-	return nil
+// ValidateSignupData returns the problems with every invalid field of the
+// signup input, none if it is valid. The password itself is checked against
+// the password policy by the server.
+func ValidateSignupData(user *inputs.InputUser) validation.Errors {
+	var errs validation.Errors
+
+	validateField(&errs, "username", user.Username, ValidateUsername)
+	validateField(&errs, "name", user.Name, ValidateName)
+	validateField(&errs, "email", user.Email, ValidateEmail)
+	if user.Password == "" {
+		errs.Add("password", validation.CodeRequired)
+	} else if user.Password != user.ConfirmPassword {
+		errs.Add("confirm_password", validation.CodeMismatch)
+	}
+	return errs
 }
 
-// ValidateLoginData compins all the input validation
-// in on function
-func ValidateLoginData(user *inputs.LoginUser) error {
+// ValidateLoginData returns the problems with every invalid field of the
+// login input, none if it is valid. Passwords set before the current
+// password policy still log in, so only their presence is checked.
+func ValidateLoginData(user *inputs.LoginUser) validation.Errors {
+	var errs validation.Errors
 
-	// Validate email format
-	if err := ValidateEmail(user.Email); err != nil {
-		return err
+	validateField(&errs, "email", user.Email, ValidateEmail)
+	if user.Password == "" {
+		errs.Add("password", validation.CodeRequired)
 	}
+	return errs
+}
 
-	// Validate password format
-	if err := ValidatePassword(user.Password); err != nil {
-		return err
+// validateField adds the problem with a required field to errs, if any.
+func validateField(errs *validation.Errors, field, value string, validate func(string) error) {
+	if value == "" {
+		errs.Add(field, validation.CodeRequired)
+	} else if validate(value) != nil {
+		errs.Add(field, validation.CodeInvalid)
 	}
-	return nil
 }
 
 // ValidateUsername checks if the provided Username is valid.
@@ -125,60 +115,116 @@ func ValidateEmail(email string) error {
 	return nil
 }
 
-// ValidatePassword checks if the password is valid. The password must be at
-// least 8 characters long, must contain at least one uppercase letter and
-// one number, and may contain the following characters: A-Z, a-z, 0-9, _, !,
-// @, #, $, ^, &, *, (, ), and +. Returns an error if the password is
-// invalid.
-func ValidatePassword(password string) error {
-	if password == "" {
-		return errors.New("password cannot be empty")
-	}
-
-	PRe := `^(?=.*[A-Z])(?=.*\d)[A-Za-z\d!@#$%^&*()_+]{8,32}$`
-	re, err := regexp2.Compile(PRe, 0)
-	if err != nil {
-		return errors.New("failed to compile regex")
-	}
+// Token types stored in the typ claim. Only access tokens authenticate
+// requests, MFA tokens can only be exchanged for access tokens by completing
+// the second factor.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeMFA     = "mfa"
+	TokenTypeSession = "session"
+)
 
-	match, err := re.MatchString(password)
-	if err != nil {
-		return err
-	}
-	if !match {
-		return errors.New("invalid password")
-	}
-	return nil
+// Claims are the JWT claims carried by the tokens of the server, created
+// with NewClaims. The subject is the email of the user the token was issued
+// to and the ID (jti) uniquely identifies the token.
+//
+// Access tokens issued to OAuth clients also carry the ID of the client and
+// the granted scope. Tokens a service account obtained for itself have the
+// client subject type, their subject is the client ID instead of an email.
+//
+// Access tokens issued at login carry the roles and permissions the user
+// had at that time, for the benefit of other services. goAuth itself always
+// checks the current ones. They also carry the ID of the session the login
+// started, which can be revoked on its own.
+//
+// Tokens of users carry the ID of the user (uid), which is how the user is
+// looked up: an email address given up by one user can be taken by another,
+// an ID is never reused. They also carry the token version the user had when
+// they were issued, and are revoked once it is raised.
+type Claims struct {
+	jwt.RegisteredClaims
+	Type        string   `json:"typ,omitempty"`
+	SubjectType string   `json:"sub_type,omitempty"`
+	ClientID    string   `json:"client_id,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"perms,omitempty"`
+	SessionID   string   `json:"sid,omitempty"`
+	UserID      uint     `json:"uid,omitempty"`
+	Version     uint     `json:"ver,omitempty"`
 }
 
-var SecretKey = []byte(os.Getenv("SECRET_KEY"))
+// Subject types stored in the sub_type claim. Tokens without a subject type
+// were issued to users.
+const (
+	SubjectTypeUser   = "user"
+	SubjectTypeClient = "client"
+)
 
-// CreateToken creates a JWT token that is valid for 24 hours and contains the
-// provided username. The token is signed with the secret key. Returns an error
-// if the username is empty.
-func CreateToken(email string) (string, error) {
-	if email == "" {
-		return "", errors.New("email cannot be zero")
+// NewClaims returns the claims of a token of the given type issued by
+// issuer to the subject that is valid for ttl, with a new unique ID.
+// Returns an error if the subject is empty.
+func NewClaims(issuer, subject, tokenType string, ttl time.Duration) (Claims, error) {
+	if subject == "" {
+		return Claims{}, errors.New("email cannot be zero")
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sup": email,
-		"exp": time.Now().Add(time.Hour * 24 * 7).Unix(),
-	})
-
-	return token.SignedString(SecretKey)
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	now := time.Now()
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Type: tokenType,
+	}, nil
 }
 
-// VerifyToken takes a JWT token and verifies its validity. If the token is valid,
-// it returns nil. If the token is invalid, it returns an error.
-func VerifyToken(tokenString string) error {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return SecretKey, nil
-	})
+// TokenVerifier provides the keys ParseClaimsWith verifies tokens with.
+type TokenVerifier interface {
+	// Keyfunc returns the key that verifies the token.
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	// Algorithms returns the accepted signing algorithms.
+	Algorithms() []string
+}
 
+// ParseClaimsWith verifies the signature and expiry of a token with the
+// keys of verifier and decodes its claims into claims. Tokens without an
+// expiry are rejected.
+func ParseClaimsWith(verifier TokenVerifier, tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, verifier.Keyfunc,
+		jwt.WithValidMethods(verifier.Algorithms()), jwt.WithExpirationRequired())
 	if err != nil || !token.Valid {
 		return errors.New("invalid token")
 	}
-
 	return nil
 }
+
+// GenerateRandomToken returns a URL safe, base64 encoded string built from
+// n cryptographically secure random bytes. It is used for opaque tokens that
+// are stored hashed in the database.
+func GenerateRandomToken(n int) (string, error) {
+	if n <= 0 {
+		return "", errors.New("token length must be positive")
+	}
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex encoded SHA-256 digest of an opaque token.
+// Only the digest is persisted so a database leak does not leak usable tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -26,10 +26,10 @@ package utils_test
 import (
 	"strings"
 	"testing"
-	"time"
 
+	"github.com/Maro1O9/goauth/internal/inputs"
 	"github.com/Maro1O9/goauth/internal/utils"
-	"github.com/golang-jwt/jwt"
+	"github.com/Maro1O9/goauth/internal/validation"
 	"github.com/stretchr/testify/require"
 )
 
@@ -106,93 +106,64 @@ func TestValidateEmail(t *testing.T) {
 	}
 }
 
-func TestValidatePassword(t *testing.T) {
-	tests := []struct {
-		name           string
-		password       string
-		shouldValidate bool
-	}{
-		{"uppercase and digit", "P@ssw0rd", true},
-		{"only lowercase", "password", false},
-		{"only digits", "12345678", false},
-		{"special characters only", "!@#$%^&*()", false},
-		{"short password", "P@ss", false},
-		{"long password", "P@ssw0rdP@ssw0rdP@ssw0rdP@ssw0rd12", false},
-		{"invalid characters", "P@ssw0rd!", true},
+func TestValidateSignupData(t *testing.T) {
+	valid := inputs.InputUser{
+		Username:        "validUsername",
+		Name:            "validName",
+		Email:           "valid@example.com",
+		Password:        "Password123",
+		ConfirmPassword: "Password123",
 	}
+	require.Empty(t, utils.ValidateSignupData(&valid))
 
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			err := utils.ValidatePassword(test.password)
-			if err == nil && !test.shouldValidate {
-				t.Errorf("expected no error, but got %s", err)
-			} else if err != nil && test.shouldValidate {
-				t.Error("expected error, but got nil")
-			}
-		})
-	}
+	// Every invalid field is reported at once
+	invalid := inputs.InputUser{Username: "a", Email: "not an email", Password: "Password123", ConfirmPassword: "Password124"}
+	require.Equal(t, validation.Errors{
+		{Field: "username", Code: validation.CodeInvalid},
+		{Field: "name", Code: validation.CodeRequired},
+		{Field: "email", Code: validation.CodeInvalid},
+		{Field: "confirm_password", Code: validation.CodeMismatch},
+	}, utils.ValidateSignupData(&invalid))
 }
 
-func TestCreateToken(t *testing.T) {
-	tests := []struct {
-		name      string
-		username  string
-		wantErr   bool
-		wantToken string
-	}{
-		{"empty username", "", true, ""},
-		{"valid username", "testuser", false, ""},
-	}
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			token, err := utils.CreateToken(test.username)
-			if test.wantErr {
-				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
-				require.NotEmpty(t, token)
-			}
-		})
-	}
+func TestValidateLoginData(t *testing.T) {
+	require.Empty(t, utils.ValidateLoginData(&inputs.LoginUser{Email: "valid@example.com", Password: "weak"}))
+	require.Equal(t, validation.Errors{
+		{Field: "email", Code: validation.CodeRequired},
+		{Field: "password", Code: validation.CodeRequired},
+	}, utils.ValidateLoginData(&inputs.LoginUser{}))
 }
 
-func TestVerifyToken(t *testing.T) {
+func TestGenerateRandomToken(t *testing.T) {
 	tests := []struct {
-		name        string
-		tokenString string
-		wantErr     bool
+		name    string
+		length  int
+		wantErr bool
 	}{
-		{"valid token", createValidToken(t), false},
-		{"invalid token", "invalid-token", true},
-		{"empty token string", "", true},
-		{"expired token", createExpiredToken(t), true},
+		{"zero length", 0, true},
+		{"negative length", -1, true},
+		{"valid length", 32, false},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := utils.VerifyToken(test.tokenString)
+			token, err := utils.GenerateRandomToken(test.length)
 			if test.wantErr {
 				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
+				return
 			}
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+
+			other, err := utils.GenerateRandomToken(test.length)
+			require.NoError(t, err)
+			require.NotEqual(t, token, other)
 		})
 	}
 }
-func createValidToken(t *testing.T) string {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username": "testuser",
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
-	})
-	tokenString, err := token.SignedString(utils.SecretKey)
-	require.NoError(t, err)
-	return tokenString
-}
-func createExpiredToken(t *testing.T) string {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username": "testuser",
-		"exp":      time.Now().Add(-time.Hour * 24).Unix(),
-	})
-	tokenString, err := token.SignedString(utils.SecretKey)
-	require.NoError(t, err)
-	return tokenString
+
+func TestHashToken(t *testing.T) {
+	hash := utils.HashToken("token")
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, utils.HashToken("token"))
+	require.NotEqual(t, hash, utils.HashToken("other-token"))
 }
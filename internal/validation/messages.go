@@ -0,0 +1,315 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package validation
+
+// messages.go translates the codes of the problems into messages in the
+// languages of the users.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Maro1O9/goauth/internal/password"
+	"golang.org/x/text/language"
+)
+
+// Supported languages.
+const (
+	English = "en"
+	French  = "fr"
+)
+
+// CodeFailed is the code of the messages summing up invalid inputs.
+const CodeFailed = "validation_failed"
+
+// Codes of the messages about requests that fail as a whole: requests that
+// cannot be read, and requests the server failed to handle.
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeInternal       = "internal_error"
+)
+
+// Keys of the messages of the requests refused as a whole, such as logins
+// with a wrong password. The code of those responses follows their status.
+const (
+	MessageNotFound                 = "not_found"
+	MessageInvalidCredentials       = "invalid_credentials"
+	MessageAccountDisabled          = "account_disabled"
+	MessagePasswordResetRequired    = "password_reset_required"
+	MessageEmailNotVerified         = "email_not_verified"
+	MessageTooManyLoginAttempts     = "too_many_login_attempts"
+	MessageTooManyRequests          = "too_many_requests"
+	MessageInvalidPassword          = "invalid_password"
+	MessageIncorrectPassword        = "incorrect_password"
+	MessageMissingAccessToken       = "missing_access_token"
+	MessageInvalidAccessToken       = "invalid_access_token"
+	MessageRevokedAccessToken       = "revoked_access_token"
+	MessageUsersOnly                = "users_only"
+	MessageClientTokenRefused       = "client_token_refused"
+	MessagePermissionDenied         = "permission_denied"
+	MessageMissingRefreshToken      = "missing_refresh_token"
+	MessageRefreshTokenReused       = "refresh_token_reused"
+	MessageInvalidRefreshToken      = "invalid_refresh_token"
+	MessageInvalidMFAToken          = "invalid_mfa_token"
+	MessageInvalidCode              = "invalid_code"
+	MessageTooManyInvalidCodes      = "too_many_invalid_codes"
+	MessageMFAEnabled               = "mfa_enabled"
+	MessageMFANotEnabled            = "mfa_not_enabled"
+	MessageNoPendingEnrollment      = "no_pending_enrollment"
+	MessageInvalidResetToken        = "invalid_reset_token"
+	MessageInvalidVerificationToken = "invalid_verification_token"
+	MessageEmailRegistered          = "email_registered"
+	MessageEmailUnchanged           = "email_unchanged"
+	MessageVerificationThrottled    = "verification_throttled"
+	MessageInvalidChallenge         = "invalid_challenge"
+	MessageInvalidPasskey           = "invalid_passkey"
+	MessageCredentialRegistered     = "credential_registered"
+	MessageUnknownCredential        = "unknown_credential"
+	MessageCredentialNotFound       = "credential_not_found"
+	MessageUserNotFound             = "user_not_found"
+	MessageOwnAccount               = "own_account"
+	MessageUserNotDeleted           = "user_not_deleted"
+	MessageCannotManageUser         = "cannot_manage_user"
+	MessageCannotGrant              = "cannot_grant"
+	MessageClientNotFound           = "client_not_found"
+	MessagePublicClientSecret       = "public_client_secret"
+	MessageInvalidRegistration      = "invalid_registration"
+	MessageRoleNotFound             = "role_not_found"
+	MessageRoleExists               = "role_exists"
+	MessageDefaultRoleChanged       = "default_role_changed"
+	MessageDefaultRoleDeleted       = "default_role_deleted"
+	MessageSessionNotFound          = "session_not_found"
+)
+
+// catalogs holds the messages of each language by code. A message for a
+// field is looked up as field.code before code, and a message with a count
+// of 1 as code.one before code. Placeholders such as {min} are replaced
+// with the params.
+var catalogs = map[string]map[string]string{
+	English: {
+		CodeFailed:         "Some fields are invalid",
+		CodeInvalidRequest: "The request could not be read",
+		CodeInternal:       "Something went wrong, please try again later",
+
+		MessageNotFound:                 "Not found",
+		MessageInvalidCredentials:       "Invalid email or password",
+		MessageAccountDisabled:          "Account is disabled",
+		MessagePasswordResetRequired:    "Password reset required, check your email for a reset link",
+		MessageEmailNotVerified:         "Email address is not verified",
+		MessageTooManyLoginAttempts:     "Too many failed login attempts, try again later",
+		MessageTooManyRequests:          "Too many requests, try again later",
+		MessageInvalidPassword:          "Invalid password",
+		MessageIncorrectPassword:        "Current password is incorrect",
+		MessageMissingAccessToken:       "Missing access token",
+		MessageInvalidAccessToken:       "Invalid access token",
+		MessageRevokedAccessToken:       "Access token has been revoked",
+		MessageUsersOnly:                "This route is only available to users",
+		MessageClientTokenRefused:       "Tokens issued to OAuth clients cannot be used on this route",
+		MessagePermissionDenied:         "Permission denied",
+		MessageMissingRefreshToken:      "Missing refresh token",
+		MessageRefreshTokenReused:       "Refresh token reuse detected",
+		MessageInvalidRefreshToken:      "Invalid refresh token",
+		MessageInvalidMFAToken:          "Invalid MFA token",
+		MessageInvalidCode:              "Invalid code",
+		MessageTooManyInvalidCodes:      "Too many invalid codes, log in again",
+		MessageMFAEnabled:               "Two-factor authentication is already enabled",
+		MessageMFANotEnabled:            "Two-factor authentication is not enabled",
+		MessageNoPendingEnrollment:      "No pending two-factor enrollment",
+		MessageInvalidResetToken:        "Invalid or expired reset token",
+		MessageInvalidVerificationToken: "Invalid or expired verification token",
+		MessageEmailRegistered:          "Email already registered",
+		MessageEmailUnchanged:           "This is already your email",
+		MessageVerificationThrottled:    "A verification email was sent recently, try again later",
+		MessageInvalidChallenge:         "Invalid or expired challenge",
+		MessageInvalidPasskey:           "The passkey could not be verified",
+		MessageCredentialRegistered:     "Credential already registered",
+		MessageUnknownCredential:        "Unknown credential",
+		MessageCredentialNotFound:       "Credential not found",
+		MessageUserNotFound:             "User not found",
+		MessageOwnAccount:               "You cannot change your own account here",
+		MessageUserNotDeleted:           "User is not deleted",
+		MessageCannotManageUser:         "Cannot manage a user with permissions you do not have",
+		MessageCannotGrant:              "Cannot grant permissions you do not have",
+		MessageClientNotFound:           "Client not found",
+		MessagePublicClientSecret:       "Public clients have no secret",
+		MessageInvalidRegistration:      "The client registration is invalid",
+		MessageRoleNotFound:             "Role not found",
+		MessageRoleExists:               "Role already exists",
+		MessageDefaultRoleChanged:       "Default roles cannot be changed",
+		MessageDefaultRoleDeleted:       "Default roles cannot be deleted",
+		MessageSessionNotFound:          "Session not found",
+
+		CodeRequired:                "This field is required",
+		CodeInvalid:                 "This value is invalid",
+		CodeMismatch:                "The values do not match",
+		CodeTaken:                   "This value is already taken",
+		"username.invalid":          "Username must be 3 to 32 letters, digits or symbols without spaces",
+		"username.taken":            "Username is already taken",
+		"name.invalid":              "Name must be 3 to 32 letters, digits or symbols without spaces",
+		"email.invalid":             "Email must be a valid email address",
+		"email.taken":               "Email is already registered",
+		"password.required":         "Password is required",
+		"confirm_password.mismatch": "Passwords do not match",
+
+		password.RuleMinLength:       "Password must be at least {min} characters long",
+		password.RuleMaxLength:       "Password must be at most {max} characters long",
+		password.RuleMaxBytes:        "Password must be at most {max} bytes long",
+		password.RuleControl:         "Password must not contain control characters",
+		password.RuleUppercase:       "Password must contain an uppercase letter",
+		password.RuleLowercase:       "Password must contain a lowercase letter",
+		password.RuleDigit:           "Password must contain a digit",
+		password.RuleSymbol:          "Password must contain a symbol",
+		password.RuleStrength:        "Password is too easy to guess, add more words or characters",
+		password.RulePersonal:        "Password must not contain your username, name or email address",
+		password.RuleBreached:        "Password appeared in a data breach, choose another one",
+		password.RuleReused:          "Password must differ from your last {count} passwords",
+		password.RuleReused + ".one": "Password must differ from your current password",
+	},
+	French: {
+		CodeFailed:         "Certains champs sont invalides",
+		CodeInvalidRequest: "La requête n'a pas pu être lue",
+		CodeInternal:       "Une erreur s'est produite, veuillez réessayer plus tard",
+
+		MessageNotFound:                 "Introuvable",
+		MessageInvalidCredentials:       "Adresse e-mail ou mot de passe incorrect",
+		MessageAccountDisabled:          "Ce compte est désactivé",
+		MessagePasswordResetRequired:    "Vous devez réinitialiser votre mot de passe, un lien de réinitialisation vous a été envoyé par e-mail",
+		MessageEmailNotVerified:         "L'adresse e-mail n'est pas vérifiée",
+		MessageTooManyLoginAttempts:     "Trop de tentatives de connexion échouées, réessayez plus tard",
+		MessageTooManyRequests:          "Trop de requêtes, réessayez plus tard",
+		MessageInvalidPassword:          "Mot de passe incorrect",
+		MessageIncorrectPassword:        "Le mot de passe actuel est incorrect",
+		MessageMissingAccessToken:       "Jeton d'accès manquant",
+		MessageInvalidAccessToken:       "Jeton d'accès invalide",
+		MessageRevokedAccessToken:       "Le jeton d'accès a été révoqué",
+		MessageUsersOnly:                "Cette route est réservée aux utilisateurs",
+		MessageClientTokenRefused:       "Les jetons émis pour les clients OAuth ne peuvent pas être utilisés sur cette route",
+		MessagePermissionDenied:         "Permission refusée",
+		MessageMissingRefreshToken:      "Jeton de rafraîchissement manquant",
+		MessageRefreshTokenReused:       "Réutilisation du jeton de rafraîchissement détectée",
+		MessageInvalidRefreshToken:      "Jeton de rafraîchissement invalide",
+		MessageInvalidMFAToken:          "Jeton d'authentification à deux facteurs invalide",
+		MessageInvalidCode:              "Code invalide",
+		MessageTooManyInvalidCodes:      "Trop de codes invalides, reconnectez-vous",
+		MessageMFAEnabled:               "L'authentification à deux facteurs est déjà activée",
+		MessageMFANotEnabled:            "L'authentification à deux facteurs n'est pas activée",
+		MessageNoPendingEnrollment:      "Aucune activation de l'authentification à deux facteurs en cours",
+		MessageInvalidResetToken:        "Lien de réinitialisation invalide ou expiré",
+		MessageInvalidVerificationToken: "Lien de vérification invalide ou expiré",
+		MessageEmailRegistered:          "Cette adresse e-mail est déjà enregistrée",
+		MessageEmailUnchanged:           "C'est déjà votre adresse e-mail",
+		MessageVerificationThrottled:    "Un e-mail de vérification a été envoyé récemment, réessayez plus tard",
+		MessageInvalidChallenge:         "Défi invalide ou expiré",
+		MessageInvalidPasskey:           "La clé d'accès n'a pas pu être vérifiée",
+		MessageCredentialRegistered:     "Cette clé d'accès est déjà enregistrée",
+		MessageUnknownCredential:        "Clé d'accès inconnue",
+		MessageCredentialNotFound:       "Clé d'accès introuvable",
+		MessageUserNotFound:             "Utilisateur introuvable",
+		MessageOwnAccount:               "Vous ne pouvez pas modifier votre propre compte ici",
+		MessageUserNotDeleted:           "Cet utilisateur n'est pas supprimé",
+		MessageCannotManageUser:         "Vous ne pouvez pas gérer un utilisateur ayant des permissions que vous n'avez pas",
+		MessageCannotGrant:              "Vous ne pouvez pas accorder des permissions que vous n'avez pas",
+		MessageClientNotFound:           "Client introuvable",
+		MessagePublicClientSecret:       "Les clients publics n'ont pas de secret",
+		MessageInvalidRegistration:      "L'enregistrement du client est invalide",
+		MessageRoleNotFound:             "Rôle introuvable",
+		MessageRoleExists:               "Ce rôle existe déjà",
+		MessageDefaultRoleChanged:       "Les rôles par défaut ne peuvent pas être modifiés",
+		MessageDefaultRoleDeleted:       "Les rôles par défaut ne peuvent pas être supprimés",
+		MessageSessionNotFound:          "Session introuvable",
+
+		CodeRequired:                "Ce champ est obligatoire",
+		CodeInvalid:                 "Cette valeur est invalide",
+		CodeMismatch:                "Les valeurs ne correspondent pas",
+		CodeTaken:                   "Cette valeur est déjà utilisée",
+		"username.invalid":          "Le nom d'utilisateur doit comporter de 3 à 32 lettres, chiffres ou symboles, sans espace",
+		"username.taken":            "Ce nom d'utilisateur est déjà pris",
+		"name.invalid":              "Le nom doit comporter de 3 à 32 lettres, chiffres ou symboles, sans espace",
+		"email.invalid":             "L'adresse e-mail est invalide",
+		"email.taken":               "Cette adresse e-mail est déjà enregistrée",
+		"password.required":         "Le mot de passe est obligatoire",
+		"confirm_password.mismatch": "Les mots de passe ne correspondent pas",
+
+		password.RuleMinLength:       "Le mot de passe doit comporter au moins {min} caractères",
+		password.RuleMaxLength:       "Le mot de passe doit comporter au plus {max} caractères",
+		password.RuleMaxBytes:        "Le mot de passe doit comporter au plus {max} octets",
+		password.RuleControl:         "Le mot de passe ne doit pas contenir de caractères de contrôle",
+		password.RuleUppercase:       "Le mot de passe doit contenir une majuscule",
+		password.RuleLowercase:       "Le mot de passe doit contenir une minuscule",
+		password.RuleDigit:           "Le mot de passe doit contenir un chiffre",
+		password.RuleSymbol:          "Le mot de passe doit contenir un symbole",
+		password.RuleStrength:        "Le mot de passe est trop facile à deviner, ajoutez des mots ou des caractères",
+		password.RulePersonal:        "Le mot de passe ne doit pas contenir votre nom d'utilisateur, votre nom ou votre adresse e-mail",
+		password.RuleBreached:        "Ce mot de passe figure dans une fuite de données, choisissez-en un autre",
+		password.RuleReused:          "Le mot de passe doit être différent de vos {count} derniers mots de passe",
+		password.RuleReused + ".one": "Le mot de passe doit être différent de votre mot de passe actuel",
+	},
+}
+
+// matcher matches the languages asked by the users with the supported ones,
+// English first as the default.
+var matcher = language.NewMatcher([]language.Tag{language.English, language.French})
+
+// Language returns the supported language best matching an Accept-Language
+// header, English if none does.
+func Language(acceptLanguage string) string {
+	tag, _ := language.MatchStrings(matcher, acceptLanguage)
+	base, _ := tag.Base()
+	if _, ok := catalogs[base.String()]; !ok {
+		return English
+	}
+	return base.String()
+}
+
+// Message returns the message of the code for the field in the language,
+// falling back to English and then to the code itself. field may be empty
+// for messages about the whole input.
+func Message(lang, field, code string, params Params) string {
+	candidates := []string{code}
+	if field != "" {
+		candidates = []string{field + "." + code, code}
+	}
+	var keys []string
+	for _, key := range candidates {
+		if count, ok := params["count"].(int); ok && count == 1 {
+			keys = append(keys, key+".one")
+		}
+		keys = append(keys, key)
+	}
+
+	for _, catalog := range []map[string]string{catalogs[lang], catalogs[English]} {
+		for _, key := range keys {
+			if message, ok := catalog[key]; ok {
+				return format(message, params)
+			}
+		}
+	}
+	return code
+}
+
+// format replaces the placeholders of a message with the params.
+func format(message string, params Params) string {
+	for name, value := range params {
+		message = strings.ReplaceAll(message, "{"+name+"}", fmt.Sprint(value))
+	}
+	return message
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package validation
+
+// validation.go collects the problems with the fields of an input, so every
+// problem is reported at once rather than only the first one.
+
+import (
+	"strings"
+)
+
+// Codes of the problems with a field. The rules of the password policy,
+// such as password.RuleMinLength, are used as codes of the password field.
+const (
+	CodeRequired = "required"
+	CodeInvalid  = "invalid"
+	CodeMismatch = "mismatch"
+	CodeTaken    = "taken"
+)
+
+// Params fill the placeholders of a message, such as {min}.
+type Params map[string]interface{}
+
+// FieldError is a problem with a field of the input.
+type FieldError struct {
+	// Field is the name of the field in the input, such as email.
+	Field string
+	// Code identifies the problem.
+	Code   string
+	Params Params
+}
+
+// Message returns the message describing the problem in the language.
+func (e FieldError) Message(lang string) string {
+	return Message(lang, e.Field, e.Code, e.Params)
+}
+
+// Errors lists the problems with the fields of an input, in the order they
+// were found.
+type Errors []FieldError
+
+// Add adds a problem with the field.
+func (e *Errors) Add(field, code string) {
+	e.AddParams(field, code, nil)
+}
+
+// AddParams adds a problem with the field whose message has placeholders.
+func (e *Errors) AddParams(field, code string, params Params) {
+	*e = append(*e, FieldError{Field: field, Code: code, Params: params})
+}
+
+// Err returns the errors as an error, nil if there are none.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error returns the English messages of the problems.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message(English)
+	}
+	return strings.Join(messages, "; ")
+}
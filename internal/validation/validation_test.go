@@ -0,0 +1,91 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/password"
+	"github.com/Maro1O9/goauth/internal/validation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrors(t *testing.T) {
+	var errs validation.Errors
+	require.NoError(t, errs.Err())
+
+	errs.Add("email", validation.CodeInvalid)
+	errs.AddParams("password", password.RuleMinLength, validation.Params{"min": 8})
+	errs.Add("confirm_password", validation.CodeMismatch)
+
+	err := errs.Err()
+	require.EqualError(t, err, "Email must be a valid email address; Password must be at least 8 characters long; Passwords do not match")
+	var found validation.Errors
+	require.ErrorAs(t, err, &found)
+	require.Len(t, found, 3)
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		lang   string
+		field  string
+		code   string
+		params validation.Params
+		want   string
+	}{
+		{"field message", validation.English, "username", validation.CodeTaken, nil, "Username is already taken"},
+		{"generic message", validation.English, "nickname", validation.CodeTaken, nil, "This value is already taken"},
+		{"params", validation.English, "password", password.RuleMaxLength, validation.Params{"max": 64}, "Password must be at most 64 characters long"},
+		{"plural", validation.English, "password", password.RuleReused, validation.Params{"count": 5}, "Password must differ from your last 5 passwords"},
+		{"singular", validation.English, "password", password.RuleReused, validation.Params{"count": 1}, "Password must differ from your current password"},
+		{"french", validation.French, "email", validation.CodeInvalid, nil, "L'adresse e-mail est invalide"},
+		{"french params", validation.French, "password", password.RuleMinLength, validation.Params{"min": 12}, "Le mot de passe doit comporter au moins 12 caractères"},
+		{"unknown language", "de", "email", validation.CodeInvalid, nil, "Email must be a valid email address"},
+		{"unknown code", validation.French, "email", "unheard_of", nil, "unheard_of"},
+		{"whole input", validation.French, "", validation.CodeFailed, nil, "Certains champs sont invalides"},
+		{"whole request", validation.French, "", validation.MessageInvalidCredentials, nil, "Adresse e-mail ou mot de passe incorrect"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, validation.Message(test.lang, test.field, test.code, test.params))
+		})
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", validation.English},
+		{"fr", validation.French},
+		{"fr-CA,fr;q=0.9,en;q=0.8", validation.French},
+		{"de-DE,fr;q=0.5", validation.French},
+		{"en-GB,fr;q=0.5", validation.English},
+		{"de", validation.English},
+		{"not a language", validation.English},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.want, validation.Language(test.header), test.header)
+	}
+}
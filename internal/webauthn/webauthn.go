@@ -0,0 +1,549 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package webauthn
+
+// webauthn.go implements the relying party side of the WebAuthn registration
+// and authentication ceremonies (https://www.w3.org/TR/webauthn-2/). It
+// supports the "none" and "packed" attestation formats. Attestation
+// certificates are checked for consistency but not chained to a trust
+// anchor, goAuth does not restrict which authenticators may be used.
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Authenticator data flags.
+const (
+	flagUserPresent  = 0x01
+	flagAttestedData = 0x40
+	flagExtensions   = 0x80
+)
+
+// ChallengeSize is the number of random bytes in a challenge.
+const ChallengeSize = 32
+
+// idFIDOGenCeAAGUID is the attestation certificate extension holding the
+// AAGUID of the authenticator.
+var idFIDOGenCeAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// Config describes the relying party.
+type Config struct {
+	// RPID is the relying party ID, usually the registrable domain.
+	RPID string
+	// RPName is the name shown to the user by the authenticator.
+	RPName string
+	// Origins are the allowed origins of the ceremonies, for example
+	// "https://example.com".
+	Origins []string
+	// Timeout is the time the user has to complete a ceremony.
+	Timeout time.Duration
+}
+
+// RelyingParty runs WebAuthn ceremonies for a relying party.
+type RelyingParty struct {
+	config Config
+	rpHash [32]byte
+}
+
+// New creates a relying party from cfg.
+func New(cfg Config) (*RelyingParty, error) {
+	if cfg.RPID == "" {
+		return nil, errors.New("webauthn: relying party ID cannot be empty")
+	}
+	if len(cfg.Origins) == 0 {
+		return nil, errors.New("webauthn: at least one origin is required")
+	}
+	if cfg.RPName == "" {
+		cfg.RPName = cfg.RPID
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	return &RelyingParty{config: cfg, rpHash: sha256.Sum256([]byte(cfg.RPID))}, nil
+}
+
+// Timeout returns the time the user has to complete a ceremony.
+func (rp *RelyingParty) Timeout() time.Duration {
+	return rp.config.Timeout
+}
+
+// NewChallenge returns a new random challenge.
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, ChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// URLEncoding is the encoding used for binary values in the JSON messages.
+var URLEncoding = base64.RawURLEncoding
+
+// Bytes is a byte slice that is encoded as unpadded base64url in JSON.
+// Padded input is accepted as well.
+type Bytes []byte
+
+// MarshalJSON encodes b as a base64url string.
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(URLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON decodes a base64url string.
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := URLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// User is the user account a credential is registered for.
+type User struct {
+	ID          Bytes  `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialDescriptor identifies a credential.
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   Bytes  `json:"id"`
+}
+
+// CredentialParameter is a credential type and algorithm the relying party accepts.
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// RelyingPartyEntity identifies the relying party.
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AuthenticatorSelection expresses the relying party's requirements on the
+// authenticator.
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey,omitempty"`
+	UserVerification string `json:"userVerification,omitempty"`
+}
+
+// CreationOptions are the options passed to navigator.credentials.create.
+type CreationOptions struct {
+	Challenge              Bytes                  `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   User                   `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options passed to navigator.credentials.get.
+type RequestOptions struct {
+	Challenge        Bytes                  `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification,omitempty"`
+}
+
+// AttestationResponse is the JSON form of the PublicKeyCredential returned
+// by navigator.credentials.create.
+type AttestationResponse struct {
+	ID       string `json:"id"`
+	RawID    Bytes  `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    Bytes `json:"clientDataJSON"`
+		AttestationObject Bytes `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON form of the PublicKeyCredential returned by
+// navigator.credentials.get.
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    Bytes  `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    Bytes `json:"clientDataJSON"`
+		AuthenticatorData Bytes `json:"authenticatorData"`
+		Signature         Bytes `json:"signature"`
+		UserHandle        Bytes `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Credential is a verified public key credential.
+type Credential struct {
+	ID              []byte
+	PublicKey       []byte // CBOR encoded COSE_Key
+	SignCount       uint32
+	AAGUID          []byte
+	AttestationType string
+}
+
+// ClientData is the collected client data of a ceremony.
+type ClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// ParseClientData decodes clientDataJSON. It does not verify it.
+func ParseClientData(clientDataJSON []byte) (*ClientData, error) {
+	var clientData ClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return nil, fmt.Errorf("webauthn: invalid client data: %w", err)
+	}
+	return &clientData, nil
+}
+
+// ChallengeBytes returns the decoded challenge of the client data.
+func (c *ClientData) ChallengeBytes() ([]byte, error) {
+	return URLEncoding.DecodeString(strings.TrimRight(c.Challenge, "="))
+}
+
+// authenticatorData is the parsed authenticator data.
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("webauthn: authenticator data too short")
+	}
+
+	authData := &authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if authData.Flags&flagAttestedData != 0 {
+		if len(rest) < 18 {
+			return nil, errors.New("webauthn: attested credential data too short")
+		}
+		authData.AAGUID = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < idLen {
+			return nil, errors.New("webauthn: credential ID too short")
+		}
+		authData.CredentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("webauthn: invalid credential public key: %w", err)
+		}
+		authData.PublicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+
+	if authData.Flags&flagExtensions != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("webauthn: invalid extensions: %w", err)
+		}
+		rest = after
+	}
+
+	if len(rest) != 0 {
+		return nil, errors.New("webauthn: trailing data after authenticator data")
+	}
+	return authData, nil
+}
+
+// BeginRegistration returns the options for registering a new credential
+// for user with the given challenge. Credentials in exclude are not
+// registered again.
+func (rp *RelyingParty) BeginRegistration(challenge []byte, user User, exclude [][]byte) *CreationOptions {
+	options := &CreationOptions{
+		Challenge: challenge,
+		RP:        RelyingPartyEntity{ID: rp.config.RPID, Name: rp.config.RPName},
+		User:      user,
+		Timeout:   rp.config.Timeout.Milliseconds(),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+		Attestation: "direct",
+	}
+	for _, alg := range SupportedAlgorithms {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	for _, id := range exclude {
+		options.ExcludeCredentials = append(options.ExcludeCredentials, CredentialDescriptor{Type: "public-key", ID: id})
+	}
+	return options
+}
+
+// BeginLogin returns the options for asserting one of the allowed
+// credentials with the given challenge. With no allowed credentials, any discoverable credential
+// for the relying party may be used.
+func (rp *RelyingParty) BeginLogin(challenge []byte, allow [][]byte) *RequestOptions {
+	options := &RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.config.RPID,
+		Timeout:          rp.config.Timeout.Milliseconds(),
+		UserVerification: "preferred",
+	}
+	for _, id := range allow {
+		options.AllowCredentials = append(options.AllowCredentials, CredentialDescriptor{Type: "public-key", ID: id})
+	}
+	return options
+}
+
+// verifyClientData checks the type, challenge and origin of the client data.
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	clientData, err := ParseClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	if clientData.Type != ceremony {
+		return fmt.Errorf("webauthn: unexpected client data type %q", clientData.Type)
+	}
+
+	got, err := clientData.ChallengeBytes()
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return errors.New("webauthn: challenge mismatch")
+	}
+
+	for _, origin := range rp.config.Origins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("webauthn: unexpected origin %q", clientData.Origin)
+}
+
+// FinishRegistration verifies the response of navigator.credentials.create
+// against the challenge that was sent and returns the new credential.
+func (rp *RelyingParty) FinishRegistration(challenge []byte, resp *AttestationResponse) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, errors.New("webauthn: unexpected credential type")
+	}
+
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	value, rest, err := decodeCBOR(resp.Response.AttestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("webauthn: invalid attestation object")
+	}
+	object, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("webauthn: invalid attestation object")
+	}
+	format, _ := object["fmt"].(string)
+	statement, _ := object["attStmt"].(map[interface{}]interface{})
+	rawAuthData, _ := object["authData"].([]byte)
+	if statement == nil {
+		return nil, errors.New("webauthn: missing attestation statement")
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.Flags&flagAttestedData == 0 {
+		return nil, errors.New("webauthn: missing attested credential data")
+	}
+	if !bytes.Equal(authData.CredentialID, resp.RawID) {
+		return nil, errors.New("webauthn: credential ID mismatch")
+	}
+
+	publicKey, err := ParsePublicKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+
+	var attestationType string
+	switch format {
+	case "none":
+		if len(statement) != 0 {
+			return nil, errors.New("webauthn: none attestation with a statement")
+		}
+		attestationType = "none"
+	case "packed":
+		if attestationType, err = verifyPackedAttestation(statement, signed, publicKey, authData.AAGUID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("webauthn: unsupported attestation format %q", format)
+	}
+
+	return &Credential{
+		ID:              authData.CredentialID,
+		PublicKey:       authData.PublicKey,
+		SignCount:       authData.SignCount,
+		AAGUID:          authData.AAGUID,
+		AttestationType: attestationType,
+	}, nil
+}
+
+// verifyPackedAttestation verifies a packed attestation statement and
+// returns the attestation type, "self" or "basic".
+func verifyPackedAttestation(statement map[interface{}]interface{}, signed []byte, credentialKey *PublicKey, aaguid []byte) (string, error) {
+	alg, ok := statement["alg"].(int64)
+	if !ok {
+		return "", errors.New("webauthn: packed attestation without algorithm")
+	}
+	sig, ok := statement["sig"].([]byte)
+	if !ok {
+		return "", errors.New("webauthn: packed attestation without signature")
+	}
+
+	x5c, ok := statement["x5c"].([]interface{})
+	if !ok {
+		// Self attestation is signed by the credential key itself
+		if alg != credentialKey.Algorithm {
+			return "", errors.New("webauthn: self attestation algorithm mismatch")
+		}
+		if err := credentialKey.Verify(signed, sig); err != nil {
+			return "", fmt.Errorf("webauthn: invalid self attestation: %w", err)
+		}
+		return "self", nil
+	}
+
+	if len(x5c) == 0 {
+		return "", errors.New("webauthn: empty attestation certificate chain")
+	}
+	der, ok := x5c[0].([]byte)
+	if !ok {
+		return "", errors.New("webauthn: invalid attestation certificate")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return "", fmt.Errorf("webauthn: invalid attestation certificate: %w", err)
+	}
+
+	if err := verifySignature(alg, cert.PublicKey, signed, sig); err != nil {
+		return "", fmt.Errorf("webauthn: invalid packed attestation: %w", err)
+	}
+
+	// Certificate requirements from section 8.2.1 of the specification
+	if cert.Version != 3 || cert.IsCA {
+		return "", errors.New("webauthn: attestation certificate must be a version 3 leaf certificate")
+	}
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(idFIDOGenCeAAGUID) {
+			continue
+		}
+		var value []byte
+		if _, err := asn1.Unmarshal(ext.Value, &value); err != nil || !bytes.Equal(value, aaguid) {
+			return "", errors.New("webauthn: attestation certificate AAGUID mismatch")
+		}
+	}
+	return "basic", nil
+}
+
+// verifyAuthenticatorData checks the relying party ID hash and that the user
+// was present.
+func (rp *RelyingParty) verifyAuthenticatorData(authData *authenticatorData) error {
+	if subtle.ConstantTimeCompare(authData.RPIDHash, rp.rpHash[:]) != 1 {
+		return errors.New("webauthn: relying party ID mismatch")
+	}
+	if authData.Flags&flagUserPresent == 0 {
+		return errors.New("webauthn: user not present")
+	}
+	return nil
+}
+
+// ErrCloned is returned by FinishLogin when the signature counter did not
+// increase, which indicates the credential may have been cloned.
+var ErrCloned = errors.New("webauthn: signature counter did not increase")
+
+// FinishLogin verifies the response of navigator.credentials.get for the
+// stored credential against the challenge that was sent. It returns the new
+// signature counter that has to be stored with the credential.
+func (rp *RelyingParty) FinishLogin(challenge []byte, resp *AssertionResponse, credential *Credential) (uint32, error) {
+	if resp.Type != "public-key" {
+		return 0, errors.New("webauthn: unexpected credential type")
+	}
+	if !bytes.Equal(resp.RawID, credential.ID) {
+		return 0, errors.New("webauthn: credential ID mismatch")
+	}
+
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	authData, err := parseAuthenticatorData(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return 0, err
+	}
+
+	publicKey, err := ParsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), resp.Response.AuthenticatorData...), clientDataHash[:]...)
+	if err := publicKey.Verify(signed, resp.Response.Signature); err != nil {
+		return 0, fmt.Errorf("webauthn: invalid assertion signature: %w", err)
+	}
+
+	// Authenticators without a counter always report zero
+	if (authData.SignCount != 0 || credential.SignCount != 0) && authData.SignCount <= credential.SignCount {
+		return 0, ErrCloned
+	}
+	return authData.SignCount, nil
+}
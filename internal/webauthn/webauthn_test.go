@@ -0,0 +1,208 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package webauthn_test
+
+import (
+	"testing"
+
+	"github.com/Maro1O9/goauth/internal/webauthn"
+	"github.com/Maro1O9/goauth/internal/webauthn/webauthntest"
+	"github.com/stretchr/testify/require"
+)
+
+const origin = "https://auth.example.com"
+
+func newRelyingParty(t *testing.T) *webauthn.RelyingParty {
+	t.Helper()
+
+	rp, err := webauthn.New(webauthn.Config{RPID: "auth.example.com", RPName: "goAuth", Origins: []string{origin}})
+	require.NoError(t, err)
+	return rp
+}
+
+func register(t *testing.T, rp *webauthn.RelyingParty, authenticator *webauthntest.Authenticator) (*webauthn.Credential, error) {
+	t.Helper()
+
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+
+	options := rp.BeginRegistration(challenge, webauthn.User{ID: []byte("1"), Name: "test", DisplayName: "Test"}, nil)
+	resp, err := authenticator.Create(options, origin)
+	require.NoError(t, err)
+	return rp.FinishRegistration(challenge, resp)
+}
+
+func TestNew(t *testing.T) {
+	_, err := webauthn.New(webauthn.Config{Origins: []string{origin}})
+	require.Error(t, err)
+
+	_, err = webauthn.New(webauthn.Config{RPID: "auth.example.com"})
+	require.Error(t, err)
+}
+
+func TestRegistration(t *testing.T) {
+	rp := newRelyingParty(t)
+	aaguid := [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	attestationKey, attestationCert, err := webauthntest.NewAttestationCertificate(aaguid)
+	require.NoError(t, err)
+	_, otherCert, err := webauthntest.NewAttestationCertificate([16]byte{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authenticator *webauthntest.Authenticator
+		wantType      string
+		wantErr       bool
+	}{
+		{"none attestation", webauthntest.New(), "none", false},
+		{"packed self attestation", &webauthntest.Authenticator{Format: webauthntest.FormatPacked}, "self", false},
+		{"packed basic attestation", &webauthntest.Authenticator{
+			AAGUID:          aaguid,
+			Format:          webauthntest.FormatPacked,
+			AttestationKey:  attestationKey,
+			AttestationCert: attestationCert,
+		}, "basic", false},
+		{"packed attestation signed by another key", &webauthntest.Authenticator{
+			AAGUID:          aaguid,
+			Format:          webauthntest.FormatPacked,
+			AttestationKey:  attestationKey,
+			AttestationCert: otherCert,
+		}, "", true},
+		{"unsupported format", &webauthntest.Authenticator{Format: "tpm"}, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			credential, err := register(t, rp, test.authenticator)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.wantType, credential.AttestationType)
+			require.Len(t, credential.ID, 32)
+
+			_, err = webauthn.ParsePublicKey(credential.PublicKey)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRegistrationRejectsMismatches(t *testing.T) {
+	rp := newRelyingParty(t)
+	authenticator := webauthntest.New()
+	user := webauthn.User{ID: []byte("1"), Name: "test", DisplayName: "Test"}
+
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	other, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+
+	// Wrong challenge
+	resp, err := authenticator.Create(rp.BeginRegistration(challenge, user, nil), origin)
+	require.NoError(t, err)
+	_, err = rp.FinishRegistration(other, resp)
+	require.Error(t, err)
+
+	// Wrong origin
+	resp, err = authenticator.Create(rp.BeginRegistration(challenge, user, nil), "https://evil.example.com")
+	require.NoError(t, err)
+	_, err = rp.FinishRegistration(challenge, resp)
+	require.Error(t, err)
+
+	// Wrong relying party ID
+	options := rp.BeginRegistration(challenge, user, nil)
+	options.RP.ID = "evil.example.com"
+	resp, err = authenticator.Create(options, origin)
+	require.NoError(t, err)
+	_, err = rp.FinishRegistration(challenge, resp)
+	require.Error(t, err)
+
+	// Tampered attestation object
+	resp, err = authenticator.Create(rp.BeginRegistration(challenge, user, nil), origin)
+	require.NoError(t, err)
+	resp.Response.AttestationObject = resp.Response.AttestationObject[:len(resp.Response.AttestationObject)-5]
+	_, err = rp.FinishRegistration(challenge, resp)
+	require.Error(t, err)
+}
+
+func TestLogin(t *testing.T) {
+	rp := newRelyingParty(t)
+	authenticator := webauthntest.New()
+
+	credential, err := register(t, rp, authenticator)
+	require.NoError(t, err)
+
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+
+	resp, err := authenticator.Get(rp.BeginLogin(challenge, [][]byte{credential.ID}), origin)
+	require.NoError(t, err)
+	signCount, err := rp.FinishLogin(challenge, resp, credential)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, signCount)
+	credential.SignCount = signCount
+
+	// Discoverable credentials work without an allow list
+	resp, err = authenticator.Get(rp.BeginLogin(challenge, nil), origin)
+	require.NoError(t, err)
+	signCount, err = rp.FinishLogin(challenge, resp, credential)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, signCount)
+
+	// A counter that did not increase indicates a cloned authenticator
+	_, err = rp.FinishLogin(challenge, resp, &webauthn.Credential{ID: credential.ID, PublicKey: credential.PublicKey, SignCount: 5})
+	require.ErrorIs(t, err, webauthn.ErrCloned)
+
+	// Tampered signature
+	resp, err = authenticator.Get(rp.BeginLogin(challenge, nil), origin)
+	require.NoError(t, err)
+	resp.Response.Signature[len(resp.Response.Signature)-1] ^= 0xff
+	_, err = rp.FinishLogin(challenge, resp, credential)
+	require.Error(t, err)
+
+	// Assertions for another challenge are rejected
+	other, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	resp, err = authenticator.Get(rp.BeginLogin(challenge, nil), origin)
+	require.NoError(t, err)
+	_, err = rp.FinishLogin(other, resp, credential)
+	require.Error(t, err)
+}
+
+func TestParsePublicKey(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a map", []byte{0x01}},
+		{"truncated", []byte{0xa5, 0x01, 0x02}},
+		{"missing algorithm", []byte{0xa1, 0x01, 0x02}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := webauthn.ParsePublicKey(test.data)
+			require.Error(t, err)
+		})
+	}
+}
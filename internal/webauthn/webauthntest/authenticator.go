@@ -0,0 +1,277 @@
+// Copyright 2025 Mahmoud Abdelrahman <deprecated>
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package webauthntest
+
+// authenticator.go provides a software authenticator, so the WebAuthn
+// ceremonies can be tested without hardware.
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/Maro1O9/goauth/internal/webauthn"
+)
+
+// Attestation formats produced by the authenticator.
+const (
+	FormatNone   = "none"
+	FormatPacked = "packed"
+)
+
+// Authenticator is a software authenticator holding ES256 credentials.
+type Authenticator struct {
+	// AAGUID identifies the authenticator model.
+	AAGUID [16]byte
+	// Format is the attestation format, FormatNone or FormatPacked.
+	Format string
+	// AttestationKey and AttestationCert are used for packed basic
+	// attestation. If they are nil, packed self attestation is used.
+	AttestationKey  *ecdsa.PrivateKey
+	AttestationCert []byte
+
+	credentials []*credential
+}
+
+type credential struct {
+	id         []byte
+	key        *ecdsa.PrivateKey
+	rpID       string
+	userHandle []byte
+	signCount  uint32
+}
+
+// New returns an authenticator producing "none" attestations.
+func New() *Authenticator {
+	return &Authenticator{Format: FormatNone}
+}
+
+// Create performs navigator.credentials.create for the given origin and
+// returns the response the browser would send to the relying party.
+func (a *Authenticator) Create(options *webauthn.CreationOptions, origin string) (*webauthn.AttestationResponse, error) {
+	for _, excluded := range options.ExcludeCredentials {
+		if a.find(options.RP.ID, excluded.ID) != nil {
+			return nil, errors.New("webauthntest: credential already registered")
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	cred := &credential{id: id, key: key, rpID: options.RP.ID, userHandle: options.User.ID}
+	a.credentials = append(a.credentials, cred)
+
+	clientDataJSON, err := clientData("webauthn.create", options.Challenge, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attested credential data: AAGUID, credential ID length, ID and key
+	attested := append([]byte(nil), a.AAGUID[:]...)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(id)))
+	attested = append(attested, id...)
+	attested = append(attested, encodeCBOR(COSEKey(&key.PublicKey))...)
+	authData := authenticatorData(cred.rpID, 0x01|0x04|0x40, cred.signCount, attested)
+
+	statement := map[interface{}]interface{}{}
+	if a.Format == FormatPacked {
+		clientDataHash := sha256.Sum256(clientDataJSON)
+		signed := append(append([]byte(nil), authData...), clientDataHash[:]...)
+
+		signer := key
+		if a.AttestationKey != nil {
+			signer = a.AttestationKey
+		}
+		sig, err := sign(signer, signed)
+		if err != nil {
+			return nil, err
+		}
+
+		statement["alg"] = int64(webauthn.AlgES256)
+		statement["sig"] = sig
+		if a.AttestationCert != nil {
+			statement["x5c"] = []interface{}{a.AttestationCert}
+		}
+	}
+
+	object := encodeCBOR(map[interface{}]interface{}{
+		"fmt":      a.Format,
+		"attStmt":  statement,
+		"authData": authData,
+	})
+
+	resp := &webauthn.AttestationResponse{
+		ID:    webauthn.URLEncoding.EncodeToString(id),
+		RawID: id,
+		Type:  "public-key",
+	}
+	resp.Response.ClientDataJSON = clientDataJSON
+	resp.Response.AttestationObject = object
+	return resp, nil
+}
+
+// Get performs navigator.credentials.get for the given origin and returns
+// the response the browser would send to the relying party. Without allowed
+// credentials in the options, the first credential for the relying party is
+// used.
+func (a *Authenticator) Get(options *webauthn.RequestOptions, origin string) (*webauthn.AssertionResponse, error) {
+	var cred *credential
+	if len(options.AllowCredentials) == 0 {
+		for _, c := range a.credentials {
+			if c.rpID == options.RPID {
+				cred = c
+				break
+			}
+		}
+	}
+	for _, allowed := range options.AllowCredentials {
+		if cred = a.find(options.RPID, allowed.ID); cred != nil {
+			break
+		}
+	}
+	if cred == nil {
+		return nil, errors.New("webauthntest: no credential for relying party")
+	}
+
+	clientDataJSON, err := clientData("webauthn.get", options.Challenge, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	cred.signCount++
+	authData := authenticatorData(cred.rpID, 0x01|0x04, cred.signCount, nil)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	sig, err := sign(cred.key, append(append([]byte(nil), authData...), clientDataHash[:]...))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &webauthn.AssertionResponse{
+		ID:    webauthn.URLEncoding.EncodeToString(cred.id),
+		RawID: cred.id,
+		Type:  "public-key",
+	}
+	resp.Response.ClientDataJSON = clientDataJSON
+	resp.Response.AuthenticatorData = authData
+	resp.Response.Signature = sig
+	resp.Response.UserHandle = cred.userHandle
+	return resp, nil
+}
+
+func (a *Authenticator) find(rpID string, id []byte) *credential {
+	for _, c := range a.credentials {
+		if c.rpID == rpID && string(c.id) == string(id) {
+			return c
+		}
+	}
+	return nil
+}
+
+// COSEKey returns the COSE_Key map of an ES256 public key.
+func COSEKey(pub *ecdsa.PublicKey) map[interface{}]interface{} {
+	return map[interface{}]interface{}{
+		int64(1):  int64(2),                 // kty: EC2
+		int64(3):  int64(webauthn.AlgES256), // alg
+		int64(-1): int64(1),                 // crv: P-256
+		int64(-2): pub.X.FillBytes(make([]byte, 32)),
+		int64(-3): pub.Y.FillBytes(make([]byte, 32)),
+	}
+}
+
+// EncodeCOSEKey returns the CBOR encoded COSE_Key of an ES256 public key.
+func EncodeCOSEKey(pub *ecdsa.PublicKey) []byte {
+	return encodeCBOR(COSEKey(pub))
+}
+
+// NewAttestationCertificate creates a self signed packed attestation
+// certificate for the AAGUID, along with its private key.
+func NewAttestationCertificate(aaguid [16]byte) (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, err := asn1.Marshal(aaguid[:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"US"},
+			Organization:       []string{"goAuth"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "goAuth software authenticator",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}, Value: value},
+		},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, der, nil
+}
+
+func clientData(ceremony string, challenge []byte, origin string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   webauthn.URLEncoding.EncodeToString(challenge),
+		"origin":      origin,
+		"crossOrigin": false,
+	})
+}
+
+func authenticatorData(rpID string, flags byte, signCount uint32, attested []byte) []byte {
+	rpHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte(nil), rpHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	return append(data, attested...)
+}
+
+func sign(key *ecdsa.PrivateKey, data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	return ecdsa.SignASN1(rand.Reader, key, digest[:])
+}